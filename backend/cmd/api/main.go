@@ -4,20 +4,40 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/cache"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/config"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/email"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/handler"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/metrics"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/service"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ビルド情報（ビルド時に -ldflags "-X main.Version=... -X main.Commit=... -X main.BuildTime=..." で設定する）
+// 設定しない場合（go run など）は "dev"
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "dev"
 )
 
 func main() {
+	log.Printf("Build: version=%s commit=%s buildTime=%s", Version, Commit, BuildTime)
+
 	// .envファイルの読み込み（存在する場合）
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
@@ -28,71 +48,254 @@ func main() {
 
 	// DynamoDBクライアントの初期化
 	ctx := context.Background()
-	dbClient, err := repository.NewDynamoDBClient(ctx, cfg.DynamoDBTable)
+	// スロットリングは SDK がジッター付きの指数バックオフで再試行する
+	// 再試行も DYNAMODB_TIMEOUT の範囲内で行うため、MaxBackoff は DYNAMODB_TIMEOUT より十分短くする
+	maxAttempts, err := strconv.Atoi(cfg.DynamoDBMaxAttempts)
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	retryCfg := repository.RetryConfig{
+		MaxAttempts: maxAttempts,
+		MaxBackoff:  parseTimeout("DYNAMODB_MAX_BACKOFF", cfg.DynamoDBMaxBackoff, time.Second),
+	}
+	dbClient, err := repository.NewDynamoDBClient(ctx, cfg.DynamoDBTable, cfg.DynamoDBEndpoint, cfg.AWSRegion, retryCfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize DynamoDB client: %v", err)
 	}
+	// 各操作に期限を付ける（DynamoDB が遅い場合に HTTP のワーカーを占有し続けないように）
+	dbClient = dbClient.WithTimeout(parseTimeout("DYNAMODB_TIMEOUT", cfg.DynamoDBTimeout, 5*time.Second))
+	if cfg.DynamoDBEndpoint != "" {
+		log.Printf("Using DynamoDB Local (endpoint: %s, table: %s)", cfg.DynamoDBEndpoint, cfg.DynamoDBTable)
+	} else {
+		log.Printf("Using AWS DynamoDB (region: %s, table: %s)", cfg.AWSRegion, cfg.DynamoDBTable)
+	}
+
+	// メトリクスの初期化（METRICS_ENABLED=true の場合のみ）
+	// repoDB はリポジトリごとに DynamoDB のエラーを数えるクライアントを返す（無効時は共通のクライアント）
+	var appMetrics *metrics.Metrics
+	var metricsRegistry *prometheus.Registry
+	repoDB := func(name string) *repository.DynamoDBClient { return dbClient }
+	if metricsEnabled, _ := strconv.ParseBool(cfg.MetricsEnabled); metricsEnabled {
+		metricsRegistry = prometheus.NewRegistry()
+		metricsRegistry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+		appMetrics = metrics.New(metricsRegistry)
+		repoDB = func(name string) *repository.DynamoDBClient {
+			return dbClient.WithErrorObserver(name, appMetrics.ObserveDynamoDBError)
+		}
+		log.Println("Metrics enabled: GET /metrics")
+	}
 
 	// JWT認証の初期化
 	jwtExpiry, err := time.ParseDuration(cfg.JWTExpiry)
 	if err != nil {
 		jwtExpiry = 24 * time.Hour
 	}
-	jwtAuth := middleware.NewJWTAuth(cfg.JWTSecret, jwtExpiry)
+	jwtRefreshExpiry, err := time.ParseDuration(cfg.JWTRefreshExpiry)
+	if err != nil {
+		jwtRefreshExpiry = 168 * time.Hour
+	}
+	// ログアウト済みトークンの失効リスト（DynamoDB）
+	tokenRevocationRepo := repository.NewTokenRevocationRepository(repoDB("token_revocation"))
+	jwtAuth := middleware.NewJWTAuth(cfg.JWTSecret, jwtExpiry, jwtRefreshExpiry, tokenRevocationRepo, true)
 
 	// Repository の初期化
-	userRepo := repository.NewUserRepository(dbClient)
-	productRepo := repository.NewProductRepository(dbClient)
-	cartRepo := repository.NewCartRepository(dbClient)
-	orderRepo := repository.NewOrderRepository(dbClient)
-	priceHistoryRepo := repository.NewPriceHistoryRepository(dbClient)
-	inventoryRepo := repository.NewInventoryRepository(dbClient)
-	activityRepo := repository.NewActivityRepository(dbClient)
+	userRepo := repository.NewUserRepository(repoDB("user"))
+	productRepo := repository.NewProductRepository(repoDB("product"))
+	cartRepo := repository.NewCartRepository(repoDB("cart"))
+	orderRepo := repository.NewOrderRepository(repoDB("order"))
+	priceHistoryRepo := repository.NewPriceHistoryRepository(repoDB("price_history"))
+	scheduledPriceRepo := repository.NewScheduledPriceRepository(repoDB("scheduled_price"))
+	inventoryRepo := repository.NewInventoryRepository(repoDB("inventory"))
+	activityRepo := repository.NewActivityRepository(repoDB("activity"))
+	couponRepo := repository.NewCouponRepository(repoDB("coupon"))
+	counterRepo := repository.NewCounterRepository(repoDB("counter"))
+	idempotencyRepo := repository.NewIdempotencyRepository(repoDB("idempotency"))
+	categoryRepo := repository.NewCategoryRepository(repoDB("category"))
+	salesStatsRepo := repository.NewSalesStatsRepository(repoDB("sales_stats"))
+	alsoBoughtRepo := repository.NewAlsoBoughtRepository(repoDB("also_bought"))
+	passwordResetRepo := repository.NewPasswordResetRepository(repoDB("password_reset"))
+	rateLimitRepo := repository.NewRateLimitRepository(repoDB("rate_limit"))
+	reservationRepo := repository.NewReservationRepository(repoDB("reservation"))
+	// 在庫の取り置き（CART_RESERVATIONS_ENABLED=true の場合のみ。無効時は注文確定で取り置きを読まない）
+	var holdRepo *repository.HoldRepository
+	reservationsEnabled, _ := strconv.ParseBool(cfg.CartReservationsEnabled)
+	if reservationsEnabled {
+		holdRepo = repository.NewHoldRepository(repoDB("hold"))
+	}
+
+	// 商品の読み込みのキャッシュ（PRODUCT_CACHE_TTL=0 の場合は無効）
+	var productCache *service.ProductCache
+	productCacheTTL, err := time.ParseDuration(cfg.ProductCacheTTL)
+	if err != nil || productCacheTTL < 0 {
+		log.Printf("Invalid PRODUCT_CACHE_TTL %q, using default 30s", cfg.ProductCacheTTL)
+		productCacheTTL = 30 * time.Second
+	}
+	if productCacheTTL > 0 {
+		productCacheSize, err := strconv.Atoi(cfg.ProductCacheMaxSize)
+		if err != nil || productCacheSize <= 0 {
+			productCacheSize = 1000
+		}
+		productCache = service.NewProductCache(
+			cache.NewMemory[*domain.Product](productCacheTTL, productCacheSize),
+			cache.NewMemory[[]*domain.Product](productCacheTTL, productCacheSize),
+			cache.NewMemory[[]*domain.ProductSummary](productCacheTTL, productCacheSize),
+		)
+	}
+
+	// メール送信（EMAIL_PROVIDER=ses の場合のみ実際に送信する）
+	var emailSender service.EmailSender
+	switch cfg.EmailProvider {
+	case "ses":
+		sesClient, err := newSESClient(ctx, cfg.AWSRegion)
+		if err != nil {
+			log.Fatalf("Failed to initialize SES client: %v", err)
+		}
+		emailSender = email.NewSESSender(sesClient, cfg.EmailFrom)
+		log.Printf("Sending email via SES (from: %s)", cfg.EmailFrom)
+	default:
+		if cfg.EmailProvider != "log" {
+			log.Printf("Invalid EMAIL_PROVIDER %q, using \"log\"", cfg.EmailProvider)
+		}
+		emailSender = email.NewLogSender()
+	}
 
 	// Service の初期化
-	userService := service.NewUserService(userRepo)
-	productService := service.NewProductService(productRepo)
-	cartService := service.NewCartService(cartRepo, productRepo)
-	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo)
-	priceHistoryService := service.NewPriceHistoryService(priceHistoryRepo, productRepo)
-	inventoryService := service.NewInventoryService(inventoryRepo, productRepo)
-	activityService := service.NewActivityService(activityRepo)
+	userService := service.NewUserService(userRepo, passwordResetRepo, rateLimitRepo, jwtAuth, emailSender, cfg.BcryptCost)
+	bestsellerCacheTTL := parseTimeout("BESTSELLER_CACHE_TTL", cfg.BestsellerCacheTTL, time.Minute)
+	facetCacheTTL := parseTimeout("FACET_CACHE_TTL", cfg.FacetCacheTTL, 5*time.Minute)
+	productService := service.NewProductService(productRepo, counterRepo, salesStatsRepo, alsoBoughtRepo, productCache, cfg.SKUPrefix, bestsellerCacheTTL, facetCacheTTL)
+	maxCartItems, err := strconv.Atoi(cfg.MaxCartItems)
+	if err != nil || maxCartItems <= 0 {
+		maxCartItems = 50
+	}
+	maxItemQuantity, err := strconv.Atoi(cfg.MaxItemQuantity)
+	if err != nil || maxItemQuantity <= 0 {
+		maxItemQuantity = 99
+	}
+	cartService := service.NewCartService(cartRepo, productRepo, holdRepo, maxCartItems, maxItemQuantity)
+	maxOrderItems, err := strconv.Atoi(cfg.MaxOrderItemsPerPage)
+	if err != nil || maxOrderItems <= 0 {
+		maxOrderItems = 100
+	}
+	orderPricePolicy := cfg.OrderPricePolicy
+	if orderPricePolicy != service.OrderPricePolicySnapshot && orderPricePolicy != service.OrderPricePolicyCurrent {
+		log.Printf("Invalid ORDER_PRICE_POLICY %q, using %q", orderPricePolicy, service.OrderPricePolicySnapshot)
+		orderPricePolicy = service.OrderPricePolicySnapshot
+	}
+	taxRate, err := strconv.ParseFloat(cfg.TaxRate, 64)
+	if err != nil || taxRate < 0 || taxRate >= 1 {
+		log.Printf("Invalid TAX_RATE %q, using default 0.10", cfg.TaxRate)
+		taxRate = 0.10
+	}
+	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo, idempotencyRepo, couponRepo, userRepo, holdRepo, reservationRepo, emailSender, maxOrderItems, orderPricePolicy, taxRate)
+	priceHistoryService := service.NewPriceHistoryService(priceHistoryRepo, scheduledPriceRepo, productRepo, productCache)
+	inventoryService := service.NewInventoryService(inventoryRepo, productRepo, productCache)
+	activityService := service.NewActivityService(activityRepo, productRepo)
+	couponService := service.NewCouponService(couponRepo)
+	exportService := service.NewExportService(userRepo, cartRepo, orderRepo, activityRepo)
+	categoryService := service.NewCategoryService(categoryRepo)
+
+	// アプリケーション全体のコンテキスト（SIGINT / SIGTERM でキャンセル）
+	// バックグラウンドのゴルーチンは appCtx で起動し、workers で終了を待つ
+	// → Kubernetes では SIGTERM の後に猶予期間を過ぎると SIGKILL されるため、その前に処理を終わらせる
+	appCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	schedulerInterval, err := time.ParseDuration(cfg.PriceSchedulerInterval)
+	if err != nil || schedulerInterval <= 0 {
+		schedulerInterval = time.Minute
+	}
+	priceScheduler := service.NewPriceScheduler(priceHistoryService, schedulerInterval)
+	workers.Go(func() {
+		priceScheduler.Run(appCtx)
+	})
+
+	reservationSweeper := service.NewReservationSweeper(orderService, parseTimeout("RESERVATION_SWEEPER_INTERVAL", cfg.ReservationSweeperInterval, time.Minute))
+	workers.Go(func() {
+		reservationSweeper.Run(appCtx)
+	})
+
+	var holdService *service.HoldService
+	if reservationsEnabled {
+		holdDuration := parseTimeout("CART_HOLD_DURATION", cfg.CartHoldDuration, 15*time.Minute)
+		holdService = service.NewHoldService(holdRepo, cartRepo, productRepo, holdDuration)
+		holdSweeper := service.NewHoldSweeper(holdService, parseTimeout("HOLD_SWEEPER_INTERVAL", cfg.HoldSweeperInterval, time.Minute))
+		workers.Go(func() {
+			holdSweeper.Run(appCtx)
+		})
+		log.Printf("Cart reservations enabled (hold duration: %s)", holdDuration)
+	}
 
 	// Handler の初期化
+	roles := middleware.NewRoles(config.SplitList(cfg.AdminUserIDs))
 	authHandler := handler.NewAuthHandler(userService, jwtAuth)
-	productHandler := handler.NewProductHandler(productService)
+	productHandler := handler.NewProductHandler(productService, roles)
 	cartHandler := handler.NewCartHandler(cartService)
 	orderHandler := handler.NewOrderHandler(orderService)
 	priceHistoryHandler := handler.NewPriceHistoryHandler(priceHistoryService)
 	inventoryHandler := handler.NewInventoryHandler(inventoryService)
 	activityHandler := handler.NewActivityHandler(activityService)
+	couponHandler := handler.NewCouponHandler(couponService)
+	healthHandler := handler.NewHealthHandler(dbClient, handler.BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	})
+	exportHandler := handler.NewExportHandler(exportService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+
+	// CORS の設定
+	cors := middleware.NewCORS(
+		config.SplitList(cfg.CORSAllowedOrigins),
+		config.SplitList(cfg.CORSAllowedMethods),
+		config.SplitList(cfg.CORSAllowedHeaders),
+	)
 
 	// Router の設定
-	router := handler.NewRouter(jwtAuth, authHandler, productHandler, cartHandler, orderHandler, priceHistoryHandler, inventoryHandler, activityHandler)
-	httpHandler := router.Setup()
+	router := handler.NewRouter(jwtAuth, roles, cors, authHandler, productHandler, cartHandler, orderHandler, priceHistoryHandler, inventoryHandler, activityHandler, couponHandler, healthHandler, exportHandler, categoryHandler)
+	if appMetrics != nil {
+		router.EnableMetrics(appMetrics.ObserveRequest, promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	}
+	if holdService != nil {
+		router.EnableStockHolds(handler.NewHoldHandler(holdService))
+	}
+	// 処理中のリクエスト数（シャットダウン時のログ用）
+	inFlight := middleware.NewInFlight()
+	httpHandler := inFlight.Middleware(router.Setup())
 
 	// サーバーの設定
 	server := &http.Server{
-		Addr:         ":" + cfg.ServerPort,
-		Handler:      httpHandler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           httpHandler,
+		ReadTimeout:       parseTimeout("READ_TIMEOUT", cfg.ReadTimeout, 15*time.Second),
+		ReadHeaderTimeout: parseTimeout("READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout, 5*time.Second),
+		WriteTimeout:      parseTimeout("WRITE_TIMEOUT", cfg.WriteTimeout, 15*time.Second),
+		IdleTimeout:       parseTimeout("IDLE_TIMEOUT", cfg.IdleTimeout, 60*time.Second),
 	}
 
 	// Graceful shutdown
+	// 【順序】
+	//  1. シグナルを受けたら appCtx がキャンセルされ、バックグラウンドのゴルーチンが停止を始める
+	//  2. server.Shutdown で新規接続の受付を止め、処理中のリクエストの完了を待つ（最大30秒）
+	//  3. バックグラウンドのゴルーチンの終了を待ってからプロセスを終了する
+	shutdownDone := make(chan struct{})
 	go func() {
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
+		defer close(shutdownDone)
+		<-appCtx.Done()
 
-		log.Println("Shutting down server...")
+		log.Printf("Shutting down server (%d requests in flight)...", inFlight.Count())
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("Server shutdown error: %v", err)
+			log.Printf("Server shutdown error: %v (%d requests still in flight)", err, inFlight.Count())
 		}
+
+		workers.Wait()
 	}()
 
 	// サーバー起動
@@ -101,5 +304,30 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 
+	<-shutdownDone
 	log.Println("Server stopped")
 }
+
+// parseTimeout はタイムアウトなどの時間の設定（time.ParseDuration 形式）を解析する
+// 解析できない、または 0 以下の場合は警告を出して既定値を使う
+func parseTimeout(name, value string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using default %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// newSESClient は Amazon SES（v2 API）のクライアントを作成する
+func newSESClient(ctx context.Context, region string) (*sesv2.Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sesv2.NewFromConfig(awsCfg), nil
+}
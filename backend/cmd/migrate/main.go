@@ -0,0 +1,181 @@
+// backend/cmd/migrate/main.go
+// DynamoDB テーブルを作成するコマンド（infrastructure/scripts/create-table.sh の Go 版）
+//
+// 【使い方】
+//   cd backend
+//   go run cmd/migrate/main.go
+//   - API サーバーと同じ .env / 環境変数（AWS_REGION, DYNAMODB_TABLE, DYNAMODB_ENDPOINT）を読む
+//   - DYNAMODB_ENDPOINT を設定すると DynamoDB Local に作成する
+//
+// 【作成するテーブル】
+//   Primary Key: PK (HASH), SK (RANGE)
+//   GSI1: GSI1PK (HASH), GSI1SK (RANGE)
+//   GSI2: GSI2PK (HASH), GSI2SK (RANGE)
+//   課金モード: PAY_PER_REQUEST / TTL: TTL 属性 / Streams: NEW_AND_OLD_IMAGES
+//
+// テーブルが既に存在する場合は作成しない（何度実行してもよい）
+//
+// 【カテゴリの商品数の再集計】
+//   go run cmd/migrate/main.go -backfill-category-counts
+//   - 論理削除されていない商品をカテゴリごとに数え直し、カテゴリマーカーの productCount を置き換える
+//   - マーカー導入前に作成された商品がある場合や、商品数がずれた場合に実行する
+//   - 数え直しと置き換えの間の商品の作成・削除は反映されないため、商品の書き込みを止めて実行する
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/config"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/joho/godotenv"
+)
+
+// テーブルが ACTIVE になるまで待つ最大時間
+const tableActiveTimeout = 2 * time.Minute
+
+func main() {
+	backfillCategoryCounts := flag.Bool("backfill-category-counts", false, "recount products per category and replace the category marker counts")
+	flag.Parse()
+
+	// .envファイルの読み込み（存在する場合）
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	dbClient, err := repository.NewDynamoDBClient(ctx, cfg.DynamoDBTable, cfg.DynamoDBEndpoint, cfg.AWSRegion, repository.RetryConfig{})
+	if err != nil {
+		log.Fatalf("Failed to initialize DynamoDB client: %v", err)
+	}
+	// CreateTable 等は DynamoDBAPI に含まれないため具象クライアントを取り出す
+	// （計測やタイムアウトのラッパーを付けるとここで取り出せなくなるため、型を確認してから使う）
+	client, ok := dbClient.Client.(*dynamodb.Client)
+	if !ok {
+		log.Fatalf("DynamoDB client is %T, not *dynamodb.Client: migrate needs the concrete client for CreateTable", dbClient.Client)
+	}
+
+	if cfg.DynamoDBEndpoint != "" {
+		log.Printf("Target: DynamoDB Local (%s)", cfg.DynamoDBEndpoint)
+	} else {
+		log.Printf("Target: AWS DynamoDB (region: %s)", cfg.AWSRegion)
+	}
+
+	// テーブルが既に存在するかチェック
+	exists, err := tableExists(ctx, client, cfg.DynamoDBTable)
+	if err != nil {
+		log.Fatalf("Failed to describe table: %v", err)
+	}
+	if exists {
+		log.Printf("Table %s already exists, skipping", cfg.DynamoDBTable)
+	} else {
+		setupTable(ctx, client, cfg.DynamoDBTable)
+	}
+
+	if *backfillCategoryCounts {
+		log.Println("Backfilling category product counts...")
+		counts, err := repository.NewProductRepository(dbClient).CountByCategory(ctx)
+		if err != nil {
+			log.Fatalf("Failed to count products by category: %v", err)
+		}
+		replaced, err := repository.NewCategoryRepository(dbClient).ReplaceCounts(ctx, counts)
+		if err != nil {
+			log.Fatalf("Failed to replace category counts: %v", err)
+		}
+		log.Printf("Replaced product counts of %d categories", replaced)
+	}
+}
+
+// setupTable はテーブルを作成し、ACTIVE になるのを待って TTL を有効にする
+func setupTable(ctx context.Context, client *dynamodb.Client, tableName string) {
+	log.Printf("Creating table: %s", tableName)
+	if err := createTable(ctx, client, tableName); err != nil {
+		log.Fatalf("Failed to create table: %v", err)
+	}
+
+	log.Println("Waiting for table to be active...")
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, tableActiveTimeout); err != nil {
+		log.Fatalf("Table did not become active: %v", err)
+	}
+
+	// TTL有効化（CreateTable では指定できないため別途設定する）
+	log.Println("Enabling TTL on TTL attribute...")
+	if _, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String("TTL"),
+			Enabled:       aws.Bool(true),
+		},
+	}); err != nil {
+		log.Fatalf("Failed to enable TTL: %v", err)
+	}
+
+	log.Printf("Table %s created successfully", tableName)
+}
+
+// tableExists はテーブルが存在するかを返す
+// ResourceNotFoundException のみ「存在しない」とみなし、それ以外のエラーはそのまま返す
+func tableExists(ctx context.Context, client *dynamodb.Client, tableName string) (bool, error) {
+	_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// createTable はシングルテーブル設計のテーブルを作成する
+func createTable(ctx context.Context, client *dynamodb.Client, tableName string) error {
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("SK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI1PK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI1SK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI2PK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI2SK"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("SK"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			gsi("GSI1"),
+			gsi("GSI2"),
+		},
+		BillingMode: types.BillingModePayPerRequest,
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeNewAndOldImages,
+		},
+	})
+	return err
+}
+
+// gsi は <name>PK / <name>SK をキーとする、全属性を射影する GSI の定義を返す
+func gsi(name string) types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(name),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(name + "PK"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(name + "SK"), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+}
@@ -0,0 +1,44 @@
+// cmd/openapi は API の OpenAPI 3 仕様書（JSON）を生成する
+//
+// 使い方: go run cmd/openapi/main.go -o openapi.json
+//
+// ルートはサーバーと同じ一覧（handler.Router.Routes）から読むため、ルートを追加したら生成し直すだけでよい
+// ハンドラーは呼び出さないので、DynamoDB などの設定は不要
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/handler"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/openapi"
+)
+
+func main() {
+	output := flag.String("o", "openapi.json", "output file (- for stdout)")
+	version := flag.String("version", "1.0.0", "API version written to info.version")
+	flag.Parse()
+
+	// ルートの一覧だけを使うため、依存先は空のままでよい
+	// 任意の機能のルートも仕様書に含める
+	router := handler.NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	router.EnableStockHolds(&handler.HoldHandler{})
+
+	doc := openapi.Build("DynamoDB Shop API", *version, router.Routes())
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode OpenAPI document: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *output == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *output, err)
+	}
+	log.Printf("Wrote %s", *output)
+}
@@ -0,0 +1,191 @@
+// backend/cmd/seed/main.go
+// デモ用のデータ（商品・ユーザー・価格履歴）を投入するコマンド
+//
+// 【使い方】
+//   cd backend
+//   go run cmd/migrate/main.go   # テーブルがない場合は先に作成する
+//   go run cmd/seed/main.go
+//   - API サーバーと同じ .env / 環境変数（AWS_REGION, DYNAMODB_TABLE, DYNAMODB_ENDPOINT, BCRYPT_COST）を読む
+//   - DYNAMODB_ENDPOINT を設定すると DynamoDB Local に投入する
+//
+// 【投入するデータ】
+//   - 商品: 複数カテゴリの商品（SKU が DEMO- で始まるもの）と、その価格履歴
+//   - ユーザー: 管理者1名・一般ユーザー1名（パスワードは bcrypt でハッシュ化）
+//
+// 同じ SKU の商品・同じメールアドレスのユーザーが既に存在する場合は作成しない（何度実行してもよい）
+// 最後に投入したユーザーのログイン情報を表示する（パスワードはデモ用の固定値。本番環境では使わないこと）
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/config"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedProduct は投入する商品（History は価格の推移。最後の要素が現在の価格）
+type seedProduct struct {
+	SKU         string
+	Name        string
+	Description string
+	Category    string
+	Stock       int
+	History     []int
+}
+
+var seedProducts = []seedProduct{
+	{SKU: "DEMO-001", Name: "ワイヤレスイヤホン", Description: "ノイズキャンセリング対応の完全ワイヤレスイヤホン", Category: "electronics", Stock: 50, History: []int{12800, 11800, 9980}},
+	{SKU: "DEMO-002", Name: "モバイルバッテリー", Description: "10000mAh・USB-C 急速充電対応", Category: "electronics", Stock: 120, History: []int{3980, 3480}},
+	{SKU: "DEMO-003", Name: "メカニカルキーボード", Description: "テンキーレス・茶軸", Category: "electronics", Stock: 8, History: []int{14800}},
+	{SKU: "DEMO-004", Name: "オーガニックコットンTシャツ", Description: "肌触りのよいオーガニックコットン100%", Category: "clothing", Stock: 200, History: []int{2980, 2480}},
+	{SKU: "DEMO-005", Name: "デニムジャケット", Description: "定番のインディゴデニム", Category: "clothing", Stock: 30, History: []int{8900}},
+	{SKU: "DEMO-006", Name: "はじめての DynamoDB", Description: "シングルテーブル設計の入門書", Category: "books", Stock: 40, History: []int{3300, 2970, 3300}},
+	{SKU: "DEMO-007", Name: "Go 言語プログラミング", Description: "並行処理からテストまで", Category: "books", Stock: 25, History: []int{3960}},
+	{SKU: "DEMO-008", Name: "ドリップコーヒーセット", Description: "3種の豆の飲み比べ（各10袋）", Category: "food", Stock: 60, History: []int{2400, 2160}},
+}
+
+// seedUser は投入するユーザー
+type seedUser struct {
+	Role     string // 表示用
+	Email    string
+	Name     string
+	Password string
+}
+
+var seedUsers = []seedUser{
+	{Role: "admin", Email: "admin@example.com", Name: "管理者", Password: "Admin1234"},
+	{Role: "customer", Email: "customer@example.com", Name: "デモ ユーザー", Password: "Customer1234"},
+}
+
+// 価格履歴の間隔（最後の価格を現在から何日前にするかの単位）
+const priceHistoryInterval = 7 * 24 * time.Hour
+
+func main() {
+	// .envファイルの読み込み（存在する場合）
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	dbClient, err := repository.NewDynamoDBClient(ctx, cfg.DynamoDBTable, cfg.DynamoDBEndpoint, cfg.AWSRegion, repository.RetryConfig{})
+	if err != nil {
+		log.Fatalf("Failed to initialize DynamoDB client: %v", err)
+	}
+
+	if cfg.DynamoDBEndpoint != "" {
+		log.Printf("Target: DynamoDB Local (%s)", cfg.DynamoDBEndpoint)
+	} else {
+		log.Printf("Target: AWS DynamoDB (region: %s)", cfg.AWSRegion)
+	}
+
+	productRepo := repository.NewProductRepository(dbClient)
+	priceHistoryRepo := repository.NewPriceHistoryRepository(dbClient)
+	userRepo := repository.NewUserRepository(dbClient)
+
+	if err := seedCatalog(ctx, productRepo, priceHistoryRepo); err != nil {
+		log.Fatalf("Failed to seed products: %v", err)
+	}
+
+	users, err := seedAccounts(ctx, userRepo, cfg.BcryptCost)
+	if err != nil {
+		log.Fatalf("Failed to seed users: %v", err)
+	}
+
+	fmt.Println()
+	fmt.Println("Seeded credentials (demo only):")
+	for i, u := range seedUsers {
+		fmt.Printf("  %-8s email=%s password=%s userId=%s\n", u.Role, u.Email, u.Password, users[i].ID)
+	}
+	fmt.Println()
+	fmt.Printf("管理者 API を使うには .env に ADMIN_USER_IDS=%s を設定して API サーバーを再起動してください\n", users[0].ID)
+}
+
+// seedCatalog は商品と価格履歴を投入する（SKU が既に存在する商品はスキップ）
+// 価格履歴は過去の日付で記録し、最後の価格を商品の現在価格にする
+func seedCatalog(ctx context.Context, productRepo *repository.ProductRepository, priceHistoryRepo *repository.PriceHistoryRepository) error {
+	existing, err := productRepo.List(ctx, "", true)
+	if err != nil {
+		return err
+	}
+	skus := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		skus[p.SKU] = true
+	}
+
+	now := time.Now()
+	for _, sp := range seedProducts {
+		if skus[sp.SKU] {
+			log.Printf("Product %s already exists, skipping", sp.SKU)
+			continue
+		}
+
+		product := &domain.Product{
+			SKU:         sp.SKU,
+			Name:        sp.Name,
+			Description: sp.Description,
+			Price:       sp.History[len(sp.History)-1],
+			Category:    sp.Category,
+			Stock:       sp.Stock,
+		}
+		if err := productRepo.Create(ctx, product); err != nil {
+			return fmt.Errorf("create product %s: %w", sp.SKU, err)
+		}
+
+		for i, price := range sp.History {
+			history := &domain.PriceHistory{
+				ProductID: product.ID,
+				Price:     price,
+				ChangedBy: "seed",
+				Timestamp: now.Add(-time.Duration(len(sp.History)-1-i) * priceHistoryInterval),
+			}
+			if err := priceHistoryRepo.Create(ctx, history); err != nil {
+				return fmt.Errorf("create price history for %s: %w", sp.SKU, err)
+			}
+		}
+		log.Printf("Created product %s (%s) with %d price history entries", sp.SKU, sp.Name, len(sp.History))
+	}
+	return nil
+}
+
+// seedAccounts はユーザーを投入し、seedUsers と同じ順序でユーザーを返す
+// 同じメールアドレスのユーザーが既に存在する場合は作成せずにそのユーザーを返す
+func seedAccounts(ctx context.Context, userRepo *repository.UserRepository, bcryptCost int) ([]*domain.User, error) {
+	users := make([]*domain.User, 0, len(seedUsers))
+	for _, su := range seedUsers {
+		user, err := userRepo.GetByEmail(ctx, su.Email)
+		if err == nil {
+			log.Printf("User %s already exists, skipping", su.Email)
+			users = append(users, user)
+			continue
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(su.Password), bcryptCost)
+		if err != nil {
+			return nil, err
+		}
+		user = &domain.User{
+			Email:        su.Email,
+			Name:         su.Name,
+			PasswordHash: string(hashedPassword),
+		}
+		if err := userRepo.Create(ctx, user); err != nil {
+			return nil, fmt.Errorf("create user %s: %w", su.Email, err)
+		}
+		log.Printf("Created user %s", su.Email)
+		users = append(users, user)
+	}
+	return users, nil
+}
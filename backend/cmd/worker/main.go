@@ -0,0 +1,114 @@
+// backend/cmd/worker/main.go
+// DynamoDB Streams を読み、注文明細の作成から商品ごとの集計を更新するワーカー
+//   - 販売実績（PRODUCT#<id> / STATS）
+//   - 一緒に購入されている商品の回数（PRODUCT#<id> / ALSOBOUGHT#<otherId>）
+// あわせて、TTL で削除された在庫の取り置きの数量を商品の reservedStock に戻す
+//
+// 【使い方】
+//   cd backend
+//   go run cmd/worker/main.go
+//   - API サーバーと同じ .env / 環境変数（AWS_REGION, DYNAMODB_TABLE, DYNAMODB_ENDPOINT）を読む
+//   - DYNAMODB_ENDPOINT を設定すると DynamoDB Local のストリームをポーリングする
+//   - STREAM_ARN を省略した場合はテーブルの最新のストリーム（LatestStreamArn）を読む
+//
+// 【前提】テーブルの Streams が有効であること（cmd/migrate で作成したテーブルは NEW_AND_OLD_IMAGES）
+// 複数台で動かすと同じシャードを重複して読む（集計は冪等なので結果は変わらないが、書き込みが増える）ため1台で動かす
+
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/config"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/stream"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// .envファイルの読み込み（存在する場合）
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+
+	// SIGINT / SIGTERM で停止する（処理中のレコードを終えてから終了する）
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbClient, err := repository.NewDynamoDBClient(ctx, cfg.DynamoDBTable, cfg.DynamoDBEndpoint, cfg.AWSRegion, repository.RetryConfig{})
+	if err != nil {
+		log.Fatalf("Failed to initialize DynamoDB client: %v", err)
+	}
+	dbClient = dbClient.WithTimeout(parseDuration("DYNAMODB_TIMEOUT", cfg.DynamoDBTimeout, 5*time.Second))
+
+	streamsClient, err := newStreamsClient(ctx, cfg.DynamoDBEndpoint, cfg.AWSRegion)
+	if err != nil {
+		log.Fatalf("Failed to initialize DynamoDB Streams client: %v", err)
+	}
+
+	streamARN := cfg.StreamARN
+	if streamARN == "" {
+		out, err := dbClient.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: dbClient.Table()})
+		if err != nil {
+			log.Fatalf("Failed to describe table: %v", err)
+		}
+		streamARN = aws.ToString(out.Table.LatestStreamArn)
+		if streamARN == "" {
+			log.Fatalf("Streams are not enabled on table %s", cfg.DynamoDBTable)
+		}
+	}
+
+	salesStatsRepo := repository.NewSalesStatsRepository(dbClient)
+	orderRepo := repository.NewOrderRepository(dbClient)
+	alsoBoughtRepo := repository.NewAlsoBoughtRepository(dbClient)
+	handlers := stream.Handlers{
+		stream.NewSalesAggregator(salesStatsRepo),
+		stream.NewAlsoBoughtAggregator(orderRepo, alsoBoughtRepo),
+		stream.NewHoldReleaser(repository.NewHoldRepository(dbClient)),
+	}
+	poller := stream.NewPoller(streamsClient, streamARN, handlers, parseDuration("STREAM_POLL_INTERVAL", cfg.StreamPollInterval, time.Second))
+
+	log.Printf("Worker started (stream: %s)", streamARN)
+	poller.Run(ctx)
+	log.Println("Worker stopped")
+}
+
+// newStreamsClient は DynamoDB Streams のクライアントを生成する
+// endpoint を指定した場合は DynamoDB Local などのカスタムエンドポイントに接続する
+func newStreamsClient(ctx context.Context, endpoint, region string) (*dynamodbstreams.Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamodbstreams.NewFromConfig(awsCfg, func(o *dynamodbstreams.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}), nil
+}
+
+// parseDuration は時間の設定（time.ParseDuration 形式）を解析する
+// 解析できない、または 0 以下の場合は警告を出して既定値を使う
+func parseDuration(name, value string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using default %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -0,0 +1,90 @@
+// Package apperr はクライアントに返すエラーを表す型を提供する
+//
+// 【目的】
+// サービスがエラーに HTTP ステータスと機械可読なコードを持たせることで、
+// ハンドラーごとに errors.Is を並べてステータスを決める処理をなくす
+//
+// 【使い方】
+//
+//	var ErrCartEmpty = apperr.BadRequest("cart_empty", "Cart is empty")
+//
+//	// 原因（リポジトリのエラーなど）を付けて返す（errors.Is / errors.As で原因も辿れる）
+//	return nil, ErrCartEmpty.WithCause(err)
+//
+//	// ハンドラー
+//	apperr.WriteError(w, r, err)
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// Error はクライアントに返すエラー
+// Message はそのままレスポンスに含めるため、内部の情報を含めないこと
+type Error struct {
+	Status  int    // HTTP ステータス
+	Code    string // 機械可読なコード（例: insufficient_stock）
+	Message string // クライアント向けのメッセージ
+	Err     error  // 原因（ログ・errors.Is 用。レスポンスには含めない）
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// WithCause は原因となったエラーを付けたコピーを返す
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is はステータスとコードが同じ *Error を同じエラーとみなす
+// → WithCause で作ったコピーも errors.Is(err, ErrXxx) で判定できる
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Status == e.Status && t.Code == e.Code
+}
+
+// WriteError はエラーをレスポンスに書き込む
+// *Error（ラップされたものを含む）の場合はそのステータス・メッセージ・コードを返す
+// それ以外の想定外のエラーは内部の情報を出さないよう 500 と汎用のメッセージを返し、原因をログに出力する
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Status >= http.StatusInternalServerError {
+			middleware.LogError(r.Context(), e.Message, err)
+		}
+		response.ErrorWithCode(w, e.Status, e.Message, e.Code)
+		return
+	}
+	middleware.LogError(r.Context(), "Internal server error", err)
+	response.Error(w, http.StatusInternalServerError, "Internal server error")
+}
@@ -0,0 +1,23 @@
+// backend/internal/cache/cache.go
+// 読み込みの多いデータをキャッシュするためのインターフェース
+//
+// 【実装】
+//   Memory: プロセス内の TTL + 最大件数（LRU）付きキャッシュ
+//   複数のインスタンスで共有したい場合は、同じインターフェースで Redis などの実装に差し替える
+//
+// 【注意】キャッシュの値は古い可能性がある
+//   在庫数など、古い値で判断すると売り越しになる処理（注文確定など）ではキャッシュを使わないこと
+
+package cache
+
+import "context"
+
+// Cache はキーごとに値を保持するキャッシュ
+// 取得できない（期限切れ・未登録・キャッシュの障害）場合は ok=false を返し、呼び出し側は元のデータを読む
+type Cache[V any] interface {
+	Get(ctx context.Context, key string) (value V, ok bool)
+	Set(ctx context.Context, key string, value V)
+	Delete(ctx context.Context, key string)
+	// Clear はすべてのキーを削除する
+	Clear(ctx context.Context)
+}
@@ -0,0 +1,102 @@
+// backend/internal/cache/memory.go
+// プロセス内の TTL 付きキャッシュ
+//
+// 【追い出し】
+//   - 書き込みから ttl を過ぎた値は Get で返さず削除する
+//   - 件数が maxSize を超えた場合は、最も長く使われていない（LRU）値から削除する
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// Memory は Cache のプロセス内実装（複数のゴルーチンから安全に使える）
+type Memory[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[string]*list.Element // キー → lru の要素
+	lru     *list.List               // 先頭ほど最近使われた値
+}
+
+var _ Cache[any] = (*Memory[any])(nil)
+
+// NewMemory は値を ttl の間、最大 maxSize 件まで保持するキャッシュを生成する
+func NewMemory[V any](ttl time.Duration, maxSize int) *Memory[V] {
+	return &Memory[V]{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *Memory[V]) Get(_ context.Context, key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := elem.Value.(*memoryEntry[V])
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return zero, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *Memory[V]) Set(_ context.Context, key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Memory[V]) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *Memory[V]) Clear(_ context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// remove は要素を削除する（c.mu を取得した状態で呼ぶ）
+func (c *Memory[V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry[V]).key)
+}
@@ -1,16 +1,70 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
 	AWSRegion        string
 	DynamoDBTable    string
 	DynamoDBEndpoint string // ローカル開発用
+	DynamoDBTimeout  string // DynamoDB の1操作あたりの制限時間（SDK のリトライを含む）
+
+	// スロットリング・一時的なエラーの再試行
+	DynamoDBMaxAttempts string // 初回を含む最大試行回数
+	DynamoDBMaxBackoff  string // 再試行までの待ち時間の上限（time.ParseDuration 形式）
+
 	JWTSecret        string
 	JWTExpiry        string
+	JWTRefreshExpiry string
 	ServerPort       string
+	AdminUserIDs     string // 管理者のユーザーID（カンマ区切り）。空の場合は管理者用 API を誰も使えない
+
+	// HTTP サーバーのタイムアウト（time.ParseDuration 形式）
+	ReadTimeout       string
+	ReadHeaderTimeout string // ヘッダー読み込みの上限（slowloris 対策）
+	WriteTimeout      string // CSV エクスポートなど長いレスポンスがある場合は長めにする
+	IdleTimeout       string
+
+	PriceSchedulerInterval     string // 予約価格の適用間隔
+	ReservationSweeperInterval string // 期限切れの在庫の仮押さえ（注文確定中）を解放する間隔
+	MaxOrderItemsPerPage       string // 注文詳細で1回に返す明細の上限
+	MaxCartItems               string // 1つのカートに入れられる商品の種類数の上限
+	MaxItemQuantity            string // カートの1商品あたりの数量の上限
+	SKUPrefix                  string // SKU 自動採番時の接頭辞
+	BcryptCost                 int    // パスワードハッシュの計算コスト（4〜31、範囲外は既定値）
+	OrderPricePolicy           string // 注文確定時の価格: snapshot（カート追加時）/ current（現在の商品価格）
+	TaxRate                    string // 注文確定時に適用する消費税率（例: 0.10）
+	MetricsEnabled             string // "true" の場合 GET /metrics で Prometheus メトリクスを公開する
+
+	// カート内の商品の在庫の取り置き
+	CartReservationsEnabled string // "true" の場合 POST /api/v1/cart/items/{productId}/reserve を有効にする
+	CartHoldDuration        string // 取り置きの有効期間（time.ParseDuration 形式）
+	HoldSweeperInterval     string // 期限切れの取り置きを解放する間隔
+
+	// DynamoDB Streams のワーカー（cmd/worker）
+	StreamARN          string // 空の場合はテーブルの LatestStreamArn
+	StreamPollInterval string // シャードをポーリングする間隔
+	BestsellerCacheTTL string // 売れ筋商品の一覧をメモリにキャッシュする時間
+	FacetCacheTTL      string // カテゴリ別の商品数をメモリにキャッシュする時間
+
+	// 商品の読み込み（詳細・一覧）のキャッシュ
+	ProductCacheTTL     string // 0 の場合はキャッシュしない
+	ProductCacheMaxSize string // キャッシュする件数の上限（商品・一覧それぞれ）
+
+	// メール送信（パスワード再設定・注文確認）
+	EmailProvider string // log（送信せずログに出力）/ ses（Amazon SES）
+	EmailFrom     string // 送信元アドレス（SES で検証済みのもの）
+
+	// CORS（いずれもカンマ区切り）
+	CORSAllowedOrigins string // 空の場合は "*"（全オリジン許可・開発用）
+	CORSAllowedMethods string
+	CORSAllowedHeaders string
 }
 
 func Load() *Config {
@@ -18,10 +72,79 @@ func Load() *Config {
 		AWSRegion:        getEnv("AWS_REGION", "ap-northeast-1"),
 		DynamoDBTable:    getEnv("DYNAMODB_TABLE", "DynamoDBShop"),
 		DynamoDBEndpoint: getEnv("DYNAMODB_ENDPOINT", ""), // 空の場合はAWS実環境
+		DynamoDBTimeout:  getEnv("DYNAMODB_TIMEOUT", "5s"),
+
+		DynamoDBMaxAttempts: getEnv("DYNAMODB_MAX_ATTEMPTS", "5"),
+		DynamoDBMaxBackoff:  getEnv("DYNAMODB_MAX_BACKOFF", "1s"),
+
 		JWTSecret:        getEnv("JWT_SECRET", "default-secret-change-me"),
 		JWTExpiry:        getEnv("JWT_EXPIRY", "24h"),
+		JWTRefreshExpiry: getEnv("JWT_REFRESH_EXPIRY", "168h"),
 		ServerPort:       getEnv("SERVER_PORT", "8080"),
+		AdminUserIDs:     getEnv("ADMIN_USER_IDS", ""),
+
+		ReadTimeout:       getEnv("READ_TIMEOUT", "15s"),
+		ReadHeaderTimeout: getEnv("READ_HEADER_TIMEOUT", "5s"),
+		WriteTimeout:      getEnv("WRITE_TIMEOUT", "15s"),
+		IdleTimeout:       getEnv("IDLE_TIMEOUT", "60s"),
+
+		PriceSchedulerInterval:     getEnv("PRICE_SCHEDULER_INTERVAL", "1m"),
+		ReservationSweeperInterval: getEnv("RESERVATION_SWEEPER_INTERVAL", "1m"),
+		MaxOrderItemsPerPage:       getEnv("MAX_ORDER_ITEMS_PER_PAGE", "100"),
+		MaxCartItems:               getEnv("MAX_CART_ITEMS", "50"),
+		MaxItemQuantity:            getEnv("MAX_ITEM_QUANTITY", "99"),
+		SKUPrefix:                  getEnv("SKU_PREFIX", "PRD"),
+		BcryptCost:                 getBcryptCost("BCRYPT_COST"),
+		OrderPricePolicy:           getEnv("ORDER_PRICE_POLICY", "snapshot"),
+		TaxRate:                    getEnv("TAX_RATE", "0.10"),
+		MetricsEnabled:             getEnv("METRICS_ENABLED", "false"),
+
+		CartReservationsEnabled: getEnv("CART_RESERVATIONS_ENABLED", "false"),
+		CartHoldDuration:        getEnv("CART_HOLD_DURATION", "15m"),
+		HoldSweeperInterval:     getEnv("HOLD_SWEEPER_INTERVAL", "1m"),
+
+		StreamARN:          getEnv("STREAM_ARN", ""),
+		StreamPollInterval: getEnv("STREAM_POLL_INTERVAL", "1s"),
+		BestsellerCacheTTL: getEnv("BESTSELLER_CACHE_TTL", "1m"),
+		FacetCacheTTL:      getEnv("FACET_CACHE_TTL", "5m"),
+
+		ProductCacheTTL:     getEnv("PRODUCT_CACHE_TTL", "30s"),
+		ProductCacheMaxSize: getEnv("PRODUCT_CACHE_MAX_SIZE", "1000"),
+
+		EmailProvider: getEnv("EMAIL_PROVIDER", "log"),
+		EmailFrom:     getEnv("EMAIL_FROM", "no-reply@example.com"),
+
+		CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
+		CORSAllowedMethods: getEnv("CORS_ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS"),
+		CORSAllowedHeaders: getEnv("CORS_ALLOWED_HEADERS", "Content-Type, Authorization, Idempotency-Key, X-Request-ID"),
+	}
+}
+
+// SplitList はカンマ区切りの設定値を分割する（前後の空白と空要素は除く）
+func SplitList(value string) []string {
+	parts := strings.Split(value, ",")
+	list := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// getBcryptCost は bcrypt のコストを読み込む
+// 数値でない、または bcrypt の許容範囲（MinCost〜MaxCost）外の場合は警告を出して既定値（DefaultCost）を使う
+func getBcryptCost(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return bcrypt.DefaultCost
+	}
+	cost, err := strconv.Atoi(value)
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Printf("Invalid %s %q (must be %d-%d), using default %d", key, value, bcrypt.MinCost, bcrypt.MaxCost, bcrypt.DefaultCost)
+		return bcrypt.DefaultCost
 	}
+	return cost
 }
 
 func getEnv(key, defaultValue string) string {
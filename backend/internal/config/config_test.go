@@ -0,0 +1,42 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGetBcryptCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		want     int
+		wantWarn bool
+	}{
+		{name: "未設定は既定値", value: "", want: bcrypt.DefaultCost},
+		{name: "範囲内の値", value: "4", want: 4},
+		{name: "最小値未満は既定値", value: "3", want: bcrypt.DefaultCost, wantWarn: true},
+		{name: "最大値超過は既定値", value: "32", want: bcrypt.DefaultCost, wantWarn: true},
+		{name: "数値でない値は既定値", value: "high", want: bcrypt.DefaultCost, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", tt.value)
+			var buf bytes.Buffer
+			prev := log.Writer()
+			log.SetOutput(&buf)
+			t.Cleanup(func() { log.SetOutput(prev) })
+
+			if got := getBcryptCost("BCRYPT_COST"); got != tt.want {
+				t.Errorf("getBcryptCost() = %d, want %d", got, tt.want)
+			}
+			if warned := strings.Contains(buf.String(), "Invalid BCRYPT_COST"); warned != tt.wantWarn {
+				t.Errorf("warning logged = %v, want %v (log: %q)", warned, tt.wantWarn, buf.String())
+			}
+		})
+	}
+}
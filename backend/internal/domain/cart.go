@@ -11,6 +11,11 @@ type CartItem struct {
 	Version     int       `json:"version" dynamodbav:"Version"` // 楽観的ロック用
 	AddedAt     time.Time `json:"addedAt" dynamodbav:"AddedAt"`
 	UpdatedAt   time.Time `json:"updatedAt" dynamodbav:"UpdatedAt"`
+	// 価格の再計算（GET /cart?refreshPrices=true）で現在の商品価格に更新された場合のみ設定される
+	PriceChanged  bool `json:"priceChanged,omitempty" dynamodbav:"-"`
+	PreviousPrice int  `json:"previousPrice,omitempty" dynamodbav:"-"` // 更新前（カート追加時点）の価格
+	// 商品が削除された（注文できない）場合のみ設定される。合計金額には含めない
+	Unavailable bool `json:"unavailable,omitempty" dynamodbav:"-"`
 }
 
 type AddToCartRequest struct {
@@ -28,3 +33,32 @@ type Cart struct {
 	TotalPrice int        `json:"totalPrice"`
 	ItemCount  int        `json:"itemCount"`
 }
+
+// CartCount はカート内のアイテム数（ヘッダーのバッジ表示用）
+type CartCount struct {
+	Count int `json:"count"`
+}
+
+// AbandonedCart は一定期間更新されていない（放置された）カートの集計
+type AbandonedCart struct {
+	UserID        string    `json:"userId"`
+	ItemCount     int       `json:"itemCount"`
+	TotalValue    int       `json:"totalValue"`
+	LastUpdatedAt time.Time `json:"lastUpdatedAt"`
+}
+
+type AbandonedCartPage struct {
+	Carts      []AbandonedCart `json:"carts"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// StockHold はカート内の商品の在庫の取り置き（CART_RESERVATIONS_ENABLED=true の場合のみ）
+// 取り置き中の数量は商品の reservedStock に含まれ、他のユーザーは購入できない
+// 期限（ExpiresAt）を過ぎた取り置きはバックグラウンドで解放される
+type StockHold struct {
+	UserID    string    `json:"userId"`
+	ProductID string    `json:"productId"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
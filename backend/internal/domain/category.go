@@ -0,0 +1,13 @@
+package domain
+
+// Category は商品カテゴリと、そのカテゴリに属する商品数
+// 【キー設計】
+//
+//	PK: CATEGORY#<name>
+//	SK: METADATA
+//
+// dynamodbav タグは DynamoDB 上の属性名（repository.categoryRecord）と揃えている
+type Category struct {
+	Name         string `json:"name" dynamodbav:"name"`
+	ProductCount int    `json:"productCount" dynamodbav:"productCount"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Coupon はクーポン（割引コード）
+// 【キー設計】
+//
+//	PK: COUPON#<code>
+//	SK: METADATA
+type Coupon struct {
+	Code      string    `json:"code" dynamodbav:"Code"`
+	Type      string    `json:"type" dynamodbav:"Type"`   // PERCENT, FIXED
+	Value     int       `json:"value" dynamodbav:"Value"` // PERCENT: 割引率(%), FIXED: 割引額(円)
+	StartsAt  time.Time `json:"startsAt" dynamodbav:"StartsAt"`
+	ExpiresAt time.Time `json:"expiresAt" dynamodbav:"ExpiresAt"`
+	MaxUses   int       `json:"maxUses" dynamodbav:"MaxUses"`
+	UsedCount int       `json:"usedCount" dynamodbav:"UsedCount"`
+	UserID    string    `json:"userId,omitempty" dynamodbav:"UserId,omitempty"` // 特定ユーザー専用クーポンの場合のみ
+	CreatedAt time.Time `json:"createdAt" dynamodbav:"CreatedAt"`
+}
+
+// CouponValidation はクーポン検証結果
+// Valid=false の場合は Reason に無効な理由が入る
+type CouponValidation struct {
+	Code          string    `json:"code"`
+	Valid         bool      `json:"valid"`
+	Reason        string    `json:"reason,omitempty"` // EXPIRED, EXHAUSTED, NOT_APPLICABLE
+	Type          string    `json:"type"`
+	Value         int       `json:"value"`
+	RemainingUses int       `json:"remainingUses"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// CreateCouponRequest はクーポン作成リクエスト（管理者用）
+// StartsAt を省略した場合は作成直後から利用できる
+type CreateCouponRequest struct {
+	Code      string     `json:"code"`
+	Type      string     `json:"type"`  // PERCENT, FIXED
+	Value     int        `json:"value"` // PERCENT: 1〜100, FIXED: 1以上
+	StartsAt  *time.Time `json:"startsAt,omitempty"`
+	ExpiresAt time.Time  `json:"expiresAt"` // 必須（無期限のクーポンは作れない）
+	MaxUses   int        `json:"maxUses"`   // 1以上（0 は無制限ではなくエラー）
+	UserID    string     `json:"userId,omitempty"`
+}
+
+const (
+	CouponTypePercent = "PERCENT"
+	CouponTypeFixed   = "FIXED"
+)
+
+// クーポンが無効な理由
+const (
+	CouponReasonExpired       = "EXPIRED"        // 有効期間外
+	CouponReasonExhausted     = "EXHAUSTED"      // 利用回数の上限に達した
+	CouponReasonNotApplicable = "NOT_APPLICABLE" // 利用開始前（他のユーザー専用のクーポンは存在しないものとして扱う）
+)
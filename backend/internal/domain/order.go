@@ -8,14 +8,27 @@ import "time"
 //	PK: USER#<userId>
 //	SK: ORDER#<orderId>
 type Order struct {
-	ID          string      `json:"id" dynamodbav:"OrderId"`
-	UserID      string      `json:"userId" dynamodbav:"UserId"`
-	Status      string      `json:"status" dynamodbav:"Status"` // PENDING, CONFIRMED, SHIPPED, DELIVERED, CANCELLED
-	TotalAmount int         `json:"totalAmount" dynamodbav:"TotalAmount"`
-	ItemCount   int         `json:"itemCount" dynamodbav:"ItemCount"`
-	Items       []OrderItem `json:"items,omitempty"`
-	CreatedAt   time.Time   `json:"createdAt" dynamodbav:"CreatedAt"`
-	UpdatedAt   time.Time   `json:"updatedAt" dynamodbav:"UpdatedAt"`
+	ID               string      `json:"id" dynamodbav:"OrderId"`
+	UserID           string      `json:"userId" dynamodbav:"UserId"`
+	CustomerName     string      `json:"customerName,omitempty" dynamodbav:"-"`    // 管理者向け一覧でのみ設定（保存しない）
+	CustomerEmail    string      `json:"customerEmail,omitempty" dynamodbav:"-"`   // 同上
+	Status           string      `json:"status" dynamodbav:"Status"`               // PENDING, CONFIRMED, SHIPPED, DELIVERED, CANCELLED
+	PaymentStatus    string      `json:"paymentStatus" dynamodbav:"PaymentStatus"` // UNPAID, PAID, REFUNDED（Status とは独立）
+	PaidAt           *time.Time  `json:"paidAt,omitempty" dynamodbav:"PaidAt"`
+	PaymentReference string      `json:"paymentReference,omitempty" dynamodbav:"PaymentReference"` // 決済代行会社の取引IDなど
+	Subtotal         int         `json:"subtotal" dynamodbav:"Subtotal"`                           // 割引前の合計（明細の小計の和）
+	CouponCode       string      `json:"couponCode,omitempty" dynamodbav:"CouponCode"`             // 適用したクーポン（未使用の場合は空）
+	DiscountAmount   int         `json:"discountAmount" dynamodbav:"DiscountAmount"`               // クーポンによる割引額
+	TotalAmount      int         `json:"totalAmount" dynamodbav:"TotalAmount"`                     // 税抜の支払額（Subtotal - DiscountAmount）
+	TaxRate          float64     `json:"taxRate" dynamodbav:"TaxRate"`                             // 注文確定時の消費税率（例: 0.1。導入前の注文は 0）
+	TaxAmount        int         `json:"taxAmount" dynamodbav:"TaxAmount"`                         // 消費税額（TotalAmount × TaxRate、1円未満は四捨五入）
+	GrandTotal       int         `json:"grandTotal" dynamodbav:"GrandTotal"`                       // 税込の支払額（TotalAmount + TaxAmount）
+	ItemCount        int         `json:"itemCount" dynamodbav:"ItemCount"`
+	Items            []OrderItem `json:"items,omitempty"`
+	ItemsNextToken   string      `json:"itemsNextToken,omitempty"` // 明細の続きを取得するトークン（1ページに収まる場合は空）
+	ShippingAddress  *Address    `json:"shippingAddress,omitempty" dynamodbav:"ShippingAddress"`
+	CreatedAt        time.Time   `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt        time.Time   `json:"updatedAt" dynamodbav:"UpdatedAt"`
 }
 
 // OrderItem は注文明細
@@ -32,6 +45,45 @@ type OrderItem struct {
 	Subtotal    int    `json:"subtotal" dynamodbav:"Subtotal"` // Price * Quantity
 }
 
+// Invoice は注文の請求書（GET /api/v1/orders/{id}/invoice）
+// 金額はすべて注文確定時に保存した値から組み立てる（税率が後で変わっても同じ請求書になる）
+type Invoice struct {
+	OrderID         string        `json:"orderId"`
+	OrderedAt       time.Time     `json:"orderedAt"`
+	Lines           []InvoiceLine `json:"lines"`
+	Subtotal        int           `json:"subtotal"`             // 明細の小計の和
+	CouponCode      string        `json:"couponCode,omitempty"` // 割引がない場合は空
+	DiscountAmount  int           `json:"discountAmount"`
+	TaxableAmount   int           `json:"taxableAmount"` // 課税対象額（Subtotal - DiscountAmount）
+	TaxRate         float64       `json:"taxRate"`
+	TaxAmount       int           `json:"taxAmount"`
+	GrandTotal      int           `json:"grandTotal"` // 税込の請求額（TaxableAmount + TaxAmount）
+	ShippingAddress *Address      `json:"shippingAddress,omitempty"`
+}
+
+// InvoiceLine は請求書の明細1行
+type InvoiceLine struct {
+	ProductID   string `json:"productId"`
+	ProductName string `json:"productName"`
+	UnitPrice   int    `json:"unitPrice"`
+	Quantity    int    `json:"quantity"`
+	Subtotal    int    `json:"subtotal"` // UnitPrice × Quantity
+}
+
+// OrderSummary はユーザーの注文の集計（GET /api/v1/orders/summary）
+type OrderSummary struct {
+	TotalOrders  int            `json:"totalOrders"`           // 注文数（キャンセルを含む）
+	TotalSpent   int            `json:"totalSpent"`            // 税込支払額の合計（キャンセルした注文を除く）
+	StatusCounts map[string]int `json:"statusCounts"`          // ステータスごとの注文数
+	LastOrderAt  *time.Time     `json:"lastOrderAt,omitempty"` // 最後に注文した日時（注文がない場合は省略）
+}
+
+// OrderPage はページングされた注文一覧
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
 type Address struct {
 	ZipCode    string `json:"zipCode" dynamodbav:"ZipCode"`
 	Prefecture string `json:"prefecture" dynamodbav:"Prefecture"`
@@ -41,12 +93,18 @@ type Address struct {
 
 type CreateOrderRequest struct {
 	ShippingAddress *Address `json:"shippingAddress"`
+	CouponCode      string   `json:"couponCode,omitempty"` // 任意: 適用するクーポンコード
 }
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
 }
 
+// MarkPaidRequest は入金済みにする際のリクエスト
+type MarkPaidRequest struct {
+	Reference string `json:"reference"`
+}
+
 const (
 	OrderStatusPending   = "PENDING"
 	OrderStatusConfirmed = "CONFIRMED"
@@ -54,3 +112,31 @@ const (
 	OrderStatusDelivered = "DELIVERED"
 	OrderStatusCancelled = "CANCELLED"
 )
+
+// IsValidOrderStatus は status が定義済みの注文ステータスかを判定する
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// 支払いステータス（配送の進捗を表す Status とは別に管理する）
+const (
+	PaymentStatusUnpaid   = "UNPAID"
+	PaymentStatusPaid     = "PAID"
+	PaymentStatusRefunded = "REFUNDED"
+)
+
+// StockReservation は注文確定中の在庫の仮押さえ（チェックアウト1回につき1件）
+// 仮押さえした数量は商品の reservedStock に含まれ、注文確定のトランザクションで消費される
+// 注文確定の前にプロセスが落ちた場合も、期限（ExpiresAt）を過ぎるとバックグラウンドで解放される
+type StockReservation struct {
+	ID         string
+	UserID     string
+	Quantities map[string]int // 商品ID → 仮押さえする数量
+	ProductIDs []string       // 仮押さえ済み（reservedStock に加算済み）の商品ID
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
@@ -2,20 +2,102 @@ package domain
 
 import "time"
 
+// Product は商品
+// 【キー設計】
+//
+//	PK: PRODUCT#<productId>
+//	SK: METADATA
+//
+// dynamodbav タグは DynamoDB 上の属性名（repository.productRecord）と揃えている
+// 在庫は "stock" 属性のみで管理し、注文確定・在庫調整・取得のすべてで同じ属性を参照する
 type Product struct {
-	ID          string    `json:"id" dynamodbav:"ProductId"`
-	Name        string    `json:"name" dynamodbav:"Name"`
-	Description string    `json:"description" dynamodbav:"Description"`
-	Price       int       `json:"price" dynamodbav:"Price"`
-	Category    string    `json:"category" dynamodbav:"Category"`
-	Stock       int       `json:"stock" dynamodbav:"Stock"`
-	ImageURL    string    `json:"imageUrl" dynamodbav:"ImageUrl"`
-	Version     int       `json:"version" dynamodbav:"Version"` // 楽観的ロック用
-	CreatedAt   time.Time `json:"createdAt" dynamodbav:"CreatedAt"`
-	UpdatedAt   time.Time `json:"updatedAt" dynamodbav:"UpdatedAt"`
+	ID            string     `json:"id" dynamodbav:"id"`
+	SKU           string     `json:"sku,omitempty" dynamodbav:"sku,omitempty"`
+	Name          string     `json:"name" dynamodbav:"name"`
+	Description   string     `json:"description" dynamodbav:"description"`
+	Price         int        `json:"price" dynamodbav:"price"`
+	Category      string     `json:"category" dynamodbav:"category"`
+	Stock         int        `json:"stock" dynamodbav:"stock"`
+	ReservedStock int        `json:"-" dynamodbav:"reservedStock,omitempty"` // チェックアウト中・取り置き中の数量（内部の値のため API では返さない）
+	ImageURL      string     `json:"imageUrl" dynamodbav:"imageUrl"`
+	Version       int        `json:"version" dynamodbav:"version,omitempty"` // 楽観的ロック用（価格変更時に照合する）
+	CreatedAt     time.Time  `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt" dynamodbav:"updatedAt"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" dynamodbav:"deletedAt,omitempty"` // 論理削除日時
+}
+
+// ProductSalesStats は商品ごとの販売実績（DynamoDB Streams のワーカーが注文明細から集計する）
+// 【キー設計】
+//
+//	PK: PRODUCT#<productId>
+//	SK: STATS
+type ProductSalesStats struct {
+	ProductID  string    `json:"productId"`
+	UnitsSold  int       `json:"unitsSold"`
+	Revenue    int       `json:"revenue"`
+	OrderCount int       `json:"orderCount"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Bestseller は売れ筋商品（商品の内容 + 販売数）
+type Bestseller struct {
+	Product
+	UnitsSold int `json:"unitsSold"`
+}
+
+// AlsoBought は商品と同じ注文で購入された別の商品の回数（DynamoDB Streams のワーカーが注文明細から集計する）
+// 【キー設計】
+//
+//	PK: PRODUCT#<productId>
+//	SK: ALSOBOUGHT#<otherProductId>
+type AlsoBought struct {
+	ProductID      string `json:"productId"`
+	OtherProductID string `json:"otherProductId"`
+	Count          int    `json:"count"`
+}
+
+// RelatedProduct は一緒に購入されている商品（商品の内容 + 同じ注文で購入された回数）
+type RelatedProduct struct {
+	Product
+	BoughtTogether int `json:"boughtTogether"`
+}
+
+// RelatedProductPage はページングされた「一緒に購入されている商品」
+type RelatedProductPage struct {
+	Products  []*RelatedProduct `json:"products"`
+	NextToken string            `json:"nextToken,omitempty"`
+}
+
+// ProductFacets は商品一覧の絞り込み条件ごとの商品数（サイドバーの表示用）
+// 例: {"category": {"electronics": 42, "clothing": 17}}
+type ProductFacets struct {
+	Category map[string]int `json:"category"`
+}
+
+// ProductSummary は商品一覧のカード表示に必要な項目だけの商品（説明文などを含まない）
+type ProductSummary struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Price    int    `json:"price"`
+	ImageURL string `json:"imageUrl"`
+	Stock    int    `json:"stock"`
+}
+
+// ProductAvailability は指定した数量を購入できるかどうか（商品詳細を読まずに確認する用）
+// Stock は購入可能数（在庫からチェックアウト中の仮押さえ分を除いた数）
+type ProductAvailability struct {
+	Available bool `json:"available"`
+	Stock     int  `json:"stock"`
+}
+
+// ProductPage はページングされた商品一覧
+type ProductPage struct {
+	Products  []*Product `json:"products"`
+	NextToken string     `json:"nextToken,omitempty"`
 }
 
 type CreateProductRequest struct {
+	SKU         string `json:"sku"` // 省略時は自動採番（例: PRD-000123）
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       int    `json:"price"`
@@ -24,6 +106,26 @@ type CreateProductRequest struct {
 	ImageURL    string `json:"imageUrl"`
 }
 
+// Validate は商品作成リクエストを検証し、フィールド名 → エラーメッセージ のマップを返す
+// 問題がない場合は nil を返す
+func (r *CreateProductRequest) Validate() map[string]string {
+	fields := make(map[string]string)
+	if r.Name == "" {
+		fields["name"] = "is required"
+	}
+	if r.Price <= 0 {
+		fields["price"] = "must be positive"
+	}
+	if r.Stock < 0 {
+		fields["stock"] = "must not be negative"
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
 type UpdateProductRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -38,6 +140,58 @@ type PriceHistory struct {
 	Price     int       `json:"price" dynamodbav:"Price"`
 	ChangedBy string    `json:"changedBy" dynamodbav:"ChangedBy"`
 	Timestamp time.Time `json:"timestamp" dynamodbav:"CreatedAt"`
+	// 取得した範囲内で1つ前（古い側）の価格との比較。最も古い点（比較対象がない）は設定しない
+	ChangePercent *float64 `json:"changePercent,omitempty" dynamodbav:"-"` // 変化率（%、小数第2位まで）。前の価格が0の場合は設定しない
+	Direction     string   `json:"direction,omitempty" dynamodbav:"-"`     // PriceDirectionUp / PriceDirectionDown / PriceDirectionFlat
+}
+
+// PriceUpdate は価格変更の結果
+type PriceUpdate struct {
+	Price   int           `json:"price"`   // 変更後の価格
+	Product *Product      `json:"product"` // 更新後の商品
+	History *PriceHistory `json:"history"` // 記録した価格履歴（価格が変わらなかった場合は null）
+}
+
+// 価格履歴の変化の向き
+const (
+	PriceDirectionUp   = "up"
+	PriceDirectionDown = "down"
+	PriceDirectionFlat = "flat"
+)
+
+// 価格履歴の間引き（GET /products/{id}/price-history?interval=）の単位
+// 期間内の各区間（UTC）で最後の価格だけを返す
+const (
+	PriceIntervalHourly = "hourly"
+	PriceIntervalDaily  = "daily"
+	PriceIntervalWeekly = "weekly" // 月曜始まり
+)
+
+// PriceHistoryStats は取得した範囲の価格の統計
+type PriceHistoryStats struct {
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+	Average float64 `json:"average"` // 小数第2位まで
+	Count   int     `json:"count"`
+}
+
+// PriceHistoryResponse は価格履歴と、その範囲の統計
+type PriceHistoryResponse struct {
+	Points []*PriceHistory   `json:"points"`
+	Stats  PriceHistoryStats `json:"stats"`
+}
+
+// ScheduledPrice は予約された価格変更
+// 【キー設計】
+//
+//	PK: PRODUCT#<productId>
+//	SK: SCHEDPRICE#<effectiveAt>
+type ScheduledPrice struct {
+	ProductID   string    `json:"productId" dynamodbav:"ProductId"`
+	Price       int       `json:"price" dynamodbav:"Price"`
+	EffectiveAt time.Time `json:"effectiveAt" dynamodbav:"EffectiveAt"` // 価格を適用する日時
+	CreatedBy   string    `json:"createdBy" dynamodbav:"CreatedBy"`
+	CreatedAt   time.Time `json:"createdAt" dynamodbav:"CreatedAt"`
 }
 
 type InventoryLog struct {
@@ -50,3 +204,42 @@ type InventoryLog struct {
 	OrderID       string    `json:"orderId,omitempty" dynamodbav:"OrderId,omitempty"`
 	Timestamp     time.Time `json:"timestamp" dynamodbav:"CreatedAt"`
 }
+
+// StockAdjustment は在庫調整の結果
+type StockAdjustment struct {
+	Stock   int           `json:"stock"`   // 調整後の在庫数
+	Product *Product      `json:"product"` // 更新後の商品
+	Log     *InventoryLog `json:"log"`     // 記録した在庫変動ログ
+}
+
+// InventorySummary は期間内の在庫変動ログの集計（在庫の照合用）
+// 各合計は変動ログの在庫数の増減（NewStock - PreviousStock）から求めるため、
+// OpeningStock + TotalIn - TotalOut + NetAdjust = ClosingStock になる
+type InventorySummary struct {
+	ProductID    string    `json:"productId"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	OpeningStock int       `json:"openingStock"` // 期間の最初のログの変動前の在庫数（ログがない場合は現在の在庫数）
+	ClosingStock int       `json:"closingStock"` // 期間の最後のログの変動後の在庫数（ログがない場合は現在の在庫数）
+	TotalIn      int       `json:"totalIn"`      // IN で増えた在庫数の合計
+	TotalOut     int       `json:"totalOut"`     // OUT で減った在庫数の合計（0 未満に切り詰められた分は含まない）
+	NetAdjust    int       `json:"netAdjust"`    // ADJUST による増減の合計（減った場合は負）
+	LogCount     int       `json:"logCount"`
+}
+
+// ProductImportResult は一括登録の1件ごとの結果
+// Index はリクエスト配列内の位置（0始まり）
+type ProductImportResult struct {
+	Index     int    `json:"index"`
+	Success   bool   `json:"success"`
+	ProductID string `json:"productId,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProductImportResponse は一括登録の結果
+type ProductImportResponse struct {
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Results []ProductImportResult `json:"results"`
+}
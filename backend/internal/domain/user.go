@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"net/mail"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 type User struct {
 	ID           string    `json:"id" dynamodbav:"UserId"`
@@ -17,12 +22,150 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 }
 
+// 登録時の入力制限
+const (
+	MaxEmailLength    = 254 // RFC 5321 のアドレス長の上限
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt が扱えるのは先頭72バイトまで
+	MaxNameLength     = 100
+)
+
+// Validate は登録リクエストを検証し、フィールド名 → エラーメッセージ のマップを返す
+// 問題がない場合は nil を返す
+func (r *RegisterRequest) Validate() map[string]string {
+	fields := make(map[string]string)
+
+	switch {
+	case r.Email == "":
+		fields["email"] = "is required"
+	case len(r.Email) > MaxEmailLength:
+		fields["email"] = "is too long"
+	case !isValidEmail(r.Email):
+		fields["email"] = "is not a valid email address"
+	}
+
+	if msg := validatePassword(r.Password); msg != "" {
+		fields["password"] = msg
+	}
+
+	switch {
+	case r.Name == "":
+		fields["name"] = "is required"
+	case utf8.RuneCountInString(r.Name) > MaxNameLength:
+		fields["name"] = "must be at most 100 characters"
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// validatePassword はパスワードを検証し、不正な場合は理由を返す（登録・再設定で共通）
+func validatePassword(password string) string {
+	switch {
+	case password == "":
+		return "is required"
+	case len(password) < MinPasswordLength:
+		return "must be at least 8 characters"
+	case len(password) > MaxPasswordLength:
+		return "must be at most 72 bytes"
+	case !hasLetterAndDigit(password):
+		return "must contain at least one letter and one digit"
+	}
+	return ""
+}
+
+// isValidEmail はメールアドレスの形式を確認する
+// net/mail は "Name <user@example.com>" 形式も受け付けるため、アドレス部分だけが入力された場合のみ有効とする
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// hasLetterAndDigit は文字列に英字と数字がそれぞれ1文字以上含まれるかを返す
+func hasLetterAndDigit(s string) bool {
+	var letter, digit bool
+	for _, c := range s {
+		switch {
+		case unicode.IsLetter(c):
+			letter = true
+		case unicode.IsDigit(c):
+			digit = true
+		}
+	}
+	return letter && digit
+}
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// UpdateProfileRequest はプロフィール更新リクエスト
+// 空のフィールドは変更しない（パスワードはこのリクエストでは変更できない）
+type UpdateProfileRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// DeleteAccountRequest は退会リクエスト（本人確認のためパスワードを再入力させる）
+// 退会時にユーザーのトークンはすべて失効させるため、RefreshToken は互換性のために受け付けるだけで使わない
+type DeleteAccountRequest struct {
+	Password     string `json:"password"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// ForgotPasswordRequest はパスワード再設定の申請
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest は再設定トークンを使ったパスワードの再設定
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Validate は再設定リクエストを検証し、フィールド名 → エラーメッセージ のマップを返す
+// 問題がない場合は nil を返す
+func (r *ResetPasswordRequest) Validate() map[string]string {
+	fields := make(map[string]string)
+	if r.Token == "" {
+		fields["token"] = "is required"
+	}
+	if msg := validatePassword(r.Password); msg != "" {
+		fields["password"] = msg
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         *User  `json:"user"`
+}
+
+// TokenResponse はリフレッシュ時に返す新しいトークンの組
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// UserDataExport はユーザーの全データのエクスポート（データポータビリティ対応）
+// パスワードハッシュは User の json:"-" により含まれない
+type UserDataExport struct {
+	ExportedAt time.Time       `json:"exportedAt"`
+	Profile    *User           `json:"profile"`
+	Cart       []*CartItem     `json:"cart"`
+	Orders     []*Order        `json:"orders"`
+	Activities []*UserActivity `json:"activities"`
 }
@@ -0,0 +1,947 @@
+package dynamotest
+
+// 式（KeyConditionExpression / ConditionExpression / FilterExpression / UpdateExpression / ProjectionExpression）の解釈
+//
+// 【対応している構文】このリポジトリが使う範囲のみ
+//   条件: = <> < <= > >=, BETWEEN, AND, OR, NOT, 括弧,
+//         attribute_exists, attribute_not_exists, begins_with, contains
+//   更新: SET（+ / - の演算、if_not_exists, list_append）, ADD（数値・セット）, REMOVE, DELETE（セット）
+//   属性はトップレベルのみ（a.b や a[0] のようなネストしたパスには対応しない）
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota // 属性名・#名前・:値・キーワード・関数名
+	tokPunct                  // ( ) , = <> < <= > >= + -
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdentPart(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: expr[i:j]})
+			i = j
+		case c == '<' || c == '>':
+			if i+1 < len(expr) && (expr[i+1] == '=' || (c == '<' && expr[i+1] == '>')) {
+				tokens = append(tokens, token{kind: tokPunct, text: expr[i : i+2]})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: tokPunct, text: string(c)})
+				i++
+			}
+		case strings.IndexByte("(),=+-", c) >= 0:
+			tokens = append(tokens, token{kind: tokPunct, text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q in expression %q", c, expr)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '#' || c == ':' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// exprContext は式の名前・値のプレースホルダー
+type exprContext struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	ctx    exprContext
+	expr   string
+}
+
+func newParser(expr string, ctx exprContext) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens, ctx: ctx, expr: expr}, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, word)
+}
+
+func (p *parser) expectPunct(text string) error {
+	t := p.next()
+	if t.kind != tokPunct || t.text != text {
+		return fmt.Errorf("expected %q in expression %q", text, p.expr)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf(format+" in expression %q", append(args, p.expr)...)
+}
+
+// attrName は属性名のトークンを実際の属性名にする（#name はプレースホルダーから引く）
+func (p *parser) attrName(t token) (string, error) {
+	if t.kind != tokIdent || strings.HasPrefix(t.text, ":") {
+		return "", p.errorf("expected attribute name, got %q", t.text)
+	}
+	if strings.HasPrefix(t.text, "#") {
+		name, ok := p.ctx.names[t.text]
+		if !ok {
+			return "", p.errorf("undefined attribute name placeholder %s", t.text)
+		}
+		return name, nil
+	}
+	return t.text, nil
+}
+
+// ---- 値（オペランド） ----
+
+// operand は item から値を取り出す（属性がない場合は ok=false）
+type operand func(item map[string]types.AttributeValue) (av types.AttributeValue, ok bool, err error)
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, p.errorf("expected operand, got %q", t.text)
+	}
+
+	if strings.HasPrefix(t.text, ":") {
+		v, ok := p.ctx.values[t.text]
+		if !ok {
+			return nil, p.errorf("undefined value placeholder %s", t.text)
+		}
+		return func(map[string]types.AttributeValue) (types.AttributeValue, bool, error) { return v, true, nil }, nil
+	}
+
+	if p.peek().kind == tokPunct && p.peek().text == "(" {
+		return p.parseOperandFunc(t.text)
+	}
+
+	name, err := p.attrName(t)
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) (types.AttributeValue, bool, error) {
+		v, ok := item[name]
+		return v, ok, nil
+	}, nil
+}
+
+func (p *parser) parseOperandFunc(fn string) (operand, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	switch fn {
+	case "if_not_exists":
+		path, err := p.attrName(p.next())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+		def, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) (types.AttributeValue, bool, error) {
+			if v, ok := item[path]; ok {
+				return v, true, nil
+			}
+			return def(item)
+		}, nil
+	case "list_append":
+		a, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+		b, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) (types.AttributeValue, bool, error) {
+			av, aok, err := a(item)
+			if err != nil {
+				return nil, false, err
+			}
+			bv, bok, err := b(item)
+			if err != nil {
+				return nil, false, err
+			}
+			al, aIsList := av.(*types.AttributeValueMemberL)
+			bl, bIsList := bv.(*types.AttributeValueMemberL)
+			if !aok || !bok || !aIsList || !bIsList {
+				return nil, false, fmt.Errorf("list_append requires two existing lists")
+			}
+			out := append(append([]types.AttributeValue{}, al.Value...), bl.Value...)
+			return &types.AttributeValueMemberL{Value: out}, true, nil
+		}, nil
+	case "size":
+		path, err := p.attrName(p.next())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) (types.AttributeValue, bool, error) {
+			v, ok := item[path]
+			if !ok {
+				return nil, false, nil
+			}
+			n, ok := sizeOf(v)
+			if !ok {
+				return nil, false, nil
+			}
+			return &types.AttributeValueMemberN{Value: fmt.Sprint(n)}, true, nil
+		}, nil
+	}
+	return nil, p.errorf("unsupported function %s", fn)
+}
+
+func sizeOf(v types.AttributeValue) (int, bool) {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value), true
+	case *types.AttributeValueMemberB:
+		return len(v.Value), true
+	case *types.AttributeValueMemberL:
+		return len(v.Value), true
+	case *types.AttributeValueMemberM:
+		return len(v.Value), true
+	case *types.AttributeValueMemberSS:
+		return len(v.Value), true
+	case *types.AttributeValueMemberNS:
+		return len(v.Value), true
+	}
+	return 0, false
+}
+
+// ---- 条件式 ----
+
+// condition は item が条件を満たすかを返す
+type condition func(item map[string]types.AttributeValue) (bool, error)
+
+// parseCondition は条件式を解釈する（空文字の場合は常に真）
+func parseCondition(expr string, ctx exprContext) (condition, error) {
+	if strings.TrimSpace(expr) == "" {
+		return func(map[string]types.AttributeValue) (bool, error) { return true, nil }, nil
+	}
+	p, err := newParser(expr, ctx)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected token %q", p.peek().text)
+	}
+	return cond, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item map[string]types.AttributeValue) (bool, error) {
+			ok, err := l(item)
+			if err != nil || ok {
+				return ok, err
+			}
+			return right(item)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item map[string]types.AttributeValue) (bool, error) {
+			ok, err := l(item)
+			if err != nil || !ok {
+				return false, err
+			}
+			return right(item)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) (bool, error) {
+			ok, err := inner(item)
+			return !ok, err
+		}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+	t := p.peek()
+	if t.kind == tokPunct && t.text == "(" {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	// 真偽値を返す関数
+	if t.kind == tokIdent && p.tokens[p.pos+1].kind == tokPunct && p.tokens[p.pos+1].text == "(" {
+		switch t.text {
+		case "attribute_exists", "attribute_not_exists":
+			p.next()
+			p.next()
+			name, err := p.attrName(p.next())
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			want := t.text == "attribute_exists"
+			return func(item map[string]types.AttributeValue) (bool, error) {
+				_, ok := item[name]
+				return ok == want, nil
+			}, nil
+		case "begins_with", "contains":
+			p.next()
+			p.next()
+			a, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(","); err != nil {
+				return nil, err
+			}
+			b, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			fn := t.text
+			return func(item map[string]types.AttributeValue) (bool, error) {
+				av, aok, err := a(item)
+				if err != nil || !aok {
+					return false, err
+				}
+				bv, bok, err := b(item)
+				if err != nil || !bok {
+					return false, err
+				}
+				if fn == "begins_with" {
+					as, ok1 := av.(*types.AttributeValueMemberS)
+					bs, ok2 := bv.(*types.AttributeValueMemberS)
+					return ok1 && ok2 && strings.HasPrefix(as.Value, bs.Value), nil
+				}
+				return containsValue(av, bv), nil
+			}, nil
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("BETWEEN") {
+		p.next()
+		low, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("AND") {
+			return nil, p.errorf("expected AND in BETWEEN")
+		}
+		p.next()
+		high, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) (bool, error) {
+			v, ok, err := left(item)
+			if err != nil || !ok {
+				return false, err
+			}
+			lv, _, err := low(item)
+			if err != nil {
+				return false, err
+			}
+			hv, _, err := high(item)
+			if err != nil {
+				return false, err
+			}
+			c1, ok1 := compareValues(v, lv)
+			c2, ok2 := compareValues(v, hv)
+			return ok1 && ok2 && c1 >= 0 && c2 <= 0, nil
+		}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokPunct {
+		return nil, p.errorf("expected comparator, got %q", op.text)
+	}
+	switch op.text {
+	case "=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, p.errorf("unsupported comparator %q", op.text)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) (bool, error) {
+		lv, lok, err := left(item)
+		if err != nil {
+			return false, err
+		}
+		rv, rok, err := right(item)
+		if err != nil {
+			return false, err
+		}
+		if !lok || !rok {
+			// 属性がない場合、比較は（<> を含めて）偽になる
+			return false, nil
+		}
+		if op.text == "=" || op.text == "<>" {
+			eq := equalValues(lv, rv)
+			return eq == (op.text == "="), nil
+		}
+		c, ok := compareValues(lv, rv)
+		if !ok {
+			return false, nil
+		}
+		switch op.text {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}, nil
+}
+
+// ---- 更新式 ----
+
+type updateAction struct {
+	kind  string // SET / REMOVE / ADD / DELETE
+	name  string
+	value operand                                                             // ADD / DELETE の値
+	set   func(map[string]types.AttributeValue) (types.AttributeValue, error) // SET の右辺
+}
+
+// parseUpdate は更新式を解釈する
+func parseUpdate(expr string, ctx exprContext) ([]updateAction, error) {
+	p, err := newParser(expr, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []updateAction
+	for p.peek().kind != tokEOF {
+		kw := p.next()
+		clause := strings.ToUpper(kw.text)
+		switch clause {
+		case "SET", "REMOVE", "ADD", "DELETE":
+		default:
+			return nil, p.errorf("expected SET/REMOVE/ADD/DELETE, got %q", kw.text)
+		}
+		for {
+			name, err := p.attrName(p.next())
+			if err != nil {
+				return nil, err
+			}
+			action := updateAction{kind: clause, name: name}
+			switch clause {
+			case "SET":
+				if err := p.expectPunct("="); err != nil {
+					return nil, err
+				}
+				action.set, err = p.parseSetValue()
+				if err != nil {
+					return nil, err
+				}
+			case "ADD", "DELETE":
+				action.value, err = p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+			}
+			actions = append(actions, action)
+
+			if t := p.peek(); t.kind == tokPunct && t.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("empty update expression")
+	}
+	return actions, nil
+}
+
+func (p *parser) parseSetValue() (func(map[string]types.AttributeValue) (types.AttributeValue, error), error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokPunct || (t.text != "+" && t.text != "-") {
+		return func(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+			v, ok, err := left(item)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+			}
+			return v, nil
+		}, nil
+	}
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	sign := 1
+	if t.text == "-" {
+		sign = -1
+	}
+	return func(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+		lv, lok, err := left(item)
+		if err != nil {
+			return nil, err
+		}
+		rv, rok, err := right(item)
+		if err != nil {
+			return nil, err
+		}
+		if !lok || !rok {
+			return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+		}
+		return addNumbers(lv, rv, sign)
+	}, nil
+}
+
+// applyUpdate は更新式を適用した新しいアイテムを返す（右辺はすべて更新前のアイテムで評価する）
+func applyUpdate(old map[string]types.AttributeValue, actions []updateAction, keyNames []string) (map[string]types.AttributeValue, error) {
+	updated := copyItem(old)
+	for _, a := range actions {
+		for _, k := range keyNames {
+			if a.name == k {
+				return nil, fmt.Errorf("cannot update attribute %s. This attribute is part of the key", k)
+			}
+		}
+		switch a.kind {
+		case "SET":
+			v, err := a.set(old)
+			if err != nil {
+				return nil, err
+			}
+			updated[a.name] = copyValue(v)
+		case "REMOVE":
+			delete(updated, a.name)
+		case "ADD":
+			v, _, err := a.value(old)
+			if err != nil {
+				return nil, err
+			}
+			cur, exists := old[a.name]
+			if !exists {
+				updated[a.name] = copyValue(v)
+				continue
+			}
+			if _, ok := v.(*types.AttributeValueMemberN); ok {
+				sum, err := addNumbers(cur, v, 1)
+				if err != nil {
+					return nil, err
+				}
+				updated[a.name] = sum
+				continue
+			}
+			merged, err := mergeSets(cur, v, true)
+			if err != nil {
+				return nil, err
+			}
+			updated[a.name] = merged
+		case "DELETE":
+			v, _, err := a.value(old)
+			if err != nil {
+				return nil, err
+			}
+			cur, exists := old[a.name]
+			if !exists {
+				continue
+			}
+			merged, err := mergeSets(cur, v, false)
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := sizeOf(merged); n == 0 {
+				delete(updated, a.name)
+			} else {
+				updated[a.name] = merged
+			}
+		}
+	}
+	return updated, nil
+}
+
+// ---- 射影式 ----
+
+// parseProjection は ProjectionExpression の属性名の一覧を返す（空文字の場合は nil = 全属性）
+func parseProjection(expr string, names map[string]string) ([]string, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	var attrs []string
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "#") {
+			name, ok := names[part]
+			if !ok {
+				return nil, fmt.Errorf("undefined attribute name placeholder %s in projection", part)
+			}
+			part = name
+		}
+		if part == "" || strings.ContainsAny(part, ".[ ") {
+			return nil, fmt.Errorf("unsupported projection %q", expr)
+		}
+		attrs = append(attrs, part)
+	}
+	return attrs, nil
+}
+
+func project(item map[string]types.AttributeValue, attrs []string) map[string]types.AttributeValue {
+	if attrs == nil {
+		return copyItem(item)
+	}
+	out := make(map[string]types.AttributeValue, len(attrs))
+	for _, name := range attrs {
+		if v, ok := item[name]; ok {
+			out[name] = copyValue(v)
+		}
+	}
+	return out
+}
+
+// ---- 値の比較・演算 ----
+
+func parseNumber(s string) (*big.Float, error) {
+	f, _, err := big.ParseFloat(s, 10, 200, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return f, nil
+}
+
+func formatNumber(f *big.Float) string {
+	if f.IsInt() {
+		i, _ := f.Int(nil)
+		return i.String()
+	}
+	return f.Text('g', -1)
+}
+
+func addNumbers(a, b types.AttributeValue, sign int) (types.AttributeValue, error) {
+	an, ok1 := a.(*types.AttributeValueMemberN)
+	bn, ok2 := b.(*types.AttributeValueMemberN)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+	}
+	x, err := parseNumber(an.Value)
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseNumber(bn.Value)
+	if err != nil {
+		return nil, err
+	}
+	if sign < 0 {
+		y.Neg(y)
+	}
+	return &types.AttributeValueMemberN{Value: formatNumber(new(big.Float).SetPrec(200).Add(x, y))}, nil
+}
+
+// compareValues は同じ型（S / N / B）の値を比較する（型が違う・比較できない場合は ok=false）
+func compareValues(a, b types.AttributeValue) (int, bool) {
+	switch av := a.(type) {
+	case *types.AttributeValueMemberS:
+		bv, ok := b.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av.Value, bv.Value), true
+	case *types.AttributeValueMemberN:
+		bv, ok := b.(*types.AttributeValueMemberN)
+		if !ok {
+			return 0, false
+		}
+		x, err1 := parseNumber(av.Value)
+		y, err2 := parseNumber(bv.Value)
+		if err1 != nil || err2 != nil {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	case *types.AttributeValueMemberB:
+		bv, ok := b.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(string(av.Value), string(bv.Value)), true
+	}
+	return 0, false
+}
+
+func equalValues(a, b types.AttributeValue) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	switch av := a.(type) {
+	case *types.AttributeValueMemberBOOL:
+		bv, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && av.Value == bv.Value
+	case *types.AttributeValueMemberNULL:
+		_, ok := b.(*types.AttributeValueMemberNULL)
+		return ok
+	case *types.AttributeValueMemberL:
+		bv, ok := b.(*types.AttributeValueMemberL)
+		if !ok || len(av.Value) != len(bv.Value) {
+			return false
+		}
+		for i := range av.Value {
+			if !equalValues(av.Value[i], bv.Value[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		bv, ok := b.(*types.AttributeValueMemberM)
+		if !ok || len(av.Value) != len(bv.Value) {
+			return false
+		}
+		for k, v := range av.Value {
+			w, ok := bv.Value[k]
+			if !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberSS:
+		bv, ok := b.(*types.AttributeValueMemberSS)
+		return ok && sameStrings(av.Value, bv.Value)
+	case *types.AttributeValueMemberNS:
+		bv, ok := b.(*types.AttributeValueMemberNS)
+		return ok && sameStrings(av.Value, bv.Value)
+	}
+	return false
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(container, v types.AttributeValue) bool {
+	switch c := container.(type) {
+	case *types.AttributeValueMemberS:
+		s, ok := v.(*types.AttributeValueMemberS)
+		return ok && strings.Contains(c.Value, s.Value)
+	case *types.AttributeValueMemberSS:
+		s, ok := v.(*types.AttributeValueMemberS)
+		return ok && containsString(c.Value, s.Value)
+	case *types.AttributeValueMemberNS:
+		n, ok := v.(*types.AttributeValueMemberN)
+		return ok && containsString(c.Value, n.Value)
+	case *types.AttributeValueMemberL:
+		for _, e := range c.Value {
+			if equalValues(e, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSets はセットの和（add=true）・差（add=false）を返す
+func mergeSets(cur, v types.AttributeValue, add bool) (types.AttributeValue, error) {
+	merge := func(a, b []string) []string {
+		out := make([]string, 0, len(a)+len(b))
+		if add {
+			out = append(out, a...)
+			for _, s := range b {
+				if !containsString(out, s) {
+					out = append(out, s)
+				}
+			}
+			return out
+		}
+		for _, s := range a {
+			if !containsString(b, s) {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	switch c := cur.(type) {
+	case *types.AttributeValueMemberSS:
+		if s, ok := v.(*types.AttributeValueMemberSS); ok {
+			return &types.AttributeValueMemberSS{Value: merge(c.Value, s.Value)}, nil
+		}
+	case *types.AttributeValueMemberNS:
+		if s, ok := v.(*types.AttributeValueMemberNS); ok {
+			return &types.AttributeValueMemberNS{Value: merge(c.Value, s.Value)}, nil
+		}
+	}
+	return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+}
+
+// ---- コピー ----
+
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	out := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+func copyValue(v types.AttributeValue) types.AttributeValue {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte(nil), v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberBS:
+		out := make([][]byte, len(v.Value))
+		for i, b := range v.Value {
+			out[i] = append([]byte(nil), b...)
+		}
+		return &types.AttributeValueMemberBS{Value: out}
+	case *types.AttributeValueMemberL:
+		out := make([]types.AttributeValue, len(v.Value))
+		for i, e := range v.Value {
+			out[i] = copyValue(e)
+		}
+		return &types.AttributeValueMemberL{Value: out}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	}
+	return v
+}
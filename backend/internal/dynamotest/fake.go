@@ -0,0 +1,746 @@
+// Package dynamotest はリポジトリの単体テスト用の DynamoDB のフェイク（repository.DynamoDBAPI の実装）
+//
+// 【フェイクの範囲】
+//   - アイテムはメモリ上に保持し、キーは PK / SK（文字列）の単一テーブル設計を前提にする
+//   - GSI は GSI1 / GSI2（GSI1PK・GSI1SK などの属性を持つアイテムだけがインデックスに含まれる）
+//   - 式は expression.go の範囲で評価する（条件を満たさない場合は実物と同じ例外を返す）
+//   - Query の1ページの件数は PageSize で制限できる（実物の 1MB 制限によるページ分割の再現）
+//
+// 【障害の再現】
+//   - Hook: 各操作の前に呼ばれ、エラーを返すとその操作は失敗する（スロットリング・遅延・競合など）
+//   - UnprocessedWrites / UnprocessedKeys: 次の BatchWriteItem / BatchGetItem で未処理として返す件数
+//
+// ctx がキャンセル済みの場合は、実物の SDK と同じく操作を行わずに ctx のエラーを返す
+package dynamotest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// DynamoDB の API の上限
+const (
+	maxBatchWriteItems    = 25
+	maxBatchGetKeys       = 100
+	maxTransactWriteItems = 100
+)
+
+// テーブルの主キーと GSI のキー属性
+var (
+	tableKey  = keySchema{pk: "PK", sk: "SK"}
+	indexKeys = map[string]keySchema{
+		"GSI1": {pk: "GSI1PK", sk: "GSI1SK"},
+		"GSI2": {pk: "GSI2PK", sk: "GSI2SK"},
+	}
+)
+
+type keySchema struct {
+	pk, sk string
+}
+
+// Call は記録した操作の呼び出し
+type Call struct {
+	Op    string // GetItem, Query など
+	Input any    // *dynamodb.GetItemInput など
+}
+
+// Fake はメモリ上の DynamoDB
+type Fake struct {
+	// PageSize は Query の1ページで評価する最大件数（0 は無制限）
+	PageSize int
+	// Hook は各操作の前に呼ばれる（op は GetItem, Query などの操作名）
+	// エラーを返すと操作は行われずにそのエラーを返す
+	Hook func(ctx context.Context, op string, input any) error
+	// UnprocessedWrites は次の BatchWriteItem の呼び出しで未処理として返すリクエスト数（返した分だけ減る）
+	UnprocessedWrites int
+	// UnprocessedKeys は次の BatchGetItem の呼び出しで未処理として返すキー数（返した分だけ減る）
+	UnprocessedKeys int
+
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue // PK + "\x00" + SK → アイテム
+	calls []Call
+}
+
+// New は空のテーブルのフェイクを返す
+func New() *Fake {
+	return &Fake{items: make(map[string]map[string]types.AttributeValue)}
+}
+
+// Item は構造体・マップを DynamoDB のアイテムに変換する（テストデータの作成用。変換できない場合は panic）
+func Item(v any) map[string]types.AttributeValue {
+	item, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		panic(fmt.Sprintf("dynamotest.Item: %v", err))
+	}
+	return item
+}
+
+// Seed はアイテムをそのまま書き込む（条件・フックは評価しない）
+func (f *Fake) Seed(items ...map[string]types.AttributeValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, item := range items {
+		k, err := storageKey(item)
+		if err != nil {
+			panic(fmt.Sprintf("dynamotest.Seed: %v", err))
+		}
+		f.items[k] = copyItem(item)
+	}
+}
+
+// Get は PK / SK のアイテムを返す（ない場合は nil）
+func (f *Fake) Get(pk, sk string) map[string]types.AttributeValue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return copyItem(f.items[pk+"\x00"+sk])
+}
+
+// Items はテーブルのすべてのアイテムを返す（順序は不定）
+func (f *Fake) Items() []map[string]types.AttributeValue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	items := make([]map[string]types.AttributeValue, 0, len(f.items))
+	for _, item := range f.items {
+		items = append(items, copyItem(item))
+	}
+	return items
+}
+
+// Len はテーブルのアイテム数を返す
+func (f *Fake) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.items)
+}
+
+// Calls は記録した呼び出しを順に返す
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount は op の呼び出し回数を返す
+func (f *Fake) CallCount(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// TransactionCanceled は TransactionCanceledException を作る（Hook から返す例外の作成用）
+// codes は操作の順に "None", "ConditionalCheckFailed", "TransactionConflict" など
+func TransactionCanceled(codes ...string) error {
+	reasons := make([]types.CancellationReason, len(codes))
+	for i, code := range codes {
+		reasons[i] = types.CancellationReason{Code: aws.String(code)}
+	}
+	return &types.TransactionCanceledException{
+		Message:             aws.String("Transaction cancelled"),
+		CancellationReasons: reasons,
+	}
+}
+
+// Throttled はスロットリングの例外（ProvisionedThroughputExceededException）を返す
+func Throttled() error {
+	return &types.ProvisionedThroughputExceededException{Message: aws.String("Rate of requests exceeds the allowed throughput")}
+}
+
+// begin は呼び出しを記録し、ctx とフックを確認する
+func (f *Fake) begin(ctx context.Context, op string, input any) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Op: op, Input: input})
+	hook := f.Hook
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("operation error DynamoDB: %s, %w", op, err)
+	}
+	if hook != nil {
+		if err := hook(ctx, op, input); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func validationError(format string, args ...any) error {
+	return &smithy.GenericAPIError{Code: "ValidationException", Message: fmt.Sprintf(format, args...)}
+}
+
+func storageKey(key map[string]types.AttributeValue) (string, error) {
+	pk, ok1 := key[tableKey.pk].(*types.AttributeValueMemberS)
+	sk, ok2 := key[tableKey.sk].(*types.AttributeValueMemberS)
+	if !ok1 || !ok2 {
+		return "", validationError("the provided key element does not match the schema")
+	}
+	return pk.Value + "\x00" + sk.Value, nil
+}
+
+// keyOnly はキーが PK / SK だけで構成されていることを確認する
+func keyOnly(key map[string]types.AttributeValue) (string, error) {
+	if len(key) != 2 {
+		return "", validationError("the provided key element does not match the schema")
+	}
+	return storageKey(key)
+}
+
+func returnedOld(old map[string]types.AttributeValue, rv types.ReturnValuesOnConditionCheckFailure) map[string]types.AttributeValue {
+	if rv == types.ReturnValuesOnConditionCheckFailureAllOld && old != nil {
+		return copyItem(old)
+	}
+	return nil
+}
+
+// GetItem はキーのアイテムを返す
+func (f *Fake) GetItem(ctx context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := f.begin(ctx, "GetItem", in); err != nil {
+		return nil, err
+	}
+	k, err := keyOnly(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := parseProjection(aws.ToString(in.ProjectionExpression), in.ExpressionAttributeNames)
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[k]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: project(item, attrs)}, nil
+}
+
+// PutItem はアイテムを書き込む（ConditionExpression は既存のアイテムに対して評価する）
+func (f *Fake) PutItem(ctx context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := f.begin(ctx, "PutItem", in); err != nil {
+		return nil, err
+	}
+	k, err := storageKey(in.Item)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := parseCondition(aws.ToString(in.ConditionExpression), exprContext{in.ExpressionAttributeNames, in.ExpressionAttributeValues})
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old := f.items[k]
+	ok, err := cond(orEmpty(old))
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	if !ok {
+		return nil, &types.ConditionalCheckFailedException{
+			Message: aws.String("The conditional request failed"),
+			Item:    returnedOld(old, in.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	f.items[k] = copyItem(in.Item)
+
+	out := &dynamodb.PutItemOutput{}
+	if in.ReturnValues == types.ReturnValueAllOld && old != nil {
+		out.Attributes = copyItem(old)
+	}
+	return out, nil
+}
+
+// UpdateItem はアイテムを更新する（アイテムがない場合はキーだけのアイテムに対して更新式を適用して作成する）
+func (f *Fake) UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := f.begin(ctx, "UpdateItem", in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, updated, err := f.prepareUpdate(in.Key, aws.ToString(in.UpdateExpression), aws.ToString(in.ConditionExpression),
+		exprContext{in.ExpressionAttributeNames, in.ExpressionAttributeValues}, in.ReturnValuesOnConditionCheckFailure)
+	if err != nil {
+		return nil, err
+	}
+	k, _ := storageKey(in.Key)
+	f.items[k] = updated
+
+	out := &dynamodb.UpdateItemOutput{}
+	switch in.ReturnValues {
+	case types.ReturnValueAllNew, types.ReturnValueUpdatedNew:
+		out.Attributes = copyItem(updated)
+	case types.ReturnValueAllOld, types.ReturnValueUpdatedOld:
+		out.Attributes = copyItem(old)
+	}
+	return out, nil
+}
+
+// prepareUpdate は条件を評価し、更新後のアイテムを作る（書き込みはしない）。f.mu を保持して呼ぶ
+func (f *Fake) prepareUpdate(key map[string]types.AttributeValue, updateExpr, condExpr string, ctx exprContext, rv types.ReturnValuesOnConditionCheckFailure) (old, updated map[string]types.AttributeValue, err error) {
+	k, err := keyOnly(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	actions, err := parseUpdate(updateExpr, ctx)
+	if err != nil {
+		return nil, nil, validationError("%v", err)
+	}
+	cond, err := parseCondition(condExpr, ctx)
+	if err != nil {
+		return nil, nil, validationError("%v", err)
+	}
+
+	old = f.items[k]
+	ok, err := cond(orEmpty(old))
+	if err != nil {
+		return nil, nil, validationError("%v", err)
+	}
+	if !ok {
+		return nil, nil, &types.ConditionalCheckFailedException{
+			Message: aws.String("The conditional request failed"),
+			Item:    returnedOld(old, rv),
+		}
+	}
+
+	base := old
+	if base == nil {
+		base = copyItem(key)
+	}
+	updated, err = applyUpdate(base, actions, []string{tableKey.pk, tableKey.sk})
+	if err != nil {
+		return nil, nil, validationError("%v", err)
+	}
+	return old, updated, nil
+}
+
+// DeleteItem はアイテムを削除する
+func (f *Fake) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := f.begin(ctx, "DeleteItem", in); err != nil {
+		return nil, err
+	}
+	k, err := keyOnly(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := parseCondition(aws.ToString(in.ConditionExpression), exprContext{in.ExpressionAttributeNames, in.ExpressionAttributeValues})
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old := f.items[k]
+	ok, err := cond(orEmpty(old))
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	if !ok {
+		return nil, &types.ConditionalCheckFailedException{
+			Message: aws.String("The conditional request failed"),
+			Item:    returnedOld(old, in.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	delete(f.items, k)
+
+	out := &dynamodb.DeleteItemOutput{}
+	if in.ReturnValues == types.ReturnValueAllOld && old != nil {
+		out.Attributes = copyItem(old)
+	}
+	return out, nil
+}
+
+// Query はテーブル・GSI のアイテムをソートキー順に返す
+// Limit・PageSize で評価する件数を打ち切った場合、続きのアイテムがあれば LastEvaluatedKey を返す
+// FilterExpression は評価したアイテムに「後から」適用する（実物と同じく1ページの件数が減る）
+func (f *Fake) Query(ctx context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := f.begin(ctx, "Query", in); err != nil {
+		return nil, err
+	}
+
+	schema := tableKey
+	if in.IndexName != nil {
+		s, ok := indexKeys[*in.IndexName]
+		if !ok {
+			return nil, validationError("the table does not have the specified index: %s", *in.IndexName)
+		}
+		if aws.ToBool(in.ConsistentRead) {
+			return nil, validationError("consistent reads are not supported on global secondary indexes")
+		}
+		schema = s
+	}
+
+	ctxExpr := exprContext{in.ExpressionAttributeNames, in.ExpressionAttributeValues}
+	keyCond, err := parseCondition(aws.ToString(in.KeyConditionExpression), ctxExpr)
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	filter, err := parseCondition(aws.ToString(in.FilterExpression), ctxExpr)
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	attrs, err := parseProjection(aws.ToString(in.ProjectionExpression), in.ExpressionAttributeNames)
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// キー条件を満たすアイテムをソートキー順（同じ値はテーブルのキー順）に並べる
+	var matched []map[string]types.AttributeValue
+	for _, item := range f.items {
+		if _, ok := item[schema.pk]; !ok {
+			continue
+		}
+		if _, ok := item[schema.sk]; !ok {
+			continue
+		}
+		ok, err := keyCond(item)
+		if err != nil {
+			return nil, validationError("%v", err)
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+	forward := in.ScanIndexForward == nil || *in.ScanIndexForward
+	sort.Slice(matched, func(i, j int) bool {
+		c := compareOrder(matched[i], matched[j], schema)
+		if forward {
+			return c < 0
+		}
+		return c > 0
+	})
+
+	// ExclusiveStartKey の次のアイテムから読む
+	start := 0
+	if len(in.ExclusiveStartKey) > 0 {
+		start = len(matched)
+		for i, item := range matched {
+			c := compareOrder(item, in.ExclusiveStartKey, schema)
+			if (forward && c > 0) || (!forward && c < 0) {
+				start = i
+				break
+			}
+		}
+	}
+
+	limit := len(matched) - start
+	if in.Limit != nil && int(*in.Limit) < limit {
+		limit = int(*in.Limit)
+	}
+	if f.PageSize > 0 && f.PageSize < limit {
+		limit = f.PageSize
+	}
+
+	out := &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{}}
+	evaluated := matched[start : start+limit]
+	for _, item := range evaluated {
+		ok, err := filter(item)
+		if err != nil {
+			return nil, validationError("%v", err)
+		}
+		if ok {
+			out.Items = append(out.Items, project(item, attrs))
+		}
+	}
+	out.Count = int32(len(out.Items))
+	out.ScannedCount = int32(len(evaluated))
+
+	if start+limit < len(matched) && limit > 0 {
+		last := evaluated[len(evaluated)-1]
+		lek := map[string]types.AttributeValue{
+			tableKey.pk: copyValue(last[tableKey.pk]),
+			tableKey.sk: copyValue(last[tableKey.sk]),
+		}
+		lek[schema.pk] = copyValue(last[schema.pk])
+		lek[schema.sk] = copyValue(last[schema.sk])
+		out.LastEvaluatedKey = lek
+	}
+	return out, nil
+}
+
+// compareOrder はクエリの並び順（ソートキー → テーブルのキー）で a と b を比較する
+func compareOrder(a, b map[string]types.AttributeValue, schema keySchema) int {
+	for _, name := range []string{schema.sk, tableKey.pk, tableKey.sk} {
+		av, aok := a[name]
+		bv, bok := b[name]
+		if !aok || !bok {
+			continue
+		}
+		if c, ok := compareValues(av, bv); ok && c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// BatchWriteItem は Put / Delete をまとめて行う（UnprocessedWrites の件数分は未処理として返す）
+func (f *Fake) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := f.begin(ctx, "BatchWriteItem", in); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, reqs := range in.RequestItems {
+		total += len(reqs)
+	}
+	if total == 0 || total > maxBatchWriteItems {
+		return nil, validationError("too many items requested for the BatchWriteItem call: %d", total)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seen := make(map[string]bool, total)
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}
+	for table, reqs := range in.RequestItems {
+		for _, req := range reqs {
+			var key map[string]types.AttributeValue
+			switch {
+			case req.PutRequest != nil:
+				key = req.PutRequest.Item
+			case req.DeleteRequest != nil:
+				key = req.DeleteRequest.Key
+			default:
+				return nil, validationError("write request must have a put or delete request")
+			}
+			k, err := storageKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if seen[k] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[k] = true
+
+			if f.UnprocessedWrites > 0 {
+				f.UnprocessedWrites--
+				out.UnprocessedItems[table] = append(out.UnprocessedItems[table], req)
+				continue
+			}
+			if req.PutRequest != nil {
+				f.items[k] = copyItem(req.PutRequest.Item)
+			} else {
+				delete(f.items, k)
+			}
+		}
+	}
+	return out, nil
+}
+
+// BatchGetItem はキーのアイテムをまとめて返す（UnprocessedKeys の件数分は未処理として返す）
+func (f *Fake) BatchGetItem(ctx context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if err := f.begin(ctx, "BatchGetItem", in); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, ka := range in.RequestItems {
+		total += len(ka.Keys)
+	}
+	if total == 0 || total > maxBatchGetKeys {
+		return nil, validationError("too many items requested for the BatchGetItem call: %d", total)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+	for table, ka := range in.RequestItems {
+		attrs, err := parseProjection(aws.ToString(ka.ProjectionExpression), ka.ExpressionAttributeNames)
+		if err != nil {
+			return nil, validationError("%v", err)
+		}
+		seen := make(map[string]bool, len(ka.Keys))
+		out.Responses[table] = []map[string]types.AttributeValue{}
+		for _, key := range ka.Keys {
+			k, err := keyOnly(key)
+			if err != nil {
+				return nil, err
+			}
+			if seen[k] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[k] = true
+
+			if f.UnprocessedKeys > 0 {
+				f.UnprocessedKeys--
+				u := out.UnprocessedKeys[table]
+				u.Keys = append(u.Keys, key)
+				u.ProjectionExpression = ka.ProjectionExpression
+				u.ExpressionAttributeNames = ka.ExpressionAttributeNames
+				u.ConsistentRead = ka.ConsistentRead
+				out.UnprocessedKeys[table] = u
+				continue
+			}
+			if item, ok := f.items[k]; ok {
+				out.Responses[table] = append(out.Responses[table], project(item, attrs))
+			}
+		}
+	}
+	return out, nil
+}
+
+// TransactWriteItems はすべての条件を評価してから、すべての書き込みをまとめて行う
+// 1つでも条件を満たさない場合は何も書き込まず TransactionCanceledException（操作の順の CancellationReasons）を返す
+func (f *Fake) TransactWriteItems(ctx context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := f.begin(ctx, "TransactWriteItems", in); err != nil {
+		return nil, err
+	}
+	if len(in.TransactItems) == 0 || len(in.TransactItems) > maxTransactWriteItems {
+		return nil, validationError("member must have length less than or equal to %d", maxTransactWriteItems)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type write struct {
+		key  string
+		item map[string]types.AttributeValue // nil は削除
+	}
+	writes := make([]*write, len(in.TransactItems))
+	reasons := make([]types.CancellationReason, len(in.TransactItems))
+	seen := make(map[string]bool, len(in.TransactItems))
+	failed := false
+
+	for i, ti := range in.TransactItems {
+		var (
+			key      map[string]types.AttributeValue
+			condExpr *string
+			ctxExpr  exprContext
+			rv       types.ReturnValuesOnConditionCheckFailure
+		)
+		switch {
+		case ti.Put != nil:
+			key, condExpr, rv = ti.Put.Item, ti.Put.ConditionExpression, ti.Put.ReturnValuesOnConditionCheckFailure
+			ctxExpr = exprContext{ti.Put.ExpressionAttributeNames, ti.Put.ExpressionAttributeValues}
+		case ti.Update != nil:
+			key, condExpr, rv = ti.Update.Key, ti.Update.ConditionExpression, ti.Update.ReturnValuesOnConditionCheckFailure
+			ctxExpr = exprContext{ti.Update.ExpressionAttributeNames, ti.Update.ExpressionAttributeValues}
+		case ti.Delete != nil:
+			key, condExpr, rv = ti.Delete.Key, ti.Delete.ConditionExpression, ti.Delete.ReturnValuesOnConditionCheckFailure
+			ctxExpr = exprContext{ti.Delete.ExpressionAttributeNames, ti.Delete.ExpressionAttributeValues}
+		case ti.ConditionCheck != nil:
+			key, condExpr, rv = ti.ConditionCheck.Key, ti.ConditionCheck.ConditionExpression, ti.ConditionCheck.ReturnValuesOnConditionCheckFailure
+			ctxExpr = exprContext{ti.ConditionCheck.ExpressionAttributeNames, ti.ConditionCheck.ExpressionAttributeValues}
+		default:
+			return nil, validationError("transact item %d has no operation", i)
+		}
+
+		k, err := storageKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[k] {
+			return nil, validationError("transaction request cannot include multiple operations on one item")
+		}
+		seen[k] = true
+
+		old := f.items[k]
+		cond, err := parseCondition(aws.ToString(condExpr), ctxExpr)
+		if err != nil {
+			return nil, validationError("%v", err)
+		}
+		ok, err := cond(orEmpty(old))
+		if err != nil {
+			return nil, validationError("%v", err)
+		}
+		if !ok {
+			failed = true
+			reasons[i] = types.CancellationReason{
+				Code:    aws.String("ConditionalCheckFailed"),
+				Message: aws.String("The conditional request failed"),
+				Item:    returnedOld(old, rv),
+			}
+			continue
+		}
+		reasons[i] = types.CancellationReason{Code: aws.String("None")}
+
+		switch {
+		case ti.Put != nil:
+			writes[i] = &write{key: k, item: copyItem(ti.Put.Item)}
+		case ti.Delete != nil:
+			if _, err := keyOnly(key); err != nil {
+				return nil, err
+			}
+			writes[i] = &write{key: k}
+		case ti.Update != nil:
+			// 条件は評価済みのため、更新式だけを適用する
+			_, updated, err := f.prepareUpdate(key, aws.ToString(ti.Update.UpdateExpression), "", ctxExpr, rv)
+			if err != nil {
+				return nil, err
+			}
+			writes[i] = &write{key: k, item: updated}
+		}
+	}
+
+	if failed {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, w := range writes {
+		if w == nil {
+			continue
+		}
+		if w.item == nil {
+			delete(f.items, w.key)
+		} else {
+			f.items[w.key] = w.item
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// DescribeTable はテーブルと GSI を ACTIVE として返す
+func (f *Fake) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if err := f.begin(ctx, "DescribeTable", in); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(indexKeys))
+	for name := range indexKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	gsis := make([]types.GlobalSecondaryIndexDescription, 0, len(names))
+	for _, name := range names {
+		gsis = append(gsis, types.GlobalSecondaryIndexDescription{
+			IndexName:   aws.String(name),
+			IndexStatus: types.IndexStatusActive,
+		})
+	}
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName:              in.TableName,
+			TableStatus:            types.TableStatusActive,
+			GlobalSecondaryIndexes: gsis,
+		},
+	}, nil
+}
+
+func orEmpty(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return map[string]types.AttributeValue{}
+	}
+	return item
+}
@@ -0,0 +1,113 @@
+package dynamotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestUpdateItemCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		update    string
+		condition string
+		values    map[string]any
+		wantErr   bool
+		wantStock string
+	}{
+		{name: "条件を満たす", update: "SET stock = stock - :qty", condition: "stock >= :qty", values: map[string]any{":qty": 3}, wantStock: "2"},
+		{name: "条件を満たさない", update: "SET stock = stock - :qty", condition: "stock >= :qty", values: map[string]any{":qty": 6}, wantErr: true, wantStock: "5"},
+		{name: "ない属性との比較は偽", update: "SET stock = :qty", condition: "missing < :qty", values: map[string]any{":qty": 1}, wantErr: true, wantStock: "5"},
+		{name: "ADD と if_not_exists", update: "SET note = if_not_exists(note, :s) ADD stock :qty", condition: "attribute_exists(PK) AND NOT begins_with(SK, :s)", values: map[string]any{":qty": 1, ":s": "X"}, wantStock: "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := New()
+			fake.Seed(Item(map[string]any{"PK": "P#1", "SK": "META", "stock": 5}))
+
+			_, err := fake.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+				Key:                       Item(map[string]any{"PK": "P#1", "SK": "META"}),
+				UpdateExpression:          aws.String(tt.update),
+				ConditionExpression:       aws.String(tt.condition),
+				ExpressionAttributeValues: Item(tt.values),
+			})
+			var cfe *types.ConditionalCheckFailedException
+			if errors.As(err, &cfe) != tt.wantErr {
+				t.Fatalf("UpdateItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := fake.Get("P#1", "META")["stock"].(*types.AttributeValueMemberN).Value; got != tt.wantStock {
+				t.Errorf("stock = %s, want %s", got, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestQueryPaging(t *testing.T) {
+	fake := New()
+	fake.PageSize = 2
+	for _, sk := range []string{"A#3", "A#1", "B#1", "A#2"} {
+		fake.Seed(Item(map[string]any{"PK": "U#1", "SK": sk}))
+	}
+
+	var got []string
+	var startKey map[string]types.AttributeValue
+	pages := 0
+	for {
+		out, err := fake.Query(context.Background(), &dynamodb.QueryInput{
+			KeyConditionExpression:    aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			ExpressionAttributeValues: Item(map[string]any{":pk": "U#1", ":prefix": "A#"}),
+			ScanIndexForward:          aws.Bool(false),
+			ExclusiveStartKey:         startKey,
+		})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		pages++
+		for _, item := range out.Items {
+			got = append(got, item["SK"].(*types.AttributeValueMemberS).Value)
+		}
+		if startKey = out.LastEvaluatedKey; startKey == nil {
+			break
+		}
+	}
+
+	want := []string{"A#3", "A#2", "A#1"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Query() = %v, want %v", got, want)
+	}
+	if pages != 2 {
+		t.Errorf("pages = %d, want 2", pages)
+	}
+}
+
+func TestTransactWriteItemsAllOrNothing(t *testing.T) {
+	fake := New()
+	fake.Seed(Item(map[string]any{"PK": "P#1", "SK": "META", "stock": 1}))
+
+	_, err := fake.TransactWriteItems(context.Background(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{Item: Item(map[string]any{"PK": "O#1", "SK": "META"})}},
+			{Update: &types.Update{
+				Key:                       Item(map[string]any{"PK": "P#1", "SK": "META"}),
+				UpdateExpression:          aws.String("SET stock = stock - :qty"),
+				ConditionExpression:       aws.String("stock >= :qty"),
+				ExpressionAttributeValues: Item(map[string]any{":qty": 2}),
+			}},
+		},
+	})
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) {
+		t.Fatalf("TransactWriteItems() error = %v, want TransactionCanceledException", err)
+	}
+	if codes := []string{aws.ToString(tce.CancellationReasons[0].Code), aws.ToString(tce.CancellationReasons[1].Code)}; codes[0] != "None" || codes[1] != "ConditionalCheckFailed" {
+		t.Errorf("CancellationReasons = %v", codes)
+	}
+	if fake.Len() != 1 {
+		t.Errorf("table has %d items, want 1 (nothing written)", fake.Len())
+	}
+}
@@ -0,0 +1,9 @@
+// Package email はメール送信の実装を提供する
+//
+// 【実装】
+//   - LogSender: 送信せずにログに出力する（開発用・既定）
+//   - SESSender: Amazon SES で送信する
+//
+// 利用側（service）は Send だけを持つ小さなインターフェースに依存するため、
+// 実装を差し替えたり、テストで送信内容を確認する偽の実装に置き換えたりできる
+package email
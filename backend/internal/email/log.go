@@ -0,0 +1,18 @@
+package email
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender はメールを送信せず、内容をログに出力する（開発用・EMAIL_PROVIDER=log）
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("Email (not sent): to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
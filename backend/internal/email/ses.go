@@ -0,0 +1,40 @@
+package email
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// SESSender は Amazon SES（v2 API）でテキストメールを送信する（EMAIL_PROVIDER=ses）
+// 送信元アドレス（from）は SES で検証済みである必要がある
+type SESSender struct {
+	client *sesv2.Client
+	from   string
+}
+
+func NewSESSender(client *sesv2.Client, from string) *SESSender {
+	return &SESSender{client: client, from: from}
+}
+
+// Send は1通のメールを送信する
+// 【使用API】SendEmail（Simple コンテンツ）
+func (s *SESSender) Send(ctx context.Context, to, subject, body string) error {
+	_, err := s.client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(s.from),
+		Destination: &types.Destination{
+			ToAddresses: []string{to},
+		},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(subject), Charset: aws.String("UTF-8")},
+				Body: &types.Body{
+					Text: &types.Content{Data: aws.String(body), Charset: aws.String("UTF-8")},
+				},
+			},
+		},
+	})
+	return err
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -19,6 +18,7 @@ type ActivityService interface {
 	LogActivities(ctx context.Context, userID string, reqs []*domain.LogActivityRequest) error
 	GetUserActivities(ctx context.Context, userID string, limit int32) ([]*domain.UserActivity, error)
 	GetUserActivitiesByAction(ctx context.Context, userID string, actionType string, limit int32) ([]*domain.UserActivity, error)
+	GetRecentlyViewed(ctx context.Context, userID string, limit int) ([]*domain.Product, error)
 }
 
 type ActivityHandler struct {
@@ -41,8 +41,7 @@ func (h *ActivityHandler) LogActivity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req domain.LogActivityRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -56,7 +55,7 @@ func (h *ActivityHandler) LogActivity(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusBadRequest, "Invalid action type")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "Failed to log activity")
+		internalError(w, r, "Failed to log activity", err)
 		return
 	}
 
@@ -73,8 +72,7 @@ func (h *ActivityHandler) BatchLogActivities(w http.ResponseWriter, r *http.Requ
 	}
 
 	var reqs []*domain.LogActivityRequest
-	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &reqs) {
 		return
 	}
 
@@ -88,7 +86,7 @@ func (h *ActivityHandler) BatchLogActivities(w http.ResponseWriter, r *http.Requ
 			response.Error(w, http.StatusBadRequest, "Invalid action type")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "Failed to log activities")
+		internalError(w, r, "Failed to log activities", err)
 		return
 	}
 
@@ -128,13 +126,41 @@ func (h *ActivityHandler) GetMyActivities(w http.ResponseWriter, r *http.Request
 			response.Error(w, http.StatusBadRequest, "Invalid action type")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch activities")
+		internalError(w, r, "Failed to fetch activities", err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, activities)
 }
 
+// GetRecentlyViewed は現在のユーザーが最近閲覧した商品を取得する（新しい順、同じ商品は1件にまとめる）
+// GET /api/v1/users/me/recently-viewed?limit=10
+func (h *ActivityHandler) GetRecentlyViewed(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	limit := service.DefaultRecentlyViewedLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = l
+	}
+
+	products, err := h.activityService.GetRecentlyViewed(r.Context(), userID, limit)
+	if err != nil {
+		internalError(w, r, "Failed to fetch recently viewed products", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, products)
+}
+
 // GetUserActivities は管理者が特定ユーザーの行動ログを取得する
 // GET /api/v1/admin/users/{userId}/activities
 func (h *ActivityHandler) GetUserActivities(w http.ResponseWriter, r *http.Request) {
@@ -174,7 +200,7 @@ func (h *ActivityHandler) GetUserActivities(w http.ResponseWriter, r *http.Reque
 			response.Error(w, http.StatusBadRequest, "Invalid action type")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch activities")
+		internalError(w, r, "Failed to fetch activities", err)
 		return
 	}
 
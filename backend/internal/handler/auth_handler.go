@@ -2,11 +2,14 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/service"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/httputil"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
@@ -15,6 +18,10 @@ type UserService interface {
 	Register(ctx context.Context, req *domain.RegisterRequest) (*domain.User, error)
 	Login(ctx context.Context, req *domain.LoginRequest) (*domain.User, error)
 	GetUserByID(ctx context.Context, id string) (*domain.User, error)
+	UpdateProfile(ctx context.Context, userID string, req *domain.UpdateProfileRequest) (*domain.User, error)
+	DeleteAccount(ctx context.Context, userID, password string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
 }
 
 type AuthHandler struct {
@@ -33,13 +40,12 @@ func NewAuthHandler(userService UserService, jwtAuth *middleware.JWTAuth) *AuthH
 // POST /api/v1/auth/register
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req domain.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Email == "" || req.Password == "" || req.Name == "" {
-		response.Error(w, http.StatusBadRequest, "Email, password, and name are required")
+	if fields := req.Validate(); fields != nil {
+		response.ValidationError(w, fields)
 		return
 	}
 
@@ -51,13 +57,20 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	token, err := h.jwtAuth.GenerateToken(user.ID, user.Email)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to generate token")
+		internalError(w, r, "Failed to generate token", err)
+		return
+	}
+
+	refreshToken, err := h.jwtAuth.GenerateRefreshToken(user.ID, user.Email)
+	if err != nil {
+		internalError(w, r, "Failed to generate token", err)
 		return
 	}
 
 	response.JSON(w, http.StatusCreated, domain.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
 	})
 }
 
@@ -65,8 +78,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 // POST /api/v1/auth/login
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req domain.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -83,16 +95,85 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	token, err := h.jwtAuth.GenerateToken(user.ID, user.Email)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to generate token")
+		internalError(w, r, "Failed to generate token", err)
+		return
+	}
+
+	refreshToken, err := h.jwtAuth.GenerateRefreshToken(user.ID, user.Email)
+	if err != nil {
+		internalError(w, r, "Failed to generate token", err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, domain.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
 	})
 }
 
+// Refresh はリフレッシュトークンから新しいトークンの組を発行する
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.RefreshToken == "" {
+		response.Error(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	token, refreshToken, err := h.jwtAuth.RefreshToken(r.Context(), req.RefreshToken)
+	if err != nil {
+		// リフレッシュトークン自体が期限切れ・不正の場合は code で再ログインが必要なことを伝える
+		middleware.WriteTokenError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, domain.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Logout は現在のアクセストークンを失効させる
+// リクエストボディに refreshToken が指定された場合はそれも失効させる
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetClaims(r.Context())
+	if claims == nil {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// ボディは省略可能
+	var req domain.RefreshRequest
+	if err := httputil.DecodeJSON(w, r, &req, httputil.DefaultMaxBodyBytes); err != nil && !errors.Is(err, httputil.ErrEmptyBody) {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := h.jwtAuth.Revoke(r.Context(), claims); err != nil {
+		internalError(w, r, "Failed to logout", err)
+		return
+	}
+
+	if req.RefreshToken != "" {
+		refreshClaims, err := h.jwtAuth.ValidateToken(r.Context(), req.RefreshToken)
+		// 既に無効なリフレッシュトークンは失効させる必要がない
+		if err == nil && refreshClaims.UserID == claims.UserID {
+			if err := h.jwtAuth.Revoke(r.Context(), refreshClaims); err != nil {
+				internalError(w, r, "Failed to logout", err)
+				return
+			}
+		}
+	}
+
+	response.Success(w, http.StatusOK, "Logged out")
+}
+
 // GetProfile は現在ログイン中のユーザー情報を取得する
 // GET /api/v1/auth/profile
 func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
@@ -110,3 +191,128 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusOK, user)
 }
+
+// UpdateProfile は現在ログイン中のユーザーの名前・メールアドレスを更新する
+// PUT /api/v1/auth/profile
+func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req domain.UpdateProfileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Name == "" && req.Email == "" {
+		response.Error(w, http.StatusBadRequest, "Name or email is required")
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if errors.Is(err, service.ErrEmailAlreadyExists) {
+			response.Error(w, http.StatusConflict, "Email already exists")
+			return
+		}
+		internalError(w, r, "Failed to update profile", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, user)
+}
+
+// DeleteAccount はアカウントを削除する（退会）
+// DELETE /api/v1/auth/profile
+// 本人確認のためリクエストボディでパスワードを受け取る
+// 削除後のユーザーの発行済みトークンの失効は UserService.DeleteAccount で行う
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetClaims(r.Context())
+	if claims == nil {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req domain.DeleteAccountRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Password == "" {
+		response.Error(w, http.StatusBadRequest, "Password is required")
+		return
+	}
+
+	if err := h.userService.DeleteAccount(r.Context(), claims.UserID, req.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			response.Error(w, http.StatusUnauthorized, "Invalid password")
+			return
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "User not found")
+			return
+		}
+		internalError(w, r, "Failed to delete account", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Account deleted")
+}
+
+// ForgotPassword はパスワード再設定トークンを発行する
+// 登録されていないメールアドレスでも同じ応答を返す（アカウントの有無を推測させない）
+// 同じメールアドレスへの申請が多すぎる場合は 429 を返す
+// POST /api/v1/auth/forgot-password
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req domain.ForgotPasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Email == "" {
+		response.Error(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		if errors.Is(err, service.ErrTooManyResetRequests) {
+			response.Error(w, http.StatusTooManyRequests, "Too many password reset requests, please try again later")
+			return
+		}
+		internalError(w, r, "Failed to request password reset", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "If the email is registered, password reset instructions have been sent")
+}
+
+// ResetPassword は再設定トークンを使ってパスワードを変更する
+// 変更前に発行されたトークンはすべて失効する（再ログインが必要）
+// POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req domain.ResetPasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if fields := req.Validate(); fields != nil {
+		response.ValidationError(w, fields)
+		return
+	}
+
+	if err := h.userService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			response.Error(w, http.StatusBadRequest, "Invalid or expired reset token")
+			return
+		}
+		internalError(w, r, "Failed to reset password", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Password has been reset")
+}
@@ -2,22 +2,31 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
-	"github.com/hosokawa-y/dynamodb-shop/backend/internal/service"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
+// 放置カート一覧の1ページあたりの件数（既定値と上限）
+const (
+	defaultAbandonedCartLimit = 50
+	maxAbandonedCartLimit     = 100
+)
+
 // CartService はカート関連のビジネスロジックを定義するインターフェース
 type CartService interface {
-	GetCart(ctx context.Context, userID string) (*domain.Cart, error)
+	GetCart(ctx context.Context, userID string, refreshPrices bool) (*domain.Cart, error)
+	GetItemCount(ctx context.Context, userID string) (*domain.CartCount, error)
 	AddItem(ctx context.Context, userID string, req *domain.AddToCartRequest) (*domain.CartItem, error)
 	UpdateQuantity(ctx context.Context, userID, productID string, req *domain.UpdateCartRequest) (*domain.CartItem, error)
 	RemoveItem(ctx context.Context, userID, productID string) error
+	ClearCart(ctx context.Context, userID string) error
+	ListAbandonedCarts(ctx context.Context, olderThan time.Duration, limit int, cursor string) (*domain.AbandonedCartPage, error)
 }
 
 type CartHandler struct {
@@ -39,15 +48,36 @@ func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cart, err := h.cartService.GetCart(r.Context(), userID)
+	// refreshPrices=true の場合は現在の商品価格で再計算する（既定はカート追加時点の価格）
+	refreshPrices := r.URL.Query().Get("refreshPrices") == "true"
+
+	cart, err := h.cartService.GetCart(r.Context(), userID, refreshPrices)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch cart")
+		apperr.WriteError(w, r, err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, cart)
 }
 
+// GetItemCount はカート内のアイテム数だけを返す（ヘッダーのバッジ表示用）
+// GET /api/v1/cart/count
+func (h *CartHandler) GetItemCount(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	count, err := h.cartService.GetItemCount(r.Context(), userID)
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, count)
+}
+
 // AddItem はカートにアイテムを追加する
 // POST /api/v1/cart/items
 func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
@@ -58,8 +88,7 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req domain.AddToCartRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -75,15 +104,7 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 
 	item, err := h.cartService.AddItem(r.Context(), userID, &req)
 	if err != nil {
-		if errors.Is(err, service.ErrInsufficientStock) {
-			response.Error(w, http.StatusBadRequest, "Insufficient stock")
-			return
-		}
-		if errors.Is(err, service.ErrInvalidQuantity) {
-			response.Error(w, http.StatusBadRequest, "Invalid quantity")
-			return
-		}
-		response.Error(w, http.StatusInternalServerError, "Failed to add item to cart")
+		apperr.WriteError(w, r, err)
 		return
 	}
 
@@ -106,8 +127,7 @@ func (h *CartHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req domain.UpdateCartRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -118,19 +138,7 @@ func (h *CartHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request) {
 
 	item, err := h.cartService.UpdateQuantity(r.Context(), userID, productID, &req)
 	if err != nil {
-		if errors.Is(err, service.ErrInsufficientStock) {
-			response.Error(w, http.StatusBadRequest, "Insufficient stock")
-			return
-		}
-		if errors.Is(err, service.ErrInvalidQuantity) {
-			response.Error(w, http.StatusBadRequest, "Invalid quantity")
-			return
-		}
-		if errors.Is(err, service.ErrOptimisticLockRetry) {
-			response.Error(w, http.StatusConflict, "Failed to update due to concurrent modifications, please retry")
-			return
-		}
-		response.Error(w, http.StatusInternalServerError, "Failed to update cart item")
+		apperr.WriteError(w, r, err)
 		return
 	}
 
@@ -153,9 +161,58 @@ func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.cartService.RemoveItem(r.Context(), userID, productID); err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to remove item from cart")
+		apperr.WriteError(w, r, err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, "Item removed from cart")
 }
+
+// ClearCart はカート内の全アイテムを削除する
+// DELETE /api/v1/cart
+// カートが空の場合も成功を返す
+func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.cartService.ClearCart(r.Context(), userID); err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Cart cleared")
+}
+
+// ListAbandonedCarts は一定期間更新されていない空でないカートを取得する（管理者用）
+// GET /api/v1/admin/abandoned-carts?olderThanDays=3&limit=50&cursor=xxx
+func (h *CartHandler) ListAbandonedCarts(w http.ResponseWriter, r *http.Request) {
+	// 放置とみなす日数（デフォルト3日）
+	olderThanDays := 3
+	if v := r.URL.Query().Get("olderThanDays"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d <= 0 {
+			response.Error(w, http.StatusBadRequest, "olderThanDays must be a positive integer")
+			return
+		}
+		olderThanDays = d
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト50、上限100）
+	limit := defaultAbandonedCartLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = min(l, maxAbandonedCartLimit)
+		}
+	}
+
+	page, err := h.cartService.ListAbandonedCarts(r.Context(), time.Duration(olderThanDays)*24*time.Hour, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
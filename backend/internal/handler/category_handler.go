@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// CategoryService はカテゴリ関連のビジネスロジックを定義するインターフェース
+type CategoryService interface {
+	List(ctx context.Context) ([]*domain.Category, error)
+}
+
+type CategoryHandler struct {
+	categoryService CategoryService
+}
+
+func NewCategoryHandler(categoryService CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+// List はカテゴリ一覧（商品数付き）を取得する
+// GET /api/v1/categories
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categoryService.List(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to fetch categories", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, categories)
+}
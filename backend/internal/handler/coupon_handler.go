@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// CouponService はクーポン関連のビジネスロジックを定義するインターフェース
+type CouponService interface {
+	Validate(ctx context.Context, userID, code string) (*domain.CouponValidation, error)
+	Create(ctx context.Context, req *domain.CreateCouponRequest) (*domain.Coupon, error)
+	List(ctx context.Context) ([]*domain.Coupon, error)
+}
+
+// クーポンコードの最大長
+const maxCouponCodeLength = 64
+
+type CouponHandler struct {
+	couponService CouponService
+}
+
+func NewCouponHandler(couponService CouponService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+	}
+}
+
+// Validate はクーポンが利用可能かを確認する（利用回数は消費しない）
+// GET /api/v1/coupons/{code}/validate
+func (h *CouponHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	code := r.PathValue("code")
+	if code == "" {
+		response.Error(w, http.StatusBadRequest, "Coupon code is required")
+		return
+	}
+
+	validation, err := h.couponService.Validate(r.Context(), userID, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponNotFound) {
+			response.Error(w, http.StatusNotFound, "Coupon not found")
+			return
+		}
+		internalError(w, r, "Failed to validate coupon", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, validation)
+}
+
+// Create はクーポンを作成する（管理者用）
+// POST /api/v1/admin/coupons
+func (h *CouponHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateCouponRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if msg := validateCreateCoupon(&req); msg != "" {
+		response.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	coupon, err := h.couponService.Create(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponAlreadyExists) {
+			response.Error(w, http.StatusConflict, "Coupon code already exists")
+			return
+		}
+		internalError(w, r, "Failed to create coupon", err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, coupon)
+}
+
+// List は全クーポンを取得する（管理者用）
+// GET /api/v1/admin/coupons
+func (h *CouponHandler) List(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.couponService.List(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to fetch coupons", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, coupons)
+}
+
+// validateCreateCoupon はクーポン作成リクエストを検証し、不正な場合はエラーメッセージを返す
+func validateCreateCoupon(req *domain.CreateCouponRequest) string {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return "Code is required"
+	}
+	if len(code) > maxCouponCodeLength {
+		return "Code is too long"
+	}
+
+	switch strings.ToUpper(req.Type) {
+	case domain.CouponTypePercent:
+		if req.Value <= 0 || req.Value > 100 {
+			return "Value must be between 1 and 100 for PERCENT coupons"
+		}
+	case domain.CouponTypeFixed:
+		if req.Value <= 0 {
+			return "Value must be positive for FIXED coupons"
+		}
+	default:
+		return "Type must be PERCENT or FIXED"
+	}
+
+	// 無制限・無期限のクーポンは作れない（maxUses = 0 や expiresAt の省略は「制限なし」ではなくエラー）
+	if req.MaxUses <= 0 {
+		return "maxUses must be positive"
+	}
+	if req.ExpiresAt.IsZero() {
+		return "expiresAt is required"
+	}
+	if req.StartsAt != nil && !req.StartsAt.Before(req.ExpiresAt) {
+		return "startsAt must be before expiresAt"
+	}
+	return ""
+}
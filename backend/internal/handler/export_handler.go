@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// ExportService はユーザーデータのエクスポートを定義するインターフェース
+type ExportService interface {
+	ExportUserData(ctx context.Context, userID string) (*domain.UserDataExport, error)
+}
+
+type ExportHandler struct {
+	exportService ExportService
+}
+
+func NewExportHandler(exportService ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportMyData はログイン中のユーザーの全データをJSONファイルとして返す
+// GET /api/v1/users/me/export
+func (h *ExportHandler) ExportMyData(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	export, err := h.exportService.ExportUserData(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "User not found")
+			return
+		}
+		internalError(w, r, "Failed to export user data", err)
+		return
+	}
+
+	// ダウンロード用のファイルとして返す
+	w.Header().Set("Content-Disposition", `attachment; filename="user-data-`+userID+`.json"`)
+	response.JSON(w, http.StatusOK, export)
+}
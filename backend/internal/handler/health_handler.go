@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// Pinger は依存先（DynamoDB）への疎通確認を定義するインターフェース
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DependencyChecker は詳細なヘルスチェックで依存先（DynamoDB）の状態を確認するインターフェース
+type DependencyChecker interface {
+	Pinger
+	ProbeRead(ctx context.Context) error
+	DescribeStatus(ctx context.Context) (*repository.TableStatus, error)
+}
+
+// ヘルスチェックの結果
+const (
+	healthStatusOK       = "ok"
+	healthStatusDegraded = "degraded"
+)
+
+// BuildInfo は実行中のアプリケーションのビルド情報（GET /version）
+// クライアントはデプロイの検知に使う
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// DetailedHealthResponse は依存先ごとの状態（GET /health/detailed）
+// 1つでも ok でないチェックがある場合、Status は degraded になる
+type DetailedHealthResponse struct {
+	Status string        `json:"status"`
+	Build  BuildInfo     `json:"build"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthCheck は依存先1つの確認結果
+// Detail は状態（テーブル・GSI の TableStatus / IndexStatus）またはエラーの内容
+type HealthCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type HealthHandler struct {
+	db    DependencyChecker
+	build BuildInfo
+}
+
+func NewHealthHandler(db DependencyChecker, build BuildInfo) *HealthHandler {
+	return &HealthHandler{
+		db:    db,
+		build: build,
+	}
+}
+
+// Liveness はプロセスが応答できるかだけを返す（依存先には問い合わせない）
+// GET /healthz
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Version は実行中のアプリケーションのビルド情報を返す（依存先には問い合わせない）
+// GET /version
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, h.build)
+}
+
+// Readiness はDynamoDBに到達できるかを確認する（ロードバランサー用）
+// 到達できない場合は 503 と {"status":"degraded"} を返す
+// GET /health
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.Ping(r.Context()); err != nil {
+		log.Printf("Health check failed: %v", err)
+		response.JSON(w, http.StatusServiceUnavailable, map[string]string{"status": "degraded"})
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Detailed は依存先ごとの状態と所要時間を返す（管理者用。障害時に DB とアプリのどちらの問題かを切り分ける）
+// GET /health/detailed
+//
+// 【チェック項目】
+//   - dynamodb:    GetItem でアイテムを読めるか
+//   - table:       DescribeTable のテーブルの状態（ACTIVE 以外は degraded）
+//   - index:<名前>: 同じ DescribeTable の GSI の状態（所要時間は table と同じ）
+//
+// いずれかが ok でない場合は 503 を返す（本文は同じ形式）
+func (h *HealthHandler) Detailed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	checks := make([]HealthCheck, 0, 4)
+
+	start := time.Now()
+	err := h.db.ProbeRead(ctx)
+	checks = append(checks, newHealthCheck("dynamodb", time.Since(start), err, ""))
+
+	start = time.Now()
+	table, err := h.db.DescribeStatus(ctx)
+	latency := time.Since(start)
+	if err != nil {
+		checks = append(checks, newHealthCheck("table", latency, err, ""))
+	} else {
+		checks = append(checks, newHealthCheck("table", latency, nil, table.Status))
+
+		names := make([]string, 0, len(table.Indexes))
+		for name := range table.Indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			checks = append(checks, newHealthCheck("index:"+name, latency, nil, table.Indexes[name]))
+		}
+	}
+
+	resp := DetailedHealthResponse{
+		Status: healthStatusOK,
+		Build:  h.build,
+		Checks: checks,
+	}
+	for _, c := range checks {
+		if c.Status != healthStatusOK {
+			resp.Status = healthStatusDegraded
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status != healthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	response.JSON(w, status, resp)
+}
+
+// newHealthCheck はチェック1件の結果を作る
+// err がある場合、または state が ACTIVE 以外の場合は degraded とする（state が空の場合は状態を見ない）
+func newHealthCheck(name string, latency time.Duration, err error, state string) HealthCheck {
+	c := HealthCheck{
+		Name:      name,
+		Status:    healthStatusOK,
+		LatencyMs: latency.Milliseconds(),
+		Detail:    state,
+	}
+	if err != nil {
+		c.Status = healthStatusDegraded
+		c.Detail = err.Error()
+		return c
+	}
+	if state != "" && state != "ACTIVE" {
+		c.Status = healthStatusDegraded
+	}
+	return c
+}
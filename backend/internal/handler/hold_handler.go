@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/service"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// HoldService は在庫の取り置き関連のビジネスロジックを定義するインターフェース
+type HoldService interface {
+	Reserve(ctx context.Context, userID, productID string) (*domain.StockHold, error)
+	Release(ctx context.Context, userID, productID string) error
+}
+
+type HoldHandler struct {
+	holdService HoldService
+}
+
+func NewHoldHandler(holdService HoldService) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+	}
+}
+
+// Reserve はカートアイテムの数量分の在庫を一定時間取り置く
+// 既に取り置いている場合は現在のカートの数量に合わせ、期限を延長する
+// POST /api/v1/cart/items/{productId}/reserve
+func (h *HoldHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID := r.PathValue("productId")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	hold, err := h.holdService.Reserve(r.Context(), userID, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			response.Error(w, http.StatusNotFound, "Cart item not found")
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if errors.Is(err, service.ErrInsufficientStock) {
+			response.Error(w, http.StatusConflict, "Insufficient stock")
+			return
+		}
+		if errors.Is(err, repository.ErrHoldConflict) {
+			response.Error(w, http.StatusConflict, "Reservation was modified concurrently, please retry")
+			return
+		}
+		internalError(w, r, "Failed to reserve stock", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, hold)
+}
+
+// Release は在庫の取り置きを解放する
+// DELETE /api/v1/cart/items/{productId}/reserve
+func (h *HoldHandler) Release(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID := r.PathValue("productId")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	if err := h.holdService.Release(r.Context(), userID, productID); err != nil {
+		if errors.Is(err, repository.ErrHoldNotFound) {
+			response.Error(w, http.StatusNotFound, "Reservation not found")
+			return
+		}
+		internalError(w, r, "Failed to release reservation", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Reservation released")
+}
@@ -2,20 +2,23 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 // InventoryService は在庫管理関連のビジネスロジックを定義するインターフェース
 type InventoryService interface {
-	AdjustStock(ctx context.Context, productID string, changeType string, quantity int, reason string) error
+	AdjustStock(ctx context.Context, productID string, changeType string, quantity int, reason string) (*domain.Product, *domain.InventoryLog, error)
 	GetLogs(ctx context.Context, productID string, limit int32) ([]*domain.InventoryLog, error)
 	GetLogsWithRange(ctx context.Context, productID string, startTime, endTime time.Time) ([]*domain.InventoryLog, error)
+	Summarize(ctx context.Context, productID string, startTime, endTime time.Time) (*domain.InventorySummary, error)
 }
 
 type InventoryHandler struct {
@@ -45,8 +48,7 @@ func (h *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req AdjustStockRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -66,12 +68,23 @@ func (h *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.inventoryService.AdjustStock(r.Context(), productID, req.ChangeType, req.Quantity, req.Reason); err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to adjust stock")
+	product, log, err := h.inventoryService.AdjustStock(r.Context(), productID, req.ChangeType, req.Quantity, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			response.Error(w, http.StatusNotFound, "Product not found")
+		case errors.Is(err, repository.ErrProductVersionMismatch):
+			// 在庫を読み込んでから更新するまでの間に他の更新があった
+			response.Error(w, http.StatusConflict, "Product was modified by another request, please retry")
+		case errors.Is(err, repository.ErrStockBelowReserved):
+			response.Error(w, http.StatusConflict, "Stock cannot be less than the quantity reserved by checkouts and holds")
+		default:
+			internalError(w, r, "Failed to adjust stock", err)
+		}
 		return
 	}
 
-	response.Success(w, http.StatusOK, "Stock adjusted successfully")
+	response.Data(w, http.StatusOK, "Stock adjusted successfully", domain.StockAdjustment{Stock: product.Stock, Product: product, Log: log})
 }
 
 // GetLogs は商品の在庫変動履歴を取得する
@@ -97,22 +110,19 @@ func (h *InventoryHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	endStr := r.URL.Query().Get("end")
 
 	if startStr != "" && endStr != "" {
-		startTime, err := time.Parse("2006-01-02", startStr)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		startTime, endTime, ok := parseDateRange(w, startStr, endStr)
+		if !ok {
 			return
 		}
-		endTime, err := time.Parse("2006-01-02", endStr)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "Invalid end date format (use YYYY-MM-DD)")
-			return
-		}
-		// 終了日は23:59:59まで含める
-		endTime = endTime.Add(24*time.Hour - time.Second)
 
 		logs, err := h.inventoryService.GetLogsWithRange(r.Context(), productID, startTime, endTime)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "Failed to fetch inventory logs")
+			// 期間内の件数が多すぎて全件を読み切れない場合は、期間を狭めてもらう
+			if errors.Is(err, repository.ErrTooManyPages) {
+				response.Error(w, http.StatusBadRequest, "Date range too large, please narrow the range")
+				return
+			}
+			internalError(w, r, "Failed to fetch inventory logs", err)
 			return
 		}
 		response.JSON(w, http.StatusOK, logs)
@@ -122,13 +132,62 @@ func (h *InventoryHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	// 期間指定がない場合はlimit件数取得
 	logs, err := h.inventoryService.GetLogs(r.Context(), productID, limit)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch inventory logs")
+		internalError(w, r, "Failed to fetch inventory logs", err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, logs)
 }
 
+// GetSummary は指定期間の在庫変動を ChangeType ごとに集計した結果と期首・期末の在庫数を返す
+// GET /api/v1/products/{id}/inventory-summary?start=2025-01-01&end=2025-12-31
+func (h *InventoryHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	productID := r.PathValue("id")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+	if startStr == "" || endStr == "" {
+		response.Error(w, http.StatusBadRequest, "start and end are required (use YYYY-MM-DD)")
+		return
+	}
+	startTime, endTime, ok := parseDateRange(w, startStr, endStr)
+	if !ok {
+		return
+	}
+
+	summary, err := h.inventoryService.Summarize(r.Context(), productID, startTime, endTime)
+	if err != nil {
+		if errors.Is(err, repository.ErrTooManyPages) {
+			response.Error(w, http.StatusBadRequest, "Date range too large, please narrow the range")
+			return
+		}
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, summary)
+}
+
+// parseDateRange は YYYY-MM-DD 形式の開始日・終了日を解析する（終了日は23:59:59まで含める）
+// 形式が不正な場合は 400 を書き込み、ok=false を返す
+func parseDateRange(w http.ResponseWriter, startStr, endStr string) (startTime, endTime time.Time, ok bool) {
+	startTime, err := time.Parse("2006-01-02", startStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+	endTime, err = time.Parse("2006-01-02", endStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid end date format (use YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+	return startTime, endTime.Add(24*time.Hour - time.Second), true
+}
+
 // GetAllLogs は全商品の在庫変動履歴を取得する（管理者用）
 // GET /api/v1/admin/inventory-logs?productId=xxx&limit=50
 func (h *InventoryHandler) GetAllLogs(w http.ResponseWriter, r *http.Request) {
@@ -149,7 +208,7 @@ func (h *InventoryHandler) GetAllLogs(w http.ResponseWriter, r *http.Request) {
 
 	logs, err := h.inventoryService.GetLogs(r.Context(), productID, limit)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch inventory logs")
+		internalError(w, r, "Failed to fetch inventory logs", err)
 		return
 	}
 
@@ -2,22 +2,50 @@ package handler
 
 import (
 	"context"
-	"errors"
+	"encoding/csv"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
-	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 // OrderServiceInterface は注文関連のビジネスロジックを定義するインターフェース
 type OrderServiceInterface interface {
-	CreateOrder(ctx context.Context, userID string) (*domain.Order, error)
-	GetOrders(ctx context.Context, userID string) ([]*domain.Order, error)
-	GetOrderByID(ctx context.Context, userID, orderID string) (*domain.Order, error)
+	CreateOrder(ctx context.Context, userID, idempotencyKey string, req *domain.CreateOrderRequest) (*domain.Order, error)
+	GetOrders(ctx context.Context, userID, status string, limit int32, cursor string) (*domain.OrderPage, error)
+	Summarize(ctx context.Context, userID string) (*domain.OrderSummary, error)
+	ListByMonth(ctx context.Context, yyyymm string, limit int32, cursor string) (*domain.OrderPage, error)
+	ListByStatus(ctx context.Context, status string, limit int32, cursor string) (*domain.OrderPage, error)
+	EachByMonth(ctx context.Context, yyyymm string, fn func(orders []*domain.Order) error) error
+	GetOrderByID(ctx context.Context, userID, orderID string, itemLimit int, itemsToken string) (*domain.Order, error)
+	BuildInvoice(ctx context.Context, userID, orderID string) (*domain.Invoice, error)
+	MarkPaid(ctx context.Context, orderID, reference string) (*domain.Order, error)
+	UpdateStatus(ctx context.Context, orderID, status string) (*domain.Order, error)
 }
 
+// Idempotency-Key ヘッダーの最大長
+const maxIdempotencyKeyLength = 255
+
+// 決済リファレンスの最大長
+const maxPaymentReferenceLength = 255
+
+// 注文履歴の1ページあたりの件数（既定値と上限）
+const (
+	defaultOrderHistoryLimit = 20
+	maxOrderHistoryLimit     = 100
+)
+
+// 管理者向けの注文一覧（月別・ステータス別）の1ページあたりの件数（既定値と上限）
+const (
+	defaultAdminOrderLimit = 50
+	maxAdminOrderLimit     = 100
+)
+
 type OrderHandler struct {
 	orderService OrderServiceInterface
 }
@@ -37,32 +65,54 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.orderService.CreateOrder(r.Context(), userID)
+	var req domain.CreateOrderRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if msg := validateAddress(req.ShippingAddress); msg != "" {
+		response.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Idempotency-Key ヘッダー（任意）: クライアントの再送による二重注文を防ぐ
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		response.Error(w, http.StatusBadRequest, "Idempotency-Key is too long")
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(r.Context(), userID, idempotencyKey, &req)
 	if err != nil {
-		// カートが空の場合
-		if errors.Is(err, repository.ErrCartItemNotFound) {
-			response.Error(w, http.StatusBadRequest, "Cart is empty")
-			return
-		}
-		// 在庫不足の場合
-		if errors.Is(err, repository.ErrInsufficientStock) {
-			response.Error(w, http.StatusConflict, "Insufficient stock for one or more items")
-			return
-		}
-		// トランザクション競合の場合
-		if errors.Is(err, repository.ErrTransactionConflict) {
-			response.Error(w, http.StatusConflict, "Transaction conflict, please retry")
-			return
-		}
-		response.Error(w, http.StatusInternalServerError, "Failed to create order")
+		apperr.WriteError(w, r, err)
 		return
 	}
 
 	response.JSON(w, http.StatusCreated, order)
 }
 
-// GetOrders はユーザーの注文一覧を取得する
-// GET /api/v1/orders
+// validateAddress は配送先住所を検証し、不正な場合はエラーメッセージを返す
+// 前後の空白は取り除いた状態で保存する
+func validateAddress(a *domain.Address) string {
+	if a == nil {
+		return "Shipping address is required"
+	}
+
+	a.ZipCode = strings.TrimSpace(a.ZipCode)
+	a.Prefecture = strings.TrimSpace(a.Prefecture)
+	a.City = strings.TrimSpace(a.City)
+	a.Address = strings.TrimSpace(a.Address)
+
+	if a.ZipCode == "" || a.Prefecture == "" || a.City == "" || a.Address == "" {
+		return "Shipping address requires zipCode, prefecture, city, and address"
+	}
+	return ""
+}
+
+// GetOrders はユーザーの注文一覧を新しい順に取得する
+// GET /api/v1/orders?limit=20&cursor=xxx&status=SHIPPED
+// limit は既定 20・上限 100。続きがある場合は nextCursor を次のリクエストの cursor に渡す
+// status を指定した場合は読み込んだ後に絞り込むため、1ページの件数は limit 未満になることがある
 func (h *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	if userID == "" {
@@ -70,17 +120,177 @@ func (h *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, err := h.orderService.GetOrders(r.Context(), userID)
+	// クエリパラメータからlimitを取得（デフォルト20、上限100）
+	limit := int32(defaultOrderHistoryLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = int32(min(l, maxOrderHistoryLimit))
+		}
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !domain.IsValidOrderStatus(status) {
+		response.Error(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	page, err := h.orderService.GetOrders(r.Context(), userID, status, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// GetSummary はログインユーザーの注文の集計を取得する
+// GET /api/v1/orders/summary
+func (h *OrderHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	summary, err := h.orderService.Summarize(r.Context(), userID)
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, summary)
+}
+
+// ListByMonth は指定月の全ユーザーの注文を新しい順に取得する（管理者用）
+// GET /api/v1/admin/orders?month=2025-01&limit=50&cursor=xxx
+// limit は既定 50・上限 100。続きがある場合は nextCursor を次のリクエストの cursor に渡す
+func (h *OrderHandler) ListByMonth(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		response.Error(w, http.StatusBadRequest, "month is required")
+		return
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid month format (use YYYY-MM)")
+		return
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト50、上限100）
+	limit := int32(defaultAdminOrderLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = int32(min(l, maxAdminOrderLimit))
+		}
+	}
+
+	page, err := h.orderService.ListByMonth(r.Context(), month, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// ListByStatus は指定ステータスの全ユーザーの注文を、そのステータスになった日時の古い順に取得する（管理者用）
+// GET /api/v1/admin/orders/by-status?status=PENDING&limit=50&cursor=xxx
+// limit は既定 50・上限 100
+func (h *OrderHandler) ListByStatus(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		response.Error(w, http.StatusBadRequest, "status is required")
+		return
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト50、上限100）
+	limit := int32(defaultAdminOrderLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = int32(min(l, maxAdminOrderLimit))
+		}
+	}
+
+	page, err := h.orderService.ListByStatus(r.Context(), status, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// ExportCSV は指定月の全ユーザーの注文をCSVファイルとして返す（管理者用・経理向け）
+// GET /api/v1/admin/orders/export?month=2025-01
+// 列: orderId, userId, status, totalAmount, itemCount, createdAt, taxAmount, grandTotal（税額・税込合計は後から追加したため末尾）
+//
+// 【ストリーミング】
+// DynamoDB のページを取得するたびにCSVへ書き出して Flush するため、件数が多い月でもメモリ使用量は一定
+// 書き出し開始後にエラーが起きた場合はステータスを変更できないため、ログに残して途中で打ち切る
+func (h *OrderHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		response.Error(w, http.StatusBadRequest, "month is required")
+		return
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid month format (use YYYY-MM)")
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="orders-`+month+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return cw.Write([]string{"orderId", "userId", "status", "totalAmount", "itemCount", "createdAt", "taxAmount", "grandTotal"})
+	}
+
+	err := h.orderService.EachByMonth(r.Context(), month, func(orders []*domain.Order) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, order := range orders {
+			if err := cw.Write([]string{
+				order.ID,
+				order.UserID,
+				order.Status,
+				strconv.Itoa(order.TotalAmount),
+				strconv.Itoa(order.ItemCount),
+				order.CreatedAt.Format(time.RFC3339),
+				strconv.Itoa(order.TaxAmount),
+				strconv.Itoa(order.GrandTotal),
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch orders")
+		if !started {
+			internalError(w, r, "Failed to export orders", err)
+			return
+		}
+		middleware.LogError(r.Context(), "Failed to export orders for "+month, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, orders)
+	// 注文が1件もない月でもヘッダー行だけのCSVを返す
+	if !started {
+		if err := start(); err != nil {
+			middleware.LogError(r.Context(), "Failed to export orders for "+month, err)
+			return
+		}
+	}
+	cw.Flush()
 }
 
 // GetOrderByID は注文詳細を取得する
-// GET /api/v1/orders/{id}
+// GET /api/v1/orders/{id}?itemLimit=100&itemsToken=xxx
 func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	if userID == "" {
@@ -94,13 +304,100 @@ func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.orderService.GetOrderByID(r.Context(), userID, orderID)
-	if err != nil {
-		if errors.Is(err, repository.ErrOrderNotFound) {
-			response.Error(w, http.StatusNotFound, "Order not found")
-			return
+	// 明細の取得件数（未指定の場合はサーバー側の上限まで）
+	itemLimit := 0
+	if v := r.URL.Query().Get("itemLimit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			itemLimit = l
 		}
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch order")
+	}
+
+	order, err := h.orderService.GetOrderByID(r.Context(), userID, orderID, itemLimit, r.URL.Query().Get("itemsToken"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, order)
+}
+
+// GetInvoice は注文の請求書（明細・小計・割引・消費税・税込合計）を取得する
+// GET /api/v1/orders/{id}/invoice
+func (h *OrderHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	orderID := r.PathValue("id")
+	if orderID == "" {
+		response.Error(w, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	invoice, err := h.orderService.BuildInvoice(r.Context(), userID, orderID)
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, invoice)
+}
+
+// MarkPaid は注文を入金済みにする（管理者用）
+// POST /api/v1/admin/orders/{id}/mark-paid
+func (h *OrderHandler) MarkPaid(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	if orderID == "" {
+		response.Error(w, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	var req domain.MarkPaidRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Reference = strings.TrimSpace(req.Reference)
+	if req.Reference == "" {
+		response.Error(w, http.StatusBadRequest, "Payment reference is required")
+		return
+	}
+	if len(req.Reference) > maxPaymentReferenceLength {
+		response.Error(w, http.StatusBadRequest, "Payment reference is too long")
+		return
+	}
+
+	order, err := h.orderService.MarkPaid(r.Context(), orderID, req.Reference)
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, order)
+}
+
+// UpdateStatus は注文のステータスを変更する（管理者用）
+// PUT /api/v1/admin/orders/{id}/status
+func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	if orderID == "" {
+		response.Error(w, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	var req domain.UpdateOrderStatusRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Status == "" {
+		response.Error(w, http.StatusBadRequest, "Status is required")
+		return
+	}
+
+	order, err := h.orderService.UpdateStatus(r.Context(), orderID, req.Status)
+	if err != nil {
+		apperr.WriteError(w, r, err)
 		return
 	}
 
@@ -2,21 +2,27 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 // PriceHistoryService は価格履歴関連のビジネスロジックを定義するインターフェース
 type PriceHistoryService interface {
-	UpdatePrice(ctx context.Context, productID string, newPrice int, changedBy string) error
-	GetHistory(ctx context.Context, productID string, limit int32) ([]*domain.PriceHistory, error)
-	GetHistoryWithRange(ctx context.Context, productID string, startTime, endTime time.Time) ([]*domain.PriceHistory, error)
+	UpdatePrice(ctx context.Context, productID string, newPrice, expectedVersion int, changedBy string) (*domain.Product, *domain.PriceHistory, error)
+	GetHistory(ctx context.Context, productID string, limit int32) (*domain.PriceHistoryResponse, error)
+	GetHistoryWithRange(ctx context.Context, productID string, startTime, endTime time.Time, interval string) (*domain.PriceHistoryResponse, error)
+	SchedulePriceChange(ctx context.Context, productID string, price int, effectiveAt time.Time, changedBy string) (*domain.ScheduledPrice, error)
+	ListScheduledPrices(ctx context.Context, productID string) ([]*domain.ScheduledPrice, error)
+	CancelScheduledPrice(ctx context.Context, productID string, effectiveAt time.Time) error
+	ApplyScheduledPrices(ctx context.Context, now time.Time) (int, error)
 }
 
 type PriceHistoryHandler struct {
@@ -31,7 +37,14 @@ func NewPriceHistoryHandler(priceHistoryService PriceHistoryService) *PriceHisto
 
 // UpdatePriceRequest は価格更新リクエストの構造体
 type UpdatePriceRequest struct {
-	Price int `json:"price"`
+	Price   int `json:"price"`
+	Version int `json:"version"` // 楽観的ロック用（読み込んだ商品の version）
+}
+
+// SchedulePriceRequest は価格変更予約リクエストの構造体
+type SchedulePriceRequest struct {
+	Price       int       `json:"price"`
+	EffectiveAt time.Time `json:"effectiveAt"` // RFC3339形式（例: 2025-01-01T00:00:00+09:00）
 }
 
 // UpdatePrice は商品の価格を更新する
@@ -44,8 +57,7 @@ func (h *PriceHistoryHandler) UpdatePrice(w http.ResponseWriter, r *http.Request
 	}
 
 	var req UpdatePriceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -60,16 +72,23 @@ func (h *PriceHistoryHandler) UpdatePrice(w http.ResponseWriter, r *http.Request
 		userID = "unknown"
 	}
 
-	if err := h.priceHistoryService.UpdatePrice(r.Context(), productID, req.Price, userID); err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to update price")
+	// 他の管理者が先に商品を変更していた場合は 409（商品を取得し直してから再試行してもらう）
+	product, history, err := h.priceHistoryService.UpdatePrice(r.Context(), productID, req.Price, req.Version, userID)
+	if err != nil {
+		var appErr *apperr.Error
+		if errors.As(err, &appErr) {
+			apperr.WriteError(w, r, err)
+			return
+		}
+		internalError(w, r, "Failed to update price", err)
 		return
 	}
 
-	response.Success(w, http.StatusOK, "Price updated successfully")
+	response.Data(w, http.StatusOK, "Price updated successfully", domain.PriceUpdate{Price: product.Price, Product: product, History: history})
 }
 
 // GetHistory は商品の価格履歴を取得する
-// GET /api/v1/products/{id}/price-history?limit=50&start=2025-01-01&end=2025-12-31
+// GET /api/v1/products/{id}/price-history?limit=50&start=2025-01-01&end=2025-12-31&interval=daily
 func (h *PriceHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("id")
 	if productID == "" {
@@ -89,36 +108,139 @@ func (h *PriceHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request)
 	// 期間指定がある場合はGetHistoryWithRangeを使用
 	startStr := r.URL.Query().Get("start")
 	endStr := r.URL.Query().Get("end")
+	// interval（hourly / daily / weekly）は期間指定と組み合わせて使う（区間ごとに最後の価格に間引く）
+	interval := r.URL.Query().Get("interval")
 
 	if startStr != "" && endStr != "" {
-		startTime, err := time.Parse("2006-01-02", startStr)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		startTime, endTime, ok := parseDateRange(w, startStr, endStr)
+		if !ok {
 			return
 		}
-		endTime, err := time.Parse("2006-01-02", endStr)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "Invalid end date format (use YYYY-MM-DD)")
-			return
-		}
-		// 終了日は23:59:59まで含める
-		endTime = endTime.Add(24*time.Hour - time.Second)
 
-		histories, err := h.priceHistoryService.GetHistoryWithRange(r.Context(), productID, startTime, endTime)
+		histories, err := h.priceHistoryService.GetHistoryWithRange(r.Context(), productID, startTime, endTime, interval)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "Failed to fetch price history")
+			// 期間内の件数が多すぎて全件を読み切れない場合は、期間を狭めてもらう
+			if errors.Is(err, repository.ErrTooManyPages) {
+				response.Error(w, http.StatusBadRequest, "Date range too large, please narrow the range")
+				return
+			}
+			apperr.WriteError(w, r, err)
 			return
 		}
 		response.JSON(w, http.StatusOK, histories)
 		return
 	}
 
+	if interval != "" {
+		response.Error(w, http.StatusBadRequest, "interval requires start and end")
+		return
+	}
+
 	// 期間指定がない場合はlimit件数取得
 	histories, err := h.priceHistoryService.GetHistory(r.Context(), productID, limit)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch price history")
+		internalError(w, r, "Failed to fetch price history", err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, histories)
 }
+
+// SchedulePrice は指定日時に適用する価格変更を予約する
+// POST /api/v1/admin/products/{id}/schedule-price
+func (h *PriceHistoryHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	productID := r.PathValue("id")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	var req SchedulePriceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Price <= 0 {
+		response.Error(w, http.StatusBadRequest, "Price must be positive")
+		return
+	}
+
+	if req.EffectiveAt.IsZero() {
+		response.Error(w, http.StatusBadRequest, "EffectiveAt is required")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+
+	scheduled, err := h.priceHistoryService.SchedulePriceChange(r.Context(), productID, req.Price, req.EffectiveAt, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		internalError(w, r, "Failed to schedule price change", err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, scheduled)
+}
+
+// ListScheduledPrices は商品の適用前の価格変更の予約を適用日時順に返す
+// GET /api/v1/admin/products/{id}/scheduled-prices
+func (h *PriceHistoryHandler) ListScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	productID := r.PathValue("id")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	scheduled, err := h.priceHistoryService.ListScheduledPrices(r.Context(), productID)
+	if err != nil {
+		internalError(w, r, "Failed to fetch scheduled prices", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, scheduled)
+}
+
+// CancelScheduledPrice は適用前の価格変更の予約を取り消す
+// DELETE /api/v1/admin/products/{id}/scheduled-prices/{effectiveAt}
+// effectiveAt は予約の適用日時（RFC3339。例: 2025-12-01T00:00:00Z）
+func (h *PriceHistoryHandler) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	productID := r.PathValue("id")
+	if productID == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	effectiveAt, err := time.Parse(time.RFC3339, r.PathValue("effectiveAt"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid effectiveAt format (use RFC3339)")
+		return
+	}
+
+	if err := h.priceHistoryService.CancelScheduledPrice(r.Context(), productID, effectiveAt); err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Scheduled price change cancelled")
+}
+
+// ApplyScheduledPricesResponse は予約価格の手動適用の結果
+type ApplyScheduledPricesResponse struct {
+	Applied int `json:"applied"` // 適用した予約の件数
+}
+
+// ApplyScheduledPrices は適用日時を過ぎた予約価格をその場で適用する（ローカル開発での動作確認用）
+// POST /api/v1/admin/apply-scheduled-prices
+// 通常はバックグラウンドのスケジューラー（PRICE_SCHEDULER_INTERVAL ごと）が適用する
+func (h *PriceHistoryHandler) ApplyScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	applied, err := h.priceHistoryService.ApplyScheduledPrices(r.Context(), time.Now())
+	if err != nil {
+		internalError(w, r, "Failed to apply scheduled prices", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, ApplyScheduledPricesResponse{Applied: applied})
+}
@@ -2,48 +2,265 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/service"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 // ProductService は商品関連のビジネスロジックを定義するインターフェース
 type ProductService interface {
-	List(ctx context.Context, category string) ([]*domain.Product, error)
-	GetByID(ctx context.Context, id string) (*domain.Product, error)
+	List(ctx context.Context, category string, includeDeleted bool) ([]*domain.Product, error)
+	ListSummaries(ctx context.Context, category string) ([]*domain.ProductSummary, error)
+	ListByCreatedRange(ctx context.Context, start, end time.Time, limit int32, nextToken string) (*domain.ProductPage, error)
+	ListLowStock(ctx context.Context, threshold int, limit int32, nextToken string) (*domain.ProductPage, error)
+	ListBestsellers(ctx context.Context, limit int) ([]*domain.Bestseller, error)
+	ListRelated(ctx context.Context, productID string, limit int, nextToken string) (*domain.RelatedProductPage, error)
+	GetFacets(ctx context.Context) (*domain.ProductFacets, error)
+	GetByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error)
+	CheckAvailability(ctx context.Context, id string, quantity int) (*domain.ProductAvailability, error)
 	Create(ctx context.Context, req *domain.CreateProductRequest) (*domain.Product, error)
+	ImportProducts(ctx context.Context, reqs []*domain.CreateProductRequest) (*domain.ProductImportResponse, error)
 	Update(ctx context.Context, id string, req *domain.UpdateProductRequest) (*domain.Product, error)
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) (*domain.Product, error)
+	HardDelete(ctx context.Context, id string) error
 }
 
+// 売れ筋商品のデフォルトの件数
+const defaultBestsellerLimit = 10
+
+// 「一緒に購入されている商品」のデフォルトの件数
+const defaultRelatedLimit = 10
+
+// 在庫僅少とみなす在庫数のデフォルト値
+const defaultLowStockThreshold = 10
+
+// 管理者向けの商品一覧（作成日時の範囲・在庫僅少）の1ページの件数の既定値と上限
+// 上限を超える limit は上限に丸める（int32 への変換で桁あふれさせない）
+const (
+	defaultAdminProductLimit = 50
+	maxAdminProductLimit     = 100
+)
+
+// 一括登録で1リクエストに含められる商品数の上限
+const maxImportProducts = 500
+
 type ProductHandler struct {
 	productService ProductService
+	roles          *middleware.Roles
 }
 
-func NewProductHandler(productService ProductService) *ProductHandler {
+// roles は includeDeleted=true を管理者にだけ許可するために使う
+func NewProductHandler(productService ProductService, roles *middleware.Roles) *ProductHandler {
 	return &ProductHandler{
 		productService: productService,
+		roles:          roles,
 	}
 }
 
 // List は商品一覧を取得する
-// GET /api/v1/products?category=xxx
+// GET /api/v1/products?category=xxx&includeDeleted=true
+// includeDeleted=true の場合は論理削除済みの商品も含める（管理者のみ。それ以外は無視する）
+// fields=card の場合はカード表示に必要な項目（id, name, price, imageUrl, stock）だけを返す（includeDeleted は無視）
 func (h *ProductHandler) List(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
 
-	products, err := h.productService.List(r.Context(), category)
+	switch r.URL.Query().Get("fields") {
+	case "":
+	case "card":
+		summaries, err := h.productService.ListSummaries(r.Context(), category)
+		if err != nil {
+			internalError(w, r, "Failed to fetch products", err)
+			return
+		}
+		response.JSON(w, http.StatusOK, summaries)
+		return
+	default:
+		response.Error(w, http.StatusBadRequest, "fields must be card")
+		return
+	}
+
+	products, err := h.productService.List(r.Context(), category, h.includeDeleted(r))
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to fetch products")
+		internalError(w, r, "Failed to fetch products", err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, products)
 }
 
+// ListByCreatedRange は作成日時の範囲で商品を取得する（管理者用）
+// GET /api/v1/admin/products?createdFrom=2025-01-01&createdTo=2025-01-31&limit=50&nextToken=xxx
+// 日付は YYYY-MM-DD（UTC）または RFC3339 で指定する。YYYY-MM-DD の createdTo はその日の終わりまで含める
+// limit は既定 50・上限 100
+func (h *ProductHandler) ListByCreatedRange(w http.ResponseWriter, r *http.Request) {
+	fromStr := r.URL.Query().Get("createdFrom")
+	toStr := r.URL.Query().Get("createdTo")
+	if fromStr == "" || toStr == "" {
+		response.Error(w, http.StatusBadRequest, "createdFrom and createdTo are required")
+		return
+	}
+
+	start, _, err := parseUTCDate(fromStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid createdFrom format (use YYYY-MM-DD or RFC3339)")
+		return
+	}
+	end, dateOnly, err := parseUTCDate(toStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid createdTo format (use YYYY-MM-DD or RFC3339)")
+		return
+	}
+	if dateOnly {
+		// 終了日は23:59:59まで含める
+		end = end.Add(24*time.Hour - time.Second)
+	}
+	if end.Before(start) {
+		response.Error(w, http.StatusBadRequest, "createdFrom must be before createdTo")
+		return
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト50、上限100）
+	limit := int32(defaultAdminProductLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = int32(min(l, maxAdminProductLimit))
+		}
+	}
+
+	page, err := h.productService.ListByCreatedRange(r.Context(), start, end, limit, r.URL.Query().Get("nextToken"))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			response.Error(w, http.StatusBadRequest, "Invalid nextToken")
+			return
+		}
+		internalError(w, r, "Failed to fetch products", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// ListLowStock は在庫数が threshold 以下の商品を在庫の少ない順に取得する（管理者用）
+// GET /api/v1/admin/products/low-stock?threshold=5&limit=50&nextToken=xxx
+// フィルターは読み込み後に適用されるため、件数が limit 未満でも nextToken があれば続きを取得する
+// 在庫の少ない順の並び替えは1ページの中だけで行う（ページをまたいだ順序は保証しない）
+// limit は既定 50・上限 100（フィルター前に読む件数の上限）
+func (h *ProductHandler) ListLowStock(w http.ResponseWriter, r *http.Request) {
+	threshold := defaultLowStockThreshold
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		t, err := strconv.Atoi(v)
+		if err != nil || t < 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid threshold")
+			return
+		}
+		threshold = t
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト50、上限100）
+	limit := int32(defaultAdminProductLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = int32(min(l, maxAdminProductLimit))
+		}
+	}
+
+	page, err := h.productService.ListLowStock(r.Context(), threshold, limit, r.URL.Query().Get("nextToken"))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			response.Error(w, http.StatusBadRequest, "Invalid nextToken")
+			return
+		}
+		internalError(w, r, "Failed to fetch low-stock products", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// ListBestsellers は販売数の多い順に商品を取得する
+// GET /api/v1/products/bestsellers?limit=10
+func (h *ProductHandler) ListBestsellers(w http.ResponseWriter, r *http.Request) {
+	// クエリパラメータからlimitを取得（デフォルト10、上限 service.MaxBestsellers）
+	limit := defaultBestsellerLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = min(l, service.MaxBestsellers)
+		}
+	}
+
+	bestsellers, err := h.productService.ListBestsellers(r.Context(), limit)
+	if err != nil {
+		internalError(w, r, "Failed to fetch bestsellers", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, bestsellers)
+}
+
+// ListRelated は商品と同じ注文で購入された回数の多い順に「一緒に購入されている商品」を取得する
+// GET /api/v1/products/{id}/related?limit=10&nextToken=...
+func (h *ProductHandler) ListRelated(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	// クエリパラメータからlimitを取得（デフォルト10、上限 service.MaxRelatedProducts）
+	limit := defaultRelatedLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = min(l, service.MaxRelatedProducts)
+		}
+	}
+
+	page, err := h.productService.ListRelated(r.Context(), id, limit, r.URL.Query().Get("nextToken"))
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, page)
+}
+
+// GetFacets はカテゴリ別の商品数を取得する（サイドバーの表示用）
+// GET /api/v1/products/facets
+func (h *ProductHandler) GetFacets(w http.ResponseWriter, r *http.Request) {
+	facets, err := h.productService.GetFacets(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to fetch product facets", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, facets)
+}
+
+// parseUTCDate は YYYY-MM-DD または RFC3339 の日時を UTC で解釈する
+// dateOnly は YYYY-MM-DD 形式だったかどうか
+func parseUTCDate(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t.UTC(), false, nil
+}
+
 // GetByID は指定IDの商品を取得する
-// GET /api/v1/products/{id}
+// GET /api/v1/products/{id}?includeDeleted=true
+// includeDeleted=true は管理者のみ有効（それ以外は論理削除済みの商品を 404 とする）
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -51,7 +268,7 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.productService.GetByID(r.Context(), id)
+	product, err := h.productService.GetByID(r.Context(), id, h.includeDeleted(r))
 	if err != nil {
 		response.Error(w, http.StatusNotFound, "Product not found")
 		return
@@ -60,29 +277,80 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, product)
 }
 
+// CheckAvailability は指定した数量を購入できるかどうかを返す
+// GET /api/v1/products/{id}/availability?quantity=3
+func (h *ProductHandler) CheckAvailability(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	quantity, err := strconv.Atoi(r.URL.Query().Get("quantity"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "quantity must be a positive integer")
+		return
+	}
+
+	availability, err := h.productService.CheckAvailability(r.Context(), id, quantity)
+	if err != nil {
+		apperr.WriteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, availability)
+}
+
 // Create は新規商品を作成する
 // POST /api/v1/products
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req domain.CreateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Name == "" || req.Price <= 0 {
-		response.Error(w, http.StatusBadRequest, "Name and positive price are required")
+	if fields := req.Validate(); fields != nil {
+		response.ValidationError(w, fields)
 		return
 	}
 
 	product, err := h.productService.Create(r.Context(), &req)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to create product")
+		internalError(w, r, "Failed to create product", err)
 		return
 	}
 
 	response.JSON(w, http.StatusCreated, product)
 }
 
+// Import は商品を一括登録する（管理者用）
+// POST /api/v1/admin/products/import
+// リクエスト: CreateProductRequest の配列（最大 maxImportProducts 件）
+// 1件ごとの成否と作成・失敗件数のサマリーを返す（一部が失敗しても 200）
+func (h *ProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var reqs []*domain.CreateProductRequest
+	if !decodeJSON(w, r, &reqs) {
+		return
+	}
+
+	if len(reqs) == 0 {
+		response.Error(w, http.StatusBadRequest, "At least one product is required")
+		return
+	}
+	if len(reqs) > maxImportProducts {
+		response.Error(w, http.StatusBadRequest, fmt.Sprintf("Too many products (max %d)", maxImportProducts))
+		return
+	}
+
+	result, err := h.productService.ImportProducts(r.Context(), reqs)
+	if err != nil {
+		internalError(w, r, "Failed to import products", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
 // Update は商品情報を更新する
 // PUT /api/v1/products/{id}
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -93,21 +361,32 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req domain.UpdateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	product, err := h.productService.Update(r.Context(), id, &req)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			response.Error(w, http.StatusNotFound, "Product not found")
+		case errors.Is(err, repository.ErrProductVersionMismatch):
+			response.Error(w, http.StatusConflict, "Product was modified by another request, please refetch and retry")
+		case errors.Is(err, repository.ErrStockBelowReserved):
+			response.Error(w, http.StatusConflict, "Stock cannot be less than the quantity reserved by checkouts and holds")
+		case errors.Is(err, repository.ErrTransactionConflict):
+			response.Error(w, http.StatusConflict, "Product was modified concurrently, please retry")
+		default:
+			// 内部のエラーメッセージはクライアントに返さない（ログにのみ出力する）
+			internalError(w, r, "Failed to update product", err)
+		}
 		return
 	}
 
 	response.JSON(w, http.StatusOK, product)
 }
 
-// Delete は商品を削除する
+// Delete は商品を論理削除する
 // DELETE /api/v1/products/{id}
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -117,9 +396,79 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.productService.Delete(r.Context(), id); err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to delete product")
+		if errors.Is(err, repository.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if errors.Is(err, repository.ErrTransactionConflict) {
+			response.Error(w, http.StatusConflict, "Product was modified concurrently, please retry")
+			return
+		}
+		internalError(w, r, "Failed to delete product", err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, "Product deleted successfully")
 }
+
+// Restore は論理削除した商品を元に戻す
+// POST /api/v1/products/{id}/restore
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	product, err := h.productService.Restore(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotDeleted) {
+			response.Error(w, http.StatusConflict, "Product is not deleted")
+			return
+		}
+		if errors.Is(err, repository.ErrTransactionConflict) {
+			response.Error(w, http.StatusConflict, "Product was modified concurrently, please retry")
+			return
+		}
+		internalError(w, r, "Failed to restore product", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, product)
+}
+
+// HardDelete は商品を物理削除する（管理者用・元に戻せない）
+// DELETE /api/v1/admin/products/{id}
+func (h *ProductHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	if err := h.productService.HardDelete(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if errors.Is(err, repository.ErrTransactionConflict) {
+			response.Error(w, http.StatusConflict, "Product was modified concurrently, please retry")
+			return
+		}
+		internalError(w, r, "Failed to delete product", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Product permanently deleted")
+}
+
+// includeDeleted は includeDeleted クエリパラメータが true で、かつリクエストしたユーザーが管理者かどうかを返す
+// 商品の参照は認証が任意のため、未認証や一般ユーザーの includeDeleted=true は無視する
+func (h *ProductHandler) includeDeleted(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get("includeDeleted"))
+	return v && h.roles.HasRole(middleware.GetUserID(r.Context()), middleware.RoleAdmin)
+}
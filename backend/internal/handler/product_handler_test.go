@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+)
+
+// fakeProductService は受け取った includeDeleted を記録する ProductService（テスト用）
+// 使わないメソッドは埋め込んだ nil のインターフェースに委ねる（呼ぶと panic する）
+type fakeProductService struct {
+	ProductService
+	includeDeleted bool
+}
+
+func (s *fakeProductService) List(ctx context.Context, category string, includeDeleted bool) ([]*domain.Product, error) {
+	s.includeDeleted = includeDeleted
+	return []*domain.Product{}, nil
+}
+
+func (s *fakeProductService) GetByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error) {
+	s.includeDeleted = includeDeleted
+	return &domain.Product{ID: id}, nil
+}
+
+func (s *fakeProductService) Restore(ctx context.Context, id string) (*domain.Product, error) {
+	return &domain.Product{ID: id}, nil
+}
+
+// newProductTestRouter は商品のハンドラーだけを持つルーターを返す（admin が管理者）
+func newProductTestRouter(t *testing.T) (http.Handler, *fakeProductService, *middleware.JWTAuth) {
+	t.Helper()
+	jwtAuth := middleware.NewJWTAuth("test-secret", time.Minute, time.Hour, nil, false)
+	roles := middleware.NewRoles([]string{"admin"})
+	svc := &fakeProductService{}
+	router := NewRouter(jwtAuth, roles, middleware.NewCORS(nil, nil, nil), nil, NewProductHandler(svc, roles), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	return router.Setup(), svc, jwtAuth
+}
+
+func bearer(t *testing.T, jwtAuth *middleware.JWTAuth, userID string) string {
+	t.Helper()
+	token, err := jwtAuth.GenerateToken(userID, userID+"@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	return "Bearer " + token
+}
+
+func TestProductHandlerIncludeDeletedAdminOnly(t *testing.T) {
+	h, svc, jwtAuth := newProductTestRouter(t)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          bool
+	}{
+		{name: "未認証では無視する", path: "/api/v1/products?includeDeleted=true", want: false},
+		{name: "一般ユーザーでは無視する", path: "/api/v1/products?includeDeleted=true", authorization: bearer(t, jwtAuth, "u1"), want: false},
+		{name: "不正なトークンは未認証として扱う", path: "/api/v1/products?includeDeleted=true", authorization: "Bearer invalid", want: false},
+		{name: "管理者は一覧で削除済みを含められる", path: "/api/v1/products?includeDeleted=true", authorization: bearer(t, jwtAuth, "admin"), want: true},
+		{name: "管理者でも指定しなければ含めない", path: "/api/v1/products", authorization: bearer(t, jwtAuth, "admin"), want: false},
+		{name: "詳細も一般ユーザーでは無視する", path: "/api/v1/products/p1?includeDeleted=true", authorization: bearer(t, jwtAuth, "u1"), want: false},
+		{name: "管理者は詳細で削除済みを参照できる", path: "/api/v1/products/p1?includeDeleted=true", authorization: bearer(t, jwtAuth, "admin"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.includeDeleted = !tt.want
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+			}
+			if svc.includeDeleted != tt.want {
+				t.Errorf("includeDeleted = %v, want %v", svc.includeDeleted, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductHandlerRestoreRequiresAdmin(t *testing.T) {
+	h, _, jwtAuth := newProductTestRouter(t)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "未認証", want: http.StatusUnauthorized},
+		{name: "一般ユーザー", authorization: bearer(t, jwtAuth, "u1"), want: http.StatusForbidden},
+		{name: "管理者", authorization: bearer(t, jwtAuth, "admin"), want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/products/p1/restore", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
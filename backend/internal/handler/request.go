@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/httputil"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// decodeJSON はリクエストボディをデコードし、失敗した場合はエラーレスポンスを書き込んで false を返す
+//   - ボディが上限超過 → 413
+//   - 空・不正なJSON・未知のフィールド → 400
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := httputil.DecodeJSON(w, r, dst, httputil.DefaultMaxBodyBytes)
+	if err == nil {
+		return true
+	}
+	writeDecodeError(w, err)
+	return false
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, httputil.ErrBodyTooLarge):
+		response.Error(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+	case errors.Is(err, httputil.ErrUnknownField):
+		response.Error(w, http.StatusBadRequest, "Request body contains unknown field")
+	default:
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+	}
+}
+
+// internalError は原因のエラーをリクエストIDと一緒にログに出力してから、500 と汎用のメッセージを返す
+// 原因（DynamoDB のエラーなど）はクライアントに返さない
+func internalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	middleware.LogError(r.Context(), message, err)
+	response.Error(w, http.StatusInternalServerError, message)
+}
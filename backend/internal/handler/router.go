@@ -4,12 +4,13 @@ import (
 	"net/http"
 
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
-	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 type Router struct {
 	mux                 *http.ServeMux
 	jwtAuth             *middleware.JWTAuth
+	roles               *middleware.Roles
+	cors                *middleware.CORS
 	authHandler         *AuthHandler
 	productHandler      *ProductHandler
 	cartHandler         *CartHandler
@@ -17,10 +18,23 @@ type Router struct {
 	priceHistoryHandler *PriceHistoryHandler
 	inventoryHandler    *InventoryHandler
 	activityHandler     *ActivityHandler
+	couponHandler       *CouponHandler
+	healthHandler       *HealthHandler
+	exportHandler       *ExportHandler
+	categoryHandler     *CategoryHandler
+
+	// メトリクス（EnableMetrics を呼んだ場合のみ）
+	requestObserver middleware.RequestObserver
+	metricsHandler  http.Handler
+
+	// 在庫の取り置き（EnableStockHolds を呼んだ場合のみ）
+	holdHandler *HoldHandler
 }
 
 func NewRouter(
 	jwtAuth *middleware.JWTAuth,
+	roles *middleware.Roles,
+	cors *middleware.CORS,
 	authHandler *AuthHandler,
 	productHandler *ProductHandler,
 	cartHandler *CartHandler,
@@ -28,10 +42,16 @@ func NewRouter(
 	priceHistoryHandler *PriceHistoryHandler,
 	inventoryHandler *InventoryHandler,
 	activityHandler *ActivityHandler,
+	couponHandler *CouponHandler,
+	healthHandler *HealthHandler,
+	exportHandler *ExportHandler,
+	categoryHandler *CategoryHandler,
 ) *Router {
 	return &Router{
 		mux:                 http.NewServeMux(),
 		jwtAuth:             jwtAuth,
+		roles:               roles,
+		cors:                cors,
 		authHandler:         authHandler,
 		productHandler:      productHandler,
 		cartHandler:         cartHandler,
@@ -39,59 +59,55 @@ func NewRouter(
 		priceHistoryHandler: priceHistoryHandler,
 		inventoryHandler:    inventoryHandler,
 		activityHandler:     activityHandler,
+		couponHandler:       couponHandler,
+		healthHandler:       healthHandler,
+		exportHandler:       exportHandler,
+		categoryHandler:     categoryHandler,
 	}
 }
 
-func (r *Router) Setup() http.Handler {
-	// Health check
-	r.mux.HandleFunc("GET /health", func(w http.ResponseWriter, req *http.Request) {
-		response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
-	})
-
-	// Auth routes (public)
-	r.mux.HandleFunc("POST /api/v1/auth/register", r.authHandler.Register)
-	r.mux.HandleFunc("POST /api/v1/auth/login", r.authHandler.Login)
-
-	// Auth routes (protected)
-	r.mux.Handle("GET /api/v1/auth/profile", r.jwtAuth.Middleware(http.HandlerFunc(r.authHandler.GetProfile)))
-
-	// Product routes (public)
-	r.mux.HandleFunc("GET /api/v1/products", r.productHandler.List)
-	r.mux.HandleFunc("GET /api/v1/products/{id}", r.productHandler.GetByID)
-
-	// Product routes (protected - admin only in real app)
-	r.mux.Handle("POST /api/v1/products", r.jwtAuth.Middleware(http.HandlerFunc(r.productHandler.Create)))
-	r.mux.Handle("PUT /api/v1/products/{id}", r.jwtAuth.Middleware(http.HandlerFunc(r.productHandler.Update)))
-	r.mux.Handle("DELETE /api/v1/products/{id}", r.jwtAuth.Middleware(http.HandlerFunc(r.productHandler.Delete)))
-
-	// Cart routes (protected)
-	r.mux.Handle("GET /api/v1/cart", r.jwtAuth.Middleware(http.HandlerFunc(r.cartHandler.GetCart)))
-	r.mux.Handle("POST /api/v1/cart/items", r.jwtAuth.Middleware(http.HandlerFunc(r.cartHandler.AddItem)))
-	r.mux.Handle("PUT /api/v1/cart/items/{productId}", r.jwtAuth.Middleware(http.HandlerFunc(r.cartHandler.UpdateQuantity)))
-	r.mux.Handle("DELETE /api/v1/cart/items/{productId}", r.jwtAuth.Middleware(http.HandlerFunc(r.cartHandler.RemoveItem)))
-
-	// Order routes (protected)
-	r.mux.Handle("POST /api/v1/orders", r.jwtAuth.Middleware(http.HandlerFunc(r.orderHandler.CreateOrder)))
-	r.mux.Handle("GET /api/v1/orders", r.jwtAuth.Middleware(http.HandlerFunc(r.orderHandler.GetOrders)))
-	r.mux.Handle("GET /api/v1/orders/{id}", r.jwtAuth.Middleware(http.HandlerFunc(r.orderHandler.GetOrderByID)))
+// EnableMetrics は GET /metrics でメトリクスを公開し、リクエストごとに observe を呼び出す
+// Setup より前に呼ぶこと
+func (r *Router) EnableMetrics(observe middleware.RequestObserver, metricsHandler http.Handler) {
+	r.requestObserver = observe
+	r.metricsHandler = metricsHandler
+}
 
-	// Price history routes (public for viewing, protected for updating)
-	r.mux.HandleFunc("GET /api/v1/products/{id}/price-history", r.priceHistoryHandler.GetHistory)
-	r.mux.Handle("PUT /api/v1/products/{id}/price", r.jwtAuth.Middleware(http.HandlerFunc(r.priceHistoryHandler.UpdatePrice)))
+// EnableStockHolds はカート内の商品の在庫の取り置き API を有効にする
+// Setup より前に呼ぶこと
+func (r *Router) EnableStockHolds(holdHandler *HoldHandler) {
+	r.holdHandler = holdHandler
+}
 
-	// Inventory routes (protected - admin only in real app)
-	r.mux.Handle("PUT /api/v1/products/{id}/stock", r.jwtAuth.Middleware(http.HandlerFunc(r.inventoryHandler.AdjustStock)))
-	r.mux.Handle("GET /api/v1/products/{id}/inventory-logs", r.jwtAuth.Middleware(http.HandlerFunc(r.inventoryHandler.GetLogs)))
-	r.mux.Handle("GET /api/v1/admin/inventory-logs", r.jwtAuth.Middleware(http.HandlerFunc(r.inventoryHandler.GetAllLogs)))
+func (r *Router) Setup() http.Handler {
+	// API ルート（一覧は routes.go）
+	// ロールの確認は認証済みのユーザーIDを使うため、JWT 認証の内側に付ける
+	for _, route := range r.Routes() {
+		var h http.Handler = route.Handler
+		if route.RequiredRole != "" {
+			h = r.roles.Require(route.RequiredRole)(h)
+		}
+		if route.Protected || route.RequiredRole != "" {
+			h = r.jwtAuth.Middleware(h)
+		} else if route.OptionalAuth {
+			h = r.jwtAuth.OptionalMiddleware(h)
+		}
+		r.mux.Handle(route.Method+" "+route.Pattern, h)
+	}
 
-	// Activity routes (protected)
-	r.mux.Handle("POST /api/v1/activity", r.jwtAuth.Middleware(http.HandlerFunc(r.activityHandler.LogActivity)))
-	r.mux.Handle("POST /api/v1/activity/batch", r.jwtAuth.Middleware(http.HandlerFunc(r.activityHandler.BatchLogActivities)))
-	r.mux.Handle("GET /api/v1/activity", r.jwtAuth.Middleware(http.HandlerFunc(r.activityHandler.GetMyActivities)))
-	r.mux.Handle("GET /api/v1/admin/users/{userId}/activities", r.jwtAuth.Middleware(http.HandlerFunc(r.activityHandler.GetUserActivities)))
+	// Metrics（METRICS_ENABLED=true の場合のみ。認証なしのため外部に公開しないこと）
+	if r.metricsHandler != nil {
+		r.mux.Handle("GET /metrics", r.metricsHandler)
+	}
 
 	// Apply middleware
-	handler := middleware.Logging(middleware.CORS(r.mux))
+	// RequestID を最外にして、アクセスログにもリクエストIDが出るようにする
+	// Metrics は r.Pattern（一致したルート）を読むため ServeMux を直接ラップする
+	var mux http.Handler = r.mux
+	if r.requestObserver != nil {
+		mux = middleware.Metrics(r.requestObserver)(mux)
+	}
+	handler := middleware.RequestID(middleware.Logging(r.cors.Middleware(mux)))
 
 	return handler
 }
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// Route は1つの API ルート
+// Router.Setup はこの一覧からルートを登録し、フラグに応じてミドルウェアを付ける
+// OpenAPI の生成（cmd/openapi）も同じ一覧を読む
+// → ルートを追加するときは Routes に書くだけで、ルーターと仕様書の両方に反映される
+type Route struct {
+	Method       string           // HTTP メソッド
+	Pattern      string           // ServeMux のパスパターン（例: /api/v1/products/{id}）
+	Handler      http.HandlerFunc // 処理するハンドラー
+	Protected    bool             // true の場合は JWT 認証が必要
+	RequiredRole string           // 必要なロール（middleware.RoleAdmin など）。指定した場合は Protected でなくても認証が必要
+	OptionalAuth bool             // true の場合はトークンがあれば検証してユーザーIDを設定する（なくても通す）
+
+	// 以下は OpenAPI の生成用（ルーティングには使わない）
+	Summary  string // ルートの説明
+	Request  any    // リクエストボディの型のゼロ値（ボディがない場合は nil）
+	Response any    // 成功時のレスポンスの型のゼロ値（JSON 以外を返す場合は nil）
+	Status   int    // 成功時のステータス（0 の場合は 200）
+}
+
+// Routes は登録する API ルートの一覧を返す
+// 任意の機能（在庫の取り置きなど）のルートは、有効な場合のみ含める
+// GET /metrics は JSON の API ではないため含めない（Setup で個別に登録する）
+func (r *Router) Routes() []Route {
+	routes := []Route{
+		// Health check
+		// /healthz: liveness（プロセスの生存確認のみ）, /health: readiness（DynamoDBへの疎通確認）
+		// /version: ビルド情報, /health/detailed: 障害調査用の依存先ごとの状態（管理者のみ）
+		{Method: "GET", Pattern: "/healthz", Handler: r.healthHandler.Liveness, Summary: "生存確認", Response: map[string]string{}},
+		{Method: "GET", Pattern: "/health", Handler: r.healthHandler.Readiness, Summary: "DynamoDB への疎通確認", Response: map[string]string{}},
+		{Method: "GET", Pattern: "/version", Handler: r.healthHandler.Version, Summary: "ビルド情報（バージョン・コミット・ビルド日時）", Response: BuildInfo{}},
+		{Method: "GET", Pattern: "/health/detailed", Handler: r.healthHandler.Detailed, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "依存先ごとの状態と所要時間", Response: DetailedHealthResponse{}},
+
+		// Auth routes (public)
+		{Method: "POST", Pattern: "/api/v1/auth/register", Handler: r.authHandler.Register, Summary: "会員登録", Request: domain.RegisterRequest{}, Response: domain.AuthResponse{}, Status: http.StatusCreated},
+		{Method: "POST", Pattern: "/api/v1/auth/login", Handler: r.authHandler.Login, Summary: "ログイン", Request: domain.LoginRequest{}, Response: domain.AuthResponse{}},
+		{Method: "POST", Pattern: "/api/v1/auth/refresh", Handler: r.authHandler.Refresh, Summary: "トークン再発行", Request: domain.RefreshRequest{}, Response: domain.TokenResponse{}},
+		{Method: "POST", Pattern: "/api/v1/auth/forgot-password", Handler: r.authHandler.ForgotPassword, Summary: "パスワード再設定の申請", Request: domain.ForgotPasswordRequest{}, Response: response.SuccessResponse{}},
+		{Method: "POST", Pattern: "/api/v1/auth/reset-password", Handler: r.authHandler.ResetPassword, Summary: "パスワード再設定", Request: domain.ResetPasswordRequest{}, Response: response.SuccessResponse{}},
+
+		// Auth routes (protected)
+		{Method: "POST", Pattern: "/api/v1/auth/logout", Handler: r.authHandler.Logout, Protected: true, Summary: "ログアウト（トークン失効）", Request: domain.RefreshRequest{}, Response: response.SuccessResponse{}},
+		{Method: "GET", Pattern: "/api/v1/auth/profile", Handler: r.authHandler.GetProfile, Protected: true, Summary: "ログイン中のユーザー情報", Response: domain.User{}},
+		{Method: "PUT", Pattern: "/api/v1/auth/profile", Handler: r.authHandler.UpdateProfile, Protected: true, Summary: "プロフィール更新", Request: domain.UpdateProfileRequest{}, Response: domain.User{}},
+		{Method: "DELETE", Pattern: "/api/v1/auth/profile", Handler: r.authHandler.DeleteAccount, Protected: true, Summary: "退会", Request: domain.DeleteAccountRequest{}, Response: response.SuccessResponse{}},
+
+		// User data (protected)
+		{Method: "GET", Pattern: "/api/v1/users/me/export", Handler: r.exportHandler.ExportMyData, Protected: true, Summary: "自分のデータのエクスポート", Response: domain.UserDataExport{}},
+		{Method: "GET", Pattern: "/api/v1/users/me/recently-viewed", Handler: r.activityHandler.GetRecentlyViewed, Protected: true, Summary: "最近閲覧した商品", Response: []domain.Product{}},
+
+		// Product routes (public)
+		{Method: "GET", Pattern: "/api/v1/products", Handler: r.productHandler.List, OptionalAuth: true, Summary: "商品一覧（fields=card の場合はカード表示用の項目のみ）", Response: []domain.Product{}},
+		{Method: "GET", Pattern: "/api/v1/products/bestsellers", Handler: r.productHandler.ListBestsellers, Summary: "売れ筋商品（販売数順）", Response: []domain.Bestseller{}},
+		{Method: "GET", Pattern: "/api/v1/products/facets", Handler: r.productHandler.GetFacets, Summary: "カテゴリ別の商品数", Response: domain.ProductFacets{}},
+		{Method: "GET", Pattern: "/api/v1/products/{id}", Handler: r.productHandler.GetByID, OptionalAuth: true, Summary: "商品詳細", Response: domain.Product{}},
+		{Method: "GET", Pattern: "/api/v1/products/{id}/availability", Handler: r.productHandler.CheckAvailability, Summary: "指定した数量を購入できるか", Response: domain.ProductAvailability{}},
+		{Method: "GET", Pattern: "/api/v1/products/{id}/related", Handler: r.productHandler.ListRelated, Summary: "一緒に購入されている商品", Response: domain.RelatedProductPage{}},
+		{Method: "GET", Pattern: "/api/v1/categories", Handler: r.categoryHandler.List, Summary: "カテゴリ一覧", Response: []domain.Category{}},
+
+		// Product routes (admin only)
+		{Method: "POST", Pattern: "/api/v1/products", Handler: r.productHandler.Create, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品登録", Request: domain.CreateProductRequest{}, Response: domain.Product{}, Status: http.StatusCreated},
+		{Method: "PUT", Pattern: "/api/v1/products/{id}", Handler: r.productHandler.Update, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品更新", Request: domain.UpdateProductRequest{}, Response: domain.Product{}},
+		{Method: "DELETE", Pattern: "/api/v1/products/{id}", Handler: r.productHandler.Delete, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品削除（論理削除）", Response: response.SuccessResponse{}},
+		{Method: "POST", Pattern: "/api/v1/products/{id}/restore", Handler: r.productHandler.Restore, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "論理削除した商品の復元", Response: domain.Product{}},
+		{Method: "DELETE", Pattern: "/api/v1/admin/products/{id}", Handler: r.productHandler.HardDelete, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品の物理削除", Response: response.SuccessResponse{}},
+		{Method: "GET", Pattern: "/api/v1/admin/products", Handler: r.productHandler.ListByCreatedRange, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "登録日時の範囲で商品一覧", Response: domain.ProductPage{}},
+		{Method: "GET", Pattern: "/api/v1/admin/products/low-stock", Handler: r.productHandler.ListLowStock, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "在庫が少ない商品（ページ内で在庫の少ない順）", Response: domain.ProductPage{}},
+		{Method: "POST", Pattern: "/api/v1/admin/products/import", Handler: r.productHandler.Import, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品の一括登録", Request: []domain.CreateProductRequest{}, Response: domain.ProductImportResponse{}},
+
+		// Cart routes (protected)
+		{Method: "GET", Pattern: "/api/v1/cart", Handler: r.cartHandler.GetCart, Protected: true, Summary: "カート取得", Response: domain.Cart{}},
+		{Method: "DELETE", Pattern: "/api/v1/cart", Handler: r.cartHandler.ClearCart, Protected: true, Summary: "カートを空にする", Response: response.SuccessResponse{}},
+		{Method: "GET", Pattern: "/api/v1/cart/count", Handler: r.cartHandler.GetItemCount, Protected: true, Summary: "カート内のアイテム数", Response: domain.CartCount{}},
+		{Method: "POST", Pattern: "/api/v1/cart/items", Handler: r.cartHandler.AddItem, Protected: true, Summary: "カートに追加", Request: domain.AddToCartRequest{}, Response: domain.CartItem{}, Status: http.StatusCreated},
+		{Method: "PUT", Pattern: "/api/v1/cart/items/{productId}", Handler: r.cartHandler.UpdateQuantity, Protected: true, Summary: "カート内の数量変更", Request: domain.UpdateCartRequest{}, Response: domain.CartItem{}},
+		{Method: "DELETE", Pattern: "/api/v1/cart/items/{productId}", Handler: r.cartHandler.RemoveItem, Protected: true, Summary: "カートから削除", Response: response.SuccessResponse{}},
+		{Method: "GET", Pattern: "/api/v1/admin/abandoned-carts", Handler: r.cartHandler.ListAbandonedCarts, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "放置カート一覧", Response: domain.AbandonedCartPage{}},
+
+		// Order routes (protected)
+		{Method: "POST", Pattern: "/api/v1/orders", Handler: r.orderHandler.CreateOrder, Protected: true, Summary: "注文確定", Request: domain.CreateOrderRequest{}, Response: domain.Order{}, Status: http.StatusCreated},
+		{Method: "GET", Pattern: "/api/v1/orders", Handler: r.orderHandler.GetOrders, Protected: true, Summary: "注文履歴", Response: domain.OrderPage{}},
+		{Method: "GET", Pattern: "/api/v1/orders/summary", Handler: r.orderHandler.GetSummary, Protected: true, Summary: "注文の集計", Response: domain.OrderSummary{}},
+		{Method: "GET", Pattern: "/api/v1/orders/{id}", Handler: r.orderHandler.GetOrderByID, Protected: true, Summary: "注文詳細", Response: domain.Order{}},
+		{Method: "GET", Pattern: "/api/v1/orders/{id}/invoice", Handler: r.orderHandler.GetInvoice, Protected: true, Summary: "注文の請求書", Response: domain.Invoice{}},
+		{Method: "GET", Pattern: "/api/v1/admin/orders", Handler: r.orderHandler.ListByMonth, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "月別の注文一覧", Response: domain.OrderPage{}},
+		{Method: "GET", Pattern: "/api/v1/admin/orders/by-status", Handler: r.orderHandler.ListByStatus, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "ステータス別の注文一覧", Response: domain.OrderPage{}},
+		{Method: "GET", Pattern: "/api/v1/admin/orders/export", Handler: r.orderHandler.ExportCSV, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "月別の注文の CSV エクスポート"},
+		{Method: "POST", Pattern: "/api/v1/admin/orders/{id}/mark-paid", Handler: r.orderHandler.MarkPaid, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "入金済みにする", Request: domain.MarkPaidRequest{}, Response: domain.Order{}},
+		{Method: "PUT", Pattern: "/api/v1/admin/orders/{id}/status", Handler: r.orderHandler.UpdateStatus, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "注文ステータスの変更", Request: domain.UpdateOrderStatusRequest{}, Response: domain.Order{}},
+
+		// Price history routes (public for viewing, admin only for updating)
+		{Method: "GET", Pattern: "/api/v1/products/{id}/price-history", Handler: r.priceHistoryHandler.GetHistory, Summary: "価格履歴（変化率・統計付き）", Response: domain.PriceHistoryResponse{}},
+		{Method: "PUT", Pattern: "/api/v1/products/{id}/price", Handler: r.priceHistoryHandler.UpdatePrice, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "価格変更", Request: UpdatePriceRequest{}, Response: response.DataResponse{}},
+		{Method: "POST", Pattern: "/api/v1/admin/products/{id}/schedule-price", Handler: r.priceHistoryHandler.SchedulePrice, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "価格変更の予約", Request: SchedulePriceRequest{}, Response: domain.ScheduledPrice{}, Status: http.StatusCreated},
+		{Method: "GET", Pattern: "/api/v1/admin/products/{id}/scheduled-prices", Handler: r.priceHistoryHandler.ListScheduledPrices, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "価格変更の予約一覧", Response: []domain.ScheduledPrice{}},
+		{Method: "DELETE", Pattern: "/api/v1/admin/products/{id}/scheduled-prices/{effectiveAt}", Handler: r.priceHistoryHandler.CancelScheduledPrice, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "価格変更の予約の取り消し", Response: response.SuccessResponse{}},
+		{Method: "POST", Pattern: "/api/v1/admin/apply-scheduled-prices", Handler: r.priceHistoryHandler.ApplyScheduledPrices, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "予約価格の手動適用", Response: ApplyScheduledPricesResponse{}},
+
+		// Inventory routes (admin only)
+		{Method: "PUT", Pattern: "/api/v1/products/{id}/stock", Handler: r.inventoryHandler.AdjustStock, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "在庫調整", Request: AdjustStockRequest{}, Response: response.DataResponse{}},
+		{Method: "GET", Pattern: "/api/v1/products/{id}/inventory-logs", Handler: r.inventoryHandler.GetLogs, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "商品の在庫変動ログ", Response: []domain.InventoryLog{}},
+		{Method: "GET", Pattern: "/api/v1/products/{id}/inventory-summary", Handler: r.inventoryHandler.GetSummary, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "期間内の在庫変動の集計", Response: domain.InventorySummary{}},
+		{Method: "GET", Pattern: "/api/v1/admin/inventory-logs", Handler: r.inventoryHandler.GetAllLogs, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "全商品の在庫変動ログ", Response: []domain.InventoryLog{}},
+
+		// Activity routes (protected)
+		{Method: "POST", Pattern: "/api/v1/activity", Handler: r.activityHandler.LogActivity, Protected: true, Summary: "行動ログの記録", Request: domain.LogActivityRequest{}, Response: response.SuccessResponse{}, Status: http.StatusCreated},
+		{Method: "POST", Pattern: "/api/v1/activity/batch", Handler: r.activityHandler.BatchLogActivities, Protected: true, Summary: "行動ログの一括記録", Request: []domain.LogActivityRequest{}, Response: response.SuccessResponse{}, Status: http.StatusCreated},
+		{Method: "GET", Pattern: "/api/v1/activity", Handler: r.activityHandler.GetMyActivities, Protected: true, Summary: "自分の行動ログ", Response: []domain.UserActivity{}},
+		{Method: "GET", Pattern: "/api/v1/admin/users/{userId}/activities", Handler: r.activityHandler.GetUserActivities, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "ユーザーの行動ログ", Response: []domain.UserActivity{}},
+
+		// Coupon routes (protected)
+		{Method: "GET", Pattern: "/api/v1/coupons/{code}/validate", Handler: r.couponHandler.Validate, Protected: true, Summary: "クーポンの確認", Response: domain.CouponValidation{}},
+		{Method: "GET", Pattern: "/api/v1/admin/coupons", Handler: r.couponHandler.List, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "クーポン一覧", Response: []domain.Coupon{}},
+		{Method: "POST", Pattern: "/api/v1/admin/coupons", Handler: r.couponHandler.Create, Protected: true, RequiredRole: middleware.RoleAdmin, Summary: "クーポン作成", Request: domain.CreateCouponRequest{}, Response: domain.Coupon{}, Status: http.StatusCreated},
+	}
+
+	// 在庫の取り置き（EnableStockHolds を呼んだ場合のみ）
+	if r.holdHandler != nil {
+		routes = append(routes,
+			Route{Method: "POST", Pattern: "/api/v1/cart/items/{productId}/reserve", Handler: r.holdHandler.Reserve, Protected: true, Summary: "カート内の商品の在庫の取り置き", Response: domain.StockHold{}},
+			Route{Method: "DELETE", Pattern: "/api/v1/cart/items/{productId}/reserve", Handler: r.holdHandler.Release, Protected: true, Summary: "取り置きの解除", Response: response.SuccessResponse{}},
+		)
+	}
+
+	return routes
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+)
+
+// handlerFuncType は http.HandlerFunc として登録できるメソッドのシグネチャ
+var handlerFuncType = reflect.TypeOf(func(http.ResponseWriter, *http.Request) {})
+
+// TestRoutesReferenceEveryHandlerMethod はハンドラーのメソッドがすべてルートに登録されていることを確認する
+// （ハンドラーを追加したのに Routes に書き忘れる、という事故を防ぐ）
+func TestRoutesReferenceEveryHandlerMethod(t *testing.T) {
+	r := NewRouter(nil, nil, nil, &AuthHandler{}, &ProductHandler{}, &CartHandler{}, &OrderHandler{}, &PriceHistoryHandler{},
+		&InventoryHandler{}, &ActivityHandler{}, &CouponHandler{}, &HealthHandler{}, &ExportHandler{}, &CategoryHandler{})
+	r.EnableStockHolds(&HoldHandler{})
+
+	// メソッド値の関数名は "<pkg>.(*ProductHandler).Create-fm" の形式になる
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		name := runtime.FuncForPC(reflect.ValueOf(route.Handler).Pointer()).Name()
+		registered[strings.TrimSuffix(name[strings.LastIndex(name, "(*"):], "-fm")] = true
+	}
+
+	handlers := []any{
+		&AuthHandler{}, &ProductHandler{}, &CartHandler{}, &OrderHandler{}, &PriceHistoryHandler{}, &InventoryHandler{},
+		&ActivityHandler{}, &CouponHandler{}, &HealthHandler{}, &ExportHandler{}, &CategoryHandler{}, &HoldHandler{},
+	}
+	for _, h := range handlers {
+		typ := reflect.TypeOf(h)
+		for i := 0; i < typ.NumMethod(); i++ {
+			method := typ.Method(i)
+			// レシーバーを除いたシグネチャがハンドラーのものだけを対象にする
+			if !reflect.ValueOf(h).Method(i).Type().ConvertibleTo(handlerFuncType) {
+				continue
+			}
+			name := "(*" + typ.Elem().Name() + ")." + method.Name
+			if !registered[name] {
+				t.Errorf("%s is not referenced by any route", name)
+			}
+		}
+	}
+}
+
+// TestAdminRoutesRequireAdmin は商品・価格・在庫を変更・参照する管理用のルートが管理者に限られていることを確認する
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	jwtAuth := middleware.NewJWTAuth("test-secret", time.Minute, time.Hour, nil, false)
+	roles := middleware.NewRoles([]string{"admin"})
+	h := NewRouter(jwtAuth, roles, middleware.NewCORS(nil, nil, nil), nil, NewProductHandler(&fakeProductService{}, roles),
+		nil, nil, nil, nil, nil, nil, nil, nil, nil).Setup()
+
+	routes := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/api/v1/products"},
+		{http.MethodPut, "/api/v1/products/p1"},
+		{http.MethodDelete, "/api/v1/products/p1"},
+		{http.MethodPut, "/api/v1/products/p1/price"},
+		{http.MethodPut, "/api/v1/products/p1/stock"},
+		{http.MethodGet, "/api/v1/products/p1/inventory-logs"},
+		{http.MethodGet, "/api/v1/products/p1/inventory-summary"},
+	}
+	users := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "未認証", want: http.StatusUnauthorized},
+		{name: "一般ユーザー", authorization: bearer(t, jwtAuth, "u1"), want: http.StatusForbidden},
+	}
+
+	for _, route := range routes {
+		for _, user := range users {
+			t.Run(route.method+" "+route.path+"/"+user.name, func(t *testing.T) {
+				req := httptest.NewRequest(route.method, route.path, nil)
+				if user.authorization != "" {
+					req.Header.Set("Authorization", user.authorization)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+
+				if rec.Code != user.want {
+					t.Errorf("status = %d, want %d (body: %s)", rec.Code, user.want, rec.Body.String())
+				}
+			})
+		}
+	}
+}
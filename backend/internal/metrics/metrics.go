@@ -0,0 +1,58 @@
+// backend/internal/metrics/metrics.go
+// Prometheus メトリクスの定義
+//
+// 【メトリクス】
+//   http_requests_total{route, status}                  - リクエスト数
+//   http_request_duration_seconds{route, status}        - レイテンシ（ヒストグラム）
+//   dynamodb_errors_total{repository, operation, code}  - DynamoDB 操作のエラー数
+//
+// route は ServeMux のパターン（例: "GET /api/v1/products/{id}"）
+// → パスの ID ごとに系列が増えないよう、実際のパスではなくパターンで集計する
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Metrics struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	dynamoDBErrors  *prometheus.CounterVec
+}
+
+// New はメトリクスを生成し、reg に登録する
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by route and status.",
+		}, []string{"route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "status"}),
+		dynamoDBErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dynamodb_errors_total",
+			Help: "Number of failed DynamoDB operations by repository, operation and error code.",
+		}, []string{"repository", "operation", "code"}),
+	}
+	reg.MustRegister(m.requests, m.requestDuration, m.dynamoDBErrors)
+	return m
+}
+
+// ObserveRequest はリクエスト1件の結果を記録する（middleware.RequestObserver）
+func (m *Metrics) ObserveRequest(route string, status int, latency time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, code).Inc()
+	m.requestDuration.WithLabelValues(route, code).Observe(latency.Seconds())
+}
+
+// ObserveDynamoDBError は DynamoDB 操作のエラーを記録する（repository.ErrorObserver）
+func (m *Metrics) ObserveDynamoDBError(repository, operation, code string) {
+	m.dynamoDBErrors.WithLabelValues(repository, operation, code).Inc()
+}
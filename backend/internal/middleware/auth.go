@@ -2,42 +2,92 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey contextKey = "userID"
+	ClaimsKey contextKey = "claims"
+)
+
+// トークン種別（token_type クレーム）
+// リフレッシュトークンを API アクセスに、アクセストークンをリフレッシュに使い回せないよう区別する
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidTokenType = errors.New("invalid token type")
+	ErrTokenRevoked     = errors.New("token has been revoked")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenInvalid     = errors.New("token is invalid")
+)
+
+// 認証エラーのコード（レスポンスの code フィールド）
+// クライアントは token_expired の場合のみ /auth/refresh を試み、それ以外は再ログインする
+const (
+	ErrorCodeTokenMissing = "token_missing"
+	ErrorCodeTokenExpired = "token_expired"
+	ErrorCodeTokenInvalid = "token_invalid"
+	ErrorCodeTokenRevoked = "token_revoked"
+)
+
+// TokenDenylist は失効済みトークン（ログアウト済み・パスワード再設定前に発行済み）を管理するストア
+// 実装は repository.TokenRevocationRepository（DynamoDB）
+type TokenDenylist interface {
+	Revoke(ctx context.Context, jti, userID string, expiresAt time.Time) error
+	// RevokeOnce はまだ失効していない場合に限り失効させ、失効させたかを返す（既に失効済みの場合は false）
+	RevokeOnce(ctx context.Context, jti, userID string, expiresAt time.Time) (bool, error)
+	// RevokeUser はユーザーが before 以前に発行したトークンをすべて失効させる（until は TTL）
+	RevokeUser(ctx context.Context, userID string, before, until time.Time) error
+	// IsRevoked は jti 単位、またはユーザー単位（issuedAt が失効時刻以前）で失効済みかを返す
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+}
 
 type JWTAuth struct {
-	secret []byte
-	expiry time.Duration
+	secret        []byte
+	expiry        time.Duration
+	refreshExpiry time.Duration
+	denylist      TokenDenylist
+	checkDenylist bool // false の場合は失効リストを参照しない（DynamoDBなしのテスト用）
 }
 
 type Claims struct {
-	UserID string `json:"userId"`
-	Email  string `json:"email"`
+	UserID    string `json:"userId"`
+	Email     string `json:"email,omitempty"`
+	TokenType string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTAuth(secret string, expiry time.Duration) *JWTAuth {
+func NewJWTAuth(secret string, expiry, refreshExpiry time.Duration, denylist TokenDenylist, checkDenylist bool) *JWTAuth {
 	return &JWTAuth{
-		secret: []byte(secret),
-		expiry: expiry,
+		secret:        []byte(secret),
+		expiry:        expiry,
+		refreshExpiry: refreshExpiry,
+		denylist:      denylist,
+		checkDenylist: checkDenylist && denylist != nil,
 	}
 }
 
-// GenerateToken はユーザー情報からJWTトークンを生成する
+// GenerateToken はユーザー情報からJWTトークン（アクセストークン）を生成する
 func (j *JWTAuth) GenerateToken(userID, email string) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -47,21 +97,122 @@ func (j *JWTAuth) GenerateToken(userID, email string) (string, error) {
 	return token.SignedString(j.secret)
 }
 
+// GenerateRefreshToken はアクセストークン再発行用のリフレッシュトークンを生成する
+// 有効期限はアクセストークンより長い refreshExpiry を使う
+// 再発行するアクセストークンに引き継ぐため、メールアドレスも含める
+func (j *JWTAuth) GenerateRefreshToken(userID, email string) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secret)
+}
+
+// RefreshToken はリフレッシュトークンを検証し、新しいアクセストークンとリフレッシュトークンを返す
+// 【注意】アクセストークンが渡された場合は ErrInvalidTokenType で拒否する
+// 【リフレッシュトークンのローテーション】
+//
+//	使ったリフレッシュトークンは新しいトークンを発行する前に失効させ、同じトークンでの再発行を1回に限る
+//	（漏えいしたリフレッシュトークンを使い続けられないようにする。2回目以降は ErrTokenRevoked）
+//	失効は「まだ失効していない場合のみ」の条件付きで行うため、同じトークンで同時に再発行しても成功するのは1回だけ
+//	（ValidateToken の失効チェックは、同時に来た2つのリクエストの両方を通してしまう）
+//	メールアドレスはリフレッシュトークンから引き継ぐ（メールアドレスを含まない旧形式のトークンでは空になる）
+func (j *JWTAuth) RefreshToken(ctx context.Context, refreshToken string) (newAccess, newRefresh string, err error) {
+	claims, err := j.ValidateToken(ctx, refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return "", "", ErrInvalidTokenType
+	}
+	if j.denylist != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		revoked, err := j.denylist.RevokeOnce(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
+		if err != nil {
+			return "", "", err
+		}
+		if !revoked {
+			return "", "", ErrTokenRevoked
+		}
+	}
+
+	newAccess, err = j.GenerateToken(claims.UserID, claims.Email)
+	if err != nil {
+		return "", "", err
+	}
+	newRefresh, err = j.GenerateRefreshToken(claims.UserID, claims.Email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return newAccess, newRefresh, nil
+}
+
 // ValidateToken はトークンを検証してClaimsを返す
-func (j *JWTAuth) ValidateToken(tokenString string) (*Claims, error) {
+// 期限切れは ErrTokenExpired、署名・形式の不正は ErrTokenInvalid を返す
+// 失効リストのチェックが有効な場合、ログアウト済み・パスワード再設定前に発行されたトークンは ErrTokenRevoked を返す
+func (j *JWTAuth) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return j.secret, nil
 	})
 
+	// 期限切れ（リフレッシュで回復できる）とそれ以外（署名不正・形式不正など）を区別する
 	if err != nil {
-		return nil, err
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrTokenInvalid, err)
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
 	}
 
-	return nil, jwt.ErrSignatureInvalid
+	// jti を持たない旧形式のトークンはユーザー単位の失効だけをチェックする
+	if j.checkDenylist {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		revoked, err := j.denylist.IsRevoked(ctx, claims.ID, claims.UserID, issuedAt)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+// Revoke はトークンを失効リストに登録する
+// TTL はトークンの残り有効期間（exp）に合わせる
+func (j *JWTAuth) Revoke(ctx context.Context, claims *Claims) error {
+	if j.denylist == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return j.denylist.Revoke(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
+}
+
+// RevokeUser はユーザーの発行済みトークン（アクセストークン・リフレッシュトークン）をすべて失効させる
+// パスワード再設定後に、漏えいしたかもしれない古いトークンを使えなくするために使う
+// iat は秒単位のため、呼び出しと同じ秒に発行されたトークンも失効する
+// TTL は発行済みのトークンがすべて期限切れになる時刻（有効期間の長い方）に合わせる
+func (j *JWTAuth) RevokeUser(ctx context.Context, userID string) error {
+	if j.denylist == nil {
+		return nil
+	}
+	now := time.Now()
+	return j.denylist.RevokeUser(ctx, userID, now, now.Add(max(j.expiry, j.refreshExpiry)))
 }
 
 // Middleware は認証が必要なエンドポイント用のミドルウェア
@@ -69,27 +220,79 @@ func (j *JWTAuth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			response.Error(w, http.StatusUnauthorized, "Authorization header required")
+			response.ErrorWithCode(w, http.StatusUnauthorized, "Authorization header required", ErrorCodeTokenMissing)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			response.Error(w, http.StatusUnauthorized, "Invalid authorization header format")
+			response.ErrorWithCode(w, http.StatusUnauthorized, "Invalid authorization header format", ErrorCodeTokenInvalid)
 			return
 		}
 
-		claims, err := j.ValidateToken(parts[1])
+		claims, err := j.ValidateToken(r.Context(), parts[1])
 		if err != nil {
-			response.Error(w, http.StatusUnauthorized, "Invalid or expired token")
+			WriteTokenError(w, r, err)
+			return
+		}
+
+		// リフレッシュトークンでの API アクセスは拒否する
+		// （token_type を持たない旧形式のトークンはアクセストークンとして扱う）
+		if claims.TokenType == TokenTypeRefresh {
+			WriteTokenError(w, r, ErrInvalidTokenType)
 			return
 		}
 
+		setAccessLogUserID(r.Context(), claims.UserID)
+
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, ClaimsKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// OptionalMiddleware は認証が任意のエンドポイント用のミドルウェア
+// 有効なアクセストークンがあればユーザーIDをコンテキストに設定し、なければ（期限切れ・不正を含む）未認証のまま通す
+// 公開 API で管理者にだけ追加の情報を返す場合に使う
+func (j *JWTAuth) OptionalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := j.ValidateToken(r.Context(), parts[1])
+		if err != nil || claims.TokenType == TokenTypeRefresh {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setAccessLogUserID(r.Context(), claims.UserID)
+
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, ClaimsKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WriteTokenError はトークン検証エラーをレスポンスに書き込む
+// 認証エラーはいずれも 401 とし、code で期限切れ・不正・失効を区別する
+// 失効リストの参照失敗など、トークン自体の問題ではないエラーは 500 とし、原因をログに出力する
+func WriteTokenError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		response.ErrorWithCode(w, http.StatusUnauthorized, "Token has expired", ErrorCodeTokenExpired)
+	case errors.Is(err, ErrTokenRevoked):
+		response.ErrorWithCode(w, http.StatusUnauthorized, "Token has been revoked", ErrorCodeTokenRevoked)
+	case errors.Is(err, ErrTokenInvalid), errors.Is(err, ErrInvalidTokenType):
+		response.ErrorWithCode(w, http.StatusUnauthorized, "Invalid token", ErrorCodeTokenInvalid)
+	default:
+		LogError(r.Context(), "Failed to validate token", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to validate token")
+	}
+}
+
 // GetUserID はコンテキストからユーザーIDを取得する
 func GetUserID(ctx context.Context) string {
 	if userID, ok := ctx.Value(UserIDKey).(string); ok {
@@ -97,3 +300,11 @@ func GetUserID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// GetClaims はコンテキストから検証済みのClaimsを取得する
+func GetClaims(ctx context.Context) *Claims {
+	if claims, ok := ctx.Value(ClaimsKey).(*Claims); ok {
+		return claims
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// memoryDenylist はメモリ上の失効リスト（テスト用）
+type memoryDenylist struct {
+	mu          sync.Mutex
+	revoked     map[string]bool
+	userRevoked map[string]time.Time // userID → この時刻以前に発行されたトークンを失効
+}
+
+func (d *memoryDenylist) Revoke(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.revoked[jti] = true
+	return nil
+}
+
+func (d *memoryDenylist) RevokeOnce(ctx context.Context, jti, userID string, expiresAt time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.revoked[jti] {
+		return false, nil
+	}
+	d.revoked[jti] = true
+	return true, nil
+}
+
+func (d *memoryDenylist) RevokeUser(ctx context.Context, userID string, before, until time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.userRevoked == nil {
+		d.userRevoked = map[string]time.Time{}
+	}
+	d.userRevoked[userID] = before
+	return nil
+}
+
+func (d *memoryDenylist) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if before, ok := d.userRevoked[userID]; ok && issuedAt.Unix() <= before.Unix() {
+		return true, nil
+	}
+	return d.revoked[jti], nil
+}
+
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	j := NewJWTAuth("test-secret", time.Minute, time.Hour, &memoryDenylist{revoked: map[string]bool{}}, true)
+
+	access, err := j.GenerateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	refresh, err := j.GenerateRefreshToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantErr   error
+		wantEmail string
+	}{
+		{name: "アクセストークンでは再発行できない", token: access, wantErr: ErrInvalidTokenType},
+		{name: "メールアドレスを引き継いで再発行", token: refresh, wantEmail: "u1@example.com"},
+		{name: "使用済みのリフレッシュトークンは失効している", token: refresh, wantErr: ErrTokenRevoked},
+	}
+
+	// 順に実行する（2件目で使ったトークンを3件目で再利用する）
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newAccess, newRefresh, err := j.RefreshToken(ctx, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			claims, err := j.ValidateToken(ctx, newAccess)
+			if err != nil {
+				t.Fatalf("ValidateToken(access) error = %v", err)
+			}
+			if claims.TokenType != TokenTypeAccess || claims.UserID != "u1" || claims.Email != tt.wantEmail {
+				t.Errorf("access claims = %+v", claims)
+			}
+			// 新しいリフレッシュトークンは使える
+			if _, _, err := j.RefreshToken(ctx, newRefresh); err != nil {
+				t.Errorf("RefreshToken(new refresh token) error = %v", err)
+			}
+		})
+	}
+}
+
+// TestRefreshTokenConcurrentReuse は同じリフレッシュトークンで同時に再発行しても、成功するのは1回だけであることを確認する
+// （盗まれたトークンを正規のクライアントと同時に使っても、新しいトークンを得られるのはどちらか一方）
+func TestRefreshTokenConcurrentReuse(t *testing.T) {
+	const clients = 4
+	fake := dynamotest.New()
+	denylist := repository.NewTokenRevocationRepository(repository.NewDynamoDBClientWithAPI(fake, "test-table"))
+	j := NewJWTAuth("test-secret", time.Minute, time.Hour, denylist, true)
+
+	refresh, err := j.GenerateRefreshToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	// 全員が失効チェックを通過してから失効リストに書き込ませる（チェックと書き込みの間に割り込まれた状況）
+	var arrived sync.WaitGroup
+	arrived.Add(clients)
+	fake.Hook = func(ctx context.Context, op string, input any) error {
+		if op == "PutItem" {
+			arrived.Done()
+			arrived.Wait()
+		}
+		return nil
+	}
+
+	errs := make([]error, clients)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = j.RefreshToken(context.Background(), refresh)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrTokenRevoked):
+			t.Errorf("RefreshToken()[%d] error = %v, want ErrTokenRevoked", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d refreshes succeeded, want 1", succeeded)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	ctx := context.Background()
+	denylist := &memoryDenylist{revoked: map[string]bool{}}
+	j := NewJWTAuth("test-secret", time.Minute, time.Hour, denylist, true)
+
+	access, err := j.GenerateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	refresh, err := j.GenerateRefreshToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+	other, err := j.GenerateToken("u2", "u2@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	if err := j.RevokeUser(ctx, "u1"); err != nil {
+		t.Fatalf("RevokeUser() error = %v", err)
+	}
+
+	if _, err := j.ValidateToken(ctx, access); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("ValidateToken(access) error = %v, want ErrTokenRevoked", err)
+	}
+	if _, _, err := j.RefreshToken(ctx, refresh); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("RefreshToken() error = %v, want ErrTokenRevoked", err)
+	}
+	// 他のユーザーのトークンは失効しない
+	if _, err := j.ValidateToken(ctx, other); err != nil {
+		t.Errorf("ValidateToken(other user) error = %v", err)
+	}
+
+	// 失効より後に発行したトークンは使える（iat が秒単位のため、失効時刻を過去にずらして確認する）
+	denylist.userRevoked["u1"] = time.Now().Add(-2 * time.Second)
+	fresh, err := j.GenerateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if _, err := j.ValidateToken(ctx, fresh); err != nil {
+		t.Errorf("ValidateToken(token issued after revocation) error = %v", err)
+	}
+}
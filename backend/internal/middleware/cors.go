@@ -1,18 +1,68 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
-func CORS(next http.Handler) http.Handler {
+// CORS はクロスオリジンリクエストを許可するミドルウェアの設定
+//
+// 【オリジンの扱い】
+//   - 許可オリジンに "*" を含む場合: Access-Control-Allow-Origin: * を返す（開発用）
+//   - それ以外: リクエストの Origin が許可リストにあればその値をそのまま返す
+//     → "*" ではなく具体的なオリジンを返すことで、Cookie等を伴う credentialed リクエストが通る
+//   - 許可リストにない Origin には CORS ヘッダーを付けない（ブラウザ側でブロックされる）
+type CORS struct {
+	allowAll       bool
+	allowedOrigins map[string]struct{}
+	allowedMethods string
+	allowedHeaders string
+}
+
+func NewCORS(origins, methods, headers []string) *CORS {
+	c := &CORS{
+		allowedOrigins: make(map[string]struct{}, len(origins)),
+		allowedMethods: strings.Join(methods, ", "),
+		allowedHeaders: strings.Join(headers, ", "),
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			c.allowAll = true
+			continue
+		}
+		c.allowedOrigins[origin] = struct{}{}
+	}
+	return c
+}
+
+func (c *CORS) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		// 許可オリジンによってレスポンスが変わるため、キャッシュのキーに Origin を含めさせる
+		w.Header().Add("Vary", "Origin")
+
+		if c.allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			c.setAllowHeaders(w)
+		} else if _, ok := c.allowedOrigins[origin]; ok {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			c.setAllowHeaders(w)
+		}
+
+		// プリフライトリクエストはハンドラーまで到達させずに返す
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+func (c *CORS) setAllowHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Methods", c.allowedMethods)
+	w.Header().Set("Access-Control-Allow-Headers", c.allowedHeaders)
+	w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// InFlight は処理中のリクエスト数を数える（シャットダウン時のログ用）
+type InFlight struct {
+	count atomic.Int64
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+// Middleware はリクエストの開始で加算し、終了で減算する
+func (f *InFlight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.count.Add(1)
+		defer f.count.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count は処理中のリクエスト数を返す
+func (f *InFlight) Count() int64 {
+	return f.count.Load()
+}
@@ -1,11 +1,35 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
+
+	"github.com/aws/smithy-go"
 )
 
+// accessLogger はアクセスログ（1リクエスト1行のJSON）を出力する
+var accessLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+const accessLogKey contextKey = "accessLog"
+
+// accessLogEntry はハンドラー側で判明する情報をアクセスログへ受け渡すための入れ物
+// 認証ミドルウェアは Logging より内側で実行されるため、
+// コンテキスト経由でポインタを共有してユーザーIDを書き戻してもらう
+type accessLogEntry struct {
+	userID string
+}
+
+// setAccessLogUserID は認証済みユーザーIDをアクセスログに記録する
+func setAccessLogUserID(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessLogKey).(*accessLogEntry); ok {
+		entry.userID = userID
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	status int
@@ -16,18 +40,52 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Logging はリクエストごとに構造化ログ（JSON）を1行出力する
+// 【出力項目】method, path, status, latency_ms, request_id, user_id（未認証の場合は空）
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
 
-		next.ServeHTTP(rw, r)
+		entry := &accessLogEntry{}
+		ctx := context.WithValue(r.Context(), accessLogKey, entry)
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
-		log.Printf("%s %s %d %s",
-			r.Method,
-			r.URL.Path,
-			rw.status,
-			time.Since(start),
+		accessLogger.LogAttrs(ctx, slog.LevelInfo, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("request_id", GetRequestID(ctx)),
+			slog.String("user_id", entry.userID),
 		)
 	})
 }
+
+// LogError はリクエストの処理中に発生した想定外のエラーを構造化ログ（JSON）に1行出力する
+// クライアントには汎用のメッセージだけを返し、原因はこのログで調べる（request_id でアクセスログと突き合わせる）
+// 【出力項目】msg, request_id, user_id, error, error_type
+func LogError(ctx context.Context, message string, err error) {
+	accessLogger.LogAttrs(ctx, slog.LevelError, message,
+		slog.String("request_id", GetRequestID(ctx)),
+		slog.String("user_id", GetUserID(ctx)),
+		slog.Any("error", err),
+		slog.String("error_type", ErrorType(err)),
+	)
+}
+
+// ErrorType はログに出力するエラーの種類を返す
+//   - 期限切れ → Timeout
+//   - DynamoDB などの AWS API のエラー → エラーコード（例: ProvisionedThroughputExceededException）
+//   - それ以外 → Unknown
+func ErrorType(err error) string {
+	var apiErr smithy.APIError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorCode()
+	}
+	return "Unknown"
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// unmatchedRoute はどのルートにも一致しなかったリクエスト（404 など）の route ラベル
+const unmatchedRoute = "unmatched"
+
+// RequestObserver はリクエスト1件の route・ステータス・レイテンシを受け取る
+type RequestObserver func(route string, status int, latency time.Duration)
+
+// Metrics はリクエストごとに observe を呼び出すミドルウェアを返す
+// route には ServeMux が設定する r.Pattern を使うため、ServeMux を直接ラップすること
+// （間に r.WithContext などでリクエストを差し替えるミドルウェアを挟むと r.Pattern を読めない）
+func Metrics(observe RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rw, r)
+
+			route := r.Pattern
+			if route == "" {
+				route = unmatchedRoute
+			}
+			observe(route, rw.status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const RequestIDKey contextKey = "requestID"
+
+// RequestIDHeader はリクエストIDを受け渡しするヘッダー
+const RequestIDHeader = "X-Request-ID"
+
+// クライアントから受け取るリクエストIDの最大長（ログ汚染を防ぐ）
+const maxRequestIDLength = 128
+
+// RequestID はリクエストごとにIDを割り当てるミドルウェア
+// X-Request-ID ヘッダーがあればそれを使い、なければUUIDを生成する
+// IDはコンテキストとレスポンスヘッダーの両方に設定する
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID はコンテキストからリクエストIDを取得する
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// ロール（handler.Route.RequiredRole に指定する）
+const RoleAdmin = "admin"
+
+// Roles はユーザーのロールを判定する
+// 現在のロールは管理者のみで、ADMIN_USER_IDS に列挙したユーザーIDを管理者とする
+// （トークンにロールを含めないため、設定を変えればすぐに反映される）
+type Roles struct {
+	admins map[string]bool
+}
+
+func NewRoles(adminUserIDs []string) *Roles {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+	if len(admins) == 0 {
+		log.Println("ADMIN_USER_IDS is empty: admin routes are not accessible")
+	}
+	return &Roles{admins: admins}
+}
+
+// HasRole はユーザーがロールを持っているかを返す
+func (r *Roles) HasRole(userID, role string) bool {
+	switch role {
+	case RoleAdmin:
+		return r.admins[userID]
+	}
+	return false
+}
+
+// Require は role を持たないユーザーを 403 で拒否するミドルウェアを返す
+// ユーザーIDを読むため JWTAuth.Middleware の内側で使うこと
+func (r *Roles) Require(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !r.HasRole(GetUserID(req.Context()), role) {
+				response.Error(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
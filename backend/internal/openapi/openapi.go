@@ -0,0 +1,246 @@
+// Package openapi は API ルートの一覧（handler.Route）から OpenAPI 3 の仕様書を組み立てる
+//
+// 【スキーマ】
+// リクエスト・レスポンスの型（domain の構造体など）をリフレクションで読み、json タグからプロパティを作る
+//   - 名前付きの構造体は components/schemas に1回だけ定義し、$ref で参照する
+//   - omitempty が付いていないフィールドは required とする
+//   - time.Time は date-time 形式の文字列
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/handler"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/response"
+)
+
+// Document は OpenAPI の仕様書（JSON にそのまま変換する）
+type Document map[string]any
+
+// pathParamPattern はパスパターン中のパラメータ（{id} など）
+var pathParamPattern = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+// Build はルートの一覧から仕様書を組み立てる
+func Build(title, version string, routes []handler.Route) Document {
+	g := &generator{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	errorRef := g.schema(reflect.TypeOf(response.ErrorResponse{}))
+
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		op := map[string]any{
+			"summary":     route.Summary,
+			"operationId": operationID(route),
+			"tags":        []string{tag(route.Pattern)},
+		}
+
+		var params []any
+		for _, m := range pathParamPattern.FindAllStringSubmatch(route.Pattern, -1) {
+			params = append(params, map[string]any{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if route.Request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(route.Request))},
+				},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if route.Response != nil {
+			success["content"] = map[string]any{
+				"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(route.Response))},
+			}
+		}
+		op["responses"] = map[string]any{
+			strconv.Itoa(status): success,
+			"default": map[string]any{
+				"description": "Error",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": errorRef},
+				},
+			},
+		}
+
+		if route.Protected || route.RequiredRole != "" {
+			op["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+		}
+		if route.RequiredRole != "" {
+			op["description"] = "Requires role: " + route.RequiredRole
+		}
+
+		path := pathParamPattern.ReplaceAllString(route.Pattern, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return Document{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+}
+
+// operationID はルートの一意な ID（例: GET /api/v1/products/{id} → get_products_id）
+func operationID(route handler.Route) string {
+	path := strings.TrimPrefix(route.Pattern, "/api/v1")
+	path = strings.NewReplacer("{", "", "}", "", "...", "", "-", "_").Replace(path)
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	return strings.ToLower(route.Method) + "_" + strings.Join(parts, "_")
+}
+
+// tag はルートの分類（/api/v1/ の次の要素。admin の場合はその次まで含める）
+func tag(pattern string) string {
+	rest, ok := strings.CutPrefix(pattern, "/api/v1/")
+	if !ok {
+		return "health"
+	}
+	parts := strings.Split(rest, "/")
+	if parts[0] == "admin" && len(parts) > 1 {
+		return "admin/" + parts[1]
+	}
+	return parts[0]
+}
+
+type generator struct {
+	schemas map[string]any          // components/schemas
+	names   map[reflect.Type]string // 定義済みの構造体 → スキーマ名
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema は型のスキーマを返す（名前付きの構造体は $ref）
+func (g *generator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + g.define(t)}
+	}
+	// interface{} など（任意の値）
+	return map[string]any{}
+}
+
+// define は構造体を components/schemas に定義し、スキーマ名を返す
+// パッケージが違う同名の型はパッケージ名を付けて区別する
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, exists := g.schemas[name]; exists {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = map[string]any{} // 自己参照する型のために先に登録する
+	g.schemas[name] = g.object(t)
+	return name
+}
+
+// object は構造体のフィールドからオブジェクトのスキーマを作る（encoding/json と同じ規則でフィールドを選ぶ）
+func (g *generator) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	g.collectFields(t, properties, &required)
+
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *generator) collectFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tagValue := f.Tag.Get("json")
+		if tagValue == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tagValue, ",")
+
+		// タグのない埋め込み構造体はフィールドが展開される
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.collectFields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
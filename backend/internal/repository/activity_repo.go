@@ -18,6 +18,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -62,7 +63,7 @@ func NewActivityRepository(db *DynamoDBClient) *ActivityRepository {
 func (r *ActivityRepository) Create(ctx context.Context, activity *domain.UserActivity) error {
 	now := time.Now()
 	activity.Timestamp = now
-	activity.TTL = now.Add(TTLDuation).Unix() // 30日後のUnix Epoch秒
+	activity.TTL = ttlEpoch(now.Add(TTLDuation)) // 30日後のUnix Epoch秒
 
 	record := activityRecord{
 		PK:         "USER#" + activity.UserID,
@@ -75,7 +76,7 @@ func (r *ActivityRepository) Create(ctx context.Context, activity *domain.UserAc
 		CreatedAt:  now.Format(time.RFC3339),
 	}
 
-	item, err := attributevalue.MarshalMap(record)
+	item, err := marshalWithTTL(record)
 	if err != nil {
 		return err
 	}
@@ -91,7 +92,7 @@ func (r *ActivityRepository) Create(ctx context.Context, activity *domain.UserAc
 // BatchCreate は複数の行動ログを一括保存する
 // 【使用API】BatchWriteItem
 // 【制限】最大25件まで。それ以上の場合は分割して呼び出す
-// 【リトライ】UnprocessedItemsがある場合は再試行
+// 【リトライ】UnprocessedItemsがある場合は指数バックオフで再試行し、それでも残った場合はエラーを返す
 func (r *ActivityRepository) BatchCreate(ctx context.Context, activities []*domain.UserActivity) error {
 	if len(activities) == 0 {
 		return nil
@@ -112,7 +113,7 @@ func (r *ActivityRepository) BatchCreate(ctx context.Context, activities []*doma
 			// タイムスタンプをずらして重複を防ぐ
 			timestamp := now.Add(time.Duration(j) * time.Nanosecond)
 			activity.Timestamp = timestamp
-			activity.TTL = timestamp.Add(TTLDuation).Unix()
+			activity.TTL = ttlEpoch(timestamp.Add(TTLDuation))
 
 			record := activityRecord{
 				PK:         "USER#" + activity.UserID,
@@ -125,7 +126,7 @@ func (r *ActivityRepository) BatchCreate(ctx context.Context, activities []*doma
 				CreatedAt:  timestamp.Format(time.RFC3339),
 			}
 
-			item, err := attributevalue.MarshalMap(record)
+			item, err := marshalWithTTL(record)
 			if err != nil {
 				return err
 			}
@@ -137,27 +138,13 @@ func (r *ActivityRepository) BatchCreate(ctx context.Context, activities []*doma
 			})
 		}
 
-		// BatchWriteItem実行
-		input := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				*r.db.Table(): writeRequests,
-			},
-		}
-
-		result, err := r.db.Client.BatchWriteItem(ctx, input)
+		// BatchWriteItem実行（UnprocessedItems は batchWriteWithRetry が指数バックオフで再試行する）
+		unprocessed, err := batchWriteWithRetry(ctx, r.db, writeRequests)
 		if err != nil {
 			return err
 		}
-
-		// UnprocessedItemsがある場合は再試行(簡易版)
-		// 本番環境ではExponential Backoffを実装すべき
-		for len(result.UnprocessedItems) > 0 {
-			time.Sleep(100 * time.Millisecond)
-			input.RequestItems = result.UnprocessedItems
-			result, err = r.db.Client.BatchWriteItem(ctx, input)
-			if err != nil {
-				return err
-			}
+		if len(unprocessed) > 0 {
+			return fmt.Errorf("batch create activities: %d unprocessed items remain after %d retries", len(unprocessed), maxBatchWriteRetries)
 		}
 	}
 
@@ -195,6 +182,44 @@ func (r *ActivityRepository) GetByUserID(ctx context.Context, userID string, lim
 	return activities, nil
 }
 
+// GetAllByUserID はユーザーの行動ログを全件取得する（新しい順、データエクスポート用）
+// 【使用API】Query - LastEvaluatedKey がなくなるまで読み進める
+// TTL により30日より古いログは自動削除されているため、件数は一定範囲に収まる
+func (r *ActivityRepository) GetAllByUserID(ctx context.Context, userID string) ([]*domain.UserActivity, error) {
+	activities := make([]*domain.UserActivity, 0)
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":sk": &types.AttributeValueMemberS{Value: "ACTIVITY#"},
+			},
+			ScanIndexForward:  aws.Bool(false), // 新しい順
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			var rec activityRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			activities = append(activities, recordToActivity(&rec))
+		}
+
+		startKey = result.LastEvaluatedKey
+		if startKey == nil {
+			break
+		}
+	}
+
+	return activities, nil
+}
+
 // GetByUserIDAndAction は特定アクションタイプの行動ログを取得する
 // 【使用API】Query + FilterExpression
 func (r *ActivityRepository) GetByUserIDAndAction(ctx context.Context, userID string, actionType string, limit int32) ([]*domain.UserActivity, error) {
@@ -228,6 +253,58 @@ func (r *ActivityRepository) GetByUserIDAndAction(ctx context.Context, userID st
 	return activities, nil
 }
 
+// GetRecentProductIDsByAction は特定アクションタイプの行動ログから、新しい順に重複を除いた商品IDを最大 limit 件返す
+// 【使用API】Query + FilterExpression + ProjectionExpression
+//   - Limit はフィルタ前の評価件数にかかるため、商品IDが limit 件集まるか最後のページまで読み進める
+//   - 同じ商品の行動ログが複数ある場合は最も新しいものの位置を使う
+//
+// TTL により30日より古いログは自動削除されているため、読み込む件数は一定範囲に収まる
+func (r *ActivityRepository) GetRecentProductIDsByAction(ctx context.Context, userID string, actionType string, limit int) ([]string, error) {
+	productIDs := make([]string, 0, limit)
+	seen := make(map[string]bool, limit)
+	var startKey map[string]types.AttributeValue
+	for len(productIDs) < limit {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			FilterExpression:       aws.String("ActionType = :actionType"),
+			ProjectionExpression:   aws.String("ProductId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":         &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":sk":         &types.AttributeValueMemberS{Value: "ACTIVITY#"},
+				":actionType": &types.AttributeValueMemberS{Value: actionType},
+			},
+			ScanIndexForward:  aws.Bool(false), // 新しい順
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			var rec activityRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			if rec.ProductID == "" || seen[rec.ProductID] {
+				continue
+			}
+			seen[rec.ProductID] = true
+			productIDs = append(productIDs, rec.ProductID)
+			if len(productIDs) == limit {
+				break
+			}
+		}
+
+		startKey = result.LastEvaluatedKey
+		if startKey == nil {
+			break
+		}
+	}
+
+	return productIDs, nil
+}
+
 func recordToActivity(rec *activityRecord) *domain.UserActivity {
 	return &domain.UserActivity{
 		UserID:     rec.UserID,
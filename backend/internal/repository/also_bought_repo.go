@@ -0,0 +1,147 @@
+// backend/internal/repository/also_bought_repo.go
+// 「一緒に購入されている商品」の集計（同じ注文で購入された回数）を担当するリポジトリ
+// DynamoDB Streams のワーカー（cmd/worker）が注文明細の INSERT から集計する
+//
+// 【キー設計】
+//   集計:         PK: PRODUCT#<productId>, SK: ALSOBOUGHT#<otherProductId>
+//   適用済みマーク: PK: STREAMSEQ#<sequenceNumber>, SK: ALSOBOUGHT（TTL で自動削除）
+//
+// 【集計の向き】
+//   注文明細1件（商品 X）のイベントごとに、同じ注文の他の商品 Y について X → Y の回数を加算する
+//   注文の全明細のイベントが届くと、X → Y と Y → X の両方が加算される
+//
+// 【冪等性】
+//   販売実績（SalesStatsRepository）と同じく、加算と同じトランザクションで適用済みマークを書き込む
+//   （SK を分けているため、販売実績のマークとは独立に判定される）
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+type alsoBoughtRecord struct {
+	PK             string `dynamodbav:"PK"` // PRODUCT#<productId>
+	SK             string `dynamodbav:"SK"` // ALSOBOUGHT#<otherProductId>
+	OtherProductID string `dynamodbav:"otherProductId"`
+	Count          int    `dynamodbav:"count"`
+}
+
+type AlsoBoughtRepository struct {
+	db *DynamoDBClient
+}
+
+func NewAlsoBoughtRepository(db *DynamoDBClient) *AlsoBoughtRepository {
+	return &AlsoBoughtRepository{db: db}
+}
+
+// AddOrder は商品 productID と同じ注文で購入された商品（otherIDs）の回数をそれぞれ1加算する
+// 【使用API】TransactWriteItems
+//  1. Put: 適用済みマーク（条件: attribute_not_exists(PK)）
+//  2. Update: otherIDs ごとに ADD count（アイテムがなければ作成される）
+//
+// 1注文の商品数は MaxOrderProducts までのため、操作数はトランザクションの上限に収まる
+// sequenceNumber のレコードが適用済みの場合は加算せず applied=false を返す
+func (r *AlsoBoughtRepository) AddOrder(ctx context.Context, sequenceNumber, productID string, otherIDs []string) (applied bool, err error) {
+	if len(otherIDs) == 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	transactItems := make([]types.TransactWriteItem, 0, len(otherIDs)+1)
+	transactItems = append(transactItems, types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: r.db.Table(),
+			Item: map[string]types.AttributeValue{
+				"PK":         &types.AttributeValueMemberS{Value: "STREAMSEQ#" + sequenceNumber},
+				"SK":         &types.AttributeValueMemberS{Value: "ALSOBOUGHT"},
+				ttlAttribute: ttlValue(ttlEpoch(now.Add(streamMarkerTTL))),
+			},
+			ConditionExpression: aws.String("attribute_not_exists(PK)"),
+		},
+	})
+	for _, otherID := range otherIDs {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: r.db.Table(),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+					"SK": &types.AttributeValueMemberS{Value: "ALSOBOUGHT#" + otherID},
+				},
+				UpdateExpression: aws.String("SET otherProductId = :otherId, updatedAt = :now ADD #count :one"),
+				ExpressionAttributeNames: map[string]string{
+					"#count": "count", // count は予約語
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":otherId": &types.AttributeValueMemberS{Value: otherID},
+					":now":     &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+					":one":     &types.AttributeValueMemberN{Value: "1"},
+				},
+			},
+		})
+	}
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListByProduct は商品と同じ注文で購入された商品の集計を全件取得する（並び順は商品ID順）
+// 【使用API】Query（PK = PRODUCT#<productId>, begins_with(SK, ALSOBOUGHT#)）
+// 回数順に並べるには全件を読む必要があるため、呼び出し側で並べ替えてキャッシュする
+func (r *AlsoBoughtRepository) ListByProduct(ctx context.Context, productID string) ([]*domain.AlsoBought, error) {
+	counts := make([]*domain.AlsoBought, 0)
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+				":sk": &types.AttributeValueMemberS{Value: "ALSOBOUGHT#"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			var rec alsoBoughtRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			otherID := rec.OtherProductID
+			if otherID == "" {
+				otherID = strings.TrimPrefix(rec.SK, "ALSOBOUGHT#")
+			}
+			counts = append(counts, &domain.AlsoBought{
+				ProductID:      productID,
+				OtherProductID: otherID,
+				Count:          rec.Count,
+			})
+		}
+
+		startKey = result.LastEvaluatedKey
+		if startKey == nil {
+			break
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// BatchWriteItem / BatchGetItem の未処理分（UnprocessedItems / UnprocessedKeys）再試行の設定
+const (
+	maxBatchWriteRetries  = 5
+	batchWriteBaseBackoff = 50 * time.Millisecond
+)
+
+// BatchGetItem 1回で取得できるキーの上限
+const maxBatchGetKeys = 100
+
+// batchWriteWithRetry は requests を BatchWriteItem でまとめて書き込む
+// 【使用API】BatchWriteItem
+//   - 1回で最大 MaxBatchWriteItems（25）件のため、それを超える場合は分割して呼び出す
+//   - UnprocessedItems は指数バックオフで maxBatchWriteRetries 回まで再試行する
+//
+// 再試行しても書き込まれなかったリクエストを返す（エラーにするかは呼び出し側で決める）
+func batchWriteWithRetry(ctx context.Context, db *DynamoDBClient, requests []types.WriteRequest) ([]types.WriteRequest, error) {
+	var unprocessed []types.WriteRequest
+	for start := 0; start < len(requests); start += MaxBatchWriteItems {
+		end := min(start+MaxBatchWriteItems, len(requests))
+
+		requestItems := map[string][]types.WriteRequest{*db.Table(): requests[start:end]}
+		backoff := batchWriteBaseBackoff
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			if attempt > maxBatchWriteRetries {
+				unprocessed = append(unprocessed, requestItems[*db.Table()]...)
+				break
+			}
+			if attempt > 0 {
+				if err := sleepCtx(ctx, backoff); err != nil {
+					return nil, err
+				}
+				backoff *= 2
+			}
+
+			result, err := db.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, err
+			}
+			requestItems = result.UnprocessedItems
+		}
+	}
+	return unprocessed, nil
+}
+
+// batchGetWithRetry は keys のアイテムを BatchGetItem でまとめて取得する
+// 【使用API】BatchGetItem
+//   - 1回で最大 maxBatchGetKeys 件のため、それを超える場合は分割して呼び出す
+//   - UnprocessedKeys は指数バックオフで maxBatchWriteRetries 回まで再試行し、それでも残った場合はエラーを返す
+//   - 同じキーを重複して指定するとエラーになるため、呼び出し側で重複を除いておくこと
+//
+// projection の Keys 以外（ProjectionExpression など）を各リクエストに使う
+// 存在しないキーは結果に含まれない（エラーにはしない）
+func batchGetWithRetry(ctx context.Context, db *DynamoDBClient, keys []map[string]types.AttributeValue, projection types.KeysAndAttributes) ([]map[string]types.AttributeValue, error) {
+	items := make([]map[string]types.AttributeValue, 0, len(keys))
+	for start := 0; start < len(keys); start += maxBatchGetKeys {
+		end := min(start+maxBatchGetKeys, len(keys))
+
+		ka := projection
+		ka.Keys = keys[start:end]
+		requestItems := map[string]types.KeysAndAttributes{*db.Table(): ka}
+		backoff := batchWriteBaseBackoff
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			if attempt > maxBatchWriteRetries {
+				return nil, fmt.Errorf("batch get: unprocessed keys remain after %d retries", maxBatchWriteRetries)
+			}
+			if attempt > 0 {
+				if err := sleepCtx(ctx, backoff); err != nil {
+					return nil, err
+				}
+				backoff *= 2
+			}
+
+			result, err := db.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, result.Responses[*db.Table()]...)
+			requestItems = result.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+// batchDeleteKeys は keys のアイテムを BatchWriteItem でまとめて削除する
+// 未処理分の再試行は batchWriteWithRetry に任せ、それでも残った場合はエラーを返す
+//
+// 存在しないキーを指定してもエラーにはならない
+func batchDeleteKeys(ctx context.Context, db *DynamoDBClient, keys []map[string]types.AttributeValue) error {
+	requests := make([]types.WriteRequest, 0, len(keys))
+	for _, key := range keys {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: key},
+		})
+	}
+
+	unprocessed, err := batchWriteWithRetry(ctx, db, requests)
+	if err != nil {
+		return err
+	}
+	if len(unprocessed) > 0 {
+		return fmt.Errorf("batch delete: %d unprocessed items remain after %d retries", len(unprocessed), maxBatchWriteRetries)
+	}
+	return nil
+}
+
+// sleepCtx は d だけ待つ。ctx がキャンセルされた場合は待たずに ctx のエラーを返す
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+func batchTestKey(i int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("ITEM#%03d", i)},
+		"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+	}
+}
+
+func TestBatchWriteWithRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		unprocessed int
+		wantCalls   int
+	}{
+		{name: "25件ずつ分割して書き込む", items: 60, wantCalls: 3},
+		{name: "未処理のアイテムは再試行", items: 30, unprocessed: 4, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			fake.UnprocessedWrites = tt.unprocessed
+
+			requests := make([]types.WriteRequest, 0, tt.items)
+			for i := 0; i < tt.items; i++ {
+				item := batchTestKey(i)
+				item["n"] = &types.AttributeValueMemberN{Value: fmt.Sprint(i)}
+				requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+			}
+
+			unprocessed, err := batchWriteWithRetry(context.Background(), db, requests)
+			if err != nil {
+				t.Fatalf("batchWriteWithRetry() error = %v", err)
+			}
+			if len(unprocessed) != 0 {
+				t.Errorf("unprocessed = %d requests, want 0", len(unprocessed))
+			}
+			if fake.Len() != tt.items {
+				t.Errorf("table has %d items, want %d", fake.Len(), tt.items)
+			}
+			if got := fake.CallCount("BatchWriteItem"); got != tt.wantCalls {
+				t.Errorf("BatchWriteItem called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBatchGetWithRetry(t *testing.T) {
+	db, fake := newTestDB()
+	keys := make([]map[string]types.AttributeValue, 0, 150)
+	for i := 0; i < 150; i++ {
+		item := batchTestKey(i)
+		item["name"] = &types.AttributeValueMemberS{Value: fmt.Sprint("item", i)}
+		item["secret"] = &types.AttributeValueMemberS{Value: "x"}
+		fake.Seed(item)
+		keys = append(keys, batchTestKey(i))
+	}
+	keys = append(keys, batchTestKey(999)) // 存在しないキーは結果に含まれない
+	fake.UnprocessedKeys = 7
+
+	items, err := batchGetWithRetry(context.Background(), db, keys, types.KeysAndAttributes{
+		ProjectionExpression:     aws.String("PK, #name"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+	})
+	if err != nil {
+		t.Fatalf("batchGetWithRetry() error = %v", err)
+	}
+	if len(items) != 150 {
+		t.Fatalf("got %d items, want 150", len(items))
+	}
+	for _, item := range items {
+		if _, ok := item["secret"]; ok {
+			t.Fatalf("item %v includes an attribute outside the projection", item["PK"])
+		}
+	}
+	// 100件ずつの2回 + 未処理分の再試行1回
+	if got := fake.CallCount("BatchGetItem"); got != 3 {
+		t.Errorf("BatchGetItem called %d times, want 3", got)
+	}
+}
+
+func TestProductRepositoryBatchCreateReportsUnprocessed(t *testing.T) {
+	db, fake := newTestDB()
+	// 最初の書き込みと再試行のすべてで未処理にする
+	fake.UnprocessedWrites = 2 * (maxBatchWriteRetries + 1)
+	repo := NewProductRepository(db)
+
+	products := []*domain.Product{
+		{Name: "商品A", Price: 100, Stock: 1, Category: "books"},
+		{Name: "商品B", Price: 200, Stock: 1, Category: "books"},
+	}
+	failedIDs, err := repo.BatchCreate(context.Background(), products)
+	if err != nil {
+		t.Fatalf("BatchCreate() error = %v", err)
+	}
+	if len(failedIDs) != 2 || failedIDs[0] != products[0].ID || failedIDs[1] != products[1].ID {
+		t.Errorf("failedIDs = %v, want both product IDs", failedIDs)
+	}
+	if got, want := fake.CallCount("BatchWriteItem"), maxBatchWriteRetries+1; got != want {
+		t.Errorf("BatchWriteItem called %d times, want %d", got, want)
+	}
+	if fake.Len() != 0 {
+		t.Errorf("table has %d items, want 0", fake.Len())
+	}
+}
@@ -7,10 +7,26 @@
 //
 // 【アクセスパターン】
 //   1. ユーザーのカート全件取得  → Query(PK = "USER#xxx" AND begins_with(SK, "CART#"))
+//      件数のみ                  → 同じ Query + Select: COUNT
 //   2. カートアイテム1件取得    → GetItem(PK, SK)
-//   3. カートにアイテム追加     → PutItem
+//   3. カートにアイテム追加     → UpdateItem + ADD（既存なら数量をアトミックに加算）
 //   4. 数量更新（楽観的ロック）  → UpdateItem + ConditionExpression
 //   5. カートからアイテム削除   → DeleteItem
+//   6. 価格を現在の商品価格に更新 → UpdateItem（attribute_exists で削除済みアイテムの復活を防ぐ）
+//   7. 放置カート候補の取得     → Query(GSI2: GSI2PK = "CARTDAY#yyyy-mm-dd" AND GSI2SK < cutoff)
+//
+// 【GSI2（更新日バケット）】
+//   GSI2PK: CARTDAY#<updatedAt の日付(UTC)>
+//   GSI2SK: <updatedAt(UTC)>#<userId>#<productId>
+//   カートアイテムは全ユーザー横断のインデックスを持たないため、更新日単位でバケット化して
+//   「一定期間更新されていないカート」を日付ごとに Query できるようにする
+//   （この属性はアイテムの追加・数量更新時に書き込まれる）
+//
+// 【TTL（放置カートの自動削除）】
+//   TTL 属性に「最終更新から CartItemTTL 後」の Unix Epoch 秒を設定し、追加・数量更新・価格更新のたびに延長する
+//   DynamoDB の TTL 削除は期限後すぐではなく最大で数日遅れることがあるため、
+//   読み込み時にも期限切れのアイテムを除外する（期限切れ = まだ残っていても存在しないものとして扱う）
+//   TTL 属性を持たない既存のアイテムは期限切れにならない
 
 package repository
 
@@ -18,6 +34,7 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -29,13 +46,21 @@ import (
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
 )
 
+// CartItemTTL はカートアイテムを最終更新から自動削除するまでの期間
+const CartItemTTL = 30 * 24 * time.Hour
+
+// MaxUpdatedBeforeLimit は ListUpdatedBefore の1回の取得件数の上限
+const MaxUpdatedBeforeLimit = 100
+
 var ErrCartItemNotFound = errors.New("cart item not found")
 var ErrVersionMismatch = errors.New("version mismatch: item was modified by another request")
 
 // cartRecord はDynamoDBに保存するカートデータの構造体
 type cartRecord struct {
-	PK          string `dynamodbav:"PK"` // USER#<userId>
-	SK          string `dynamodbav:"SK"` // CART#<productId>
+	PK          string `dynamodbav:"PK"`               // USER#<userId>
+	SK          string `dynamodbav:"SK"`               // CART#<productId>
+	GSI2PK      string `dynamodbav:"GSI2PK,omitempty"` // CARTDAY#<yyyy-mm-dd>
+	GSI2SK      string `dynamodbav:"GSI2SK,omitempty"` // <updatedAt>#<userId>#<productId>
 	UserID      string `dynamodbav:"userId"`
 	ProductID   string `dynamodbav:"productId"`
 	ProductName string `dynamodbav:"productName"` // 非正規化（商品名をカートに保持）
@@ -44,6 +69,7 @@ type cartRecord struct {
 	Version     int    `dynamodbav:"version"` // 楽観的ロック用
 	AddedAt     string `dynamodbav:"addedAt"`
 	UpdatedAt   string `dynamodbav:"updatedAt"`
+	TTL         int64  `dynamodbav:"TTL,omitempty"` // Unix Epoch秒（最終更新から CartItemTTL 後）
 }
 
 // CartRepository はカートのDynamoDB操作を提供する
@@ -58,39 +84,138 @@ func NewCartRepository(db *DynamoDBClient) *CartRepository {
 	}
 }
 
-// Add はカートにアイテムを追加する
-// 【使用API】PutItem
-// 【注意】同じ商品が既にある場合は上書き（数量を加算したい場合はUpdateを使う）
-func (r *CartRepository) Add(ctx context.Context, item *domain.CartItem) error {
-	now := time.Now()
-	item.Version = 1 // 新規追加時のバージョンは1
-	item.AddedAt = now
-	item.UpdatedAt = now
-
-	record := cartRecord{
-		PK:          "USER#" + item.UserID,
-		SK:          "CART#" + item.ProductID,
-		UserID:      item.UserID,
-		ProductID:   item.ProductID,
-		ProductName: item.ProductName,
-		Price:       item.Price,
-		Quantity:    item.Quantity,
-		Version:     item.Version,
-		AddedAt:     item.AddedAt.Format(time.RFC3339),
-		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+// Add はカートにアイテムを追加する（既にある場合は数量を加算する）
+// 【使用API】UpdateItem（アイテムがなければ作成される）+ ADD + ConditionExpression
+//
+// 【ADD によるアトミックな加算】
+//
+//	読み込み → 加算 → 書き込み の順で処理すると、同時に2回追加された場合に
+//	両方が同じ数量を読んで上書きし、片方の追加分が失われる
+//	ADD quantity :delta は DynamoDB 側で加算するため、同時に実行されても全ての追加分が積み上がる
+//	version も ADD で +1 するため、数量変更の楽観的ロック（UpdateQuantity）とも整合する
+//
+// 【数量の上限】
+//
+//	ConditionExpression で「加算前の数量 <= maxQuantity - delta」を確認し、
+//	加算後の数量が maxQuantity（在庫数）を超える場合は ErrInsufficientStock を返す
+//
+// 【期限切れのアイテム】
+//
+//	TTL を過ぎてまだ削除されていないアイテムには加算せず、先に削除してから新規に追加する
+//
+// 商品名・価格・追加日時は新規作成時のみ設定する（if_not_exists）
+// 成功すると item に加算後の内容（数量・バージョンなど）が設定される
+func (r *CartRepository) Add(ctx context.Context, item *domain.CartItem, maxQuantity int) error {
+	err := r.add(ctx, item, maxQuantity)
+	var expired *expiredCartItemError
+	if !errors.As(err, &expired) {
+		return err
 	}
 
-	av, err := attributevalue.MarshalMap(record)
-	if err != nil {
+	// 期限切れのアイテムを削除して再試行（削除は TTL が切れている場合のみ）
+	if err := r.deleteExpired(ctx, item.UserID, item.ProductID, expired.ttl); err != nil {
 		return err
 	}
+	err = r.add(ctx, item, maxQuantity)
+	if errors.As(err, &expired) {
+		return ErrInsufficientStock
+	}
+	return err
+}
+
+// expiredCartItemError は加算しようとしたアイテムが期限切れだったことを示す（Add の内部でのみ使う）
+type expiredCartItemError struct {
+	ttl int64
+}
+
+func (e *expiredCartItemError) Error() string {
+	return "cart item expired"
+}
+
+func (r *CartRepository) add(ctx context.Context, item *domain.CartItem, maxQuantity int) error {
+	now := time.Now()
+	gsi2pk, gsi2sk := cartUpdatedIndexKeys(item.UserID, item.ProductID, now)
 
-	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+	result, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: r.db.Table(),
-		Item:      av,
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + item.UserID},
+			"SK": &types.AttributeValueMemberS{Value: "CART#" + item.ProductID},
+		},
+		UpdateExpression: aws.String("SET userId = :uid, productId = :pid, " +
+			"productName = if_not_exists(productName, :name), price = if_not_exists(price, :price), " +
+			"addedAt = if_not_exists(addedAt, :now), updatedAt = :now, GSI2PK = :gsi2pk, GSI2SK = :gsi2sk, #ttl = :ttl " +
+			"ADD quantity :delta, version :one"),
+		// 新規、または期限内で加算後も在庫数以内の場合のみ
+		ConditionExpression: aws.String("attribute_not_exists(quantity) OR " +
+			"(quantity <= :maxBefore AND (attribute_not_exists(#ttl) OR #ttl > :epoch))"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": ttlAttribute, // 予約語
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid":       &types.AttributeValueMemberS{Value: item.UserID},
+			":pid":       &types.AttributeValueMemberS{Value: item.ProductID},
+			":name":      &types.AttributeValueMemberS{Value: item.ProductName},
+			":price":     &types.AttributeValueMemberN{Value: strconv.Itoa(item.Price)},
+			":now":       &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+			":gsi2pk":    &types.AttributeValueMemberS{Value: gsi2pk},
+			":gsi2sk":    &types.AttributeValueMemberS{Value: gsi2sk},
+			":ttl":       ttlValue(cartItemTTL(now)),
+			":epoch":     &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			":delta":     &types.AttributeValueMemberN{Value: strconv.Itoa(item.Quantity)},
+			":one":       &types.AttributeValueMemberN{Value: "1"},
+			":maxBefore": &types.AttributeValueMemberN{Value: strconv.Itoa(maxQuantity - item.Quantity)},
+		},
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
 	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			// 失敗理由が期限切れか在庫超過かを、失敗時点のアイテムから判定する
+			var old cartRecord
+			if err := attributevalue.UnmarshalMap(cfe.Item, &old); err == nil && cartRecordExpired(&old, now) {
+				return &expiredCartItemError{ttl: old.TTL}
+			}
+			return ErrInsufficientStock
+		}
+		return err
+	}
 
-	return err
+	var record cartRecord
+	if err := attributevalue.UnmarshalMap(result.Attributes, &record); err != nil {
+		return err
+	}
+	*item = *recordToCartItem(&record)
+	return nil
+}
+
+// deleteExpired は期限切れのカートアイテムを削除する
+// 条件 TTL = :ttl で、確認後に他のリクエストが追加し直したアイテムを消さないようにする
+func (r *CartRepository) deleteExpired(ctx context.Context, userID, productID string, ttl int64) error {
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "CART#" + productID},
+		},
+		ConditionExpression: aws.String("#ttl = :ttl"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": ttlAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ttl": ttlValue(ttl),
+		},
+	})
+	if err != nil {
+		// 既に削除・更新されている場合はそのまま追加を再試行すればよい
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return nil
+		}
+		return err
+	}
+	return nil
 }
 
 // GetByUserID はユーザーのカートアイテム全件を取得する
@@ -111,18 +236,59 @@ func (r *CartRepository) GetByUserID(ctx context.Context, userID string) ([]*dom
 		return nil, err
 	}
 
+	now := time.Now()
 	items := make([]*domain.CartItem, 0, len(result.Items))
 	for _, item := range result.Items {
 		var record cartRecord
 		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
 			return nil, err
 		}
+		// TTL 削除待ちの期限切れアイテムは除外
+		if cartRecordExpired(&record, now) {
+			continue
+		}
 		items = append(items, recordToCartItem(&record))
 	}
 
 	return items, nil
 }
 
+// CountByUserID はユーザーのカートアイテム数を取得する
+// 【使用API】Query + Select: COUNT
+//   - アイテム本体を返さず件数だけを返すため、レスポンスが小さく済む
+//   - 1回の Query が読むのは最大1MBなので、LastEvaluatedKey がある間は件数を合算する
+func (r *CartRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	var count int
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":    &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":sk":    &types.AttributeValueMemberS{Value: "CART#"},
+				":epoch": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+			},
+			// TTL 削除待ちの期限切れアイテムは数えない（Count はフィルター適用後の件数）
+			FilterExpression: aws.String("attribute_not_exists(#ttl) OR #ttl > :epoch"),
+			ExpressionAttributeNames: map[string]string{
+				"#ttl": ttlAttribute,
+			},
+			Select:            types.SelectCount,
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return 0, err
+		}
+		count += int(result.Count)
+
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			return count, nil
+		}
+	}
+}
+
 // GetItem は特定のカートアイテムを1件取得する
 // 【使用API】GetItem - PK+SKで1件取得
 func (r *CartRepository) GetItem(ctx context.Context, userID, productID string) (*domain.CartItem, error) {
@@ -144,6 +310,9 @@ func (r *CartRepository) GetItem(ctx context.Context, userID, productID string)
 	if err = attributevalue.UnmarshalMap(result.Item, &record); err != nil {
 		return nil, err
 	}
+	if cartRecordExpired(&record, time.Now()) {
+		return nil, ErrCartItemNotFound
+	}
 
 	return recordToCartItem(&record), nil
 }
@@ -160,6 +329,7 @@ func (r *CartRepository) GetItem(ctx context.Context, userID, productID string)
 func (r *CartRepository) UpdateQuantity(ctx context.Context, userID, productID string, quantity, currentVersion int) error {
 	now := time.Now()
 	newVesrion := currentVersion + 1
+	gsi2pk, gsi2sk := cartUpdatedIndexKeys(userID, productID, now)
 
 	// UpdateItem: 指定した属性のみを更新（PutItemと違い全属性を指定する必要がない）
 	// SET: 属性の値を設定
@@ -170,15 +340,21 @@ func (r *CartRepository) UpdateQuantity(ctx context.Context, userID, productID s
 			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
 			"SK": &types.AttributeValueMemberS{Value: "CART#" + productID},
 		},
-		UpdateExpression: aws.String("SET quantity = :qty, version = :newVer, updatedAt = :now"),
+		UpdateExpression: aws.String("SET quantity = :qty, version = :newVer, updatedAt = :now, GSI2PK = :gsi2pk, GSI2SK = :gsi2sk, #ttl = :ttl"),
 		// ConditionExpression: 楽観的ロックの条件
 		// DBに保存されているversionと、リクエストで送られたversionが一致する場合のみ更新
 		ConditionExpression: aws.String("version = :currentVer"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": ttlAttribute, // 予約語
+		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":qty":        &types.AttributeValueMemberN{Value: strconv.Itoa(quantity)},
 			":currentVer": &types.AttributeValueMemberN{Value: strconv.Itoa(currentVersion)},
 			":newVer":     &types.AttributeValueMemberN{Value: strconv.Itoa(newVesrion)},
 			":now":        &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+			":gsi2pk":     &types.AttributeValueMemberS{Value: gsi2pk},
+			":gsi2sk":     &types.AttributeValueMemberS{Value: gsi2sk},
+			":ttl":        ttlValue(cartItemTTL(now)),
 		},
 	})
 	if err != nil {
@@ -193,6 +369,47 @@ func (r *CartRepository) UpdateQuantity(ctx context.Context, userID, productID s
 	return nil
 }
 
+// UpdatePrice はカートアイテムの価格（と商品名）を更新し、更新後のアイテムを返す
+// 【使用API】UpdateItem + ConditionExpression(attribute_exists)
+//   - 取得後に削除されたアイテムを作り直さないよう、存在する場合のみ更新する（なければ ErrCartItemNotFound）
+//   - 数量の楽観的ロックと競合しないよう version も +1 する
+func (r *CartRepository) UpdatePrice(ctx context.Context, userID, productID, productName string, price int) (*domain.CartItem, error) {
+	now := time.Now()
+	result, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "CART#" + productID},
+		},
+		UpdateExpression:    aws.String("SET price = :price, productName = :name, updatedAt = :now, #ttl = :ttl ADD version :one"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": ttlAttribute, // 予約語
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":price": &types.AttributeValueMemberN{Value: strconv.Itoa(price)},
+			":name":  &types.AttributeValueMemberS{Value: productName},
+			":now":   &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+			":one":   &types.AttributeValueMemberN{Value: "1"},
+			":ttl":   ttlValue(cartItemTTL(now)),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return nil, ErrCartItemNotFound
+		}
+		return nil, err
+	}
+
+	var record cartRecord
+	if err := attributevalue.UnmarshalMap(result.Attributes, &record); err != nil {
+		return nil, err
+	}
+	return recordToCartItem(&record), nil
+}
+
 // Delete はカートからアイテムを削除する
 // 【使用API】DeleteItem
 func (r *CartRepository) Delete(ctx context.Context, userID, productID string) error {
@@ -210,7 +427,7 @@ func (r *CartRepository) Delete(ctx context.Context, userID, productID string) e
 
 // Clear はユーザーのカートを全て削除する
 // 【使用API】Query + BatchWriteItem
-// 【注意】BatchWriteItemは最大25件まで。カートが25件を超える場合は分割が必要
+// 【注意】BatchWriteItemは最大25件まで。25件ずつ分割し、未処理分は再試行する（batchDeleteKeys）
 func (r *CartRepository) Clear(ctx context.Context, userID string) error {
 	// まずカートアイテムを全件取得
 	items, err := r.GetByUserID(ctx, userID)
@@ -222,32 +439,117 @@ func (r *CartRepository) Clear(ctx context.Context, userID string) error {
 		return nil // 削除するものがない
 	}
 
-	// BatchWriteItem用のリクエストを作成
+	// BatchWriteItemで削除
 	// 【BatchWriteItemの特徴】
-	//   - 最大25件のPut/Deleteを1回のAPIコールで実行
+	//   - 最大25件のPut/Deleteを1回のAPIコールで実行（超える場合は25件ずつ分割）
 	//   - 個別にDeleteItemを呼ぶより効率的（API呼び出し回数削減）
 	//   - 全件成功 or 全件失敗ではない（部分的な失敗あり）
-	//   - 失敗したアイテムはUnprocessedItemsで返却される
-	writeRequests := make([]types.WriteRequest, 0, len(items))
+	//   - 失敗したアイテムはUnprocessedItemsで返却される（バックオフして再試行）
+	keys := make([]map[string]types.AttributeValue, 0, len(items))
 	for _, item := range items {
-		writeRequests = append(writeRequests, types.WriteRequest{
-			DeleteRequest: &types.DeleteRequest{
-				Key: map[string]types.AttributeValue{
-					"PK": &types.AttributeValueMemberS{Value: "USER#" + item.UserID},
-					"SK": &types.AttributeValueMemberS{Value: "CART#" + item.ProductID},
-				},
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + item.UserID},
+			"SK": &types.AttributeValueMemberS{Value: "CART#" + item.ProductID},
+		})
+	}
+
+	return batchDeleteKeys(ctx, r.db, keys)
+}
+
+// ListUpdatedBefore は updatedAt が cutoff より前のカートアイテムを新しい順に最大 limit 件取得する
+// limit は 1〜MaxUpdatedBeforeLimit に丸める
+// 【使用API】Query（GSI2）- cutoff の日付から oldest の日付まで、日付バケットを1日ずつ遡る
+// 【ページング】次ページがある場合は最後に返したアイテムのキーをカーソルとして返す
+//
+//	カーソルの GSI2PK からどの日付バケットまで読んだかを復元する
+func (r *CartRepository) ListUpdatedBefore(ctx context.Context, cutoff, oldest time.Time, limit int32, cursor string) ([]*domain.CartItem, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cutoff = cutoff.UTC()
+	day := truncateToDay(cutoff)
+	if startKey != nil {
+		pk, ok := startKey["GSI2PK"].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil, "", ErrInvalidCursor
+		}
+		day, err = time.Parse("2006-01-02", strings.TrimPrefix(pk.Value, "CARTDAY#"))
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+	oldestDay := truncateToDay(oldest.UTC())
+	limit = min(max(limit, 1), MaxUpdatedBeforeLimit)
+
+	items := make([]*domain.CartItem, 0, limit)
+	var lastItem map[string]types.AttributeValue
+
+	for !day.Before(oldestDay) && int32(len(items)) < limit {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			IndexName:              aws.String("GSI2"),
+			KeyConditionExpression: aws.String("GSI2PK = :pk AND GSI2SK < :cutoff"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "CARTDAY#" + day.Format("2006-01-02")},
+				":cutoff": &types.AttributeValueMemberS{Value: cutoff.Format(time.RFC3339)},
 			},
+			ScanIndexForward:  aws.Bool(false), // 新しい順
+			Limit:             aws.Int32(limit - int32(len(items))),
+			ExclusiveStartKey: startKey,
 		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, item := range result.Items {
+			var record cartRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, "", err
+			}
+			items = append(items, recordToCartItem(&record))
+			lastItem = item
+		}
+
+		// バケット内の続きがあれば同じ日付を継続、なければ前日へ
+		startKey = result.LastEvaluatedKey
+		if startKey == nil {
+			day = day.AddDate(0, 0, -1)
+		}
 	}
 
-	// BatchWriteItemを実行
-	_, err = r.db.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]types.WriteRequest{
-			*r.db.Table(): writeRequests,
-		},
-	})
+	// 件数に達せずに oldest まで読み切った場合は最終ページ
+	if int32(len(items)) < limit || lastItem == nil {
+		return items, "", nil
+	}
 
-	return err
+	next, err := encodeCursor(keyFromItem(lastItem, "PK", "SK", "GSI2PK", "GSI2SK"))
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// cartItemTTL は updatedAt を最終更新としたときの TTL（Unix Epoch秒）を返す
+func cartItemTTL(updatedAt time.Time) int64 {
+	return ttlEpoch(updatedAt.Add(CartItemTTL))
+}
+
+// cartRecordExpired はアイテムの TTL が過ぎているかを返す（TTL 未設定の場合は false）
+func cartRecordExpired(rec *cartRecord, now time.Time) bool {
+	return rec.TTL != 0 && rec.TTL <= now.Unix()
+}
+
+// cartUpdatedIndexKeys は更新日バケット用の GSI2 キーを生成する
+// 文字列比較で時系列順になるよう UTC で揃える
+func cartUpdatedIndexKeys(userID, productID string, updatedAt time.Time) (string, string) {
+	t := updatedAt.UTC()
+	return "CARTDAY#" + t.Format("2006-01-02"), t.Format(time.RFC3339) + "#" + userID + "#" + productID
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
 // recordToCartItem はDynamoDBレコードをドメインモデルに変換する
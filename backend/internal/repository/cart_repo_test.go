@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+// seedCartItem はカートアイテムを直接書き込む
+func seedCartItem(fake *dynamotest.Fake, userID, productID string, quantity, version int, updatedAt time.Time) {
+	gsi2pk, gsi2sk := cartUpdatedIndexKeys(userID, productID, updatedAt)
+	fake.Seed(dynamotest.Item(cartRecord{
+		PK:          "USER#" + userID,
+		SK:          "CART#" + productID,
+		GSI2PK:      gsi2pk,
+		GSI2SK:      gsi2sk,
+		UserID:      userID,
+		ProductID:   productID,
+		ProductName: "商品" + productID,
+		Price:       1000,
+		Quantity:    quantity,
+		Version:     version,
+		AddedAt:     updatedAt.Format(time.RFC3339),
+		UpdatedAt:   updatedAt.Format(time.RFC3339),
+		TTL:         cartItemTTL(updatedAt),
+	}))
+}
+
+func TestCartRepositoryAdd(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		seed         func(fake *dynamotest.Fake)
+		quantity     int
+		maxQuantity  int
+		wantErr      error
+		wantQuantity int
+		wantVersion  int
+	}{
+		{
+			name:         "新規追加",
+			quantity:     2,
+			maxQuantity:  5,
+			wantQuantity: 2,
+			wantVersion:  1,
+		},
+		{
+			name:         "既存のアイテムに加算",
+			seed:         func(fake *dynamotest.Fake) { seedCartItem(fake, "u1", "p1", 2, 3, now) },
+			quantity:     3,
+			maxQuantity:  5,
+			wantQuantity: 5,
+			wantVersion:  4,
+		},
+		{
+			name:        "加算後に在庫数を超える",
+			seed:        func(fake *dynamotest.Fake) { seedCartItem(fake, "u1", "p1", 4, 1, now) },
+			quantity:    2,
+			maxQuantity: 5,
+			wantErr:     ErrInsufficientStock,
+		},
+		{
+			name:         "期限切れのアイテムは作り直す",
+			seed:         func(fake *dynamotest.Fake) { seedCartItem(fake, "u1", "p1", 4, 7, now.Add(-CartItemTTL-time.Hour)) },
+			quantity:     2,
+			maxQuantity:  5,
+			wantQuantity: 2,
+			wantVersion:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed != nil {
+				tt.seed(fake)
+			}
+			repo := NewCartRepository(db)
+
+			item := &domain.CartItem{UserID: "u1", ProductID: "p1", ProductName: "商品p1", Price: 1000, Quantity: tt.quantity}
+			err := repo.Add(context.Background(), item, tt.maxQuantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if item.Quantity != tt.wantQuantity || item.Version != tt.wantVersion {
+				t.Errorf("Add() quantity/version = %d/%d, want %d/%d", item.Quantity, item.Version, tt.wantQuantity, tt.wantVersion)
+			}
+
+			got, err := repo.GetItem(context.Background(), "u1", "p1")
+			if err != nil {
+				t.Fatalf("GetItem() error = %v", err)
+			}
+			if got.Quantity != tt.wantQuantity {
+				t.Errorf("stored quantity = %d, want %d", got.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestCartRepositoryUpdateQuantity(t *testing.T) {
+	tests := []struct {
+		name           string
+		currentVersion int
+		wantErr        error
+		wantQuantity   int
+	}{
+		{name: "バージョン一致", currentVersion: 2, wantQuantity: 5},
+		{name: "他のリクエストが先に更新", currentVersion: 1, wantErr: ErrVersionMismatch, wantQuantity: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			seedCartItem(fake, "u1", "p1", 1, 2, time.Now())
+			repo := NewCartRepository(db)
+
+			err := repo.UpdateQuantity(context.Background(), "u1", "p1", 5, tt.currentVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateQuantity() error = %v, want %v", err, tt.wantErr)
+			}
+			got, err := repo.GetItem(context.Background(), "u1", "p1")
+			if err != nil {
+				t.Fatalf("GetItem() error = %v", err)
+			}
+			if got.Quantity != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", got.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestCartRepositoryGetByUserID(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		seed    func(fake *dynamotest.Fake)
+		wantIDs []string
+	}{
+		{
+			name:    "カートが空",
+			wantIDs: []string{},
+		},
+		{
+			name: "自分のカートだけを返す",
+			seed: func(fake *dynamotest.Fake) {
+				seedCartItem(fake, "u1", "p1", 1, 1, now)
+				seedCartItem(fake, "u1", "p2", 1, 1, now)
+				seedCartItem(fake, "u2", "p3", 1, 1, now)
+			},
+			wantIDs: []string{"p1", "p2"},
+		},
+		{
+			name: "期限切れのアイテムは除外",
+			seed: func(fake *dynamotest.Fake) {
+				seedCartItem(fake, "u1", "p1", 1, 1, now.Add(-CartItemTTL-time.Minute))
+				seedCartItem(fake, "u1", "p2", 1, 1, now)
+			},
+			wantIDs: []string{"p2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed != nil {
+				tt.seed(fake)
+			}
+			repo := NewCartRepository(db)
+
+			items, err := repo.GetByUserID(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("GetByUserID() error = %v", err)
+			}
+			if got := cartProductIDs(items); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("GetByUserID() = %v, want %v", got, tt.wantIDs)
+			}
+
+			count, err := repo.CountByUserID(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("CountByUserID() error = %v", err)
+			}
+			if count != len(tt.wantIDs) {
+				t.Errorf("CountByUserID() = %d, want %d", count, len(tt.wantIDs))
+			}
+		})
+	}
+}
+
+func TestCartRepositoryUpdatePrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		seed    bool
+		wantErr error
+	}{
+		{name: "価格を更新", seed: true},
+		{name: "削除済みのアイテムは作り直さない", seed: false, wantErr: ErrCartItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed {
+				seedCartItem(fake, "u1", "p1", 1, 1, time.Now())
+			}
+			repo := NewCartRepository(db)
+
+			item, err := repo.UpdatePrice(context.Background(), "u1", "p1", "新しい名前", 1500)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdatePrice() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if fake.Len() != 0 {
+					t.Errorf("table has %d items, want 0", fake.Len())
+				}
+				return
+			}
+			if item.Price != 1500 || item.ProductName != "新しい名前" || item.Version != 2 {
+				t.Errorf("UpdatePrice() = %+v", item)
+			}
+		})
+	}
+}
+
+func TestCartRepositoryClear(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		unprocessed int
+		wantCalls   int // BatchWriteItem の呼び出し回数
+	}{
+		{name: "空のカート", items: 0, wantCalls: 0},
+		{name: "25件を超えるカートは分割して削除", items: 30, wantCalls: 2},
+		{name: "60件のカートは3回に分けて削除", items: 60, wantCalls: 3},
+		{name: "未処理のアイテムは再試行", items: 10, unprocessed: 3, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			now := time.Now()
+			for i := 0; i < tt.items; i++ {
+				seedCartItem(fake, "u1", fmt.Sprintf("p%03d", i), 1, 1, now)
+			}
+			seedCartItem(fake, "u2", "other", 1, 1, now)
+			fake.UnprocessedWrites = tt.unprocessed
+			repo := NewCartRepository(db)
+
+			if err := repo.Clear(context.Background(), "u1"); err != nil {
+				t.Fatalf("Clear() error = %v", err)
+			}
+			if fake.Len() != 1 {
+				t.Errorf("table has %d items, want only the other user's cart", fake.Len())
+			}
+			if fake.Get("USER#u2", "CART#other") == nil {
+				t.Error("Clear() deleted another user's cart item")
+			}
+			if got := fake.CallCount("BatchWriteItem"); got != tt.wantCalls {
+				t.Errorf("BatchWriteItem called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func cartProductIDs(items []*domain.CartItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	return ids
+}
@@ -0,0 +1,173 @@
+// backend/internal/repository/category_repo.go
+// 商品カテゴリのマーカーアイテムを担当するリポジトリ
+//
+// 【キー設計】
+//   PK:     CATEGORY#<name>   - パーティションキー（カテゴリ単位）
+//   SK:     METADATA          - ソートキー（固定値）
+//   GSI1PK: CATEGORY          - 全カテゴリを同じパーティションにまとめる
+//   GSI1SK: <name>            - カテゴリ名順
+//
+// 【商品数の管理】
+//   商品の作成・カテゴリ変更・削除のトランザクションに categoryCountUpdate を含め、
+//   productCount を ADD で増減する（ProductRepository から利用）
+//   商品数が0になったマーカーは削除せず残し、一覧取得時に除外する
+//   マーカー導入前の商品は数えられていないため、cmd/migrate -backfill-category-counts で
+//   商品から数え直した値に置き換える（ReplaceCounts）
+//
+// 【アクセスパターン】
+//   1. カテゴリ一覧 → Query(GSI1PK = "CATEGORY") + FilterExpression(productCount > 0)
+
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+type categoryRecord struct {
+	PK           string `dynamodbav:"PK"`     // CATEGORY#<name>
+	SK           string `dynamodbav:"SK"`     // METADATA
+	GSI1PK       string `dynamodbav:"GSI1PK"` // CATEGORY
+	GSI1SK       string `dynamodbav:"GSI1SK"` // <name>
+	Name         string `dynamodbav:"name"`
+	ProductCount int    `dynamodbav:"productCount"`
+}
+
+type CategoryRepository struct {
+	db *DynamoDBClient
+}
+
+func NewCategoryRepository(db *DynamoDBClient) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+// List は商品が1件以上あるカテゴリをカテゴリ名順に取得する
+// 【使用API】Query（GSI1: GSI1PK = CATEGORY）+ FilterExpression
+// カテゴリ数は少ない前提のため、LastEvaluatedKey を辿って全件返す
+func (r *CategoryRepository) List(ctx context.Context) ([]*domain.Category, error) {
+	categories := make([]*domain.Category, 0)
+
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			IndexName:              aws.String("GSI1"),
+			KeyConditionExpression: aws.String("GSI1PK = :pk"),
+			FilterExpression:       aws.String("productCount > :zero"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":   &types.AttributeValueMemberS{Value: "CATEGORY"},
+				":zero": &types.AttributeValueMemberN{Value: "0"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			var rec categoryRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			categories = append(categories, &domain.Category{
+				Name:         rec.Name,
+				ProductCount: rec.ProductCount,
+			})
+		}
+
+		startKey = result.LastEvaluatedKey
+		if startKey == nil {
+			break
+		}
+	}
+
+	return categories, nil
+}
+
+// ReplaceCounts はカテゴリの商品数を counts（カテゴリ名 → 商品数）の値に置き換え、更新したカテゴリ数を返す
+// counts にない既存のマーカーは 0 にする（商品がなくなったカテゴリを一覧から外す）
+// 【使用API】Query（GSI1: GSI1PK = CATEGORY）+ UpdateItem（カテゴリ単位）
+//
+// 【注意】数え直してから置き換えるまでの間に作成・削除された商品の増減は上書きされるため、
+// 商品の書き込みが止まっているとき（メンテナンス中など）に実行する
+func (r *CategoryRepository) ReplaceCounts(ctx context.Context, counts map[string]int) (int, error) {
+	markers, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ProjectionExpression:   aws.String("#name"),
+		ExpressionAttributeNames: map[string]string{
+			"#name": "name", // 予約語
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "CATEGORY"},
+		},
+	}, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	replaced := make(map[string]int, len(counts)+len(markers))
+	for _, item := range markers {
+		if name, ok := item["name"].(*types.AttributeValueMemberS); ok {
+			replaced[name.Value] = 0
+		}
+	}
+	for category, count := range counts {
+		replaced[category] = count
+	}
+
+	for category, count := range replaced {
+		if _, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: r.db.Table(),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "CATEGORY#" + category},
+				"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+			},
+			UpdateExpression: aws.String("SET #name = :name, GSI1PK = :gsi1pk, GSI1SK = :name, productCount = :count"),
+			ExpressionAttributeNames: map[string]string{
+				"#name": "name", // 予約語
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":name":   &types.AttributeValueMemberS{Value: category},
+				":gsi1pk": &types.AttributeValueMemberS{Value: "CATEGORY"},
+				":count":  &types.AttributeValueMemberN{Value: strconv.Itoa(count)},
+			},
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(replaced), nil
+}
+
+// categoryCountUpdate はカテゴリの商品数を delta だけ増減するトランザクション操作を返す
+// マーカーが存在しない場合は ADD により作成される（初めてのカテゴリ）
+func categoryCountUpdate(table *string, category string, delta int) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: table,
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "CATEGORY#" + category},
+				"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+			},
+			UpdateExpression: aws.String("SET #name = :name, GSI1PK = :gsi1pk, GSI1SK = :name ADD productCount :delta"),
+			ExpressionAttributeNames: map[string]string{
+				"#name": "name", // 予約語
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":name":   &types.AttributeValueMemberS{Value: category},
+				":gsi1pk": &types.AttributeValueMemberS{Value: "CATEGORY"},
+				":delta":  &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+func TestCategoryRepositoryReplaceCounts(t *testing.T) {
+	db, fake := newTestDB()
+	repo := NewCategoryRepository(db)
+	// ずれた商品数のマーカー（old は商品がなくなったカテゴリ）
+	for name, count := range map[string]int{"books": 3, "old": 2} {
+		fake.Seed(dynamotest.Item(categoryRecord{
+			PK:           "CATEGORY#" + name,
+			SK:           "METADATA",
+			GSI1PK:       "CATEGORY",
+			GSI1SK:       name,
+			Name:         name,
+			ProductCount: count,
+		}))
+	}
+
+	replaced, err := repo.ReplaceCounts(context.Background(), map[string]int{"books": 5, "toys": 1})
+	if err != nil {
+		t.Fatalf("ReplaceCounts() error = %v", err)
+	}
+	if replaced != 3 {
+		t.Errorf("ReplaceCounts() = %d, want 3", replaced)
+	}
+
+	got, err := repo.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []domain.Category{{Name: "books", ProductCount: 5}, {Name: "toys", ProductCount: 1}}
+	if len(got) != len(want) {
+		t.Fatalf("List() = %d categories, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("List()[%d] = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+	if n := numberAttr(fake.Get("CATEGORY#old", "METADATA"), "productCount"); n != 0 {
+		t.Errorf("productCount of old = %d, want 0", n)
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+// cancelingClient は after の操作が終わった直後に cancel する DynamoDBAPI（テスト用）
+// 操作自体は成功させ、次の操作の前にクライアントが切断した状況を再現する
+// （フェイクの Hook でキャンセルすると、その操作自体がエラーになるため使わない）
+type cancelingClient struct {
+	DynamoDBAPI
+	after  string
+	cancel context.CancelFunc
+}
+
+func (c *cancelingClient) done(op string) {
+	if op == c.after {
+		c.cancel()
+	}
+}
+
+func (c *cancelingClient) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	defer c.done("GetItem")
+	return c.DynamoDBAPI.GetItem(ctx, in, optFns...)
+}
+
+func (c *cancelingClient) Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	defer c.done("Query")
+	return c.DynamoDBAPI.Query(ctx, in, optFns...)
+}
+
+func (c *cancelingClient) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	defer c.done("BatchWriteItem")
+	return c.DynamoDBAPI.BatchWriteItem(ctx, in, optFns...)
+}
+
+// cancelAfter は after の操作が終わった直後にキャンセルされる ctx と、その ctx で使うクライアントを返す
+func cancelAfter(t *testing.T, db *DynamoDBClient, after string) (context.Context, *DynamoDBClient) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return ctx, NewDynamoDBClientWithAPI(&cancelingClient{DynamoDBAPI: db.Client, after: after, cancel: cancel}, db.TableName)
+}
+
+func TestRepositoriesReturnOnCanceledContext(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 10, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"ProductRepository.GetByID": func() error {
+			_, err := NewProductRepository(db).GetByID(ctx, "p1")
+			return err
+		},
+		"ProductRepository.List": func() error {
+			_, err := NewProductRepository(db).List(ctx, "", false)
+			return err
+		},
+		"CartRepository.Clear": func() error {
+			return NewCartRepository(db).Clear(ctx, "u1")
+		},
+		"OrderRepository.GetByID": func() error {
+			_, err := NewOrderRepository(db).GetByID(ctx, "u1", "o1", 10, "")
+			return err
+		},
+		"InventoryRepository.GetByProductIDWithRange": func() error {
+			_, err := NewInventoryRepository(db).GetByProductIDWithRange(ctx, "p1", time.Now().Add(-time.Hour), time.Now())
+			return err
+		},
+		"ActivityRepository.Create": func() error {
+			return NewActivityRepository(db).Create(ctx, &domain.UserActivity{UserID: "u1", ActionType: "VIEW", ProductID: "p1"})
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			err := call()
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("error = %v, want context.Canceled", err)
+			}
+			if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+				t.Errorf("returned after %v, want promptly", elapsed)
+			}
+		})
+	}
+	// キャンセル済みの ctx では何も書き込まない
+	if fake.Len() != 1 {
+		t.Errorf("table has %d items, want only the seeded product", fake.Len())
+	}
+}
+
+func TestOrderRepositoryGetByIDStopsAfterCancel(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 5, 1)
+	repo := NewOrderRepository(db)
+	order, items, cartItems := newTestOrder("p1")
+	if err := repo.CreateOrder(context.Background(), order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	// ヘッダーを読んだ直後にクライアントが切断した
+	ctx, canceling := cancelAfter(t, db, "GetItem")
+	queriesBefore := fake.CallCount("Query")
+
+	if _, err := NewOrderRepository(canceling).GetByID(ctx, "u1", order.ID, 10, ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetByID() error = %v, want context.Canceled", err)
+	}
+	if got := fake.CallCount("Query") - queriesBefore; got != 0 {
+		t.Errorf("Query called %d times after cancel, want 0", got)
+	}
+}
+
+func TestQueryAllPagesStopsAfterCancel(t *testing.T) {
+	db, fake := newTestDB()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		seedInventoryLog(fake, "p1", base.Add(time.Duration(i)*time.Hour), i, i+1)
+	}
+	fake.PageSize = 1
+
+	ctx, canceling := cancelAfter(t, db, "Query")
+
+	_, err := queryAllPages(ctx, canceling.Client, &dynamodb.QueryInput{
+		TableName:              db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "PRODUCT#p1"},
+		},
+	}, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("queryAllPages() error = %v, want context.Canceled", err)
+	}
+	if got := fake.CallCount("Query"); got != 1 {
+		t.Errorf("Query called %d times, want 1", got)
+	}
+}
+
+func TestBatchWriteWithRetryStopsBackoffOnCancel(t *testing.T) {
+	db, fake := newTestDB()
+	// 再試行を続けると合計 1.5 秒ほどバックオフで待つ
+	fake.UnprocessedWrites = 1 << 10
+	ctx, canceling := cancelAfter(t, db, "BatchWriteItem")
+
+	start := time.Now()
+	_, err := batchWriteWithRetry(ctx, canceling, []types.WriteRequest{{PutRequest: &types.PutRequest{Item: batchTestKey(1)}}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("batchWriteWithRetry() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("returned after %v, want promptly", elapsed)
+	}
+	if got := fake.CallCount("BatchWriteItem"); got != 1 {
+		t.Errorf("BatchWriteItem called %d times, want 1", got)
+	}
+}
@@ -0,0 +1,64 @@
+// backend/internal/repository/counter_repo.go
+// 連番（シーケンス）を払い出すカウンターのDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: COUNTER#<name>   - パーティションキー（カウンター名単位、例: COUNTER#SKU）
+//   SK: COUNTER          - ソートキー（固定値）
+//
+// 【アトミックカウンター】
+//   UpdateItem の ADD はサーバー側で加算されるため、同時に呼ばれても値が重複しない
+//   アイテムが存在しない場合は 0 から加算される（初回は 1）
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type CounterRepository struct {
+	db *DynamoDBClient
+}
+
+func NewCounterRepository(db *DynamoDBClient) *CounterRepository {
+	return &CounterRepository{
+		db: db,
+	}
+}
+
+// Next はカウンターを1進めて、進めた後の値を返す
+func (r *CounterRepository) Next(ctx context.Context, name string) (int64, error) {
+	return r.NextN(ctx, name, 1)
+}
+
+// NextN はカウンターを n 進めて、進めた後の値を返す
+// 戻り値を last とすると、last-n+1 〜 last の n 個の番号を払い出したことになる（一括採番用）
+// 【使用API】UpdateItem（ADD + ReturnValues: UPDATED_NEW）
+func (r *CounterRepository) NextN(ctx context.Context, name string, n int) (int64, error) {
+	result, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "COUNTER#" + name},
+			"SK": &types.AttributeValueMemberS{Value: "COUNTER"},
+		},
+		UpdateExpression: aws.String("ADD seq :n"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":n": &types.AttributeValueMemberN{Value: strconv.Itoa(n)},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	seq, ok := result.Attributes["seq"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, errors.New("counter " + name + ": seq attribute missing in response")
+	}
+	return strconv.ParseInt(seq.Value, 10, 64)
+}
@@ -0,0 +1,194 @@
+// backend/internal/repository/coupon_repo.go
+// クーポンデータのDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: COUPON#<code>    - パーティションキー（クーポンコード単位）
+//   SK: METADATA         - ソートキー（固定値）
+//   GSI1PK: COUPON       - 全クーポンを同じパーティションにまとめる（管理画面の一覧用）
+//   GSI1SK: <code>
+//
+// 【アクセスパターン】
+//   1. コード指定で取得 → GetItem(PK, SK)
+//   2. 全クーポン一覧   → Query(GSI1PK = "COUPON")
+//   3. 利用回数の消費   → 注文確定トランザクション内で Update（couponUseUpdate）
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponAlreadyExists = errors.New("coupon already exists")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponExhausted     = errors.New("coupon usage limit reached")
+	ErrCouponNotApplicable = errors.New("coupon is not applicable")
+)
+
+type couponRecord struct {
+	PK        string `dynamodbav:"PK"`               // COUPON#<code>
+	SK        string `dynamodbav:"SK"`               // METADATA
+	GSI1PK    string `dynamodbav:"GSI1PK,omitempty"` // COUPON（一覧用）
+	GSI1SK    string `dynamodbav:"GSI1SK,omitempty"` // <code>
+	Code      string `dynamodbav:"code"`
+	Type      string `dynamodbav:"type"` // PERCENT, FIXED
+	Value     int    `dynamodbav:"value"`
+	StartsAt  string `dynamodbav:"startsAt,omitempty"`
+	ExpiresAt string `dynamodbav:"expiresAt"`
+	MaxUses   int    `dynamodbav:"maxUses"`
+	UsedCount int    `dynamodbav:"usedCount"` // 利用回数（チェックアウト時に ADD で加算）
+	UserID    string `dynamodbav:"userId,omitempty"`
+	CreatedAt string `dynamodbav:"createdAt"`
+}
+
+type CouponRepository struct {
+	db *DynamoDBClient
+}
+
+func NewCouponRepository(db *DynamoDBClient) *CouponRepository {
+	return &CouponRepository{
+		db: db,
+	}
+}
+
+// GetByCode はクーポンコードを指定して1件取得する
+// 【使用API】GetItem
+func (r *CouponRepository) GetByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "COUPON#" + code},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	var rec couponRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return nil, err
+	}
+
+	return recordToCoupon(&rec), nil
+}
+
+// Create はクーポンを作成する
+// 【使用API】PutItem + ConditionExpression（同じコードのクーポンが既にあれば ErrCouponAlreadyExists）
+func (r *CouponRepository) Create(ctx context.Context, coupon *domain.Coupon) error {
+	now := time.Now()
+	coupon.CreatedAt = now
+
+	rec := couponRecord{
+		PK:        "COUPON#" + coupon.Code,
+		SK:        "METADATA",
+		GSI1PK:    "COUPON",
+		GSI1SK:    coupon.Code,
+		Code:      coupon.Code,
+		Type:      coupon.Type,
+		Value:     coupon.Value,
+		ExpiresAt: coupon.ExpiresAt.Format(time.RFC3339),
+		MaxUses:   coupon.MaxUses,
+		UsedCount: coupon.UsedCount,
+		UserID:    coupon.UserID,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	if !coupon.StartsAt.IsZero() {
+		rec.StartsAt = coupon.StartsAt.Format(time.RFC3339)
+	}
+
+	av, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrCouponAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+// List は全クーポンをコード順に取得する（管理者用）
+// 【使用API】Query（GSI1: GSI1PK = COUPON）
+// 【注意】GSI1 の属性を持たない作成済みのクーポンは一覧に含まれない
+func (r *CouponRepository) List(ctx context.Context) ([]*domain.Coupon, error) {
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "COUPON"},
+		},
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	coupons := make([]*domain.Coupon, 0, len(items))
+	for _, item := range items {
+		var rec couponRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, recordToCoupon(&rec))
+	}
+	return coupons, nil
+}
+
+// couponUseUpdate はクーポンの利用回数を1加算するトランザクション操作を返す
+// 【ConditionExpression】usedCount < maxUses
+//   - 検証後に他の注文が同じクーポンを使い切った場合はトランザクション全体が失敗する
+//   - 事前の検証だけでは防げない上限超過を、書き込み時点で確実に防ぐ
+func couponUseUpdate(table *string, code string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: table,
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "COUPON#" + code},
+				"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+			},
+			UpdateExpression:    aws.String("ADD usedCount :one"),
+			ConditionExpression: aws.String("attribute_exists(PK) AND usedCount < maxUses"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":one": &types.AttributeValueMemberN{Value: "1"},
+			},
+		},
+	}
+}
+
+func recordToCoupon(rec *couponRecord) *domain.Coupon {
+	return &domain.Coupon{
+		Code:      rec.Code,
+		Type:      rec.Type,
+		Value:     rec.Value,
+		StartsAt:  timeutil.ParseTime(rec.StartsAt),
+		ExpiresAt: timeutil.ParseTime(rec.ExpiresAt),
+		MaxUses:   rec.MaxUses,
+		UsedCount: rec.UsedCount,
+		UserID:    rec.UserID,
+		CreatedAt: timeutil.ParseTime(rec.CreatedAt),
+	}
+}
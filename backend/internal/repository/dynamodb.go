@@ -2,32 +2,154 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// ヘルスチェック（Ping）のタイムアウト
+const pingTimeout = 2 * time.Second
+
+// DynamoDBAPI はリポジトリが使用する DynamoDB の操作を定義するインターフェース
+// *dynamodb.Client はこのインターフェースを満たす
+// 単体テストではフェイク実装を NewDynamoDBClientWithAPI で注入し、DynamoDB なしでリポジトリを動かせる
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+}
+
+// *dynamodb.Client が DynamoDBAPI を満たすことをコンパイル時に確認する
+var _ DynamoDBAPI = (*dynamodb.Client)(nil)
+
+// RetryConfig は AWS SDK のリトライ（標準リトライヤー）の設定
+// スロットリング（ProvisionedThroughputExceededException, ThrottlingException 等）や
+// 一時的なエラー（5xx, 接続エラー）を、ジッター付きの指数バックオフで再試行する
+// 0 の項目は SDK の既定値（MaxAttempts=3, MaxBackoff=20s）を使う
+type RetryConfig struct {
+	MaxAttempts int           // 初回を含む最大試行回数
+	MaxBackoff  time.Duration // 再試行までの待ち時間の上限
+}
+
 type DynamoDBClient struct {
-	Client    *dynamodb.Client
+	Client    DynamoDBAPI
 	TableName string
 }
 
-func NewDynamoDBClient(ctx context.Context, tableName string) (*DynamoDBClient, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// NewDynamoDBClient は DynamoDB クライアントを生成する
+// endpoint を指定した場合は DynamoDB Local などのカスタムエンドポイントに接続する（空の場合は AWS 実環境）
+// region が空の場合は AWS SDK のデフォルト（AWS_REGION 環境変数や ~/.aws/config）に従う
+// retryCfg でスロットリング・一時的なエラーの再試行回数とバックオフの上限を指定する
+func NewDynamoDBClient(ctx context.Context, tableName, endpoint, region string, retryCfg RetryConfig) (*DynamoDBClient, error) {
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	opts = append(opts, config.WithRetryer(func() aws.Retryer {
+		return retry.NewStandard(func(o *retry.StandardOptions) {
+			if retryCfg.MaxAttempts > 0 {
+				o.MaxAttempts = retryCfg.MaxAttempts
+			}
+			if retryCfg.MaxBackoff > 0 {
+				o.MaxBackoff = retryCfg.MaxBackoff
+			}
+			// クライアント側のリトライ枠（トークンバケット）を無効にする
+			// → バースト時にスロットリングが続くと枠を使い切り、再試行せずに失敗してしまうため
+			o.RateLimiter = ratelimit.None
+		})
+	}))
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := dynamodb.NewFromConfig(cfg)
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
 
+	return NewDynamoDBClientWithAPI(client, tableName), nil
+}
+
+// NewDynamoDBClientWithAPI は任意の DynamoDBAPI 実装から DynamoDBClient を生成する（テスト用のフェイク注入など）
+func NewDynamoDBClientWithAPI(api DynamoDBAPI, tableName string) *DynamoDBClient {
 	return &DynamoDBClient{
-		Client:    client,
+		Client:    api,
 		TableName: tableName,
-	}, nil
+	}
 }
 
 // テーブル名を返すヘルパー
 func (d *DynamoDBClient) Table() *string {
 	return aws.String(d.TableName)
 }
+
+// Ping はDynamoDBに到達できるかを確認する（ヘルスチェック用）
+// 【使用API】DescribeTable - テーブルが存在し、認証情報が有効であることも確認できる
+func (d *DynamoDBClient) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	_, err := d.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: d.Table(),
+	})
+	return err
+}
+
+// TableStatus はテーブルと GSI の状態（DescribeTable の TableStatus / IndexStatus。例: ACTIVE, UPDATING）
+type TableStatus struct {
+	Status  string
+	Indexes map[string]string // インデックス名 → 状態
+}
+
+// DescribeStatus はテーブルと GSI の状態を取得する（詳細なヘルスチェック用）
+// 【使用API】DescribeTable
+func (d *DynamoDBClient) DescribeStatus(ctx context.Context) (*TableStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	result, err := d.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: d.Table(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TableStatus{
+		Status:  string(result.Table.TableStatus),
+		Indexes: make(map[string]string, len(result.Table.GlobalSecondaryIndexes)),
+	}
+	for _, gsi := range result.Table.GlobalSecondaryIndexes {
+		status.Indexes[aws.ToString(gsi.IndexName)] = string(gsi.IndexStatus)
+	}
+	return status, nil
+}
+
+// ProbeRead は存在しないキーを GetItem で読み、アイテムの読み込みができるかを確認する（詳細なヘルスチェック用）
+// 【使用API】GetItem
+// DescribeTable（Ping）はテーブルの管理系の API のため、読み書きの API の権限・遅延はこちらで確認する
+func (d *DynamoDBClient) ProbeRead(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	_, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: d.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "HEALTHCHECK"},
+			"SK": &types.AttributeValueMemberS{Value: "HEALTHCHECK"},
+		},
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+// newTestDB はメモリ上のフェイクを使う DynamoDBClient を返す（テスト用）
+func newTestDB() (*DynamoDBClient, *dynamotest.Fake) {
+	fake := dynamotest.New()
+	return NewDynamoDBClientWithAPI(fake, "test-table"), fake
+}
+
+func TestNewDynamoDBClientRetriesThrottling(t *testing.T) {
+	// SDK のリトライヤーを通すため、フェイクではなく HTTP のエンドポイントを立てる
+	// 最初の2回はスロットリングを返し、3回目で商品を返す
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"Rate of requests exceeds the allowed throughput"}`))
+			return
+		}
+		w.Write([]byte(`{"Item":{"PK":{"S":"PRODUCT#p1"},"SK":{"S":"METADATA"},"id":{"S":"p1"},"name":{"S":"商品"},"price":{"N":"100"},"stock":{"N":"5"}}}`))
+	}))
+	defer server.Close()
+
+	// 認証情報はダミー（署名するだけで検証はされない）
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+
+	db, err := NewDynamoDBClient(context.Background(), "test-table", server.URL, "ap-northeast-1", RetryConfig{MaxAttempts: 3, MaxBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewDynamoDBClient() error = %v", err)
+	}
+
+	product, err := NewProductRepository(db).GetByID(context.Background(), "p1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v, want success after retrying throttling", err)
+	}
+	if product.ID != "p1" || product.Stock != 5 {
+		t.Errorf("GetByID() = %+v", product)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("endpoint called %d times, want 3", got)
+	}
+
+	// 試行回数の上限を下げると、スロットリングが続く間に諦めてエラーを返す
+	calls.Store(0)
+	db, err = NewDynamoDBClient(context.Background(), "test-table", server.URL, "ap-northeast-1", RetryConfig{MaxAttempts: 2, MaxBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewDynamoDBClient() error = %v", err)
+	}
+	if _, err := NewProductRepository(db).GetByID(context.Background(), "p1"); err == nil {
+		t.Error("GetByID() succeeded, want the throttling error after 2 attempts")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("endpoint called %d times, want 2", got)
+	}
+}
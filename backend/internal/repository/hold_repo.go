@@ -0,0 +1,354 @@
+// backend/internal/repository/hold_repo.go
+// カート内の商品の在庫の取り置き（ホールド）のDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: USER#<userId>
+//   SK: HOLD#<productId>          （1ユーザー・1商品につき1件）
+//   GSI1PK: HOLD#<シャード番号>
+//   GSI1SK: <expiresAt>#<userId>#<productId>  （期限切れの取り置きを期限順に探す）
+//   GSI1PK を1つにすると取り置きの書き込みが1パーティションに集中するため、holdShards 個に分散する
+//   （分散前に作成した GSI1PK = HOLD の取り置きも ListExpired で探す）
+//
+// 【在庫との整合性】
+//   取り置きの作成・変更・解放は、商品の reservedStock の増減と同じトランザクションで行う
+//   → 取り置きアイテムの数量の合計 = 取り置きによる reservedStock の増加分 が常に保たれる
+//
+// 【TTL】
+//   期限切れの取り置きはスイーパー（ReleaseExpired）が解放する。TTL は期限から holdTTLGrace 後に設定し、
+//   スイーパーが長時間止まっていた場合に取り置きアイテムだけが残り続けないようにする保険とする
+//   TTL で削除された取り置きの reservedStock は、Streams のワーカー（stream.HoldReleaser）が
+//   ReleaseByTTL で戻す（TTL の削除はトランザクションにできないため）
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
+)
+
+// 期限から TTL による自動削除までの猶予（スイーパーが解放するための時間）
+const holdTTLGrace = 24 * time.Hour
+
+// 期限切れの取り置きを探す GSI1 のパーティション数
+const holdShards = 8
+
+// 分散前の取り置きの GSI1PK
+const legacyHoldPartition = "HOLD"
+
+var (
+	ErrHoldNotFound = errors.New("stock hold not found")
+	ErrHoldConflict = errors.New("stock hold was modified concurrently")
+	// ErrReservedStockUnderflow は戻す数量が商品の reservedStock より多い（または商品がない）ことを表す
+	ErrReservedStockUnderflow = errors.New("reserved stock is lower than the quantity to release")
+)
+
+type holdRecord struct {
+	PK        string `dynamodbav:"PK"` // USER#<userId>
+	SK        string `dynamodbav:"SK"` // HOLD#<productId>
+	GSI1PK    string `dynamodbav:"GSI1PK"`
+	GSI1SK    string `dynamodbav:"GSI1SK"`
+	UserID    string `dynamodbav:"userId"`
+	ProductID string `dynamodbav:"productId"`
+	Quantity  int    `dynamodbav:"quantity"`
+	ExpiresAt string `dynamodbav:"expiresAt"`
+	CreatedAt string `dynamodbav:"createdAt"`
+	TTL       int64  `dynamodbav:"TTL"` // Unix Epoch秒（expiresAt + holdTTLGrace）
+}
+
+type HoldRepository struct {
+	db *DynamoDBClient
+}
+
+func NewHoldRepository(db *DynamoDBClient) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+// Reserve は取り置きを作成する（既に取り置きがある場合は数量と期限を置き換える）
+// 【使用API】TransactWriteItems
+//  1. Put: 取り置きアイテム（条件: 新規なら存在しないこと、置き換えなら数量が previousQty のまま）
+//  2. Update: 商品の reservedStock を hold.Quantity - previousQty だけ増減
+//
+// 【在庫の条件】ConditionExpression では四則演算ができないため、読み込んだ在庫数 observedStock を使う
+//
+//	増やす場合: stock >= :observed AND reservedStock <= :maxReserved（maxReserved = observedStock - 増加分）
+//	→ 書き込み時点で「取り置き後も stock - reservedStock >= 0」が保証される
+//
+// 在庫が足りない場合は ErrInsufficientStock、取り置きが同時に変更された場合は ErrHoldConflict を返す
+func (r *HoldRepository) Reserve(ctx context.Context, hold *domain.StockHold, previousQty, observedStock int) error {
+	now := time.Now()
+	hold.CreatedAt = now
+
+	rec := holdRecord{
+		PK:        "USER#" + hold.UserID,
+		SK:        "HOLD#" + hold.ProductID,
+		GSI1PK:    "HOLD#" + gsiShard(hold.UserID+"#"+hold.ProductID, holdShards),
+		GSI1SK:    holdGSI1SK(hold),
+		UserID:    hold.UserID,
+		ProductID: hold.ProductID,
+		Quantity:  hold.Quantity,
+		ExpiresAt: hold.ExpiresAt.UTC().Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339),
+		TTL:       ttlEpoch(hold.ExpiresAt.Add(holdTTLGrace)),
+	}
+	item, err := marshalWithTTL(rec)
+	if err != nil {
+		return err
+	}
+
+	put := &types.Put{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	if previousQty > 0 {
+		put.ConditionExpression = aws.String("quantity = :previous")
+		put.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberN{Value: strconv.Itoa(previousQty)},
+		}
+	}
+
+	delta := hold.Quantity - previousQty
+	update := &types.Update{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + hold.ProductID},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+		UpdateExpression: aws.String("SET reservedStock = if_not_exists(reservedStock, :zero) + :delta"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":zero":  &types.AttributeValueMemberN{Value: "0"},
+			":delta": &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+		},
+	}
+	if delta > 0 {
+		update.ConditionExpression = aws.String("attribute_exists(PK) AND stock >= :observed AND (attribute_not_exists(reservedStock) OR reservedStock <= :maxReserved)")
+		update.ExpressionAttributeValues[":observed"] = &types.AttributeValueMemberN{Value: strconv.Itoa(observedStock)}
+		update.ExpressionAttributeValues[":maxReserved"] = &types.AttributeValueMemberN{Value: strconv.Itoa(observedStock - delta)}
+	} else {
+		// 減らす場合は reservedStock が負にならないことだけを確認する
+		update.ConditionExpression = aws.String("reservedStock >= :release")
+		update.ExpressionAttributeValues[":release"] = &types.AttributeValueMemberN{Value: strconv.Itoa(-delta)}
+	}
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{{Put: put}, {Update: update}},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return ErrHoldConflict
+		}
+		if isConditionFailedAt(err, 1) {
+			return ErrInsufficientStock
+		}
+		return err
+	}
+	return nil
+}
+
+// Get はユーザーの商品の取り置きを取得する（期限切れでも解放前であれば返す）
+func (r *HoldRepository) Get(ctx context.Context, userID, productID string) (*domain.StockHold, error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "HOLD#" + productID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, ErrHoldNotFound
+	}
+
+	var rec holdRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return nil, err
+	}
+	return recordToHold(&rec), nil
+}
+
+// ListByUserID はユーザーの取り置きを全件取得する（商品IDをキーとしたマップ）
+func (r *HoldRepository) ListByUserID(ctx context.Context, userID string) (map[string]*domain.StockHold, error) {
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":sk": &types.AttributeValueMemberS{Value: "HOLD#"},
+		},
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	holds := make(map[string]*domain.StockHold, len(items))
+	for _, item := range items {
+		var rec holdRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		holds[rec.ProductID] = recordToHold(&rec)
+	}
+	return holds, nil
+}
+
+// Release は取り置きを削除し、商品の reservedStock を戻す
+// 【使用API】TransactWriteItems（Delete + Update）
+// 取り置きが読み込んだ時点から変わっていない（数量・期限が同じ）場合のみ解放する
+// → 同時に注文で消費された・作り直された取り置きを二重に解放しない
+// 取り置きが見つからない・変わっていた場合は ErrHoldNotFound を返す
+func (r *HoldRepository) Release(ctx context.Context, hold *domain.StockHold) error {
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			holdDelete(r.db.Table(), hold),
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + hold.ProductID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:    aws.String("SET reservedStock = reservedStock - :qty"),
+					ConditionExpression: aws.String("reservedStock >= :qty"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":qty": &types.AttributeValueMemberN{Value: strconv.Itoa(hold.Quantity)},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return ErrHoldNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListExpired は期限が now より前の取り置きを、GSI1 のパーティションごとに期限の古い順で最大 limit 件ずつ取得する（スイーパー用）
+// 【使用API】Query（GSI1: GSI1PK = HOLD#<シャード番号> または HOLD, GSI1SK < now）をパーティションごとに実行
+func (r *HoldRepository) ListExpired(ctx context.Context, now time.Time, limit int32) ([]*domain.StockHold, error) {
+	partitions := []string{legacyHoldPartition}
+	for shard := range holdShards {
+		partitions = append(partitions, "HOLD#"+strconv.Itoa(shard))
+	}
+
+	var holds []*domain.StockHold
+	for _, pk := range partitions {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			IndexName:              aws.String("GSI1"),
+			KeyConditionExpression: aws.String("GSI1PK = :pk AND GSI1SK < :now"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":  &types.AttributeValueMemberS{Value: pk},
+				":now": &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+			},
+			Limit: aws.Int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			var rec holdRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			holds = append(holds, recordToHold(&rec))
+		}
+	}
+	return holds, nil
+}
+
+// ReleaseByTTL は TTL で削除された取り置きの数量を商品の reservedStock から戻す（Streams のワーカー用）
+// 【使用API】TransactWriteItems
+//  1. Put: 適用済みマーク（STREAMSEQ#<sequenceNumber> / HOLDRELEASE。条件: attribute_not_exists(PK)）
+//  2. Update: 商品の reservedStock を quantity だけ減らす（条件: reservedStock >= :qty）
+//
+// sequenceNumber のレコードが適用済みの場合は戻さず applied=false を返す（再送されたイベントを二重に処理しない）
+// 商品がない・reservedStock が足りない場合は ErrReservedStockUnderflow を返す
+func (r *HoldRepository) ReleaseByTTL(ctx context.Context, sequenceNumber, productID string, quantity int) (applied bool, err error) {
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: r.db.Table(),
+					Item: map[string]types.AttributeValue{
+						"PK":         &types.AttributeValueMemberS{Value: "STREAMSEQ#" + sequenceNumber},
+						"SK":         &types.AttributeValueMemberS{Value: "HOLDRELEASE"},
+						ttlAttribute: ttlValue(ttlEpoch(time.Now().Add(streamMarkerTTL))),
+					},
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:    aws.String("SET reservedStock = reservedStock - :qty"),
+					ConditionExpression: aws.String("reservedStock >= :qty"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":qty": &types.AttributeValueMemberN{Value: strconv.Itoa(quantity)},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return false, nil
+		}
+		if isConditionFailedAt(err, 1) {
+			return false, ErrReservedStockUnderflow
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// holdDelete は取り置きを削除するトランザクション操作を返す
+// 数量・期限が hold と同じ場合のみ削除する（注文確定のトランザクションでも使う）
+func holdDelete(table *string, hold *domain.StockHold) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: table,
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "USER#" + hold.UserID},
+				"SK": &types.AttributeValueMemberS{Value: "HOLD#" + hold.ProductID},
+			},
+			ConditionExpression: aws.String("quantity = :qty AND expiresAt = :expiresAt"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":qty":       &types.AttributeValueMemberN{Value: strconv.Itoa(hold.Quantity)},
+				":expiresAt": &types.AttributeValueMemberS{Value: hold.ExpiresAt.UTC().Format(time.RFC3339)},
+			},
+		},
+	}
+}
+
+func holdGSI1SK(hold *domain.StockHold) string {
+	return hold.ExpiresAt.UTC().Format(time.RFC3339) + "#" + hold.UserID + "#" + hold.ProductID
+}
+
+func recordToHold(rec *holdRecord) *domain.StockHold {
+	return &domain.StockHold{
+		UserID:    rec.UserID,
+		ProductID: rec.ProductID,
+		Quantity:  rec.Quantity,
+		ExpiresAt: timeutil.ParseTime(rec.ExpiresAt),
+		CreatedAt: timeutil.ParseTime(rec.CreatedAt),
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+func TestHoldRepositoryShardsExpiryIndex(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 100, 0)
+	repo := NewHoldRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	partitions := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		hold := &domain.StockHold{UserID: fmt.Sprintf("u%d", i), ProductID: "p1", Quantity: 1, ExpiresAt: now.Add(-time.Minute)}
+		if err := repo.Reserve(ctx, hold, 0, 100-i); err != nil {
+			t.Fatalf("Reserve(%s) error = %v", hold.UserID, err)
+		}
+		pk, _ := fake.Get("USER#"+hold.UserID, "HOLD#p1")["GSI1PK"].(*types.AttributeValueMemberS)
+		partitions[pk.Value] = true
+	}
+	if partitions[legacyHoldPartition] || len(partitions) < 2 {
+		t.Errorf("GSI1PK partitions = %v, want holds spread over HOLD#<shard>", partitions)
+	}
+
+	// 分散前に作成した取り置き（GSI1PK = HOLD）も期限切れとして見つける
+	fake.Seed(dynamotest.Item(holdRecord{
+		PK: "USER#legacy", SK: "HOLD#p1", GSI1PK: legacyHoldPartition, GSI1SK: holdGSI1SK(&domain.StockHold{UserID: "legacy", ProductID: "p1", ExpiresAt: now.Add(-time.Hour)}),
+		UserID: "legacy", ProductID: "p1", Quantity: 1, ExpiresAt: now.Add(-time.Hour).UTC().Format(time.RFC3339),
+	}))
+	// 期限前の取り置きは含めない
+	if err := repo.Reserve(ctx, &domain.StockHold{UserID: "active", ProductID: "p1", Quantity: 1, ExpiresAt: now.Add(time.Hour)}, 0, 80); err != nil {
+		t.Fatalf("Reserve(active) error = %v", err)
+	}
+
+	holds, err := repo.ListExpired(ctx, now, 100)
+	if err != nil {
+		t.Fatalf("ListExpired() error = %v", err)
+	}
+	users := map[string]bool{}
+	for _, hold := range holds {
+		users[hold.UserID] = true
+	}
+	if len(holds) != 21 || !users["legacy"] || users["active"] {
+		t.Errorf("ListExpired() returned %d holds (%v), want the 20 sharded and 1 legacy expired holds", len(holds), users)
+	}
+}
+
+func TestHoldRepositoryReleaseByTTL(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 10, 3)
+	repo := NewHoldRepository(db)
+	ctx := context.Background()
+
+	applied, err := repo.ReleaseByTTL(ctx, "seq-1", "p1", 2)
+	if err != nil || !applied {
+		t.Fatalf("ReleaseByTTL() = %v, %v, want applied", applied, err)
+	}
+	// 同じイベントの再送では戻さない
+	applied, err = repo.ReleaseByTTL(ctx, "seq-1", "p1", 2)
+	if err != nil || applied {
+		t.Fatalf("ReleaseByTTL(redelivered) = %v, %v, want not applied", applied, err)
+	}
+	if got := numberAttr(fake.Get("PRODUCT#p1", "METADATA"), "reservedStock"); got != 1 {
+		t.Errorf("reservedStock = %d, want 1", got)
+	}
+
+	if _, err := repo.ReleaseByTTL(ctx, "seq-2", "p1", 2); !errors.Is(err, ErrReservedStockUnderflow) {
+		t.Errorf("ReleaseByTTL(more than reserved) error = %v, want %v", err, ErrReservedStockUnderflow)
+	}
+	if got := numberAttr(fake.Get("PRODUCT#p1", "METADATA"), "reservedStock"); got != 1 {
+		t.Errorf("reservedStock after underflow = %d, want 1", got)
+	}
+}
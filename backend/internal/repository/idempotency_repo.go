@@ -0,0 +1,148 @@
+// backend/internal/repository/idempotency_repo.go
+// 注文作成の冪等性キー（Idempotency-Key）のDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: IDEMPOTENCY#<key>   - パーティションキー（クライアントが指定したキー）
+//   SK: <userId>            - ソートキー（別ユーザーの同じキーと衝突しないように）
+//
+// 【状態遷移】
+//   Acquire:  PENDING で作成（attribute_not_exists(PK) で二重実行を防止）
+//   Complete: 注文確定後に COMPLETED + orderId を記録
+//   Release:  注文作成に失敗した場合は削除し、同じキーで再試行できるようにする
+//
+// 【TTL】24時間で自動削除
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// 冪等性キーの保持期間
+const IdempotencyKeyTTL = 24 * time.Hour
+
+const (
+	idempotencyStatusPending   = "PENDING"
+	idempotencyStatusCompleted = "COMPLETED"
+)
+
+var ErrIdempotencyInProgress = errors.New("request with this idempotency key is still in progress")
+
+type idempotencyRecord struct {
+	PK        string `dynamodbav:"PK"` // IDEMPOTENCY#<key>
+	SK        string `dynamodbav:"SK"` // <userId>
+	Status    string `dynamodbav:"status"`
+	OrderID   string `dynamodbav:"orderId,omitempty"`
+	CreatedAt string `dynamodbav:"createdAt"`
+	TTL       int64  `dynamodbav:"TTL"` // Unix Epoch秒（24時間後）
+}
+
+type IdempotencyRepository struct {
+	db *DynamoDBClient
+}
+
+func NewIdempotencyRepository(db *DynamoDBClient) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Acquire は冪等性キーを確保する
+// 【戻り値】
+//   - 新規に確保できた場合: ("", nil)
+//   - 既に注文が作成済みの場合: (作成済みの注文ID, nil)
+//   - 同じキーのリクエストが処理中の場合: ("", ErrIdempotencyInProgress)
+func (r *IdempotencyRepository) Acquire(ctx context.Context, key, userID string) (string, error) {
+	now := time.Now()
+	item, err := marshalWithTTL(idempotencyRecord{
+		PK:        "IDEMPOTENCY#" + key,
+		SK:        userID,
+		Status:    idempotencyStatusPending,
+		CreatedAt: now.Format(time.RFC3339),
+		TTL:       ttlEpoch(now.Add(IdempotencyKeyTTL)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err == nil {
+		return "", nil
+	}
+
+	var cfe *types.ConditionalCheckFailedException
+	if !errors.As(err, &cfe) {
+		return "", err
+	}
+
+	// 既にキーが存在する → 以前のリクエストの結果を確認
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key:       idempotencyKey(key, userID),
+		// 直前の Complete を確実に読むため強整合性読み込み
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Item == nil {
+		// 条件チェックと読み込みの間に Release された → 処理中として扱い、クライアントに再試行させる
+		return "", ErrIdempotencyInProgress
+	}
+
+	var rec idempotencyRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return "", err
+	}
+	if rec.Status != idempotencyStatusCompleted || rec.OrderID == "" {
+		return "", ErrIdempotencyInProgress
+	}
+
+	return rec.OrderID, nil
+}
+
+// Complete は冪等性キーに作成した注文IDを記録する
+// 【使用API】UpdateItem
+func (r *IdempotencyRepository) Complete(ctx context.Context, key, userID, orderID string) error {
+	_, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        r.db.Table(),
+		Key:              idempotencyKey(key, userID),
+		UpdateExpression: aws.String("SET #status = :status, orderId = :orderId"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status", // status は予約語
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status":  &types.AttributeValueMemberS{Value: idempotencyStatusCompleted},
+			":orderId": &types.AttributeValueMemberS{Value: orderID},
+		},
+	})
+	return err
+}
+
+// Release は処理に失敗した冪等性キーを削除する
+// 【使用API】DeleteItem
+func (r *IdempotencyRepository) Release(ctx context.Context, key, userID string) error {
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: r.db.Table(),
+		Key:       idempotencyKey(key, userID),
+	})
+	return err
+}
+
+func idempotencyKey(key, userID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "IDEMPOTENCY#" + key},
+		"SK": &types.AttributeValueMemberS{Value: userID},
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+// ErrorObserver は DynamoDB 操作のエラーを受け取る（メトリクス用）
+// code は DynamoDB のエラーコード（例: ConditionalCheckFailedException）
+// 期限切れ（ErrDynamoTimeout）は "Timeout"、それ以外の API エラーでない場合は "Unknown"
+type ErrorObserver func(repository, operation, code string)
+
+// WithErrorObserver は、操作が失敗するたびに observe を呼び出す DynamoDBClient を返す
+// repository はメトリクスのラベルに使う名前（例: "order"）
+// 元の DynamoDBClient は変更しない
+func (d *DynamoDBClient) WithErrorObserver(repository string, observe ErrorObserver) *DynamoDBClient {
+	return NewDynamoDBClientWithAPI(&instrumentedAPI{
+		api:        d.Client,
+		repository: repository,
+		observe:    observe,
+	}, d.TableName)
+}
+
+// instrumentedAPI は DynamoDBAPI の各操作のエラーを ErrorObserver に通知するラッパー
+type instrumentedAPI struct {
+	api        DynamoDBAPI
+	repository string
+	observe    ErrorObserver
+}
+
+func (a *instrumentedAPI) record(operation string, err error) {
+	if err == nil {
+		return
+	}
+	code := "Unknown"
+	var apiErr smithy.APIError
+	switch {
+	case errors.Is(err, ErrDynamoTimeout):
+		code = "Timeout"
+	case errors.As(err, &apiErr):
+		code = apiErr.ErrorCode()
+	}
+	a.observe(a.repository, operation, code)
+}
+
+func (a *instrumentedAPI) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	out, err := a.api.GetItem(ctx, params, optFns...)
+	a.record("GetItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	out, err := a.api.PutItem(ctx, params, optFns...)
+	a.record("PutItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	out, err := a.api.Query(ctx, params, optFns...)
+	a.record("Query", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	out, err := a.api.UpdateItem(ctx, params, optFns...)
+	a.record("UpdateItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	out, err := a.api.DeleteItem(ctx, params, optFns...)
+	a.record("DeleteItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	out, err := a.api.BatchWriteItem(ctx, params, optFns...)
+	a.record("BatchWriteItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out, err := a.api.BatchGetItem(ctx, params, optFns...)
+	a.record("BatchGetItem", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	out, err := a.api.TransactWriteItems(ctx, params, optFns...)
+	a.record("TransactWriteItems", err)
+	return out, err
+}
+
+func (a *instrumentedAPI) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	out, err := a.api.DescribeTable(ctx, params, optFns...)
+	a.record("DescribeTable", err)
+	return out, err
+}
@@ -14,6 +14,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -80,6 +82,82 @@ func (r *InventoryRepository) Create(ctx context.Context, log *domain.InventoryL
 	return err
 }
 
+// RecordStockChange は在庫変動ログを追加し、同時に商品の在庫数を log.NewStock に更新する
+// 【使用API】TransactWriteItems
+//  1. Put: 在庫変動ログ（INVLOG#<timestamp>）
+//  2. Update: 商品の stock・updatedAt を更新し version を1増やす
+//     （条件: attribute_exists(PK) かつ version = expectedVersion かつ reservedStock <= 新しい在庫数）
+//
+// 条件を満たさない場合はどちらも書き込まない（ログだけが残る・在庫だけが変わることがない）
+// 商品が存在しない場合は ErrProductNotFound、バージョンが違う場合は ErrProductVersionMismatch、
+// 在庫数が reservedStock を下回る場合は ErrStockBelowReserved を返す
+func (r *InventoryRepository) RecordStockChange(ctx context.Context, log *domain.InventoryLog, expectedVersion int) error {
+	now := time.Now()
+	log.Timestamp = now
+
+	item, err := attributevalue.MarshalMap(inventoryLogRecord{
+		PK:            "PRODUCT#" + log.ProductID,
+		SK:            "INVLOG#" + now.Format(time.RFC3339),
+		ProductID:     log.ProductID,
+		ChangeType:    log.ChangeType,
+		Quantity:      log.Quantity,
+		PreviousStock: log.PreviousStock,
+		NewStock:      log.NewStock,
+		Reason:        log.Reason,
+		OrderID:       log.OrderID,
+		CreatedAt:     now.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	values := map[string]types.AttributeValue{
+		":stock": &types.AttributeValueMemberN{Value: strconv.Itoa(log.NewStock)},
+		":now":   &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+		":one":   &types.AttributeValueMemberN{Value: "1"},
+	}
+	// 仮押さえ・取り置き中の数量より在庫を減らすと、確保済みの注文が確定できなくなるため断る（ProductRepository.Update と同じ）
+	condition := "attribute_exists(PK) AND " + productVersionCondition(expectedVersion, values) +
+		" AND (attribute_not_exists(reservedStock) OR reservedStock <= :stock)"
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: r.db.Table(),
+					Item:      item,
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + log.ProductID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:                    aws.String("SET stock = :stock, updatedAt = :now ADD version :one"),
+					ConditionExpression:                 aws.String(condition),
+					ExpressionAttributeValues:           values,
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 1) {
+			var tce *types.TransactionCanceledException
+			errors.As(err, &tce)
+			old := tce.CancellationReasons[1].Item
+			if err := productUpdateConditionError(old, &domain.Product{Version: expectedVersion, Stock: log.NewStock}); err != nil {
+				return err
+			}
+			return productConditionError(old)
+		}
+		return err
+	}
+	return nil
+}
+
 // GetByProductID は商品の在庫変動履歴を取得する（新しい順）
 // 【使用API】Query + ScanIndexForward=false + Limit
 func (r *InventoryRepository) GetByProductID(ctx context.Context, productID string, limit int32) ([]*domain.InventoryLog, error) {
@@ -129,13 +207,14 @@ func (r *InventoryRepository) GetByProductIDWithRange(ctx context.Context, produ
 		ScanIndexForward: aws.Bool(false), // 新しい順
 	}
 
-	result, err := r.db.Client.Query(ctx, input)
+	// 1ページ（1MB）で打ち切らず、期間内の全件を取得する
+	items, err := queryAllPages(ctx, r.db.Client, input, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	logs := make([]*domain.InventoryLog, 0, len(result.Items))
-	for _, item := range result.Items {
+	logs := make([]*domain.InventoryLog, 0, len(items))
+	for _, item := range items {
 		var rec inventoryLogRecord
 		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
 			return nil, err
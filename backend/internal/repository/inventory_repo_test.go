@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+// seedInventoryLog は在庫変動ログを直接書き込む（Create は秒単位の SK のため、同じ秒に複数件書けない）
+func seedInventoryLog(fake *dynamotest.Fake, productID string, at time.Time, previous, current int) {
+	fake.Seed(dynamotest.Item(inventoryLogRecord{
+		PK:            "PRODUCT#" + productID,
+		SK:            "INVLOG#" + at.Format(time.RFC3339),
+		ProductID:     productID,
+		ChangeType:    "ADJUST",
+		Quantity:      current - previous,
+		PreviousStock: previous,
+		NewStock:      current,
+		Reason:        "test",
+		CreatedAt:     at.Format(time.RFC3339),
+	}))
+}
+
+func TestInventoryRepositoryGetByProductIDWithRangeDrainsPages(t *testing.T) {
+	db, fake := newTestDB()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		seedInventoryLog(fake, "p1", base.Add(time.Duration(i)*time.Hour), i, i+1)
+	}
+	seedInventoryLog(fake, "p1", base.Add(48*time.Hour), 5, 6) // 期間外
+	// 1ページ2件にして、LastEvaluatedKey を返す2ページ目以降を読ませる
+	fake.PageSize = 2
+	repo := NewInventoryRepository(db)
+
+	logs, err := repo.GetByProductIDWithRange(context.Background(), "p1", base, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetByProductIDWithRange() error = %v", err)
+	}
+	if len(logs) != 5 {
+		t.Fatalf("got %d logs, want 5", len(logs))
+	}
+	// 新しい順
+	for i, l := range logs {
+		if want := 5 - i; l.NewStock != want {
+			t.Errorf("logs[%d].NewStock = %d, want %d", i, l.NewStock, want)
+		}
+	}
+	if got := fake.CallCount("Query"); got != 3 {
+		t.Errorf("Query called %d times, want 3", got)
+	}
+}
+
+// inventoryLogItems はテーブル内の在庫変動ログを返す
+func inventoryLogItems(fake *dynamotest.Fake) []map[string]types.AttributeValue {
+	var logs []map[string]types.AttributeValue
+	for _, item := range fake.Items() {
+		if sk, ok := item["SK"].(*types.AttributeValueMemberS); ok && strings.HasPrefix(sk.Value, "INVLOG#") {
+			logs = append(logs, item)
+		}
+	}
+	return logs
+}
+
+func TestInventoryRepositoryRecordStockChangeAtomic(t *testing.T) {
+	tests := []struct {
+		name            string
+		seed            bool // 商品を作成しておくか
+		reserved        int
+		newStock        int
+		expectedVersion int
+		wantErr         error
+	}{
+		{name: "ログと在庫の両方を書き込む", seed: true, newStock: 15},
+		{name: "バージョンが違う場合はどちらも書き込まない", seed: true, newStock: 15, expectedVersion: 3, wantErr: ErrProductVersionMismatch},
+		{name: "商品がない場合はログも書き込まない", newStock: 15, wantErr: ErrProductNotFound},
+		{name: "仮押さえ数を下回る場合はどちらも書き込まない", seed: true, reserved: 4, newStock: 3, wantErr: ErrStockBelowReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed {
+				seedProduct(fake, "p1", 10, tt.reserved)
+			}
+			repo := NewInventoryRepository(db)
+
+			log := &domain.InventoryLog{
+				ProductID:     "p1",
+				ChangeType:    "ADJUST",
+				Quantity:      tt.newStock - 10,
+				PreviousStock: 10,
+				NewStock:      tt.newStock,
+				Reason:        "棚卸し",
+			}
+			err := repo.RecordStockChange(context.Background(), log, tt.expectedVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RecordStockChange() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// 1回のトランザクションだけで書き込む（個別の書き込みをしない）
+			if got := fake.CallCount("TransactWriteItems"); got != 1 {
+				t.Errorf("TransactWriteItems called %d times, want 1", got)
+			}
+			for _, op := range []string{"PutItem", "UpdateItem"} {
+				if got := fake.CallCount(op); got != 0 {
+					t.Errorf("%s called %d times, want 0", op, got)
+				}
+			}
+
+			logs := inventoryLogItems(fake)
+			product := fake.Get("PRODUCT#p1", "METADATA")
+			if tt.wantErr != nil {
+				if len(logs) != 0 {
+					t.Errorf("%d logs were written although the stock update failed", len(logs))
+				}
+				if product != nil && numberAttr(product, "stock") != 10 {
+					t.Errorf("stock = %d, want unchanged 10", numberAttr(product, "stock"))
+				}
+				return
+			}
+			if len(logs) != 1 {
+				t.Fatalf("got %d logs, want 1", len(logs))
+			}
+			if got := numberAttr(logs[0], "newStock"); got != tt.newStock {
+				t.Errorf("log newStock = %d, want %d", got, tt.newStock)
+			}
+			if got := numberAttr(product, "stock"); got != tt.newStock {
+				t.Errorf("stock = %d, want %d", got, tt.newStock)
+			}
+			if got := numberAttr(product, "version"); got != 1 {
+				t.Errorf("version = %d, want 1", got)
+			}
+		})
+	}
+}
@@ -5,21 +5,31 @@
 //
 //	複数の書き込み操作を「全て成功」または「全て失敗」で実行する仕組み
 //	→ 注文確定では以下を1つのトランザクションで実行:
-//	  1. 注文ヘッダー作成（Put）
+//	  1. 注文ヘッダー作成（Put）+ 注文所有者の逆引きアイテム作成（Put）+ ユーザーの注文の集計の加算（Update）
 //	  2. 注文明細作成（Put × 商品数）
-//	  3. 在庫減算（Update × 商品数）条件付き
+//	  3. 在庫減算（Update × 商品数）条件付き・仮押さえ分も消費
 //	  4. カートクリア（Delete × 商品数）
+//	  5. クーポン利用回数の加算（Update）条件付き・クーポン適用時のみ
+//	  6. 在庫変動ログ（OUT）の作成（Put × 商品数）
+//	  7. 在庫の取り置きの消費（Delete × 取り置きのある商品数）CART_RESERVATIONS_ENABLED=true の場合のみ
+//	  8. 在庫の仮押さえアイテムの削除（Delete）条件付き・仮押さえした商品がある場合のみ
+//	→ 1回のトランザクションは最大100操作のため、1注文の商品数は MaxOrderProducts まで
 //
 // 【キー設計】
 //
 //	注文ヘッダー: PK=USER#<userId>, SK=ORDER#<orderId>
+//	              GSI1PK=ORDERS#<yyyy-mm>, GSI1SK=<createdAt>#<orderId>（月別）
+//	              GSI2PK=STATUS#<status>, GSI2SK=<そのステータスになった日時>#<orderId>（ステータス別）
 //	注文明細:     PK=ORDER#<orderId>, SK=ITEM#<productId>
+//	注文所有者:   PK=ORDER#<orderId>, SK=OWNER（注文IDだけでヘッダーを引くための逆引き）
+//	注文の集計:   PK=USER#<userId>, SK=ORDERSTATS（注文数・支払額の合計・ステータス別件数。注文確定・ステータス変更と同じトランザクションで更新）
 package repository
 
 import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -37,20 +47,116 @@ var (
 	ErrOrderNotFound       = errors.New("order not found")
 	ErrInsufficientStock   = errors.New("insufficient stock")
 	ErrTransactionConflict = errors.New("transaction conflict: please retry")
+	ErrOrderAlreadyPaid    = errors.New("order is already paid or refunded")
+	ErrOrderAlreadyExists  = errors.New("order already exists")
+	ErrTooManyOrderItems   = errors.New("too many items in order")
+	ErrOrderStatsNotFound  = errors.New("order stats not found")
+	ErrOrderStatsConflict  = errors.New("order stats were updated concurrently")
 )
 
+// TransactWriteItems 1回あたりの操作数の上限
+const maxTransactWriteItems = 100
+
+// MaxOrderProducts は1回の注文に含められる商品の種類数の上限
+// 注文確定のトランザクションは 固定5操作（注文ヘッダー・注文所有者・注文の集計・クーポン・仮押さえ）+ 商品ごとに4操作
+// （注文明細・在庫減算・カート削除・在庫変動ログ）のため、(100 - 5) / 4 = 23 商品まで
+// 取り置きを消費する場合は商品ごとに1操作増えるため、OrderExceedsTransaction で確認する
+const MaxOrderProducts = (maxTransactWriteItems - 5) / 4
+
+// OrderExceedsTransaction は products 商品（うち holds 商品は取り置きを消費）の注文が
+// 1回のトランザクションに収まらない場合に true を返す
+func OrderExceedsTransaction(products, holds int) bool {
+	return 5+4*products+holds > maxTransactWriteItems
+}
+
+// orderStatuses は注文の集計にステータス別の件数を持つステータス
+var orderStatuses = []string{
+	domain.OrderStatusPending,
+	domain.OrderStatusConfirmed,
+	domain.OrderStatusShipped,
+	domain.OrderStatusDelivered,
+	domain.OrderStatusCancelled,
+}
+
+// InsufficientStockError は在庫不足になった商品を示すエラー
+// errors.Is(err, ErrInsufficientStock) で判定でき、errors.As で商品IDを取り出せる
+type InsufficientStockError struct {
+	ProductID string
+}
+
+func (e *InsufficientStockError) Error() string {
+	return "insufficient stock: product " + e.ProductID
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
 type orderRecord struct {
-	PK          string `dynamodbav:"PK"`     // USER#<userId>
-	SK          string `dynamodbav:"SK"`     // ORDER#<orderId>
-	GSI1PK      string `dynamodbav:"GSI1PK"` // ORDERS#<yyyy-mm>（月別検索用）
-	GSI1SK      string `dynamodbav:"GSI1SK"` // <timestamp>#<orderId>
-	OrderID     string `dynamodbav:"orderId"`
-	UserID      string `dynamodbav:"userId"`
-	Status      string `dynamodbav:"status"`
-	TotalAmount int    `dynamodbav:"totalAmount"`
-	ItemCount   int    `dynamodbav:"itemCount"`
-	CreatedAt   string `dynamodbav:"createdAt"`
-	UpdatedAt   string `dynamodbav:"updatedAt"`
+	PK      string `dynamodbav:"PK"`               // USER#<userId>
+	SK      string `dynamodbav:"SK"`               // ORDER#<orderId>
+	GSI1PK  string `dynamodbav:"GSI1PK"`           // ORDERS#<yyyy-mm>（月別検索用）
+	GSI1SK  string `dynamodbav:"GSI1SK"`           // <timestamp>#<orderId>
+	GSI2PK  string `dynamodbav:"GSI2PK,omitempty"` // STATUS#<status>（ステータス別検索用。GSI2 導入前の注文は UpdateStatus で設定される）
+	GSI2SK  string `dynamodbav:"GSI2SK,omitempty"` // <timestamp>#<orderId>
+	OrderID string `dynamodbav:"orderId"`
+	UserID  string `dynamodbav:"userId"`
+	Status  string `dynamodbav:"status"`
+	// 支払いステータス（未設定の旧データは UNPAID として扱う）
+	PaymentStatus    string `dynamodbav:"paymentStatus,omitempty"`
+	PaidAt           string `dynamodbav:"paidAt,omitempty"`
+	PaymentReference string `dynamodbav:"paymentReference,omitempty"`
+	// 金額（subtotal 未設定の旧データは totalAmount を割引前の合計として扱う）
+	Subtotal       int    `dynamodbav:"subtotal,omitempty"`
+	CouponCode     string `dynamodbav:"couponCode,omitempty"`
+	DiscountAmount int    `dynamodbav:"discountAmount,omitempty"`
+	TotalAmount    int    `dynamodbav:"totalAmount"`
+	// 消費税（税率・税額・税込合計は注文確定時の値を保存する。導入前の注文は属性なし = 税額 0）
+	TaxRate    float64 `dynamodbav:"taxRate,omitempty"`
+	TaxAmount  int     `dynamodbav:"taxAmount,omitempty"`
+	GrandTotal int     `dynamodbav:"grandTotal,omitempty"`
+	ItemCount  int     `dynamodbav:"itemCount"`
+	// 配送先住所（Map型で保存）
+	ShippingAddress *addressRecord `dynamodbav:"shippingAddress,omitempty"`
+	CreatedAt       string         `dynamodbav:"createdAt"`
+	UpdatedAt       string         `dynamodbav:"updatedAt"`
+}
+
+type addressRecord struct {
+	ZipCode    string `dynamodbav:"zipCode"`
+	Prefecture string `dynamodbav:"prefecture"`
+	City       string `dynamodbav:"city"`
+	Address    string `dynamodbav:"address"`
+}
+
+// orderOwnerRecord は注文IDから所有ユーザーを逆引きするためのアイテム
+// 注文ヘッダーは USER#<userId> 配下にあるため、管理者が注文IDだけで操作する際に使う
+type orderOwnerRecord struct {
+	PK     string `dynamodbav:"PK"` // ORDER#<orderId>
+	SK     string `dynamodbav:"SK"` // OWNER
+	UserID string `dynamodbav:"userId"`
+}
+
+// orderStatsRecord はユーザーの注文の集計アイテム
+// ステータス別の件数は statusCountAttribute の属性（confirmedCount など）に持つ
+//   - 注文確定・ステータス変更のトランザクションで ADD する（初回はアイテムがなくても ADD で作成される）
+//   - revision は更新のたびに1増やし、導入前の注文を集計した初期値を書き込む際の楽観的ロックに使う
+//   - backfilled は導入前の注文を含めて集計済みかどうか（false の場合は導入後の注文の分しか含まない）
+type orderStatsRecord struct {
+	PK          string `dynamodbav:"PK"` // USER#<userId>
+	SK          string `dynamodbav:"SK"` // ORDERSTATS
+	OrderCount  int    `dynamodbav:"orderCount"`
+	TotalSpent  int    `dynamodbav:"totalSpent"` // キャンセルした注文を除く税込支払額の合計
+	LastOrderAt string `dynamodbav:"lastOrderAt,omitempty"`
+	Revision    int    `dynamodbav:"revision"`
+	Backfilled  bool   `dynamodbav:"backfilled"`
+}
+
+// UserOrderStats はユーザーの注文の集計アイテムの内容
+type UserOrderStats struct {
+	Summary    *domain.OrderSummary
+	Revision   int  // BackfillUserStats に渡す
+	Backfilled bool // false の場合は集計アイテム導入前の注文を含まない
 }
 
 type orderItemRecord struct {
@@ -82,14 +188,31 @@ func NewOrderRepository(db *DynamoDBClient) *OrderRepository {
 //   - 各操作に ConditionExpression を設定可能
 //
 // 【実行する操作】
-//  1. Put: 注文ヘッダー
+//  1. Put: 注文ヘッダー（+ 注文所有者の逆引きアイテム）
+//     Update: ユーザーの注文の集計（ADD orderCount :one, totalSpent :grandTotal。最初の注文ではアイテムが作成される）
 //  2. Put: 注文明細（商品数分）
-//  3. Update: 商品の在庫減算（条件: Stock >= 購入数量）
+//  3. Update: 商品の在庫減算（条件: stock >= 購入数量）
 //  4. Delete: カートアイテム（商品数分）
-func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order, items []domain.OrderItem, cartItems []domain.CartItem) error {
+//  5. Update: クーポンの利用回数加算（order.CouponCode が空でない場合のみ。条件: usedCount < maxUses）
+//  6. Put: 在庫変動ログ（ChangeType=OUT、商品数分）
+//     - previousStock / newStock は stockBefore（仮押さえ時に読んだ在庫数）から算出する
+//     - トランザクション内では現在の在庫を読めないため、同時に在庫調整があった場合はずれることがある
+//  7. Delete: 在庫の取り置き（holds に含まれる商品のみ。条件: 数量・期限が読み込んだ時点のまま）
+//     - 取り置き分は reservedStock に含まれているため、在庫減算で max(取り置き数, 購入数量) を仮押さえから消費する
+//     - 取り置きが同時に解放・変更された場合は ErrTransactionConflict を返す
+//  8. Delete: 在庫の仮押さえアイテム（reservation が nil でない場合のみ。条件: 仮押さえ済みの商品数が変わっていない）
+//     - 期限切れでスイーパーが仮押さえを解放していた場合は ErrTransactionConflict を返す
+//
+// 操作数が1回のトランザクションの上限を超える場合は ErrTooManyOrderItems を返す
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order, items []domain.OrderItem, cartItems []domain.CartItem, stockBefore map[string]int, holds map[string]*domain.StockHold, reservation *domain.StockReservation) error {
+	if len(cartItems) > MaxOrderProducts || OrderExceedsTransaction(len(items), len(holds)) {
+		return ErrTooManyOrderItems
+	}
+
 	now := time.Now()
 	orderID := uuid.New().String()
 	order.ID = orderID
+	order.PaymentStatus = domain.PaymentStatusUnpaid // 確定時は未払い（支払いは MarkPaid で別に記録する）
 	order.CreatedAt = now
 	order.UpdatedAt = now
 
@@ -98,17 +221,27 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 
 	// 1. 注文ヘッダーのPut
 	orderRec := orderRecord{
-		PK:          "USER#" + order.UserID,
-		SK:          "ORDER#" + order.ID,
-		GSI1PK:      "ORDERS#" + now.Format("2006-01"),        // 月別検索用
-		GSI1SK:      now.Format(time.RFC3339) + "#" + orderID, // タイムスタンプ順
-		OrderID:     orderID,
-		UserID:      order.UserID,
-		Status:      domain.OrderStatusConfirmed,
-		TotalAmount: order.TotalAmount,
-		ItemCount:   order.ItemCount,
-		CreatedAt:   now.Format(time.RFC3339),
-		UpdatedAt:   now.Format(time.RFC3339),
+		PK:              "USER#" + order.UserID,
+		SK:              "ORDER#" + order.ID,
+		GSI1PK:          "ORDERS#" + now.Format("2006-01"),        // 月別検索用
+		GSI1SK:          now.Format(time.RFC3339) + "#" + orderID, // タイムスタンプ順
+		GSI2PK:          "STATUS#" + domain.OrderStatusConfirmed,  // ステータス別検索用
+		GSI2SK:          now.Format(time.RFC3339) + "#" + orderID,
+		OrderID:         orderID,
+		UserID:          order.UserID,
+		Status:          domain.OrderStatusConfirmed,
+		PaymentStatus:   domain.PaymentStatusUnpaid,
+		Subtotal:        order.Subtotal,
+		CouponCode:      order.CouponCode,
+		DiscountAmount:  order.DiscountAmount,
+		TotalAmount:     order.TotalAmount,
+		TaxRate:         order.TaxRate,
+		TaxAmount:       order.TaxAmount,
+		GrandTotal:      order.GrandTotal,
+		ItemCount:       order.ItemCount,
+		ShippingAddress: addressToRecord(order.ShippingAddress),
+		CreatedAt:       now.Format(time.RFC3339),
+		UpdatedAt:       now.Format(time.RFC3339),
 	}
 	orderAV, err := attributevalue.MarshalMap(orderRec)
 	if err != nil {
@@ -116,11 +249,37 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 	}
 	transactionItems = append(transactionItems, types.TransactWriteItem{
 		Put: &types.Put{
-			TableName: r.db.Table(),
-			Item:      orderAV,
+			TableName:           r.db.Table(),
+			Item:                orderAV,
+			ConditionExpression: aws.String("attribute_not_exists(PK)"), // 注文IDの重複防止
+		},
+	})
+
+	ownerAV, err := attributevalue.MarshalMap(orderOwnerRecord{
+		PK:     "ORDER#" + orderID,
+		SK:     "OWNER",
+		UserID: order.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	transactionItems = append(transactionItems, types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           r.db.Table(),
+			Item:                ownerAV,
+			ConditionExpression: aws.String("attribute_not_exists(PK)"),
 		},
 	})
 
+	// ユーザーの注文の集計に加算（最初の注文ではアイテムがないが、ADD・SET で作成される）
+	transactionItems = append(transactionItems, orderStatsUpdate(r.db.Table(), order.UserID, now,
+		"SET lastOrderAt = :now, updatedAt = :now ADD orderCount :one, totalSpent :amount, #newStatus :one, revision :one",
+		map[string]string{"#newStatus": statusCountAttribute(domain.OrderStatusConfirmed)},
+		map[string]types.AttributeValue{
+			":amount": &types.AttributeValueMemberN{Value: strconv.Itoa(order.GrandTotal)},
+		},
+	))
+
 	// 2. 注文明細のPut（商品数分）
 	for _, item := range items {
 		itemRec := orderItemRecord{
@@ -147,9 +306,17 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 
 	// 3. 在庫減算のUpdate（条件付きUpdate）
 	// 【重要】ConditionExpression で在庫チェック
-	//   - Stock >= :qty の場合のみ更新を実行
+	//   - stock >= :qty の場合のみ更新を実行
+	//   - 属性名は productRecord と同じ小文字の "stock"（GetByID が読む属性と同一）
 	//   - 在庫不足の場合はトランザクション全体が失敗
+	// stockStart は失敗理由（CancellationReasons）の位置から商品を特定するために使う
+	stockStart := len(transactionItems)
 	for _, item := range items {
+		// 仮押さえから消費する数量（取り置きが購入数量より多い場合は余った取り置き分も戻す）
+		reserved := item.Quantity
+		if hold, ok := holds[item.ProductID]; ok {
+			reserved = max(reserved, hold.Quantity)
+		}
 		transactionItems = append(transactionItems, types.TransactWriteItem{
 			Update: &types.Update{
 				TableName: r.db.Table(),
@@ -157,13 +324,15 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 					"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + item.ProductID},
 					"SK": &types.AttributeValueMemberS{Value: "METADATA"},
 				},
-				UpdateExpression: aws.String("SET stock = stock - :qty, updatedAt = :now"),
-				// 【ConditionExpression】在庫が購入数量以上あることを確認
+				// 仮押さえ（reservedStock）も同時に消費する
+				UpdateExpression: aws.String("SET stock = stock - :qty, reservedStock = reservedStock - :reserved, updatedAt = :now"),
+				// 【ConditionExpression】在庫が購入数量以上あり、仮押さえ済みであることを確認
 				// この条件を満たさない場合、トランザクション全体がロールバック
-				ConditionExpression: aws.String("stock >= :qty"),
+				ConditionExpression: aws.String("stock >= :qty AND reservedStock >= :reserved"),
 				ExpressionAttributeValues: map[string]types.AttributeValue{
-					":qty": &types.AttributeValueMemberN{Value: strconv.Itoa(item.Quantity)},
-					":now": &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+					":qty":      &types.AttributeValueMemberN{Value: strconv.Itoa(item.Quantity)},
+					":reserved": &types.AttributeValueMemberN{Value: strconv.Itoa(reserved)},
+					":now":      &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
 				},
 			},
 		})
@@ -182,6 +351,53 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 		})
 	}
 
+	// 5. クーポン利用回数の加算
+	couponIndex := -1
+	if order.CouponCode != "" {
+		couponIndex = len(transactionItems)
+		transactionItems = append(transactionItems, couponUseUpdate(r.db.Table(), order.CouponCode))
+	}
+
+	// 6. 在庫変動ログのPut（商品数分）
+	// SK に注文IDを付けて、同じ秒に別の注文で同じ商品が売れてもログが上書きされないようにする
+	for _, item := range items {
+		previousStock := stockBefore[item.ProductID]
+		logAV, err := attributevalue.MarshalMap(inventoryLogRecord{
+			PK:            "PRODUCT#" + item.ProductID,
+			SK:            "INVLOG#" + now.Format(time.RFC3339) + "#" + orderID,
+			ProductID:     item.ProductID,
+			ChangeType:    "OUT",
+			Quantity:      item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      previousStock - item.Quantity,
+			Reason:        "order",
+			OrderID:       orderID,
+			CreatedAt:     now.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		transactionItems = append(transactionItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: r.db.Table(),
+				Item:      logAV,
+			},
+		})
+	}
+
+	// 7. 在庫の取り置きのDelete（取り置きのある商品数分）
+	holdStart := len(transactionItems)
+	for _, item := range items {
+		if hold, ok := holds[item.ProductID]; ok {
+			transactionItems = append(transactionItems, holdDelete(r.db.Table(), hold))
+		}
+	}
+
+	// 8. 在庫の仮押さえアイテムのDelete
+	if reservation != nil {
+		transactionItems = append(transactionItems, reservationConsume(r.db.Table(), reservation))
+	}
+
 	// トランザクション実行
 	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
 		TransactItems: transactionItems,
@@ -192,17 +408,34 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 		//   - CancellationReasons で各操作の失敗理由を確認可能
 		//   - ConditionalCheckFailed: 条件を満たさなかった（在庫不足など）
 		//   - TransactionConflict: 別のトランザクションと競合
+		//
+		// 【失敗した操作の特定】
+		//   CancellationReasons は TransactItems と同じ順序で返る（成功扱いの操作は Code=None）
+		//   → 位置から在庫減算の対象商品を逆算し、どの商品が在庫不足かを返す
 		var tce *types.TransactionCanceledException
 		if errors.As(err, &tce) {
 			// 各操作の失敗理由をチェック
-			for _, reason := range tce.CancellationReasons {
-				if reason.Code != nil {
-					switch *reason.Code {
-					case "ConditionalCheckFailed":
-						return ErrInsufficientStock
-					case "TransactionConflict":
+			for i, reason := range tce.CancellationReasons {
+				if reason.Code == nil {
+					continue
+				}
+				switch *reason.Code {
+				case "ConditionalCheckFailed":
+					if i >= stockStart && i < stockStart+len(items) {
+						return &InsufficientStockError{ProductID: items[i-stockStart].ProductID}
+					}
+					// 検証後に他の注文がクーポンを使い切った
+					if i == couponIndex {
+						return ErrCouponExhausted
+					}
+					// 取り置き・仮押さえが期限切れで解放された・作り直された → 再試行で読み直す
+					if i >= holdStart {
 						return ErrTransactionConflict
 					}
+					// 注文ヘッダー・逆引きアイテムの重複
+					return ErrOrderAlreadyExists
+				case "TransactionConflict":
+					return ErrTransactionConflict
 				}
 			}
 		}
@@ -212,9 +445,11 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order,
 	return nil
 }
 
-// GetByUserIDはユーザーの注文一覧を取得する
+// GetByUserIDはユーザーの注文一覧を全件取得する（新しい順、データエクスポート用）
+// 【使用API】Query - LastEvaluatedKey がなくなるまで読み進める（1MB を超える注文ヘッダーも欠けずに返す）
+// defaultMaxQueryPages ページを読んでも続きがある場合は ErrTooManyPages を返す（途中までの一覧は返さない）
 func (r *OrderRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Order, error) {
-	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
 		TableName:              r.db.Table(),
 		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
@@ -222,13 +457,13 @@ func (r *OrderRepository) GetByUserID(ctx context.Context, userID string) ([]*do
 			":sk": &types.AttributeValueMemberS{Value: "ORDER#"},
 		},
 		ScanIndexForward: aws.Bool(false), // 最新注文を先頭に
-	})
+	}, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	orders := make([]*domain.Order, 0, len(result.Items))
-	for _, item := range result.Items {
+	orders := make([]*domain.Order, 0, len(items))
+	for _, item := range items {
 		var rec orderRecord
 		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
 			return nil, err
@@ -239,8 +474,236 @@ func (r *OrderRepository) GetByUserID(ctx context.Context, userID string) ([]*do
 	return orders, nil
 }
 
+// ListAllByUserID はユーザーの全注文ヘッダーを新しい順に取得する（集計用）
+// 【使用API】Query（PK = USER#<userId>, begins_with(SK, ORDER#)）を最後のページまで繰り返す
+// ProjectionExpression で集計に使う属性（ステータス・金額・日時）だけを読むため、配送先住所などは含まない
+// ConsistentRead で直前に確定した注文も含める（集計アイテムの初期値を書き込むため）
+// maxPages ページを読んでも続きがある場合は ErrTooManyPages を返す
+func (r *OrderRepository) ListAllByUserID(ctx context.Context, userID string, maxPages int) ([]*domain.Order, error) {
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ProjectionExpression:   aws.String("orderId, userId, #status, totalAmount, taxAmount, grandTotal, createdAt"),
+		ConsistentRead:         aws.Bool(true),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status", // 予約語
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":sk": &types.AttributeValueMemberS{Value: "ORDER#"},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}, maxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*domain.Order, 0, len(items))
+	for _, item := range items {
+		var rec orderRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		orders = append(orders, recordToOrder(&rec))
+	}
+	return orders, nil
+}
+
+// ListByUserIDはユーザーの注文を新しい順に最大 limit 件取得する
+// status を指定した場合はそのステータスの注文だけを返す（空文字は全件）
+// 【ページング】
+//   - 続きがある場合は次ページのカーソルを返す（最終ページは空文字）
+//   - カーソルは PK=USER#<userId> の注文ヘッダーのキーであることを検証し、
+//     他のユーザーのカーソルや注文以外のキーは ErrInvalidCursor とする
+//
+// 【FilterExpression の注意】
+//
+//	フィルタは limit 件を読んだ「後」に適用されるため、1ページの件数は limit 未満（0件もありうる）になる
+//	件数ではなく DynamoDB のカーソルで続きを判定すること（nextCursor が空になるまで読み進める）
+func (r *OrderRepository) ListByUserID(ctx context.Context, userID, status string, limit int32, cursor string) ([]*domain.Order, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if startKey != nil && !isUserOrderKey(startKey, userID) {
+		return nil, "", ErrInvalidCursor
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":sk": &types.AttributeValueMemberS{Value: "ORDER#"},
+		},
+		ScanIndexForward:  aws.Bool(false), // 最新注文を先頭に
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	}
+	if status != "" {
+		// status は予約語のため ExpressionAttributeNames で置き換える
+		input.FilterExpression = aws.String("#status = :status")
+		input.ExpressionAttributeNames = map[string]string{"#status": "status"}
+		input.ExpressionAttributeValues[":status"] = &types.AttributeValueMemberS{Value: status}
+	}
+
+	result, err := r.db.Client.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	orders := make([]*domain.Order, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rec orderRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, "", err
+		}
+		orders = append(orders, recordToOrder(&rec))
+	}
+
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return orders, next, nil
+}
+
+// isUserOrderKey は key が userID の注文ヘッダーのキー（PK=USER#<userId>, SK=ORDER#...）かを判定する
+func isUserOrderKey(key map[string]types.AttributeValue, userID string) bool {
+	if len(key) != 2 {
+		return false
+	}
+	pk, ok := key["PK"].(*types.AttributeValueMemberS)
+	if !ok || pk.Value != "USER#"+userID {
+		return false
+	}
+	sk, ok := key["SK"].(*types.AttributeValueMemberS)
+	return ok && strings.HasPrefix(sk.Value, "ORDER#")
+}
+
+// GetByMonthは指定月（yyyy-mm）の全ユーザーの注文を新しい順に取得する（管理者用）
+// 【使用API】Query（GSI1: GSI1PK = ORDERS#<yyyy-mm>）+ ScanIndexForward=false
+// 【ページング】最大 limit 件を返し、続きがある場合はカーソルを返す
+func (r *OrderRepository) GetByMonth(ctx context.Context, yyyymm string, limit int32, cursor string) ([]*domain.Order, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "ORDERS#" + yyyymm},
+		},
+		ScanIndexForward:  aws.Bool(false), // GSI1SK=<timestamp>#<orderId> の降順 = 新しい順
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	orders := make([]*domain.Order, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rec orderRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, "", err
+		}
+		orders = append(orders, recordToOrder(&rec))
+	}
+
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return orders, next, nil
+}
+
+// ListByStatus は指定ステータスの全ユーザーの注文を、そのステータスになった日時の古い順に取得する（管理者用）
+// 【使用API】Query（GSI2: GSI2PK = STATUS#<status>）+ ScanIndexForward=true
+// 古い順にするのは、出荷待ちなどの注文を古いものから処理できるようにするため
+// 【ページング】最大 limit 件を返し、続きがある場合はカーソルを返す
+// GSI2 導入前に作成され、その後ステータスが変わっていない注文は含まれない
+func (r *OrderRepository) ListByStatus(ctx context.Context, status string, limit int32, cursor string) ([]*domain.Order, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("GSI2PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "STATUS#" + status},
+		},
+		ScanIndexForward:  aws.Bool(true),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	orders := make([]*domain.Order, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rec orderRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, "", err
+		}
+		orders = append(orders, recordToOrder(&rec))
+	}
+
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return orders, next, nil
+}
+
+// EachByMonth は指定月（yyyy-mm）の全注文を新しい順に1ページずつ fn に渡す（CSVエクスポート用）
+// 【使用API】Query（GSI1: GSI1PK = ORDERS#<yyyy-mm>）を QueryPaginator で最後のページまで辿る
+// 全件をメモリに載せず、ページ単位で処理するため件数が多い月でもメモリ使用量が一定に保たれる
+// fn がエラーを返した場合はそこで中断し、そのエラーを返す
+func (r *OrderRepository) EachByMonth(ctx context.Context, yyyymm string, fn func(orders []*domain.Order) error) error {
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "ORDERS#" + yyyymm},
+		},
+		ScanIndexForward: aws.Bool(false),
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		orders := make([]*domain.Order, 0, len(result.Items))
+		for _, item := range result.Items {
+			var rec orderRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return err
+			}
+			orders = append(orders, recordToOrder(&rec))
+		}
+
+		if err := fn(orders); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetByIDは注文詳細を取得する
-func (r *OrderRepository) GetByID(ctx context.Context, userID, orderID string) (*domain.Order, error) {
+// 明細は itemLimit 件ずつ返し、続きがある場合は order.ItemsNextToken に次ページのトークンを設定する
+func (r *OrderRepository) GetByID(ctx context.Context, userID, orderID string, itemLimit int32, itemsToken string) (*domain.Order, error) {
 	// 注文ヘッダー取得
 	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: r.db.Table(),
@@ -262,51 +725,457 @@ func (r *OrderRepository) GetByID(ctx context.Context, userID, orderID string) (
 	}
 	order := recordToOrder(&rec)
 
-	// 注文明細取得
-	items, err := r.GetOrderItems(ctx, orderID)
+	// クライアントが切断した（ctx がキャンセルされた）場合は明細を読みに行かない
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 注文明細取得（ヘッダーを userID で取得できた = 所有者確認済み）
+	items, nextToken, err := r.queryOrderItems(ctx, orderID, itemLimit, itemsToken)
 	if err != nil {
 		return nil, err
 	}
 	order.Items = items
+	order.ItemsNextToken = nextToken
 
 	return order, nil
 }
 
-// GetOrderItemsは注文明細を取得する
-func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID string) ([]domain.OrderItem, error) {
-	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
-		TableName:              r.db.Table(),
-		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+// GetOrderItemsは userID の注文の明細を最大 limit 件取得する
+// 【所有者の確認】
+//
+//	注文明細（PK=ORDER#<orderId>）にはユーザーの情報がないため、
+//	先に注文ヘッダー（PK=USER#<userId>, SK=ORDER#<orderId>）の存在を確認する
+//	他のユーザーの注文・存在しない注文は ErrOrderNotFound を返す
+func (r *OrderRepository) GetOrderItems(ctx context.Context, userID, orderID string, limit int32, nextToken string) ([]domain.OrderItem, string, error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
+		},
+		ProjectionExpression: aws.String("PK"), // 存在確認のみ
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if result.Item == nil {
+		return nil, "", ErrOrderNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return r.queryOrderItems(ctx, orderID, limit, nextToken)
+}
+
+// GetItemsByOrderID は注文の明細を全件取得する
+// 所有者を確認しないため、Streams のワーカーなどの内部処理か、GetByID で所有者を確認した後にのみ使う
+func (r *OrderRepository) GetItemsByOrderID(ctx context.Context, orderID string) ([]domain.OrderItem, error) {
+	items, _, err := r.queryOrderItems(ctx, orderID, 0, "")
+	return items, err
+}
+
+// queryOrderItemsは注文明細を最大 limit 件取得する
+// 所有者を確認しないため、呼び出し側で注文ヘッダーを userID で取得済みの場合にのみ使う
+// 【ページング】
+//   - limit <= 0 の場合は全件取得する
+//   - 続きがある場合は nextToken を返す（最終ページは空文字）
+//   - Query は1回あたり1MBで打ち切られるため、limit に達するまで LastEvaluatedKey で読み進める
+func (r *OrderRepository) queryOrderItems(ctx context.Context, orderID string, limit int32, nextToken string) ([]domain.OrderItem, string, error) {
+	startKey, err := decodeCursor(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]domain.OrderItem, 0)
+	for {
+		input := &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
+				":sk": &types.AttributeValueMemberS{Value: "ITEM#"},
+			},
+			ExclusiveStartKey: startKey,
+		}
+		if limit > 0 {
+			input.Limit = aws.Int32(limit - int32(len(items)))
+		}
+
+		result, err := r.db.Client.Query(ctx, input)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, item := range result.Items {
+			var rec orderItemRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, "", err
+			}
+			items = append(items, recordToOrderItem(&rec))
+		}
+
+		startKey = result.LastEvaluatedKey
+		if startKey == nil || (limit > 0 && int32(len(items)) >= limit) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	token, err := encodeCursor(startKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, token, nil
+}
+
+// GetOwnerは注文IDから注文したユーザーのIDを取得する
+// 【使用API】GetItem（PK=ORDER#<orderId>, SK=OWNER）
+// 【注意】逆引きアイテム導入前の注文は見つからない（ErrOrderNotFound）
+func (r *OrderRepository) GetOwner(ctx context.Context, orderID string) (string, error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
+			"SK": &types.AttributeValueMemberS{Value: "OWNER"},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Item == nil {
+		return "", ErrOrderNotFound
+	}
+
+	var rec orderOwnerRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return "", err
+	}
+	return rec.UserID, nil
+}
+
+// MarkPaidは注文を入金済み（PAID）にし、入金日時と決済リファレンスを記録する
+// 【使用API】UpdateItem（条件付き）
+// 【二重計上の防止】
+//
+//	ConditionExpression で未入金（または paymentStatus 未設定の旧データ）の場合のみ更新する
+//	条件失敗時は ReturnValuesOnConditionCheckFailure で旧アイテムを受け取り、
+//	存在しない注文（ErrOrderNotFound）と入金済み・返金済み（ErrOrderAlreadyPaid）を区別する
+func (r *OrderRepository) MarkPaid(ctx context.Context, userID, orderID, reference string, paidAt time.Time) error {
+	_, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
+		},
+		UpdateExpression:    aws.String("SET paymentStatus = :paid, paidAt = :paidAt, paymentReference = :ref, updatedAt = :now"),
+		ConditionExpression: aws.String("attribute_exists(PK) AND (attribute_not_exists(paymentStatus) OR paymentStatus = :unpaid)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
-			":sk": &types.AttributeValueMemberS{Value: "ITEM#"},
+			":paid":   &types.AttributeValueMemberS{Value: domain.PaymentStatusPaid},
+			":unpaid": &types.AttributeValueMemberS{Value: domain.PaymentStatusUnpaid},
+			":paidAt": &types.AttributeValueMemberS{Value: paidAt.Format(time.RFC3339)},
+			":ref":    &types.AttributeValueMemberS{Value: reference},
+			":now":    &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
 		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			if ccf.Item == nil {
+				return ErrOrderNotFound
+			}
+			return ErrOrderAlreadyPaid
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateStatus は注文のステータスを変更し、変更後の注文を返す（明細は含まない）
+// 【使用API】GetItem（変更前のステータス・金額の読み込み）+ TransactWriteItems
+//  1. Update: 注文ヘッダーのステータス（条件: ステータスが読み込んだ時点のまま）
+//  2. Update: ユーザーの注文の集計（ステータスが変わる場合のみ）
+//     - ステータス別の件数を旧ステータスから新ステータスへ移す
+//     - キャンセルする場合は支払額の合計から税込支払額を引く（キャンセルを取り消す場合は足し戻す）
+//
+// 【GSI のキーの変更】
+//
+//	GSI2PK（STATUS#<status>）も同じ更新式で書き換える
+//	GSI のキー属性の変更は通常の属性の更新と同じで、DynamoDB がインデックスの旧エントリを削除し新しいエントリを追加する
+//	→ 旧ステータスの一覧に注文が残ることはない（インデックスへの反映は結果整合）
+//
+// 読み込み後に別の更新でステータスが変わった場合は ErrTransactionConflict を返す
+func (r *OrderRepository) UpdateStatus(ctx context.Context, userID, orderID, status string) (*domain.Order, error) {
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: "ORDER#" + orderID},
+	}
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      r.db.Table(),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if result.Item == nil {
+		return nil, ErrOrderNotFound
+	}
+	var rec orderRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return nil, err
+	}
 
-	items := make([]domain.OrderItem, 0, len(result.Items))
-	for _, item := range result.Items {
-		var rec orderItemRecord
-		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+	now := time.Now()
+	nowStr := now.Format(time.RFC3339)
+	transactionItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:           r.db.Table(),
+				Key:                 key,
+				UpdateExpression:    aws.String("SET #status = :status, GSI2PK = :gsi2pk, GSI2SK = :gsi2sk, updatedAt = :now"),
+				ConditionExpression: aws.String("#status = :oldStatus"),
+				ExpressionAttributeNames: map[string]string{
+					"#status": "status", // 予約語
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":status":    &types.AttributeValueMemberS{Value: status},
+					":oldStatus": &types.AttributeValueMemberS{Value: rec.Status},
+					":gsi2pk":    &types.AttributeValueMemberS{Value: "STATUS#" + status},
+					":gsi2sk":    &types.AttributeValueMemberS{Value: nowStr + "#" + orderID},
+					":now":       &types.AttributeValueMemberS{Value: nowStr},
+				},
+			},
+		},
+	}
+
+	if rec.Status != status {
+		// キャンセルした注文は支払額の合計に含めない
+		spentDelta := 0
+		grandTotal := recordToOrder(&rec).GrandTotal
+		switch {
+		case status == domain.OrderStatusCancelled:
+			spentDelta = -grandTotal
+		case rec.Status == domain.OrderStatusCancelled:
+			spentDelta = grandTotal
+		}
+		transactionItems = append(transactionItems, orderStatsUpdate(r.db.Table(), userID, now,
+			"SET updatedAt = :now ADD #oldStatus :minusOne, #newStatus :one, totalSpent :spent, revision :one",
+			map[string]string{
+				"#oldStatus": statusCountAttribute(rec.Status),
+				"#newStatus": statusCountAttribute(status),
+			},
+			map[string]types.AttributeValue{
+				":minusOne": &types.AttributeValueMemberN{Value: "-1"},
+				":spent":    &types.AttributeValueMemberN{Value: strconv.Itoa(spentDelta)},
+			},
+		))
+	}
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactionItems,
+	})
+	if err != nil {
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return nil, ErrTransactionConflict
+		}
+		return nil, err
+	}
+
+	rec.Status = status
+	rec.GSI2PK = "STATUS#" + status
+	rec.GSI2SK = nowStr + "#" + orderID
+	rec.UpdatedAt = nowStr
+	return recordToOrder(&rec), nil
+}
+
+// GetUserStats はユーザーの注文の集計アイテムを取得する
+// 【使用API】GetItem（ConsistentRead: 直前の注文確定・ステータス変更を反映した値を読む）
+// アイテムがない（注文を確定したことがない、または集計アイテム導入前の注文しかない）場合は ErrOrderStatsNotFound を返す
+func (r *OrderRepository) GetUserStats(ctx context.Context, userID string) (*UserOrderStats, error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      r.db.Table(),
+		Key:            orderStatsKey(userID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, ErrOrderStatsNotFound
+	}
+
+	var rec orderStatsRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return nil, err
+	}
+	summary := &domain.OrderSummary{
+		TotalOrders:  rec.OrderCount,
+		TotalSpent:   rec.TotalSpent,
+		StatusCounts: make(map[string]int),
+	}
+	for _, status := range orderStatuses {
+		av, ok := result.Item[statusCountAttribute(status)]
+		if !ok {
+			continue
+		}
+		var count int
+		if err := attributevalue.Unmarshal(av, &count); err != nil {
 			return nil, err
 		}
-		items = append(items, recordToOrderItem(&rec))
+		if count != 0 {
+			summary.StatusCounts[status] = count
+		}
+	}
+	if rec.LastOrderAt != "" {
+		lastOrderAt := timeutil.ParseTime(rec.LastOrderAt)
+		summary.LastOrderAt = &lastOrderAt
+	}
+
+	return &UserOrderStats{
+		Summary:    summary,
+		Revision:   rec.Revision,
+		Backfilled: rec.Backfilled,
+	}, nil
+}
+
+// BackfillUserStats は全注文を集計した値（summary）でユーザーの注文の集計アイテムを置き換え、集計済み（backfilled）にする
+// 集計アイテム導入前の注文を含めるために使う
+// 【ConditionExpression】アイテムがない、または revision が読み込んだ時点（revision）のまま
+//   - 集計の途中で注文確定・ステータス変更があった場合は、その分を上書きで失わないように ErrOrderStatsConflict を返す
+//   - revision にはアイテムがない場合は 0 を渡す
+func (r *OrderRepository) BackfillUserStats(ctx context.Context, userID string, summary *domain.OrderSummary, revision int) error {
+	now := time.Now().Format(time.RFC3339)
+	item := orderStatsKey(userID)
+	item["orderCount"] = &types.AttributeValueMemberN{Value: strconv.Itoa(summary.TotalOrders)}
+	item["totalSpent"] = &types.AttributeValueMemberN{Value: strconv.Itoa(summary.TotalSpent)}
+	item["revision"] = &types.AttributeValueMemberN{Value: strconv.Itoa(revision + 1)}
+	item["backfilled"] = &types.AttributeValueMemberBOOL{Value: true}
+	item["updatedAt"] = &types.AttributeValueMemberS{Value: now}
+	for _, status := range orderStatuses {
+		item[statusCountAttribute(status)] = &types.AttributeValueMemberN{Value: strconv.Itoa(summary.StatusCounts[status])}
+	}
+	if summary.LastOrderAt != nil {
+		item["lastOrderAt"] = &types.AttributeValueMemberS{Value: summary.LastOrderAt.Format(time.RFC3339)}
+	}
+
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR revision = :revision"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":revision": &types.AttributeValueMemberN{Value: strconv.Itoa(revision)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrOrderStatsConflict
+		}
+		return err
 	}
+	return nil
+}
 
-	return items, nil
+// orderStatsKey はユーザーの注文の集計アイテムのキー
+func orderStatsKey(userID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: "ORDERSTATS"},
+	}
+}
+
+// statusCountAttribute はステータス別の件数を持つ属性名（例: CONFIRMED → confirmedCount）
+func statusCountAttribute(status string) string {
+	return strings.ToLower(status) + "Count"
+}
+
+// orderStatsUpdate はユーザーの注文の集計を更新するトランザクション操作を返す
+// updateExpression では :now（更新日時）と :one（1）を使える。それ以外の名前・値は names / values で渡す
+// 条件は付けない（ADD はアイテム・属性がない場合は 0 から加算するため、最初の注文でもそのまま使える）
+func orderStatsUpdate(table *string, userID string, now time.Time, updateExpression string, names map[string]string, values map[string]types.AttributeValue) types.TransactWriteItem {
+	values[":now"] = &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)}
+	values[":one"] = &types.AttributeValueMemberN{Value: "1"}
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 table,
+			Key:                       orderStatsKey(userID),
+			UpdateExpression:          aws.String(updateExpression),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		},
+	}
 }
 
 func recordToOrder(r *orderRecord) *domain.Order {
+	paymentStatus := r.PaymentStatus
+	if paymentStatus == "" {
+		paymentStatus = domain.PaymentStatusUnpaid
+	}
+
+	var paidAt *time.Time
+	if r.PaidAt != "" {
+		t := timeutil.ParseTime(r.PaidAt)
+		paidAt = &t
+	}
+
+	subtotal := r.Subtotal
+	if subtotal == 0 {
+		subtotal = r.TotalAmount + r.DiscountAmount
+	}
+	// 税込合計が未設定の旧データは、税抜の支払額 + 税額（税額も未設定なら 0）とする
+	grandTotal := r.GrandTotal
+	if grandTotal == 0 {
+		grandTotal = r.TotalAmount + r.TaxAmount
+	}
+
 	return &domain.Order{
-		ID:          r.OrderID,
-		UserID:      r.UserID,
-		Status:      r.Status,
-		TotalAmount: r.TotalAmount,
-		ItemCount:   r.ItemCount,
-		CreatedAt:   timeutil.ParseTime(r.CreatedAt),
-		UpdatedAt:   timeutil.ParseTime(r.UpdatedAt),
+		ID:               r.OrderID,
+		UserID:           r.UserID,
+		Status:           r.Status,
+		PaymentStatus:    paymentStatus,
+		PaidAt:           paidAt,
+		PaymentReference: r.PaymentReference,
+		Subtotal:         subtotal,
+		CouponCode:       r.CouponCode,
+		DiscountAmount:   r.DiscountAmount,
+		TotalAmount:      r.TotalAmount,
+		TaxRate:          r.TaxRate,
+		TaxAmount:        r.TaxAmount,
+		GrandTotal:       grandTotal,
+		ItemCount:        r.ItemCount,
+		ShippingAddress:  recordToAddress(r.ShippingAddress),
+		CreatedAt:        timeutil.ParseTime(r.CreatedAt),
+		UpdatedAt:        timeutil.ParseTime(r.UpdatedAt),
+	}
+}
+
+func addressToRecord(a *domain.Address) *addressRecord {
+	if a == nil {
+		return nil
+	}
+	return &addressRecord{
+		ZipCode:    a.ZipCode,
+		Prefecture: a.Prefecture,
+		City:       a.City,
+		Address:    a.Address,
+	}
+}
+
+func recordToAddress(r *addressRecord) *domain.Address {
+	if r == nil {
+		return nil
+	}
+	return &domain.Address{
+		ZipCode:    r.ZipCode,
+		Prefecture: r.Prefecture,
+		City:       r.City,
+		Address:    r.Address,
 	}
 }
 
@@ -0,0 +1,469 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+// seedProduct は在庫数と仮押さえ数を指定して商品を直接書き込む
+func seedProduct(fake *dynamotest.Fake, id string, stock, reserved int) {
+	item := dynamotest.Item(productRecord{
+		PK:        "PRODUCT#" + id,
+		SK:        "METADATA",
+		GSI1PK:    "PRODUCT",
+		GSI1SK:    "CATEGORY#test#" + id,
+		ID:        id,
+		Name:      "商品" + id,
+		Price:     1000,
+		Category:  "test",
+		Stock:     stock,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	})
+	item["reservedStock"] = &types.AttributeValueMemberN{Value: strconv.Itoa(reserved)}
+	fake.Seed(item)
+}
+
+// numberAttr は数値属性の値を返す（属性がない場合は -1）
+func numberAttr(item map[string]types.AttributeValue, name string) int {
+	av, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return -1
+	}
+	n, err := strconv.Atoi(av.Value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// newTestOrder は products の商品を1個ずつ購入する注文・明細・カートを作る
+func newTestOrder(products ...string) (*domain.Order, []domain.OrderItem, []domain.CartItem) {
+	order := &domain.Order{UserID: "u1", Subtotal: 1000 * len(products), TotalAmount: 1000 * len(products), GrandTotal: 1100 * len(products), ItemCount: len(products)}
+	items := make([]domain.OrderItem, 0, len(products))
+	cartItems := make([]domain.CartItem, 0, len(products))
+	for _, id := range products {
+		items = append(items, domain.OrderItem{ProductID: id, ProductName: "商品" + id, Price: 1000, Quantity: 1})
+		cartItems = append(cartItems, domain.CartItem{UserID: "u1", ProductID: id, Quantity: 1})
+	}
+	return order, items, cartItems
+}
+
+func TestOrderRepositoryCreateOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		seed        func(fake *dynamotest.Fake)
+		products    []string
+		coupon      string
+		wantErr     error
+		wantProduct string // InsufficientStockError の商品ID
+	}{
+		{
+			name: "注文を確定",
+			seed: func(fake *dynamotest.Fake) {
+				seedProduct(fake, "p1", 5, 1)
+				seedProduct(fake, "p2", 3, 1)
+			},
+			products: []string{"p1", "p2"},
+		},
+		{
+			name: "2つ目の商品が在庫不足",
+			seed: func(fake *dynamotest.Fake) {
+				seedProduct(fake, "p1", 5, 1)
+				seedProduct(fake, "p2", 0, 1)
+			},
+			products:    []string{"p1", "p2"},
+			wantErr:     ErrInsufficientStock,
+			wantProduct: "p2",
+		},
+		{
+			name: "クーポンを使い切っている",
+			seed: func(fake *dynamotest.Fake) {
+				seedProduct(fake, "p1", 5, 1)
+				fake.Seed(dynamotest.Item(map[string]any{"PK": "COUPON#SALE", "SK": "METADATA", "usedCount": 3, "maxUses": 3}))
+			},
+			products: []string{"p1"},
+			coupon:   "SALE",
+			wantErr:  ErrCouponExhausted,
+		},
+		{
+			name:     "商品数がトランザクションの上限を超える",
+			products: make([]string, MaxOrderProducts+1),
+			wantErr:  ErrTooManyOrderItems,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed != nil {
+				tt.seed(fake)
+			}
+			now := time.Now()
+			for _, id := range tt.products {
+				seedCartItem(fake, "u1", id, 1, 1, now)
+			}
+			before := fake.Len()
+			repo := NewOrderRepository(db)
+
+			order, items, cartItems := newTestOrder(tt.products...)
+			order.CouponCode = tt.coupon
+			err := repo.CreateOrder(context.Background(), order, items, cartItems, map[string]int{}, nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var ise *InsufficientStockError
+				if tt.wantProduct != "" && (!errors.As(err, &ise) || ise.ProductID != tt.wantProduct) {
+					t.Errorf("CreateOrder() error = %v, want product %s", err, tt.wantProduct)
+				}
+				// 失敗した場合は何も書き込まれない
+				if fake.Len() != before {
+					t.Errorf("table has %d items after failure, want %d", fake.Len(), before)
+				}
+				return
+			}
+
+			for _, id := range tt.products {
+				if fake.Get("USER#u1", "CART#"+id) != nil {
+					t.Errorf("cart item %s was not deleted", id)
+				}
+				if got := numberAttr(fake.Get("PRODUCT#"+id, "METADATA"), "reservedStock"); got != 0 {
+					t.Errorf("reservedStock of %s = %d, want 0", id, got)
+				}
+			}
+			if got := numberAttr(fake.Get("PRODUCT#p1", "METADATA"), "stock"); got != 4 {
+				t.Errorf("stock of p1 = %d, want 4", got)
+			}
+			if owner, err := repo.GetOwner(context.Background(), order.ID); err != nil || owner != "u1" {
+				t.Errorf("GetOwner() = %q, %v", owner, err)
+			}
+			stats, err := repo.GetUserStats(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("GetUserStats() error = %v", err)
+			}
+			if stats.Summary.TotalOrders != 1 || stats.Summary.TotalSpent != order.GrandTotal {
+				t.Errorf("stats = %+v, want 1 order of %d", stats.Summary, order.GrandTotal)
+			}
+		})
+	}
+}
+
+func TestOrderRepositoryGetByID(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		itemLimit int32
+		wantErr   error
+		wantItems int
+		wantNext  bool
+	}{
+		{name: "明細を全件取得", userID: "u1", itemLimit: 10, wantItems: 3},
+		{name: "明細をページ分割", userID: "u1", itemLimit: 2, wantItems: 2, wantNext: true},
+		{name: "他のユーザーの注文", userID: "u2", itemLimit: 10, wantErr: ErrOrderNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			for _, id := range []string{"p1", "p2", "p3"} {
+				seedProduct(fake, id, 5, 1)
+			}
+			repo := NewOrderRepository(db)
+			order, items, cartItems := newTestOrder("p1", "p2", "p3")
+			if err := repo.CreateOrder(context.Background(), order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+
+			got, err := repo.GetByID(context.Background(), tt.userID, order.ID, tt.itemLimit, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(got.Items) != tt.wantItems || (got.ItemsNextToken != "") != tt.wantNext {
+				t.Errorf("GetByID() items = %d, next = %q", len(got.Items), got.ItemsNextToken)
+			}
+			if got.Status != domain.OrderStatusConfirmed || got.GrandTotal != order.GrandTotal {
+				t.Errorf("GetByID() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestOrderRepositoryUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		hook       func(ctx context.Context, op string, input any) error
+		wantErr    error
+		wantSpent  int
+		wantCounts map[string]int
+	}{
+		{
+			name:       "発送済みに変更",
+			status:     domain.OrderStatusShipped,
+			wantSpent:  1100,
+			wantCounts: map[string]int{domain.OrderStatusShipped: 1},
+		},
+		{
+			name:       "キャンセルは支払額の合計から除く",
+			status:     domain.OrderStatusCancelled,
+			wantSpent:  0,
+			wantCounts: map[string]int{domain.OrderStatusCancelled: 1},
+		},
+		{
+			name:   "読み込み後に別の更新でステータスが変わった",
+			status: domain.OrderStatusShipped,
+			hook: func(ctx context.Context, op string, input any) error {
+				if op == "TransactWriteItems" {
+					return dynamotest.TransactionCanceled("ConditionalCheckFailed", "None")
+				}
+				return nil
+			},
+			wantErr:    ErrTransactionConflict,
+			wantSpent:  1100,
+			wantCounts: map[string]int{domain.OrderStatusConfirmed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			seedProduct(fake, "p1", 5, 1)
+			repo := NewOrderRepository(db)
+			order, items, cartItems := newTestOrder("p1")
+			if err := repo.CreateOrder(context.Background(), order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+
+			fake.Hook = tt.hook
+			updated, err := repo.UpdateStatus(context.Background(), "u1", order.ID, tt.status)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateStatus() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && updated.Status != tt.status {
+				t.Errorf("UpdateStatus() status = %s, want %s", updated.Status, tt.status)
+			}
+
+			fake.Hook = nil
+			stats, err := repo.GetUserStats(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("GetUserStats() error = %v", err)
+			}
+			if stats.Summary.TotalSpent != tt.wantSpent {
+				t.Errorf("TotalSpent = %d, want %d", stats.Summary.TotalSpent, tt.wantSpent)
+			}
+			if len(stats.Summary.StatusCounts) != len(tt.wantCounts) {
+				t.Errorf("StatusCounts = %v, want %v", stats.Summary.StatusCounts, tt.wantCounts)
+			}
+			for status, want := range tt.wantCounts {
+				if got := stats.Summary.StatusCounts[status]; got != want {
+					t.Errorf("StatusCounts[%s] = %d, want %d", status, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("存在しない注文", func(t *testing.T) {
+		db, _ := newTestDB()
+		_, err := NewOrderRepository(db).UpdateStatus(context.Background(), "u1", "missing", domain.OrderStatusShipped)
+		if !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("UpdateStatus() error = %v, want %v", err, ErrOrderNotFound)
+		}
+	})
+}
+
+func TestOrderRepositoryUserStatsAccumulates(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 5, 1)
+	seedProduct(fake, "p2", 5, 1)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	if _, err := repo.GetUserStats(ctx, "u1"); !errors.Is(err, ErrOrderStatsNotFound) {
+		t.Fatalf("GetUserStats() before the first order error = %v, want %v", err, ErrOrderStatsNotFound)
+	}
+
+	var orderIDs []string
+	for _, id := range []string{"p1", "p2"} {
+		order, items, cartItems := newTestOrder(id)
+		if err := repo.CreateOrder(ctx, order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+			t.Fatalf("CreateOrder(%s) error = %v", id, err)
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	steps := []struct {
+		name       string
+		status     string // 空の場合はステータスを変えない
+		wantSpent  int
+		wantCounts map[string]int
+	}{
+		{name: "2件目の注文は既存の集計に加算する", wantSpent: 2200, wantCounts: map[string]int{domain.OrderStatusConfirmed: 2}},
+		{name: "キャンセルで支払額を引く", status: domain.OrderStatusCancelled, wantSpent: 1100,
+			wantCounts: map[string]int{domain.OrderStatusConfirmed: 1, domain.OrderStatusCancelled: 1}},
+		{name: "キャンセルの取り消しで足し戻す", status: domain.OrderStatusConfirmed, wantSpent: 2200,
+			wantCounts: map[string]int{domain.OrderStatusConfirmed: 2}},
+	}
+	for i, step := range steps {
+		if step.status != "" {
+			if _, err := repo.UpdateStatus(ctx, "u1", orderIDs[0], step.status); err != nil {
+				t.Fatalf("%s: UpdateStatus() error = %v", step.name, err)
+			}
+		}
+		stats, err := repo.GetUserStats(ctx, "u1")
+		if err != nil {
+			t.Fatalf("%s: GetUserStats() error = %v", step.name, err)
+		}
+		// 注文数はステータスを変えても変わらない
+		if stats.Summary.TotalOrders != 2 || stats.Summary.TotalSpent != step.wantSpent {
+			t.Errorf("%s: orders, spent = %d, %d, want 2, %d", step.name, stats.Summary.TotalOrders, stats.Summary.TotalSpent, step.wantSpent)
+		}
+		if !maps.Equal(stats.Summary.StatusCounts, step.wantCounts) {
+			t.Errorf("%s: StatusCounts = %v, want %v", step.name, stats.Summary.StatusCounts, step.wantCounts)
+		}
+		// 注文確定・ステータス変更のたびに revision が進む
+		if want := 2 + i; stats.Revision != want {
+			t.Errorf("%s: Revision = %d, want %d", step.name, stats.Revision, want)
+		}
+		if stats.Backfilled {
+			t.Errorf("%s: Backfilled = true, want false", step.name)
+		}
+		if stats.Summary.LastOrderAt == nil {
+			t.Errorf("%s: LastOrderAt = nil", step.name)
+		}
+	}
+}
+
+func TestOrderRepositoryBackfillUserStats(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 5, 1)
+	seedProduct(fake, "p2", 5, 1)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	// 導入前の注文（3件・3300円）を含めて集計した値
+	lastOrderAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	backfill := &domain.OrderSummary{
+		TotalOrders:  3,
+		TotalSpent:   3300,
+		StatusCounts: map[string]int{domain.OrderStatusConfirmed: 3},
+		LastOrderAt:  &lastOrderAt,
+	}
+
+	order, items, cartItems := newTestOrder("p1")
+	if err := repo.CreateOrder(ctx, order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	stats, err := repo.GetUserStats(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+
+	// 読み込んだ後に注文が確定した場合は置き換えない（その注文の分を失わない）
+	if err := repo.BackfillUserStats(ctx, "u1", backfill, stats.Revision-1); !errors.Is(err, ErrOrderStatsConflict) {
+		t.Fatalf("BackfillUserStats() with a stale revision error = %v, want %v", err, ErrOrderStatsConflict)
+	}
+	if got, _ := repo.GetUserStats(ctx, "u1"); got.Summary.TotalOrders != 1 || got.Backfilled {
+		t.Errorf("stats after the conflict = %+v, backfilled %v, want unchanged", got.Summary, got.Backfilled)
+	}
+
+	if err := repo.BackfillUserStats(ctx, "u1", backfill, stats.Revision); err != nil {
+		t.Fatalf("BackfillUserStats() error = %v", err)
+	}
+	// 置き換えた後の注文は、置き換えた値に加算する
+	order, items, cartItems = newTestOrder("p2")
+	if err := repo.CreateOrder(ctx, order, items, cartItems, map[string]int{}, nil, nil); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, err := repo.GetUserStats(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if !got.Backfilled {
+		t.Error("Backfilled = false, want true")
+	}
+	if got.Summary.TotalOrders != 4 || got.Summary.TotalSpent != 4400 || got.Summary.StatusCounts[domain.OrderStatusConfirmed] != 4 {
+		t.Errorf("stats = %+v, want 4 orders of 4400", got.Summary)
+	}
+	if got.Summary.LastOrderAt == nil || !got.Summary.LastOrderAt.After(lastOrderAt) {
+		t.Errorf("LastOrderAt = %v, want the latest order", got.Summary.LastOrderAt)
+	}
+}
+
+// seedOrder は作成日時を指定して注文ヘッダーを直接書き込む（GSI1 の月別パーティションに載る）
+func seedOrder(fake *dynamotest.Fake, userID, orderID string, createdAt time.Time) {
+	created := createdAt.UTC().Format(time.RFC3339)
+	fake.Seed(dynamotest.Item(orderRecord{
+		PK:          "USER#" + userID,
+		SK:          "ORDER#" + orderID,
+		GSI1PK:      "ORDERS#" + createdAt.UTC().Format("2006-01"),
+		GSI1SK:      created + "#" + orderID,
+		OrderID:     orderID,
+		UserID:      userID,
+		Status:      domain.OrderStatusConfirmed,
+		TotalAmount: 1000,
+		ItemCount:   1,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}))
+}
+
+func TestOrderRepositoryGetByMonth(t *testing.T) {
+	db, fake := newTestDB()
+	repo := NewOrderRepository(db)
+	seedOrder(fake, "u1", "dec-last", time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC))
+	seedOrder(fake, "u1", "jan-1", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	seedOrder(fake, "u2", "jan-2", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	seedOrder(fake, "u1", "jan-3", time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC))
+	seedOrder(fake, "u2", "feb-1", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name  string
+		month string
+		limit int32
+		want  []string
+	}{
+		{name: "月内の全ユーザーの注文を新しい順に返す", month: "2025-01", limit: 10, want: []string{"jan-3", "jan-2", "jan-1"}},
+		{name: "カーソルでページングしても順序が保たれる", month: "2025-01", limit: 2, want: []string{"jan-3", "jan-2", "jan-1"}},
+		{name: "注文のない月", month: "2025-03", limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			cursor := ""
+			for {
+				orders, next, err := repo.GetByMonth(context.Background(), tt.month, tt.limit, cursor)
+				if err != nil {
+					t.Fatalf("GetByMonth() error = %v", err)
+				}
+				if int32(len(orders)) > tt.limit {
+					t.Errorf("page has %d orders, want at most %d", len(orders), tt.limit)
+				}
+				for _, o := range orders {
+					got = append(got, o.ID)
+				}
+				if cursor = next; cursor == "" {
+					break
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("orders = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
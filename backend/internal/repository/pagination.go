@@ -0,0 +1,142 @@
+// backend/internal/repository/pagination.go
+// Query のページング用カーソル（LastEvaluatedKey）のエンコード/デコードと、全ページ取得のヘルパー
+//
+// 【カーソル形式】
+//   LastEvaluatedKey（キー属性のマップ）を JSON にして base64url でエンコードした文字列
+//   クライアントは中身を解釈せず、次ページ取得時にそのまま渡す
+
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrTooManyPages  = errors.New("query exceeded the maximum number of pages")
+)
+
+// queryAllPages で読み込むページ数のデフォルト上限
+// 1ページは最大1MBのため、100ページ = 最大約100MB の読み込みで打ち切る
+// 管理者用・バッチ処理用のため大きめにしている
+const defaultMaxQueryPages = 100
+
+// 認証なしで呼べる API から使う queryAllPages のページ数の上限
+// 誰でも大量の読み込み（読み込みキャパシティの消費）を起こせないよう小さくする
+const publicMaxQueryPages = 5
+
+// cursorValue はキー属性1つ分の値（キー属性は S か N のみ）
+type cursorValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+}
+
+// encodeCursor は LastEvaluatedKey をカーソル文字列に変換する
+// key が空の場合（最終ページ）は空文字を返す
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]cursorValue, len(key))
+	for name, av := range key {
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			values[name] = cursorValue{S: &v.Value}
+		case *types.AttributeValueMemberN:
+			values[name] = cursorValue{N: &v.Value}
+		default:
+			return "", errors.New("unsupported key attribute type: " + name)
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor はカーソル文字列を ExclusiveStartKey に変換する
+// cursor が空の場合は nil（先頭から取得）を返す
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values map[string]cursorValue
+	if err := json.Unmarshal(data, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	key := make(map[string]types.AttributeValue, len(values))
+	for name, v := range values {
+		switch {
+		case v.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *v.N}
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+	return key, nil
+}
+
+// keyFromItem はアイテムから指定したキー属性だけを取り出す
+// 途中でページを打ち切る場合に、最後に返したアイテムを ExclusiveStartKey として使うために使用する
+func keyFromItem(item map[string]types.AttributeValue, names ...string) map[string]types.AttributeValue {
+	key := make(map[string]types.AttributeValue, len(names))
+	for _, name := range names {
+		if av, ok := item[name]; ok {
+			key[name] = av
+		}
+	}
+	return key
+}
+
+// queryAllPages は LastEvaluatedKey がなくなるまで Query を繰り返し、全ページのアイテムを返す
+// 【用途】期間指定の履歴取得など、1ページ（1MB）で打ち切られると結果が欠けてしまう Query
+// 【上限】maxPages ページを読んでも続きがある場合は ErrTooManyPages を返す（暴走した読み込みの防止）
+//
+//	maxPages が 0 以下の場合は defaultMaxQueryPages を使う（公開 API からは publicMaxQueryPages を渡す）
+//	途中までの結果を黙って返すことはしない
+//
+// input は書き換えない（ExclusiveStartKey はコピーに設定する）
+// ctx がキャンセルされた場合は次のページを読まずに ctx.Err() を返す
+func queryAllPages(ctx context.Context, client DynamoDBAPI, input *dynamodb.QueryInput, maxPages int) ([]map[string]types.AttributeValue, error) {
+	if maxPages <= 0 {
+		maxPages = defaultMaxQueryPages
+	}
+
+	in := *input
+	var items []map[string]types.AttributeValue
+	for page := 0; page < maxPages; page++ {
+		result, err := client.Query(ctx, &in)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, result.Items...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		in.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return nil, ErrTooManyPages
+}
@@ -0,0 +1,100 @@
+// backend/internal/repository/password_reset_repo.go
+// パスワード再設定トークンのDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: RESET#<tokenHash>   - トークンの SHA-256（平文のトークンは保存しない）
+//   SK: RESET               - ソートキー（固定値）
+//
+// 【TTL】
+//   有効期限（expiresAt）を TTL に設定する
+//   TTL による削除は最大数日遅れることがあるため、使用時にも expiresAt を条件で確認する
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+type passwordResetRecord struct {
+	PK        string `dynamodbav:"PK"` // RESET#<tokenHash>
+	SK        string `dynamodbav:"SK"` // RESET
+	UserID    string `dynamodbav:"userId"`
+	ExpiresAt int64  `dynamodbav:"expiresAt"` // Unix Epoch秒（条件式で比較するため数値で持つ）
+	CreatedAt string `dynamodbav:"createdAt"`
+	TTL       int64  `dynamodbav:"TTL"`
+}
+
+type PasswordResetRepository struct {
+	db *DynamoDBClient
+}
+
+func NewPasswordResetRepository(db *DynamoDBClient) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Create はパスワード再設定トークンを保存する
+// 【使用API】PutItem
+func (r *PasswordResetRepository) Create(ctx context.Context, tokenHash, userID string, expiresAt time.Time) error {
+	item, err := marshalWithTTL(passwordResetRecord{
+		PK:        "RESET#" + tokenHash,
+		SK:        "RESET",
+		UserID:    userID,
+		ExpiresAt: expiresAt.Unix(),
+		CreatedAt: time.Now().Format(time.RFC3339),
+		TTL:       ttlEpoch(expiresAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	return err
+}
+
+// Consume はトークンを削除し、トークンを発行したユーザーのIDを返す（1回限りの使用）
+// 【使用API】DeleteItem + ConditionExpression + ReturnValues=ALL_OLD
+//
+//	存在確認と削除を1回の条件付き削除で行うため、同じトークンを同時に使っても成功するのは1回だけ
+//
+// トークンが存在しない・期限切れの場合は ErrResetTokenInvalid を返す
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (string, error) {
+	result, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "RESET#" + tokenHash},
+			"SK": &types.AttributeValueMemberS{Value: "RESET"},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK) AND expiresAt > :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return "", ErrResetTokenInvalid
+		}
+		return "", err
+	}
+
+	var rec passwordResetRecord
+	if err := attributevalue.UnmarshalMap(result.Attributes, &rec); err != nil {
+		return "", err
+	}
+	return rec.UserID, nil
+}
@@ -10,11 +10,18 @@
 //   - ISO 8601形式（RFC3339）を使用することで、文字列の辞書順=時系列順になる
 //   - BETWEEN クエリで範囲取得が可能
 //   - ScanIndexForward=false で新しい順に取得
+//
+// 【価格変更】
+//   RecordPriceChange は価格履歴の追加と商品の価格の更新を1つのトランザクションで行う
+//   （どちらか一方だけが書き込まれて、履歴と現在の価格が食い違うことがない）
+//   商品のバージョンを条件にするため、読み込んだ後に他の変更があった場合は書き込まない
 
 package repository
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -48,22 +55,9 @@ func NewPriceHistoryRepository(db *DynamoDBClient) *PriceHistoryRepository {
 // Create は価格履歴をDynamoDBに保存する
 // 【使用API】PutItem
 // 【ポイント】タイムスタンプをSKに含めることで、同一商品の価格履歴を時系列で管理
+// Timestamp が未設定の場合は現在時刻を使う（過去の日時を指定できるのはデモデータの投入用）
 func (r *PriceHistoryRepository) Create(ctx context.Context, history *domain.PriceHistory) error {
-	now := time.Now()
-	history.Timestamp = now
-
-	// SK の形式: PRICE#2025-01-15T10:30:00Z
-	// ISO 8601形式なので、文字列ソートすると時系列順になる
-	record := priceHistoryRecord{
-		PK:        "PRODUCT#" + history.ProductID,
-		SK:        "PRICE#" + now.Format(time.RFC3339),
-		ProductID: history.ProductID,
-		Price:     history.Price,
-		ChangedBy: history.ChangedBy,
-		ChangedAt: now.Format(time.RFC3339),
-	}
-
-	item, err := attributevalue.MarshalMap(record)
+	item, err := priceHistoryItem(history)
 	if err != nil {
 		return err
 	}
@@ -76,6 +70,84 @@ func (r *PriceHistoryRepository) Create(ctx context.Context, history *domain.Pri
 	return err
 }
 
+// RecordPriceChange は価格履歴を追加し、同時に商品の価格を history.Price に更新する
+// 【使用API】TransactWriteItems
+//  1. Put: 価格履歴（PRICE#<timestamp>）
+//  2. Update: 商品の price・updatedAt を更新し version を1増やす
+//     （条件: attribute_exists(PK) かつ version = expectedVersion。0 の場合は version 属性がないこと）
+//
+// 条件を満たさない場合はどちらも書き込まない
+// 商品が存在しない場合は ErrProductNotFound、バージョンが違う場合は ErrProductVersionMismatch を返す
+// （どちらかは ReturnValuesOnConditionCheckFailure で受け取った旧アイテムの有無で判断する）
+func (r *PriceHistoryRepository) RecordPriceChange(ctx context.Context, history *domain.PriceHistory, expectedVersion int) error {
+	item, err := priceHistoryItem(history)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]types.AttributeValue{
+		":price": &types.AttributeValueMemberN{Value: strconv.Itoa(history.Price)},
+		":now":   &types.AttributeValueMemberS{Value: history.Timestamp.Format(time.RFC3339)},
+		":one":   &types.AttributeValueMemberN{Value: "1"},
+	}
+	condition := "attribute_exists(PK) AND " + productVersionCondition(expectedVersion, values)
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: r.db.Table(),
+					Item:      item,
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + history.ProductID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:                    aws.String("SET price = :price, updatedAt = :now ADD version :one"),
+					ConditionExpression:                 aws.String(condition),
+					ExpressionAttributeValues:           values,
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 1) {
+			var tce *types.TransactionCanceledException
+			errors.As(err, &tce)
+			return productConditionError(tce.CancellationReasons[1].Item)
+		}
+		return err
+	}
+	return nil
+}
+
+// priceHistoryItem は価格履歴のアイテムを作る
+// Timestamp が未設定の場合は現在時刻を設定する
+func priceHistoryItem(history *domain.PriceHistory) (map[string]types.AttributeValue, error) {
+	changedAt := history.Timestamp
+	if changedAt.IsZero() {
+		changedAt = time.Now()
+	}
+	history.Timestamp = changedAt
+
+	// SK の形式: PRICE#2025-01-15T10:30:00Z
+	// ISO 8601形式なので、文字列ソートすると時系列順になる
+	record := priceHistoryRecord{
+		PK:        "PRODUCT#" + history.ProductID,
+		SK:        "PRICE#" + changedAt.Format(time.RFC3339),
+		ProductID: history.ProductID,
+		Price:     history.Price,
+		ChangedBy: history.ChangedBy,
+		ChangedAt: changedAt.Format(time.RFC3339),
+	}
+	return attributevalue.MarshalMap(record)
+}
+
 // GetByProductID は商品の価格履歴を取得する（新しい順）
 // 【使用API】Query + ScanIndexForward=false
 //
@@ -125,6 +197,8 @@ func (r *PriceHistoryRepository) GetByProductID(ctx context.Context, productID s
 //	SK BETWEEN :start AND :end
 //	→ startからendの範囲のアイテムを取得
 //	→ 時系列データの範囲クエリに最適
+//
+// publicMaxQueryPages ページを読んでも続きがある場合は ErrTooManyPages を返す（期間を狭めてもらう）
 func (r *PriceHistoryRepository) GetByProductIDWithRange(ctx context.Context, productID string, startTime, endTime time.Time) ([]*domain.PriceHistory, error) {
 	// SKの形式にあわせて時間をフォーマット
 	startSK := "PRICE#" + startTime.Format(time.RFC3339)
@@ -141,13 +215,15 @@ func (r *PriceHistoryRepository) GetByProductIDWithRange(ctx context.Context, pr
 		ScanIndexForward: aws.Bool(true), // 古い順(昇順)に取得しグラフ描画しやすくする
 	}
 
-	result, err := r.db.Client.Query(ctx, input)
+	// 1ページ（1MB）で打ち切らず、期間内の全件を取得する
+	// 認証なしの価格履歴 API から呼ばれるため、読むページ数は publicMaxQueryPages までにする
+	items, err := queryAllPages(ctx, r.db.Client, input, publicMaxQueryPages)
 	if err != nil {
 		return nil, err
 	}
 
-	histories := make([]*domain.PriceHistory, 0, len(result.Items))
-	for _, item := range result.Items {
+	histories := make([]*domain.PriceHistory, 0, len(items))
+	for _, item := range items {
 		var record priceHistoryRecord
 		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
 			return nil, err
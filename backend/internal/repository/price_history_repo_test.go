@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+func TestPriceHistoryRepositoryGetByProductIDWithRangePageCap(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		items   int
+		wantErr error
+	}{
+		{name: "上限のページ数で読み切れる", items: publicMaxQueryPages},
+		// 上限のページを読んでも続きがある（1ページ1件のため）
+		{name: "上限を超えるページ数はエラー", items: publicMaxQueryPages + 1, wantErr: ErrTooManyPages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			repo := NewPriceHistoryRepository(db)
+			ctx := context.Background()
+			for i := 0; i < tt.items; i++ {
+				if err := repo.Create(ctx, &domain.PriceHistory{ProductID: "p1", Price: 100 + i, Timestamp: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			fake.PageSize = 1
+
+			histories, err := repo.GetByProductIDWithRange(ctx, "p1", base, base.Add(24*time.Hour))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByProductIDWithRange() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(histories) != tt.items {
+				t.Errorf("got %d histories, want %d", len(histories), tt.items)
+			}
+			if got := fake.CallCount("Query"); got > publicMaxQueryPages {
+				t.Errorf("Query called %d times, want at most %d", got, publicMaxQueryPages)
+			}
+		})
+	}
+}
+
+func TestPriceHistoryRepositoryRecordPriceChangeAtomic(t *testing.T) {
+	changedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	historySK := "PRICE#" + changedAt.Format(time.RFC3339)
+
+	tests := []struct {
+		name            string
+		seed            bool // 商品を作成しておくか
+		expectedVersion int
+		wantErr         error
+	}{
+		{name: "履歴と価格の両方を書き込む", seed: true},
+		{name: "バージョンが違う場合はどちらも書き込まない", seed: true, expectedVersion: 3, wantErr: ErrProductVersionMismatch},
+		{name: "商品がない場合は履歴も書き込まない", wantErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			if tt.seed {
+				seedProduct(fake, "p1", 10, 0)
+			}
+			repo := NewPriceHistoryRepository(db)
+
+			err := repo.RecordPriceChange(context.Background(), &domain.PriceHistory{ProductID: "p1", Price: 1500, ChangedBy: "admin", Timestamp: changedAt}, tt.expectedVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RecordPriceChange() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// 1回のトランザクションだけで書き込む（個別の書き込みをしない）
+			if got := fake.CallCount("TransactWriteItems"); got != 1 {
+				t.Errorf("TransactWriteItems called %d times, want 1", got)
+			}
+			for _, op := range []string{"PutItem", "UpdateItem"} {
+				if got := fake.CallCount(op); got != 0 {
+					t.Errorf("%s called %d times, want 0", op, got)
+				}
+			}
+
+			history := fake.Get("PRODUCT#p1", historySK)
+			product := fake.Get("PRODUCT#p1", "METADATA")
+			if tt.wantErr != nil {
+				if history != nil {
+					t.Error("history was written although the price update failed")
+				}
+				if product != nil && numberAttr(product, "price") != 1000 {
+					t.Errorf("price = %d, want unchanged 1000", numberAttr(product, "price"))
+				}
+				return
+			}
+			if history == nil {
+				t.Fatal("history was not written")
+			}
+			if got := numberAttr(product, "price"); got != 1500 {
+				t.Errorf("price = %d, want 1500", got)
+			}
+			if got := numberAttr(product, "version"); got != 1 {
+				t.Errorf("version = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestPriceHistoryRepositoryRecordPriceChangeCanceled(t *testing.T) {
+	db, fake := newTestDB()
+	seedProduct(fake, "p1", 10, 0)
+	// 同じ商品への別のトランザクションと競合した
+	fake.Hook = func(ctx context.Context, op string, input any) error {
+		if op == "TransactWriteItems" {
+			return dynamotest.TransactionCanceled("None", "TransactionConflict")
+		}
+		return nil
+	}
+	repo := NewPriceHistoryRepository(db)
+
+	changedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := repo.RecordPriceChange(context.Background(), &domain.PriceHistory{ProductID: "p1", Price: 1500, Timestamp: changedAt}, 0); err == nil {
+		t.Fatal("RecordPriceChange() error = nil, want the transaction error")
+	}
+	if fake.Get("PRODUCT#p1", "PRICE#"+changedAt.Format(time.RFC3339)) != nil {
+		t.Error("history was written although the transaction was canceled")
+	}
+	if got := numberAttr(fake.Get("PRODUCT#p1", "METADATA"), "price"); got != 1000 {
+		t.Errorf("price = %d, want unchanged 1000", got)
+	}
+}
@@ -6,17 +6,47 @@
 //   SK:     METADATA            - ソートキー（固定値）
 //   GSI1PK: PRODUCT             - 全商品を同じパーティションにまとめる
 //   GSI1SK: CATEGORY#<カテゴリ>#<商品ID> - カテゴリ検索用
+//   GSI2PK: PRODUCT             - 全商品を同じパーティションにまとめる
+//   GSI2SK: CREATED#<作成日時(UTC)>#<商品ID> - 作成日時の範囲検索用
 //
 // 【アクセスパターン】
 //   1. 商品ID指定で取得     → GetItem(PK, SK)
+//      複数の商品ID指定     → BatchGetItem（100件ずつ）
+//      在庫数のみ          → GetItem(PK, SK) + ProjectionExpression(stock, reservedStock, deletedAt)
 //   2. 全商品一覧          → Query(GSI1PK = "PRODUCT")
 //   3. カテゴリ別商品一覧   → Query(GSI1PK = "PRODUCT" AND begins_with(GSI1SK, "CATEGORY#xxx"))
+//      カード表示用の一覧   → 2, 3 + ProjectionExpression(id, name, price, imageUrl, stock)
+//   4. 作成日時の範囲検索   → Query(GSI2PK = "PRODUCT" AND GSI2SK BETWEEN "CREATED#start" AND "CREATED#end")
+//   5. 在庫僅少の商品一覧   → Query(GSI1PK = "PRODUCT") + FilterExpression(stock <= :threshold)
+//   6. カテゴリ別の商品数   → Query(GSI1PK = "PRODUCT") + ProjectionExpression(category) を全ページ集計
+//
+// 【論理削除】
+//   Delete は deletedAt を設定するだけでアイテムは残す（過去の注文・価格履歴から参照されるため）
+//   一覧（List）は既定で attribute_not_exists(deletedAt) で除外する
+//   物理削除は管理者用の HardDelete のみ
+//
+// 【在庫の仮押さえ（reservedStock）】
+//   チェックアウト中の数量を reservedStock に加算しておき、注文確定のトランザクションで
+//   stock と reservedStock を同時に減算する（加算・解放は reservation_repo.go / hold_repo.go）
+//   購入可能数 = stock - reservedStock
+//   Update で在庫数を変える場合も stock >= reservedStock を条件にする（仮押さえ分を売れなくしない）
+//
+// 【カテゴリの商品数】
+//   作成・カテゴリ変更・削除は TransactWriteItems でカテゴリマーカー（category_repo.go）の
+//   productCount も同時に増減する
+//
+// 【バージョン（楽観的ロック）】
+//   作成時は version = 1、Update と価格変更（PriceHistoryRepository.RecordPriceChange）で1ずつ増やす
+//   version 属性がない既存の商品はバージョン 0 として扱う
 
 package repository
 
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -29,24 +59,37 @@ import (
 	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
 )
 
-var ErrProductNotFound = errors.New("product not found")
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrProductNotDeleted = errors.New("product is not deleted")
+	// ErrProductVersionMismatch は商品が読み込んだ後に他のリクエストで更新されたことを表す
+	ErrProductVersionMismatch = errors.New("product version mismatch: product was modified by another request")
+	// 在庫数をチェックアウト中・取り置き中の数量（reservedStock）より少なくしようとした
+	ErrStockBelowReserved = errors.New("stock cannot be less than the reserved quantity")
+)
 
 // productRecord はDynamoDBに保存する商品データの構造体
 // dynamodbavタグでDynamoDBの属性名を指定
 type productRecord struct {
-	PK          string `dynamodbav:"PK"`     // パーティションキー: PRODUCT#<id>
-	SK          string `dynamodbav:"SK"`     // ソートキー: METADATA
-	GSI1PK      string `dynamodbav:"GSI1PK"` // GSI1パーティションキー: PRODUCT
-	GSI1SK      string `dynamodbav:"GSI1SK"` // GSI1ソートキー: CATEGORY#<category>#<id>
-	ID          string `dynamodbav:"id"`
-	Name        string `dynamodbav:"name"`
-	Description string `dynamodbav:"description"`
-	Price       int    `dynamodbav:"price"`
-	Category    string `dynamodbav:"category"`
-	Stock       int    `dynamodbav:"stock"`
-	ImageURL    string `dynamodbav:"imageUrl"`
-	CreatedAt   string `dynamodbav:"createdAt"`
-	UpdatedAt   string `dynamodbav:"updatedAt"`
+	PK            string `dynamodbav:"PK"`               // パーティションキー: PRODUCT#<id>
+	SK            string `dynamodbav:"SK"`               // ソートキー: METADATA
+	GSI1PK        string `dynamodbav:"GSI1PK"`           // GSI1パーティションキー: PRODUCT
+	GSI1SK        string `dynamodbav:"GSI1SK"`           // GSI1ソートキー: CATEGORY#<category>#<id>
+	GSI2PK        string `dynamodbav:"GSI2PK,omitempty"` // GSI2パーティションキー: PRODUCT
+	GSI2SK        string `dynamodbav:"GSI2SK,omitempty"` // GSI2ソートキー: CREATED#<createdAt>#<id>
+	ID            string `dynamodbav:"id"`
+	SKU           string `dynamodbav:"sku,omitempty"`
+	Name          string `dynamodbav:"name"`
+	Description   string `dynamodbav:"description"`
+	Price         int    `dynamodbav:"price"`
+	Category      string `dynamodbav:"category"`
+	Stock         int    `dynamodbav:"stock"`
+	ReservedStock int    `dynamodbav:"reservedStock,omitempty"` // チェックアウト中の仮押さえ数
+	ImageURL      string `dynamodbav:"imageUrl"`
+	CreatedAt     string `dynamodbav:"createdAt"`
+	UpdatedAt     string `dynamodbav:"updatedAt"`
+	DeletedAt     string `dynamodbav:"deletedAt,omitempty"` // 論理削除日時（未削除の場合は属性なし）
+	Version       int    `dynamodbav:"version,omitempty"`   // 楽観的ロック用（属性がない既存の商品は 0）
 }
 
 // ProductRepository は商品のDynamoDB操作を提供する
@@ -62,31 +105,15 @@ func NewProductRepository(db *DynamoDBClient) *ProductRepository {
 }
 
 // Create は新規商品をDynamoDBに保存する
-// 【使用API】PutItem - 新規アイテムの作成（または上書き）
+// 【使用API】TransactWriteItems - 商品の作成（Put）+ カテゴリの商品数の加算（Update）
 func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	now := time.Now()
 	product.ID = uuid.New().String()
 	product.CreatedAt = now
 	product.UpdatedAt = now
+	product.Version = 1
 
-	// DynamoDB用のレコード構造体を作成
-	// GSI1SK の形式: CATEGORY#electronics#uuid
-	// → begins_with で "CATEGORY#electronics" を指定するとそのカテゴリの商品だけ取得できる
-	record := productRecord{
-		PK:          "PRODUCT#" + product.ID,
-		SK:          "METADATA",
-		GSI1PK:      "PRODUCT",                                         // 全商品で共通
-		GSI1SK:      "CATEGORY#" + product.Category + "#" + product.ID, // カテゴリ検索用
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Category:    product.Category,
-		Stock:       product.Stock,
-		ImageURL:    product.ImageURL,
-		CreatedAt:   product.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
-	}
+	record := newProductRecord(product)
 
 	// Go構造体 → DynamoDB AttributeValue に変換
 	item, err := attributevalue.MarshalMap(record)
@@ -94,18 +121,110 @@ func (r *ProductRepository) Create(ctx context.Context, product *domain.Product)
 		return err
 	}
 
-	// PutItem: アイテムを作成（同じキーが存在する場合は上書き）
-	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: r.db.Table(),
-		Item:      item,
+	// 商品の作成とカテゴリの商品数の加算を1つのトランザクションで行う
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: r.db.Table(),
+				Item:      item,
+			},
+		},
+	}
+	if product.Category != "" {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), product.Category, 1))
+	}
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	})
 
 	return err
 }
 
+// BatchCreate は複数の商品を一括保存する（カタログの一括登録用）
+// 【使用API】BatchWriteItem（最大25件）
+// 【制限】1回の呼び出しは MaxBatchWriteItems 件まで（呼び出し側で分割する）
+//
+// 【UnprocessedItems の再試行】
+//   - スロットリング等で書き込まれなかったアイテムは batchWriteWithRetry で再試行する
+//   - それでも残ったアイテムの商品IDを failedIDs として返す（エラーにはしない）
+//
+// 【注意】BatchWriteItem はトランザクションではないため、カテゴリの商品数は加算しない
+// 書き込み成功後に呼び出し側で AddCategoryCounts を呼ぶ
+func (r *ProductRepository) BatchCreate(ctx context.Context, products []*domain.Product) (failedIDs []string, err error) {
+	if len(products) == 0 {
+		return nil, nil
+	}
+	if len(products) > MaxBatchWriteItems {
+		return nil, fmt.Errorf("batch create: %d items exceeds limit of %d", len(products), MaxBatchWriteItems)
+	}
+
+	now := time.Now()
+	writeRequests := make([]types.WriteRequest, 0, len(products))
+	for _, product := range products {
+		product.ID = uuid.New().String()
+		product.CreatedAt = now
+		product.UpdatedAt = now
+		product.Version = 1
+
+		item, err := attributevalue.MarshalMap(newProductRecord(product))
+		if err != nil {
+			return nil, err
+		}
+		writeRequests = append(writeRequests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	unprocessed, err := batchWriteWithRetry(ctx, r.db, writeRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range unprocessed {
+		if req.PutRequest == nil {
+			continue
+		}
+		if id, ok := req.PutRequest.Item["id"].(*types.AttributeValueMemberS); ok {
+			failedIDs = append(failedIDs, id.Value)
+		}
+	}
+
+	return failedIDs, nil
+}
+
+// AddCategoryCounts はカテゴリごとの商品数を counts の分だけ加算する（BatchCreate の後処理）
+// 【使用API】UpdateItem（カテゴリ単位）
+func (r *ProductRepository) AddCategoryCounts(ctx context.Context, counts map[string]int) error {
+	for category, count := range counts {
+		update := categoryCountUpdate(r.db.Table(), category, count).Update
+		if _, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 update.TableName,
+			Key:                       update.Key,
+			UpdateExpression:          update.UpdateExpression,
+			ExpressionAttributeNames:  update.ExpressionAttributeNames,
+			ExpressionAttributeValues: update.ExpressionAttributeValues,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetByID は商品IDを指定して1件取得する
 // 【使用API】GetItem - PK+SKを指定して1件取得（最も高速）
 func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Product, error) {
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDConsistent は強整合性読み込みで商品を1件取得する
+// 書き込んだ直後の値を確実に読む場合（更新後の値を返さない TransactWriteItems の後など）に使う
+// 読み込みキャパシティは GetByID の2倍消費する
+func (r *ProductRepository) GetByIDConsistent(ctx context.Context, id string) (*domain.Product, error) {
+	return r.getByID(ctx, id, true)
+}
+
+func (r *ProductRepository) getByID(ctx context.Context, id string, consistent bool) (*domain.Product, error) {
 	// GetItem: パーティションキー + ソートキーを指定して取得
 	// 特徴: 1件のみ取得、最も低レイテンシー、読み込みキャパシティ消費が最小
 	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
@@ -114,6 +233,7 @@ func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Pro
 			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
 			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
 		},
+		ConsistentRead: aws.Bool(consistent),
 	})
 	if err != nil {
 		return nil, err
@@ -133,6 +253,75 @@ func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Pro
 	return recordToProduct(&record), nil
 }
 
+// GetStock は商品の在庫数と仮押さえ数だけを取得する（購入可能かどうかの確認用）
+// 【使用API】GetItem + ProjectionExpression
+//   - 必要な属性だけを返すため、説明文などを含む商品全体を転送しない
+//   - 消費する読み込みキャパシティはアイテム全体のサイズで決まるため、GetByID と変わらない
+//
+// 商品が存在しない、または論理削除済みの場合は ErrProductNotFound を返す
+func (r *ProductRepository) GetStock(ctx context.Context, id string) (stock, reservedStock int, err error) {
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+		ProjectionExpression: aws.String("stock, reservedStock, deletedAt"),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if result.Item == nil {
+		return 0, 0, ErrProductNotFound
+	}
+
+	var record productRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return 0, 0, err
+	}
+	if record.DeletedAt != "" {
+		return 0, 0, ErrProductNotFound
+	}
+	return record.Stock, record.ReservedStock, nil
+}
+
+// BatchGetByIDs は複数の商品をまとめて取得し、商品IDをキーとしたマップで返す
+// 【使用API】BatchGetItem
+//   - 同じキーを重複して指定するとエラーになるため、ID は重複を除いてから取得する
+//   - 分割と UnprocessedKeys の再試行は batchGetWithRetry に任せる
+//
+// 存在しない商品はマップに含まれない（エラーにはしない）
+func (r *ProductRepository) BatchGetByIDs(ctx context.Context, ids []string) (map[string]*domain.Product, error) {
+	products := make(map[string]*domain.Product, len(ids))
+
+	keys := make([]map[string]types.AttributeValue, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		})
+	}
+
+	items, err := batchGetWithRetry(ctx, r.db, keys, types.KeysAndAttributes{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var record productRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, err
+		}
+		products[record.ID] = recordToProduct(&record)
+	}
+
+	return products, nil
+}
+
 // List は商品一覧を取得する（カテゴリ指定可能）
 // 【使用API】Query - GSI1を使用した一覧取得
 //
@@ -147,7 +336,65 @@ func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Pro
 //	  ✅ CATEGORY#electronics#001
 //	  ✅ CATEGORY#electronics#002
 //	  ❌ CATEGORY#clothing#003
-func (r *ProductRepository) List(ctx context.Context, category string) ([]*domain.Product, error) {
+func (r *ProductRepository) List(ctx context.Context, category string, includeDeleted bool) ([]*domain.Product, error) {
+	input := listQueryInput(r.db.Table(), category, includeDeleted)
+
+	// Query実行
+	// 特徴: パーティション内の複数アイテムを効率的に取得
+	// Scanと違い、パーティションキーを指定するので無駄な読み込みが発生しない
+	result, err := r.db.Client.Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// 結果をドメインモデルに変換
+	products := make([]*domain.Product, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record productRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, err
+		}
+		products = append(products, recordToProduct(&record))
+	}
+
+	return products, nil
+}
+
+// ListSummaries は商品一覧をカード表示に必要な項目だけで取得する（論理削除済みの商品は除外）
+// 【使用API】Query（List と同じ GSI1 の条件）+ ProjectionExpression
+//   - 説明文などの長い属性を返さないため、レスポンスが小さくなる
+//   - name は DynamoDB の予約語のため ExpressionAttributeNames で指定する
+//   - FilterExpression（deletedAt）は射影に含めない属性でも評価できる
+func (r *ProductRepository) ListSummaries(ctx context.Context, category string) ([]*domain.ProductSummary, error) {
+	input := listQueryInput(r.db.Table(), category, false)
+	input.ProjectionExpression = aws.String("id, #name, price, imageUrl, stock")
+	input.ExpressionAttributeNames = map[string]string{"#name": "name"}
+
+	result, err := r.db.Client.Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*domain.ProductSummary, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record productRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &domain.ProductSummary{
+			ID:       record.ID,
+			Name:     record.Name,
+			Price:    record.Price,
+			ImageURL: record.ImageURL,
+			Stock:    record.Stock,
+		})
+	}
+
+	return summaries, nil
+}
+
+// listQueryInput は商品一覧（List / ListSummaries）の GSI1 の Query を組み立てる
+func listQueryInput(table *string, category string, includeDeleted bool) *dynamodb.QueryInput {
 	var input *dynamodb.QueryInput
 
 	if category != "" {
@@ -157,7 +404,7 @@ func (r *ProductRepository) List(ctx context.Context, category string) ([]*domai
 		// KeyConditionExpression で GSI1PK と GSI1SK の両方を条件に含める
 		// begins_with は前方一致検索（プレフィックス検索）
 		input = &dynamodb.QueryInput{
-			TableName:              r.db.Table(),
+			TableName:              table,
 			IndexName:              aws.String("GSI1"),                                      // GSI1インデックスを使用
 			KeyConditionExpression: aws.String("GSI1PK = :pk AND begins_with(GSI1SK, :sk)"), // キー条件式
 			ExpressionAttributeValues: map[string]types.AttributeValue{
@@ -171,7 +418,7 @@ func (r *ProductRepository) List(ctx context.Context, category string) ([]*domai
 		// ========================================
 		// GSI1PK = "PRODUCT" の全レコードを取得
 		input = &dynamodb.QueryInput{
-			TableName:              r.db.Table(),
+			TableName:              table,
 			IndexName:              aws.String("GSI1"),
 			KeyConditionExpression: aws.String("GSI1PK = :pk"),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
@@ -180,44 +427,396 @@ func (r *ProductRepository) List(ctx context.Context, category string) ([]*domai
 		}
 	}
 
-	// Query実行
-	// 特徴: パーティション内の複数アイテムを効率的に取得
-	// Scanと違い、パーティションキーを指定するので無駄な読み込みが発生しない
-	result, err := r.db.Client.Query(ctx, input)
+	// 論理削除済みの商品は既定で除外する
+	if !includeDeleted {
+		input.FilterExpression = aws.String("attribute_not_exists(deletedAt)")
+	}
+	return input
+}
+
+// Update は既存商品を更新する
+// 【使用API】UpdateItem + ConditionExpression
+//
+// 【ConditionExpression の役割】
+//
+//	attribute_exists(PK) = PKが存在する場合のみ実行
+//	→ 存在しないアイテムへの誤った更新を防ぐ
+//	→ 条件を満たさない場合は ConditionalCheckFailedException が発生
+//
+// 【カテゴリ変更時】oldCategory と異なる場合は TransactWriteItems で
+// 旧カテゴリの商品数の減算・新カテゴリの商品数の加算を同時に行う
+// （商品の条件に category = :oldCategory を加え、読み込み後のカテゴリ変更と競合した場合は失敗させる）
+//
+// 【楽観的ロック】product.Version（読み込んだ時点のバージョン）と一致する場合のみ更新し、version を1増やす
+// 商品が存在しない場合は ErrProductNotFound、バージョンが違う場合は ErrProductVersionMismatch を返す
+// 在庫数が reservedStock（チェックアウト中・取り置き中の数量）を下回る場合は ErrStockBelowReserved を返す
+//
+// 成功した場合は product を更新後の内容（reservedStock など他の更新も反映された値）にする
+// UpdateItem の場合は ReturnValues=ALL_NEW で受け取った値にし、
+// 更新後の値を返さない TransactWriteItems の場合は強整合性読み込みで読み直した値にする
+// （読み直しに失敗した場合は、更新は書き込まれていてもそのエラーを返す）
+func (r *ProductRepository) Update(ctx context.Context, product *domain.Product, oldCategory string) error {
+	now := time.Now()
+
+	// 【UpdateItem】変更可能な属性のみを SET する
+	// PutItem で丸ごと置き換えると、チェックアウト中に加算された reservedStock を上書きしてしまうため
+	// attribute_exists(PK): 既存アイテムが存在する場合のみ更新を許可
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + product.ID},
+		"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+	}
+	updateExpr := aws.String("SET #name = :name, #description = :description, price = :price, category = :category, stock = :stock, imageUrl = :imageUrl, GSI1SK = :gsi1sk, updatedAt = :now ADD version :one")
+	names := map[string]string{
+		"#name":        "name", // 予約語
+		"#description": "description",
+	}
+	values := map[string]types.AttributeValue{
+		":name":        &types.AttributeValueMemberS{Value: product.Name},
+		":description": &types.AttributeValueMemberS{Value: product.Description},
+		":price":       &types.AttributeValueMemberN{Value: strconv.Itoa(product.Price)},
+		":category":    &types.AttributeValueMemberS{Value: product.Category},
+		":stock":       &types.AttributeValueMemberN{Value: strconv.Itoa(product.Stock)},
+		":imageUrl":    &types.AttributeValueMemberS{Value: product.ImageURL},
+		":gsi1sk":      &types.AttributeValueMemberS{Value: "CATEGORY#" + product.Category + "#" + product.ID},
+		":now":         &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+		":one":         &types.AttributeValueMemberN{Value: "1"},
+	}
+	// 仮押さえ・取り置き中の数量より在庫を減らすと、確保済みの注文が確定できなくなるため断る
+	condition := "attribute_exists(PK) AND " + productVersionCondition(product.Version, values) +
+		" AND (attribute_not_exists(reservedStock) OR reservedStock <= :stock)"
+
+	if product.Category == oldCategory {
+		result, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                           r.db.Table(),
+			Key:                                 key,
+			UpdateExpression:                    updateExpr,
+			ConditionExpression:                 aws.String(condition),
+			ExpressionAttributeNames:            names,
+			ExpressionAttributeValues:           values,
+			ReturnValues:                        types.ReturnValueAllNew,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		})
+		if err != nil {
+			var ccf *types.ConditionalCheckFailedException
+			if errors.As(err, &ccf) {
+				return productUpdateConditionError(ccf.Item, product)
+			}
+			return err
+		}
+		var rec productRecord
+		if err := attributevalue.UnmarshalMap(result.Attributes, &rec); err != nil {
+			return err
+		}
+		*product = *recordToProduct(&rec)
+		return nil
+	}
+
+	values[":oldCategory"] = &types.AttributeValueMemberS{Value: oldCategory}
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:                           r.db.Table(),
+				Key:                                 key,
+				UpdateExpression:                    updateExpr,
+				ConditionExpression:                 aws.String(condition + " AND category = :oldCategory"),
+				ExpressionAttributeNames:            names,
+				ExpressionAttributeValues:           values,
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+	}
+	if oldCategory != "" {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), oldCategory, -1))
+	}
+	if product.Category != "" {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), product.Category, 1))
+	}
+
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
 	if err != nil {
-		return nil, err
+		if isConditionFailedAt(err, 0) {
+			var tce *types.TransactionCanceledException
+			errors.As(err, &tce)
+			old := tce.CancellationReasons[0].Item
+			if err := productUpdateConditionError(old, product); !errors.Is(err, ErrProductVersionMismatch) || versionOf(old) != product.Version {
+				return err
+			}
+			// 旧アイテムがあってバージョン・在庫の条件も満たしている場合は、カテゴリの変更と競合した
+			return ErrTransactionConflict
+		}
+		return err
+	}
+
+	updated, err := r.GetByIDConsistent(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	*product = *updated
+	return nil
+}
+
+// productVersionCondition は商品のバージョンが expectedVersion であることの条件式を返す
+// version 属性がない既存の商品はバージョン 0 として扱う
+func productVersionCondition(expectedVersion int, values map[string]types.AttributeValue) string {
+	if expectedVersion <= 0 {
+		return "attribute_not_exists(version)"
+	}
+	values[":version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(expectedVersion)}
+	return "version = :version"
+}
+
+// productConditionError は商品の条件付き更新が失敗したときの旧アイテム（ALL_OLD）から原因を判断する
+// 旧アイテムがない場合は ErrProductNotFound、ある場合は ErrProductVersionMismatch
+func productConditionError(old map[string]types.AttributeValue) error {
+	if len(old) == 0 {
+		return ErrProductNotFound
+	}
+	return ErrProductVersionMismatch
+}
+
+// productUpdateConditionError は Update の条件が失敗したときの原因を判断する
+// バージョンは一致しているのに在庫数が reservedStock を下回る場合は ErrStockBelowReserved を返す
+func productUpdateConditionError(old map[string]types.AttributeValue, product *domain.Product) error {
+	if len(old) > 0 && versionOf(old) == product.Version {
+		if reserved, ok := old["reservedStock"].(*types.AttributeValueMemberN); ok {
+			if n, _ := strconv.Atoi(reserved.Value); n > product.Stock {
+				return ErrStockBelowReserved
+			}
+		}
+	}
+	return productConditionError(old)
+}
+
+// versionOf はアイテムの version 属性を返す（属性がない場合は 0）
+func versionOf(item map[string]types.AttributeValue) int {
+	if v, ok := item["version"].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.Atoi(v.Value)
+		return n
+	}
+	return 0
+}
+
+// HardDelete は商品を物理削除する（管理者用）
+// 【使用API】TransactWriteItems（Delete + ConditionExpression）
+//
+// 【注意】DynamoDBの削除は存在しないキーを指定してもエラーにならない
+//
+//	→ ConditionExpression で存在チェックを追加することで、
+//	  存在しない場合にエラーを返すようにしている
+//
+// 【カテゴリの商品数】削除と同じトランザクションで category の商品数を減算する
+// 商品の条件に category = :category を加え、読み込み後にカテゴリが変わっていた場合は失敗させる
+// 論理削除済み（softDeleted）の商品は論理削除時に減算済みのため減算しない
+func (r *ProductRepository) HardDelete(ctx context.Context, id, category string, softDeleted bool) error {
+	condition := "attribute_exists(PK) AND category = :category AND attribute_not_exists(deletedAt)" // 存在する場合のみ削除
+	if softDeleted {
+		condition = "attribute_exists(PK) AND category = :category AND attribute_exists(deletedAt)"
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName: r.db.Table(),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
+					"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+				},
+				ConditionExpression: aws.String(condition),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":category": &types.AttributeValueMemberS{Value: category},
+				},
+			},
+		},
+	}
+	if category != "" && !softDeleted {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), category, -1))
+	}
+
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil && isConditionFailedAt(err, 0) {
+		return ErrTransactionConflict
+	}
+	return err
+}
+
+// SoftDelete は商品を論理削除する（deletedAt を設定）
+// 【使用API】TransactWriteItems（Update + カテゴリの商品数の減算）
+// 読み込み後に削除・カテゴリ変更された場合は ErrTransactionConflict を返す
+func (r *ProductRepository) SoftDelete(ctx context.Context, id, category string) error {
+	now := time.Now().Format(time.RFC3339)
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: r.db.Table(),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
+					"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+				},
+				UpdateExpression:    aws.String("SET deletedAt = :now, updatedAt = :now"),
+				ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deletedAt) AND category = :category"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":now":      &types.AttributeValueMemberS{Value: now},
+					":category": &types.AttributeValueMemberS{Value: category},
+				},
+			},
+		},
+	}
+	if category != "" {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), category, -1))
+	}
+
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil && isConditionFailedAt(err, 0) {
+		return ErrTransactionConflict
+	}
+	return err
+}
+
+// Restore は論理削除した商品を元に戻す（deletedAt を削除）
+// 【使用API】TransactWriteItems（Update + カテゴリの商品数の加算）
+// 読み込み後に復元・カテゴリ変更された場合は ErrTransactionConflict を返す
+func (r *ProductRepository) Restore(ctx context.Context, id, category string) error {
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: r.db.Table(),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
+					"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+				},
+				UpdateExpression:    aws.String("REMOVE deletedAt SET updatedAt = :now"),
+				ConditionExpression: aws.String("attribute_exists(deletedAt) AND category = :category"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":now":      &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
+					":category": &types.AttributeValueMemberS{Value: category},
+				},
+			},
+		},
+	}
+	if category != "" {
+		transactItems = append(transactItems, categoryCountUpdate(r.db.Table(), category, 1))
+	}
+
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil && isConditionFailedAt(err, 0) {
+		return ErrTransactionConflict
+	}
+	return err
+}
+
+// ListByCreatedRange は作成日時が start 〜 end（両端を含む）の商品を作成日時の昇順で取得する
+// 【使用API】Query（GSI2）+ BETWEEN
+// 【ページング】最大 limit 件を返し、続きがある場合は nextToken を返す
+func (r *ProductRepository) ListByCreatedRange(ctx context.Context, start, end time.Time, limit int32, nextToken string) ([]*domain.Product, string, error) {
+	startKey, err := decodeCursor(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// end 側は同時刻に作成された全商品を含めるため、商品IDより大きい "~" を付ける
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("GSI2PK = :pk AND GSI2SK BETWEEN :start AND :end"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: "PRODUCT"},
+			":start": &types.AttributeValueMemberS{Value: "CREATED#" + start.UTC().Format(time.RFC3339)},
+			":end":   &types.AttributeValueMemberS{Value: "CREATED#" + end.UTC().Format(time.RFC3339) + "#~"},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
 	}
 
-	// 結果をドメインモデルに変換
 	products := make([]*domain.Product, 0, len(result.Items))
 	for _, item := range result.Items {
 		var record productRecord
 		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		products = append(products, recordToProduct(&record))
 	}
 
-	return products, nil
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return products, next, nil
 }
 
-// Update は既存商品を更新する
-// 【使用API】PutItem + ConditionExpression
-//
-// 【ConditionExpression の役割】
+// ListLowStock は在庫数が threshold 以下の商品を在庫の少ない順に取得する
+// 【使用API】Query（GSI1: GSI1PK = PRODUCT）+ FilterExpression
 //
-//	attribute_exists(PK) = PKが存在する場合のみ実行
-//	→ 存在しないアイテムへの誤った更新を防ぐ
-//	→ 条件を満たさない場合は ConditionalCheckFailedException が発生
-func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
-	now := time.Now()
+// 【FilterExpression の注意点】
+//   - フィルターは読み込んだ後に適用されるため、Limit は「フィルター前」の評価件数に対する上限になる
+//   - 1ページの件数が limit 未満（0件の場合もある）でも nextToken があれば続きが存在する
+//   - 並び替え（在庫の昇順）はページ内でのみ行う
+func (r *ProductRepository) ListLowStock(ctx context.Context, threshold int, limit int32, nextToken string) ([]*domain.Product, string, error) {
+	startKey, err := decodeCursor(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		FilterExpression:       aws.String("stock <= :threshold"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: "PRODUCT"},
+			":threshold": &types.AttributeValueMemberN{Value: strconv.Itoa(threshold)},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	products := make([]*domain.Product, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record productRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, "", err
+		}
+		products = append(products, recordToProduct(&record))
+	}
+
+	// 在庫が少ない（緊急度が高い）商品を先頭にする
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].Stock < products[j].Stock
+	})
+
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return products, next, nil
+}
 
-	record := productRecord{
+// newProductRecord は新規作成する商品の DynamoDB レコードを生成する
+// GSI1SK の形式: CATEGORY#electronics#uuid
+// → begins_with で "CATEGORY#electronics" を指定するとそのカテゴリの商品だけ取得できる
+func newProductRecord(product *domain.Product) productRecord {
+	return productRecord{
 		PK:          "PRODUCT#" + product.ID,
 		SK:          "METADATA",
-		GSI1PK:      "PRODUCT",
-		GSI1SK:      "CATEGORY#" + product.Category + "#" + product.ID,
+		GSI1PK:      "PRODUCT",                                         // 全商品で共通
+		GSI1SK:      "CATEGORY#" + product.Category + "#" + product.ID, // カテゴリ検索用
+		GSI2PK:      "PRODUCT",
+		GSI2SK:      productCreatedSortKey(product.CreatedAt, product.ID), // 作成日時の範囲検索用
 		ID:          product.ID,
+		SKU:         product.SKU,
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
@@ -225,58 +824,75 @@ func (r *ProductRepository) Update(ctx context.Context, product *domain.Product)
 		Stock:       product.Stock,
 		ImageURL:    product.ImageURL,
 		CreatedAt:   product.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   now.Format(time.RFC3339),
-	}
-
-	item, err := attributevalue.MarshalMap(record)
-	if err != nil {
-		return err
+		UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
+		Version:     product.Version,
 	}
+}
 
-	// PutItem + ConditionExpression で条件付き更新
-	// attribute_exists(PK): 既存アイテムが存在する場合のみ更新を許可
-	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           r.db.Table(),
-		Item:                item,
-		ConditionExpression: aws.String("attribute_exists(PK)"),
-	})
+// productCreatedSortKey は作成日時検索用の GSI2SK を生成する
+// 文字列比較で時系列順になるよう UTC で揃える
+func productCreatedSortKey(createdAt time.Time, id string) string {
+	return "CREATED#" + createdAt.UTC().Format(time.RFC3339) + "#" + id
+}
 
-	return err
+// recordToProduct はDynamoDBレコードをドメインモデルに変換する
+// PK, SK, GSI1PK, GSI1SK, GSI2PK, GSI2SK はDynamoDB専用の属性なので、ドメインモデルには含めない
+func recordToProduct(r *productRecord) *domain.Product {
+	p := &domain.Product{
+		ID:            r.ID,
+		SKU:           r.SKU,
+		Name:          r.Name,
+		Description:   r.Description,
+		Price:         r.Price,
+		Category:      r.Category,
+		Stock:         r.Stock,
+		ReservedStock: r.ReservedStock,
+		ImageURL:      r.ImageURL,
+		CreatedAt:     timeutil.ParseTime(r.CreatedAt),
+		UpdatedAt:     timeutil.ParseTime(r.UpdatedAt),
+		Version:       r.Version,
+	}
+	if r.DeletedAt != "" {
+		deletedAt := timeutil.ParseTime(r.DeletedAt)
+		p.DeletedAt = &deletedAt
+	}
+	return p
 }
 
-// Delete は商品を削除する
-// 【使用API】DeleteItem + ConditionExpression
-//
-// 【注意】DynamoDBのDeleteItemは存在しないキーを指定してもエラーにならない
+// CountByCategory は論理削除されていない商品の数をカテゴリごとに数える（ファセット用）
+// 【使用API】Query（GSI1: GSI1PK = PRODUCT）+ FilterExpression + ProjectionExpression
+//   - category 属性だけを射影し、ページごとに Go で集計する（全件をメモリに溜めない）
+//   - LastEvaluatedKey がなくなるまで全ページ読むため、商品数に比例して読み込みが増える
+//     → 呼び出し側で結果をキャッシュすること
 //
-//	→ ConditionExpression で存在チェックを追加することで、
-//	  存在しない場合にエラーを返すようにしている
-func (r *ProductRepository) Delete(ctx context.Context, id string) error {
-	// DeleteItem: PK+SKを指定して削除
-	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: r.db.Table(),
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + id},
-			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+// カテゴリが未設定の商品は数えない
+func (r *ProductRepository) CountByCategory(ctx context.Context) (map[string]int, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		FilterExpression:       aws.String("attribute_not_exists(deletedAt)"),
+		ProjectionExpression:   aws.String("category"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "PRODUCT"},
 		},
-		ConditionExpression: aws.String("attribute_exists(PK)"), // 存在する場合のみ削除
-	})
+	}
 
-	return err
-}
+	counts := make(map[string]int)
+	for {
+		result, err := r.db.Client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			if category, ok := item["category"].(*types.AttributeValueMemberS); ok && category.Value != "" {
+				counts[category.Value]++
+			}
+		}
 
-// recordToProduct はDynamoDBレコードをドメインモデルに変換する
-// PK, SK, GSI1PK, GSI1SK はDynamoDB専用の属性なので、ドメインモデルには含めない
-func recordToProduct(r *productRecord) *domain.Product {
-	return &domain.Product{
-		ID:          r.ID,
-		Name:        r.Name,
-		Description: r.Description,
-		Price:       r.Price,
-		Category:    r.Category,
-		Stock:       r.Stock,
-		ImageURL:    r.ImageURL,
-		CreatedAt:   timeutil.ParseTime(r.CreatedAt),
-		UpdatedAt:   timeutil.ParseTime(r.UpdatedAt),
+		if len(result.LastEvaluatedKey) == 0 {
+			return counts, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
 	}
 }
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+)
+
+// seedProductCreatedAt は作成日時を指定して商品を直接書き込む（GSI2 に載る）
+func seedProductCreatedAt(fake *dynamotest.Fake, id string, createdAt time.Time) {
+	created := createdAt.UTC().Format(time.RFC3339)
+	fake.Seed(dynamotest.Item(productRecord{
+		PK:        "PRODUCT#" + id,
+		SK:        "METADATA",
+		GSI1PK:    "PRODUCT",
+		GSI1SK:    "CATEGORY#test#" + id,
+		GSI2PK:    "PRODUCT",
+		GSI2SK:    "CREATED#" + created + "#" + id,
+		ID:        id,
+		Name:      "商品" + id,
+		Category:  "test",
+		CreatedAt: created,
+		UpdatedAt: created,
+	}))
+}
+
+func TestProductRepositoryUpdateStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		stock    int
+		category string // 空の場合はカテゴリを変えない（UpdateItem）、指定した場合はトランザクション
+		wantErr  error
+	}{
+		{name: "仮押さえ数まで減らせる", stock: 3},
+		{name: "仮押さえ数を下回る", stock: 2, wantErr: ErrStockBelowReserved},
+		{name: "カテゴリ変更と同時に仮押さえ数まで減らせる", stock: 3, category: "other"},
+		{name: "カテゴリ変更と同時に仮押さえ数を下回る", stock: 2, category: "other", wantErr: ErrStockBelowReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			repo := NewProductRepository(db)
+			seedProduct(fake, "p1", 5, 3)
+
+			product, err := repo.GetByID(context.Background(), "p1")
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			oldCategory := product.Category
+			if tt.category != "" {
+				product.Category = tt.category
+			}
+			product.Stock = tt.stock
+
+			err = repo.Update(context.Background(), product, oldCategory)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			want := 5
+			if tt.wantErr == nil {
+				want = tt.stock
+			}
+			if got := numberAttr(fake.Get("PRODUCT#p1", "METADATA"), "stock"); got != want {
+				t.Errorf("stock = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestProductRepositoryUpdateCategoryReturnsStoredProduct(t *testing.T) {
+	db, fake := newTestDB()
+	repo := NewProductRepository(db)
+	seedProduct(fake, "p1", 5, 3)
+
+	product, err := repo.GetByID(context.Background(), "p1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	// 読み込んだ後に、取り置きが1個に減った（バージョンは変わらない）
+	fake.Hook = func(ctx context.Context, op string, input any) error {
+		if op == "TransactWriteItems" {
+			item := fake.Get("PRODUCT#p1", "METADATA")
+			item["reservedStock"] = &types.AttributeValueMemberN{Value: "1"}
+			fake.Seed(item)
+		}
+		return nil
+	}
+	oldCategory := product.Category
+	product.Category = "other"
+	product.Stock = 4
+
+	if err := repo.Update(context.Background(), product, oldCategory); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// 書き込んだ値ではなく、テーブルに保存されている値にする
+	if product.ReservedStock != 1 {
+		t.Errorf("ReservedStock = %d, want 1 (the stored value)", product.ReservedStock)
+	}
+	if product.Stock != 4 || product.Category != "other" || product.Version != 1 {
+		t.Errorf("stock, category, version = %d, %q, %d, want 4, \"other\", 1", product.Stock, product.Category, product.Version)
+	}
+
+	calls := fake.Calls()
+	last := calls[len(calls)-1]
+	in, ok := last.Input.(*dynamodb.GetItemInput)
+	if !ok || !aws.ToBool(in.ConsistentRead) {
+		t.Errorf("last call = %s, want a consistent GetItem", last.Op)
+	}
+}
+
+func TestProductRepositoryListByCreatedRange(t *testing.T) {
+	db, fake := newTestDB()
+	repo := NewProductRepository(db)
+	// 2月の範囲の前後1秒ずつに商品を置く（同じ秒に作成された商品も含める）
+	seedProductCreatedAt(fake, "jan-last", time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC))
+	seedProductCreatedAt(fake, "feb-first-a", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	seedProductCreatedAt(fake, "feb-first-b", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	seedProductCreatedAt(fake, "feb-last", time.Date(2025, 2, 28, 23, 59, 59, 0, time.UTC))
+	seedProductCreatedAt(fake, "mar-first", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	// JST で指定しても UTC に揃えて比較する（2025-03-01T08:59:59+09:00 = 2025-02-28T23:59:59Z）
+	jst := time.FixedZone("JST", 9*60*60)
+	start := time.Date(2025, 2, 1, 9, 0, 0, 0, jst)
+	end := time.Date(2025, 3, 1, 8, 59, 59, 0, jst)
+	want := []string{"feb-first-a", "feb-first-b", "feb-last"}
+
+	tests := []struct {
+		name      string
+		limit     int32
+		wantPages int
+	}{
+		{name: "1ページ", limit: 10, wantPages: 1},
+		{name: "nextToken でページング", limit: 2, wantPages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			token := ""
+			pages := 0
+			for {
+				products, next, err := repo.ListByCreatedRange(context.Background(), start, end, tt.limit, token)
+				if err != nil {
+					t.Fatalf("ListByCreatedRange() error = %v", err)
+				}
+				pages++
+				for _, p := range products {
+					got = append(got, p.ID)
+				}
+				if token = next; token == "" {
+					break
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("products = %v, want %v", got, want)
+			}
+			// 最後のページが limit ちょうどの場合は空のページが1回増える
+			if pages < tt.wantPages || pages > tt.wantPages+1 {
+				t.Errorf("pages = %d, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+
+	t.Run("不正な nextToken", func(t *testing.T) {
+		if _, _, err := repo.ListByCreatedRange(context.Background(), start, end, 10, "not-a-token"); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ListByCreatedRange() error = %v, want %v", err, ErrInvalidCursor)
+		}
+	})
+}
+
+func TestProductRepositoryListLowStock(t *testing.T) {
+	db, fake := newTestDB()
+	repo := NewProductRepository(db)
+	// GSI1SK（商品ID）順に並べると在庫順にならないように置く
+	for id, stock := range map[string]int{"a": 8, "b": 50, "c": 0, "d": 5, "e": 10, "f": 11} {
+		seedProduct(fake, id, stock, 0)
+	}
+
+	var got []string
+	token := ""
+	for {
+		products, next, err := repo.ListLowStock(context.Background(), 10, 3, token)
+		if err != nil {
+			t.Fatalf("ListLowStock() error = %v", err)
+		}
+		// ページ内は在庫の少ない順
+		if !slices.IsSortedFunc(products, func(a, b *domain.Product) int { return a.Stock - b.Stock }) {
+			t.Errorf("page is not sorted by stock: %v", products)
+		}
+		for _, p := range products {
+			if p.Stock > 10 {
+				t.Errorf("product %s has stock %d, want <= 10", p.ID, p.Stock)
+			}
+			got = append(got, p.ID)
+		}
+		if token = next; token == "" {
+			break
+		}
+	}
+
+	slices.Sort(got)
+	if want := []string{"a", "c", "d", "e"}; !slices.Equal(got, want) {
+		t.Errorf("low-stock products = %v, want %v", got, want)
+	}
+}
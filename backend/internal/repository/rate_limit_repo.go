@@ -0,0 +1,68 @@
+// backend/internal/repository/rate_limit_repo.go
+// 操作回数の制限（レート制限）のカウンターのDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: RATELIMIT#<key>          - パーティションキー（制限の対象単位、例: RATELIMIT#FORGOTPW#<emailHash>）
+//   SK: WINDOW#<windowStart>     - ソートキー（固定ウィンドウの開始時刻、Unix Epoch秒）
+//
+// 【固定ウィンドウ】
+//   window ごとにカウンターを分け、UpdateItem の ADD で回数を数える（同時に呼ばれても数え漏れがない）
+//   ウィンドウの境目をまたぐと最大で上限の2倍まで通るが、メールの連続送信を抑える用途には十分
+//
+// 【TTL】
+//   ウィンドウの終了時刻を TTL に設定する（終わったウィンドウのカウンターは参照しない）
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type RateLimitRepository struct {
+	db *DynamoDBClient
+}
+
+func NewRateLimitRepository(db *DynamoDBClient) *RateLimitRepository {
+	return &RateLimitRepository{
+		db: db,
+	}
+}
+
+// Hit は now を含むウィンドウのカウンターを1進めて、進めた後の回数を返す
+// 【使用API】UpdateItem（ADD + ReturnValues: UPDATED_NEW）
+func (r *RateLimitRepository) Hit(ctx context.Context, key string, window time.Duration, now time.Time) (int64, error) {
+	windowStart := now.Truncate(window)
+
+	result, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "RATELIMIT#" + key},
+			"SK": &types.AttributeValueMemberS{Value: "WINDOW#" + strconv.FormatInt(windowStart.Unix(), 10)},
+		},
+		UpdateExpression: aws.String("ADD hits :one SET #ttl = :ttl"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": ttlAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":ttl": ttlValue(ttlEpoch(windowStart.Add(window))),
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	hits, ok := result.Attributes["hits"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, errors.New("rate limit " + key + ": hits attribute missing in response")
+	}
+	return strconv.ParseInt(hits.Value, 10, 64)
+}
@@ -0,0 +1,296 @@
+// backend/internal/repository/reservation_repo.go
+// 注文確定中の在庫の仮押さえ（チェックアウトのリース）のDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: RESERVATION#<reservationId>
+//   SK: RESERVATION
+//   GSI1PK: RESERVATION#<シャード番号>               （期限切れの仮押さえを探す。書き込みを reservationShards 個に分散）
+//   GSI1SK: <expiresAt>#<reservationId>
+//
+// 【在庫との整合性】
+//   商品の reservedStock への加算・減算は、仮押さえアイテムの products（仮押さえ済みの商品IDの集合）の
+//   追加・削除と同じトランザクションで行う
+//   → products に含まれる商品の quantities の合計 = チェックアウトによる reservedStock の増加分 が常に保たれる
+//   注文確定のトランザクションでは、products の件数が変わっていないことを条件に仮押さえアイテムを削除する
+//
+// 【期限と TTL】
+//   注文確定の前にプロセスが落ちると reservedStock が戻らないため、期限切れの仮押さえは
+//   スイーパー（OrderService.ReleaseExpiredReservations）が解放する
+//   TTL は期限から reservationTTLGrace 後に設定する（holdTTLGrace と同じく保険）
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
+)
+
+const (
+	// 期限から TTL による自動削除までの猶予（スイーパーが解放するための時間）
+	reservationTTLGrace = 24 * time.Hour
+
+	// 期限切れの仮押さえを探す GSI1 のパーティション数
+	// チェックアウトのたびに書き込まれるため、1つのパーティションに集めると書き込みが集中する
+	reservationShards = 8
+)
+
+var (
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	ErrReservationExpired  = errors.New("stock reservation expired")
+)
+
+type reservationRecord struct {
+	PK            string         `dynamodbav:"PK"` // RESERVATION#<reservationId>
+	SK            string         `dynamodbav:"SK"` // RESERVATION
+	GSI1PK        string         `dynamodbav:"GSI1PK"`
+	GSI1SK        string         `dynamodbav:"GSI1SK"`
+	ReservationID string         `dynamodbav:"reservationId"`
+	UserID        string         `dynamodbav:"userId"`
+	Quantities    map[string]int `dynamodbav:"quantities"`
+	Products      []string       `dynamodbav:"products,stringset,omitempty"` // 仮押さえ済みの商品ID（空になると属性ごと消える）
+	ExpiresAt     string         `dynamodbav:"expiresAt"`
+	CreatedAt     string         `dynamodbav:"createdAt"`
+	TTL           int64          `dynamodbav:"TTL"` // Unix Epoch秒（expiresAt + reservationTTLGrace）
+}
+
+type ReservationRepository struct {
+	db *DynamoDBClient
+}
+
+func NewReservationRepository(db *DynamoDBClient) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// Begin は仮押さえアイテムを作成する（この時点では商品の reservedStock は変えない）
+// reservation.ID・CreatedAt を設定する
+func (r *ReservationRepository) Begin(ctx context.Context, reservation *domain.StockReservation) error {
+	now := time.Now()
+	reservation.ID = uuid.New().String()
+	reservation.CreatedAt = now
+	reservation.ProductIDs = nil
+
+	item, err := marshalWithTTL(reservationRecord{
+		PK:            "RESERVATION#" + reservation.ID,
+		SK:            "RESERVATION",
+		GSI1PK:        "RESERVATION#" + gsiShard(reservation.ID, reservationShards),
+		GSI1SK:        reservation.ExpiresAt.UTC().Format(time.RFC3339) + "#" + reservation.ID,
+		ReservationID: reservation.ID,
+		UserID:        reservation.UserID,
+		Quantities:    reservation.Quantities,
+		ExpiresAt:     reservation.ExpiresAt.UTC().Format(time.RFC3339),
+		CreatedAt:     now.Format(time.RFC3339),
+		TTL:           ttlEpoch(reservation.ExpiresAt.Add(reservationTTLGrace)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	return err
+}
+
+// Reserve は商品の在庫を reservation.Quantities[productID] だけ仮押さえする
+// 【使用API】TransactWriteItems
+//  1. Update: 商品の reservedStock を加算（条件は HoldRepository.Reserve と同じく observedStock を使う）
+//  2. Update: 仮押さえアイテムの products に商品IDを追加（条件: 期限内で、まだ仮押さえしていないこと）
+//
+// 在庫が足りない場合は ErrInsufficientStock、仮押さえが期限切れ・解放済みの場合は ErrReservationExpired を返す
+// 成功した場合は reservation.ProductIDs に商品IDを追加する
+func (r *ReservationRepository) Reserve(ctx context.Context, reservation *domain.StockReservation, productID string, observedStock int) error {
+	quantity := reservation.Quantities[productID]
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:    aws.String("SET reservedStock = if_not_exists(reservedStock, :zero) + :qty"),
+					ConditionExpression: aws.String("attribute_exists(PK) AND stock >= :observed AND (attribute_not_exists(reservedStock) OR reservedStock <= :maxReserved)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":zero":        &types.AttributeValueMemberN{Value: "0"},
+						":qty":         &types.AttributeValueMemberN{Value: strconv.Itoa(quantity)},
+						":observed":    &types.AttributeValueMemberN{Value: strconv.Itoa(observedStock)},
+						":maxReserved": &types.AttributeValueMemberN{Value: strconv.Itoa(observedStock - quantity)},
+					},
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName:           r.db.Table(),
+					Key:                 reservationKey(reservation.ID),
+					UpdateExpression:    aws.String("ADD products :products"),
+					ConditionExpression: aws.String("attribute_exists(PK) AND expiresAt > :now AND NOT contains(products, :productId)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":products":  &types.AttributeValueMemberSS{Value: []string{productID}},
+						":productId": &types.AttributeValueMemberS{Value: productID},
+						":now":       &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return ErrInsufficientStock
+		}
+		if isConditionFailedAt(err, 1) {
+			return ErrReservationExpired
+		}
+		return err
+	}
+	reservation.ProductIDs = append(reservation.ProductIDs, productID)
+	return nil
+}
+
+// Release は仮押さえした商品1件分の在庫を戻す
+// 【使用API】TransactWriteItems
+//  1. Update: 商品の reservedStock を減算（条件: 負にならないこと）
+//  2. Update: 仮押さえアイテムの products から商品IDを削除（条件: products に含まれていること）
+//
+// 注文確定で消費された・既に解放された場合は ErrReservationNotFound を返す（二重に解放しない）
+func (r *ReservationRepository) Release(ctx context.Context, reservation *domain.StockReservation, productID string) error {
+	_, err := r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+						"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+					},
+					UpdateExpression:    aws.String("SET reservedStock = reservedStock - :qty"),
+					ConditionExpression: aws.String("reservedStock >= :qty"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":qty": &types.AttributeValueMemberN{Value: strconv.Itoa(reservation.Quantities[productID])},
+					},
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName:           r.db.Table(),
+					Key:                 reservationKey(reservation.ID),
+					UpdateExpression:    aws.String("DELETE products :products"),
+					ConditionExpression: aws.String("contains(products, :productId)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":products":  &types.AttributeValueMemberSS{Value: []string{productID}},
+						":productId": &types.AttributeValueMemberS{Value: productID},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 1) {
+			return ErrReservationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete は全商品を解放し終えた仮押さえアイテムを削除する
+// 仮押さえ済みの商品が残っている場合は削除しない（ErrReservationNotFound を返し、スイーパーが解放する）
+func (r *ReservationRepository) Delete(ctx context.Context, reservationID string) error {
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           r.db.Table(),
+		Key:                 reservationKey(reservationID),
+		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(products)"),
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return ErrReservationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListExpired は期限が now より前の仮押さえを取得する（スイーパー用）
+// 【使用API】Query × reservationShards（GSI1: GSI1PK = RESERVATION#<シャード>, GSI1SK < now）
+// シャードごとに期限の古い順に最大 limit 件ずつ読む
+func (r *ReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int32) ([]*domain.StockReservation, error) {
+	var reservations []*domain.StockReservation
+	for shard := range reservationShards {
+		result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              r.db.Table(),
+			IndexName:              aws.String("GSI1"),
+			KeyConditionExpression: aws.String("GSI1PK = :pk AND GSI1SK < :now"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":  &types.AttributeValueMemberS{Value: "RESERVATION#" + strconv.Itoa(shard)},
+				":now": &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+			},
+			Limit: aws.Int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			var rec reservationRecord
+			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+				return nil, err
+			}
+			reservations = append(reservations, recordToReservation(&rec))
+		}
+	}
+	return reservations, nil
+}
+
+// reservationConsume は注文確定のトランザクションで仮押さえアイテムを削除する操作を返す
+// 仮押さえ済みの商品数が reservation.ProductIDs と同じ場合のみ削除する
+// → 期限切れでスイーパーが一部を解放していた場合はトランザクション全体を失敗させる
+func reservationConsume(table *string, reservation *domain.StockReservation) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName:           table,
+			Key:                 reservationKey(reservation.ID),
+			ConditionExpression: aws.String("attribute_exists(PK) AND size(products) = :count"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":count": &types.AttributeValueMemberN{Value: strconv.Itoa(len(reservation.ProductIDs))},
+			},
+		},
+	}
+}
+
+func reservationKey(reservationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "RESERVATION#" + reservationID},
+		"SK": &types.AttributeValueMemberS{Value: "RESERVATION"},
+	}
+}
+
+// gsiShard は id を shards 個のシャードに振り分けた番号を返す（同じ id は常に同じシャード）
+func gsiShard(id string, shards int) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return strconv.Itoa(int(h.Sum32() % uint32(shards)))
+}
+
+func recordToReservation(rec *reservationRecord) *domain.StockReservation {
+	return &domain.StockReservation{
+		ID:         rec.ReservationID,
+		UserID:     rec.UserID,
+		Quantities: rec.Quantities,
+		ProductIDs: rec.Products,
+		ExpiresAt:  timeutil.ParseTime(rec.ExpiresAt),
+		CreatedAt:  timeutil.ParseTime(rec.CreatedAt),
+	}
+}
@@ -0,0 +1,192 @@
+// backend/internal/repository/sales_stats_repo.go
+// 商品ごとの販売実績（販売数・売上・注文数）を担当するリポジトリ
+// DynamoDB Streams のワーカー（cmd/worker）が注文明細の INSERT から集計する
+//
+// 【キー設計】
+//   販売実績:     PK: PRODUCT#<productId>, SK: STATS
+//                GSI2PK: BESTSELLER, GSI2SK: <unitsSold を0埋め12桁>#<productId>（売れ筋順の取得用）
+//   適用済みマーク: PK: STREAMSEQ#<sequenceNumber>, SK: APPLIED（TTL で自動削除）
+//
+// 【GSI2SK の更新】
+//   ADD で加算した値は同じ更新式の SET で使えないため、加算後に販売数を読み直して GSI2SK を更新する
+//   （unitsSold が読んだ値のままの場合のみ更新する条件付き。先に別の加算があった場合はその加算側が更新する）
+//
+// 【冪等性】
+//   Streams のレコードはワーカーの再起動やリトライで複数回届くことがある
+//   → 販売実績の ADD と同じトランザクションで、レコードのシーケンス番号の適用済みマークを
+//     attribute_not_exists 条件付きで書き込み、同じレコードを二重に集計しない
+//   マークは Streams の保持期間（24時間）より長く残せば十分なため、streamMarkerTTL 後に TTL で削除する
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
+)
+
+// 適用済みマークを残す期間（Streams の保持期間 24時間 + 余裕）
+const streamMarkerTTL = 48 * time.Hour
+
+// 売れ筋順の GSI のパーティション
+const bestsellerPartition = "BESTSELLER"
+
+type salesStatsRecord struct {
+	PK         string `dynamodbav:"PK"` // PRODUCT#<productId>
+	SK         string `dynamodbav:"SK"` // STATS
+	ProductID  string `dynamodbav:"productId"`
+	UnitsSold  int    `dynamodbav:"unitsSold"`
+	Revenue    int    `dynamodbav:"revenue"`
+	OrderCount int    `dynamodbav:"orderCount"`
+	UpdatedAt  string `dynamodbav:"updatedAt"`
+}
+
+type SalesStatsRepository struct {
+	db *DynamoDBClient
+}
+
+func NewSalesStatsRepository(db *DynamoDBClient) *SalesStatsRepository {
+	return &SalesStatsRepository{db: db}
+}
+
+// AddSale は商品の販売実績に1件の注文明細を加算する
+// 【使用API】TransactWriteItems
+//  1. Put: 適用済みマーク（条件: attribute_not_exists(PK)）
+//  2. Update: 販売実績（ADD unitsSold, revenue, orderCount。アイテムがなければ作成される）
+//
+// 加算後に GSI2SK（売れ筋順のソートキー）を更新する
+//
+// sequenceNumber のレコードが適用済みの場合は加算せず applied=false を返す
+// （前回 GSI2SK の更新だけが失敗していた場合に備え、GSI2SK の更新は行う）
+func (r *SalesStatsRepository) AddSale(ctx context.Context, sequenceNumber, productID string, quantity, amount int) (applied bool, err error) {
+	now := time.Now()
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: r.db.Table(),
+					Item: map[string]types.AttributeValue{
+						"PK":         &types.AttributeValueMemberS{Value: "STREAMSEQ#" + sequenceNumber},
+						"SK":         &types.AttributeValueMemberS{Value: "APPLIED"},
+						ttlAttribute: ttlValue(ttlEpoch(now.Add(streamMarkerTTL))),
+					},
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+						"SK": &types.AttributeValueMemberS{Value: "STATS"},
+					},
+					// 【ADD】数値属性に加算する（属性がなければ 0 から加算）
+					UpdateExpression: aws.String("SET productId = :productId, updatedAt = :now ADD unitsSold :qty, revenue :amount, orderCount :one"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":productId": &types.AttributeValueMemberS{Value: productID},
+						":now":       &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+						":qty":       &types.AttributeValueMemberN{Value: strconv.Itoa(quantity)},
+						":amount":    &types.AttributeValueMemberN{Value: strconv.Itoa(amount)},
+						":one":       &types.AttributeValueMemberN{Value: "1"},
+					},
+				},
+			},
+		},
+	})
+	applied = true
+	if err != nil {
+		if !isConditionFailedAt(err, 0) {
+			return false, err
+		}
+		applied = false
+	}
+
+	if err := r.updateRankKey(ctx, productID); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// updateRankKey は現在の販売数から GSI2SK を更新する
+func (r *SalesStatsRepository) updateRankKey(ctx context.Context, productID string) error {
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+		"SK": &types.AttributeValueMemberS{Value: "STATS"},
+	}
+	result, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            r.db.Table(),
+		Key:                  key,
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("unitsSold"),
+	})
+	if err != nil {
+		return err
+	}
+	var rec salesStatsRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           r.db.Table(),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET GSI2PK = :pk, GSI2SK = :sk"),
+		ConditionExpression: aws.String("unitsSold = :units"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: bestsellerPartition},
+			":sk":    &types.AttributeValueMemberS{Value: fmt.Sprintf("%012d#%s", rec.UnitsSold, productID)},
+			":units": &types.AttributeValueMemberN{Value: strconv.Itoa(rec.UnitsSold)},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		// 読んだ後に別の加算があった → その加算側が GSI2SK を更新する
+		return nil
+	}
+	return err
+}
+
+// ListTop は販売数の多い順に最大 limit 件の販売実績を取得する
+// 【使用API】Query（GSI2: GSI2PK = BESTSELLER、GSI2SK の降順）
+// ワーカーが一度も集計していない場合は空のスライスを返す
+func (r *SalesStatsRepository) ListTop(ctx context.Context, limit int32) ([]*domain.ProductSalesStats, error) {
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("GSI2PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: bestsellerPartition},
+		},
+		ScanIndexForward: aws.Bool(false), // 販売数の多い順
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*domain.ProductSalesStats, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rec salesStatsRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &domain.ProductSalesStats{
+			ProductID:  rec.ProductID,
+			UnitsSold:  rec.UnitsSold,
+			Revenue:    rec.Revenue,
+			OrderCount: rec.OrderCount,
+			UpdatedAt:  timeutil.ParseTime(rec.UpdatedAt),
+		})
+	}
+	return stats, nil
+}
@@ -0,0 +1,201 @@
+// backend/internal/repository/scheduled_price_repo.go
+// 予約価格変更のDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK:     PRODUCT#<productId>             - パーティションキー（商品単位）
+//   SK:     SCHEDPRICE#<effectiveAt>        - ソートキー（適用日時順）
+//   GSI1PK: SCHEDPRICE                      - 全商品の予約を同じパーティションにまとめる
+//   GSI1SK: <effectiveAt>#<productId>       - 適用日時順に並べる
+//
+// 【アクセスパターン】
+//   1. 予約の登録                 → PutItem
+//   2. 適用時刻を過ぎた予約の取得  → Query(GSI1PK = "SCHEDPRICE" AND GSI1SK < :until)
+//   3. 適用済み予約の削除          → DeleteItem
+//   4. 商品の予約一覧              → Query(PK = PRODUCT#<productId> AND begins_with(SK, "SCHEDPRICE#"))
+//   5. 予約の取り消し              → DeleteItem + ConditionExpression(attribute_exists(PK))
+//
+// 【ポイント】
+//   effectiveAt は必ずUTCでフォーマットする
+//   → タイムゾーンが混在すると文字列の辞書順 = 時系列順が成り立たなくなる
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/pkg/timeutil"
+)
+
+// 1回のスイープで取得する予約の最大件数
+const maxDueScheduledPrices = 100
+
+var ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+
+type scheduledPriceRecord struct {
+	PK          string `dynamodbav:"PK"`     // PRODUCT#<productId>
+	SK          string `dynamodbav:"SK"`     // SCHEDPRICE#<effectiveAt>
+	GSI1PK      string `dynamodbav:"GSI1PK"` // SCHEDPRICE
+	GSI1SK      string `dynamodbav:"GSI1SK"` // <effectiveAt>#<productId>
+	ProductID   string `dynamodbav:"productId"`
+	Price       int    `dynamodbav:"price"`
+	EffectiveAt string `dynamodbav:"effectiveAt"` // 適用日時（RFC3339形式、UTC）
+	CreatedBy   string `dynamodbav:"createdBy"`   // 予約者（ユーザーID）
+	CreatedAt   string `dynamodbav:"createdAt"`
+}
+
+type ScheduledPriceRepository struct {
+	db *DynamoDBClient
+}
+
+func NewScheduledPriceRepository(db *DynamoDBClient) *ScheduledPriceRepository {
+	return &ScheduledPriceRepository{
+		db: db,
+	}
+}
+
+// Create は価格変更の予約を保存する
+// 【使用API】PutItem
+// 同じ商品・同じ適用日時の予約は上書きされる（最後の予約が有効）
+func (r *ScheduledPriceRepository) Create(ctx context.Context, scheduled *domain.ScheduledPrice) error {
+	now := time.Now()
+	scheduled.CreatedAt = now
+	effectiveAt := scheduled.EffectiveAt.UTC().Format(time.RFC3339)
+
+	record := scheduledPriceRecord{
+		PK:          "PRODUCT#" + scheduled.ProductID,
+		SK:          "SCHEDPRICE#" + effectiveAt,
+		GSI1PK:      "SCHEDPRICE",
+		GSI1SK:      effectiveAt + "#" + scheduled.ProductID,
+		ProductID:   scheduled.ProductID,
+		Price:       scheduled.Price,
+		EffectiveAt: effectiveAt,
+		CreatedBy:   scheduled.CreatedBy,
+		CreatedAt:   now.Format(time.RFC3339),
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: r.db.Table(),
+		Item:      item,
+	})
+
+	return err
+}
+
+// ListDue は適用時刻を過ぎた（effectiveAt <= now の）予約を古い順に取得する
+// 【使用API】Query(GSI1) + 範囲条件
+//
+// GSI1SK は <effectiveAt>#<productId> のため、GSI1SK < "<now>#~" で適用日時が now の秒以前の予約を取得する
+// （"~" は商品IDに使われるどの文字よりも大きい）
+// 件数は maxDueScheduledPrices までで、残りは次回のスイープで処理する
+func (r *ScheduledPriceRepository) ListDue(ctx context.Context, now time.Time) ([]*domain.ScheduledPrice, error) {
+	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk AND GSI1SK < :until"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: "SCHEDPRICE"},
+			":until": &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339) + "#~"},
+		},
+		ScanIndexForward: aws.Bool(true), // 古い予約から順に適用する
+		Limit:            aws.Int32(maxDueScheduledPrices),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]*domain.ScheduledPrice, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rec scheduledPriceRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, recordToScheduledPrice(&rec))
+	}
+
+	return scheduled, nil
+}
+
+// Delete は予約を削除する
+// 【使用API】DeleteItem
+func (r *ScheduledPriceRepository) Delete(ctx context.Context, productID string, effectiveAt time.Time) error {
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+			"SK": &types.AttributeValueMemberS{Value: "SCHEDPRICE#" + effectiveAt.UTC().Format(time.RFC3339)},
+		},
+	})
+	return err
+}
+
+// ListByProduct は商品の予約を適用日時の古い順に取得する
+// 【使用API】Query（PK = PRODUCT#<productId>, begins_with(SK, SCHEDPRICE#)）
+func (r *ScheduledPriceRepository) ListByProduct(ctx context.Context, productID string) ([]*domain.ScheduledPrice, error) {
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+			":sk": &types.AttributeValueMemberS{Value: "SCHEDPRICE#"},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]*domain.ScheduledPrice, 0, len(items))
+	for _, item := range items {
+		var rec scheduledPriceRecord
+		if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, recordToScheduledPrice(&rec))
+	}
+	return scheduled, nil
+}
+
+// Cancel は適用前の予約を取り消す
+// 【使用API】DeleteItem + ConditionExpression(attribute_exists(PK))
+// 予約が存在しない（適用済み・取り消し済みを含む）場合は ErrScheduledPriceNotFound を返す
+func (r *ScheduledPriceRepository) Cancel(ctx context.Context, productID string, effectiveAt time.Time) error {
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "PRODUCT#" + productID},
+			"SK": &types.AttributeValueMemberS{Value: "SCHEDPRICE#" + effectiveAt.UTC().Format(time.RFC3339)},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrScheduledPriceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func recordToScheduledPrice(rec *scheduledPriceRecord) *domain.ScheduledPrice {
+	return &domain.ScheduledPrice{
+		ProductID:   rec.ProductID,
+		Price:       rec.Price,
+		EffectiveAt: timeutil.ParseTime(rec.EffectiveAt),
+		CreatedBy:   rec.CreatedBy,
+		CreatedAt:   timeutil.ParseTime(rec.CreatedAt),
+	}
+}
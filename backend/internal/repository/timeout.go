@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ErrDynamoTimeout は DynamoDB の操作が制限時間内に終わらなかったことを示す
+// errors.Is(err, context.DeadlineExceeded) でも判定できる
+var ErrDynamoTimeout = errors.New("dynamodb operation timed out")
+
+// WithTimeout は、各操作に timeout の期限を付けて実行する DynamoDBClient を返す
+// 【目的】DynamoDB が遅い場合に、HTTP のワーカーがサーバーの WriteTimeout まで占有され続けるのを防ぐ
+//   - リクエストの ctx から子コンテキストを作るため、リクエスト側の期限・キャンセルもそのまま効く
+//   - SDK のリトライも含めて timeout 以内に打ち切る
+//   - 期限切れの場合は ErrDynamoTimeout を返す
+//
+// timeout が 0 以下の場合は期限を付けない（元の DynamoDBClient を返す）
+func (d *DynamoDBClient) WithTimeout(timeout time.Duration) *DynamoDBClient {
+	if timeout <= 0 {
+		return d
+	}
+	return NewDynamoDBClientWithAPI(&timeoutAPI{api: d.Client, timeout: timeout}, d.TableName)
+}
+
+// timeoutAPI は DynamoDBAPI の各操作に期限を付けるラッパー
+type timeoutAPI struct {
+	api     DynamoDBAPI
+	timeout time.Duration
+}
+
+// withTimeout は期限付きの子コンテキストで fn を実行する
+func withTimeout[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, fmt.Errorf("%w: %w", ErrDynamoTimeout, err)
+	}
+	return out, err
+}
+
+func (a *timeoutAPI) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+		return a.api.GetItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.PutItemOutput, error) {
+		return a.api.PutItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.QueryOutput, error) {
+		return a.api.Query(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.UpdateItemOutput, error) {
+		return a.api.UpdateItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.DeleteItemOutput, error) {
+		return a.api.DeleteItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.BatchWriteItemOutput, error) {
+		return a.api.BatchWriteItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.BatchGetItemOutput, error) {
+		return a.api.BatchGetItem(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.TransactWriteItemsOutput, error) {
+		return a.api.TransactWriteItems(ctx, params, optFns...)
+	})
+}
+
+func (a *timeoutAPI) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return withTimeout(ctx, a.timeout, func(ctx context.Context) (*dynamodb.DescribeTableOutput, error) {
+		return a.api.DescribeTable(ctx, params, optFns...)
+	})
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDynamoDBClientWithTimeout(t *testing.T) {
+	db, fake := newTestDB()
+	// 遅い DynamoDB の再現: 各操作を delay だけ待たせる（ctx が終わった場合はその時点で返す）
+	var delay time.Duration
+	fake.Hook = func(ctx context.Context, op string, input any) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+			return nil
+		}
+	}
+	repo := NewProductRepository(db.WithTimeout(50 * time.Millisecond))
+
+	t.Run("期限内に終われば結果を返す", func(t *testing.T) {
+		delay = 0
+		if _, err := repo.GetByID(context.Background(), "missing"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("GetByID() error = %v, want %v", err, ErrProductNotFound)
+		}
+	})
+
+	t.Run("期限を過ぎたら ErrDynamoTimeout で打ち切る", func(t *testing.T) {
+		delay = time.Second
+		start := time.Now()
+		_, err := repo.GetByID(context.Background(), "missing")
+		if !errors.Is(err, ErrDynamoTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GetByID() error = %v, want ErrDynamoTimeout wrapping context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("GetByID() took %s, want it cut off near the 50ms timeout", elapsed)
+		}
+	})
+
+	t.Run("リクエスト側のキャンセルはタイムアウトとしない", func(t *testing.T) {
+		delay = time.Second
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := repo.GetByID(ctx, "missing")
+		if !errors.Is(err, context.Canceled) || errors.Is(err, ErrDynamoTimeout) {
+			t.Errorf("GetByID() error = %v, want context.Canceled without ErrDynamoTimeout", err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+// backend/internal/repository/token_revocation_repo.go
+// 失効済みJWT（ログアウト済みトークン）のDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   PK: REVOKED#<jti>            - パーティションキー（JWT ID単位）
+//   SK: REVOKED                  - ソートキー（固定値）
+//
+//   PK: REVOKEDUSER#<userId>     - ユーザー単位の失効（パスワード再設定時）
+//   SK: REVOKED                  - ソートキー（固定値）
+//   revokedBefore 以前に発行（iat）されたそのユーザーのトークンをすべて失効させる
+//
+// 【TTL】
+//   トークン本来の有効期限（exp）をTTLに設定する
+//   期限切れのトークンは署名検証の時点で拒否されるため、それ以降は失効リストに残す必要がない
+//   ユーザー単位の失効は、失効時点で発行済みのトークンがすべて期限切れになる時刻を TTL にする
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type revokedTokenRecord struct {
+	PK        string `dynamodbav:"PK"` // REVOKED#<jti>
+	SK        string `dynamodbav:"SK"` // REVOKED
+	JTI       string `dynamodbav:"jti"`
+	UserID    string `dynamodbav:"userId"`
+	ExpiresAt string `dynamodbav:"expiresAt"`
+	RevokedAt string `dynamodbav:"revokedAt"`
+	TTL       int64  `dynamodbav:"TTL"` // Unix Epoch秒（トークンの exp）
+}
+
+type revokedUserRecord struct {
+	PK            string `dynamodbav:"PK"` // REVOKEDUSER#<userId>
+	SK            string `dynamodbav:"SK"` // REVOKED
+	UserID        string `dynamodbav:"userId"`
+	RevokedBefore int64  `dynamodbav:"revokedBefore"` // Unix Epoch秒（JWT の iat と同じ精度）
+	RevokedAt     string `dynamodbav:"revokedAt"`
+	TTL           int64  `dynamodbav:"TTL"`
+}
+
+type TokenRevocationRepository struct {
+	db *DynamoDBClient
+}
+
+func NewTokenRevocationRepository(db *DynamoDBClient) *TokenRevocationRepository {
+	return &TokenRevocationRepository{
+		db: db,
+	}
+}
+
+// Revoke はトークンを失効リストに登録する（ログアウト用。登録済みの場合も成功する）
+// 【使用API】PutItem
+func (r *TokenRevocationRepository) Revoke(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	item, err := revokedTokenItem(jti, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: r.db.Table(),
+		Item:      item,
+	})
+	return err
+}
+
+// RevokeOnce はトークンがまだ失効していない場合に限り失効リストに登録する（リフレッシュトークンのローテーション用）
+// 登録した場合は true、既に失効済みだった場合は false を返す
+// 【使用API】PutItem（ConditionExpression: attribute_not_exists(PK)）
+// 同じトークンで同時に呼ばれても true を返すのは1回だけ（失効の確認と登録の間に別の呼び出しが割り込めない）
+func (r *TokenRevocationRepository) RevokeOnce(ctx context.Context, jti, userID string, expiresAt time.Time) (bool, error) {
+	item, err := revokedTokenItem(jti, userID, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           r.db.Table(),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// revokedTokenItem はトークン単位の失効アイテムを作る
+func revokedTokenItem(jti, userID string, expiresAt time.Time) (map[string]types.AttributeValue, error) {
+	return marshalWithTTL(revokedTokenRecord{
+		PK:        "REVOKED#" + jti,
+		SK:        "REVOKED",
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		RevokedAt: time.Now().Format(time.RFC3339),
+		TTL:       ttlEpoch(expiresAt),
+	})
+}
+
+// RevokeUser はユーザーが before 以前に発行したトークンをすべて失効させる
+// until には before 時点で発行済みのトークンがすべて期限切れになる時刻を指定する（TTL に使う）
+// 【使用API】PutItem（再度失効させた場合は新しい時刻で上書きする）
+func (r *TokenRevocationRepository) RevokeUser(ctx context.Context, userID string, before, until time.Time) error {
+	item, err := marshalWithTTL(revokedUserRecord{
+		PK:            "REVOKEDUSER#" + userID,
+		SK:            "REVOKED",
+		UserID:        userID,
+		RevokedBefore: before.Unix(),
+		RevokedAt:     time.Now().Format(time.RFC3339),
+		TTL:           ttlEpoch(until),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: r.db.Table(),
+		Item:      item,
+	})
+	return err
+}
+
+// IsRevoked はトークンが失効済みかを確認する
+// トークン単位の失効（jti）と、ユーザー単位の失効（issuedAt が revokedBefore 以前）のどちらかに該当すれば失効済み
+// iat は秒単位のため、失効と同じ秒に発行されたトークンも失効済みとして扱う
+// jti を持たない旧形式のトークンはユーザー単位の失効だけを確認する
+// 【使用API】BatchGetItem（2件を1回で取得。ログアウト直後のリクエストも確実に拒否するため強整合性読み込み）
+func (r *TokenRevocationRepository) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	keys := []map[string]types.AttributeValue{{
+		"PK": &types.AttributeValueMemberS{Value: "REVOKEDUSER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: "REVOKED"},
+	}}
+	if jti != "" {
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "REVOKED#" + jti},
+			"SK": &types.AttributeValueMemberS{Value: "REVOKED"},
+		})
+	}
+
+	items, err := batchGetWithRetry(ctx, r.db, keys, types.KeysAndAttributes{
+		ProjectionExpression: aws.String("PK, revokedBefore"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		before, ok := item["revokedBefore"].(*types.AttributeValueMemberN)
+		if !ok {
+			// トークン単位の失効
+			return true, nil
+		}
+		revokedBefore, err := strconv.ParseInt(before.Value, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		if issuedAt.Unix() <= revokedBefore {
+			return true, nil
+		}
+	}
+	return false, nil
+}
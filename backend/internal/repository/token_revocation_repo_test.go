@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenRevocationRepositoryRevokeUser(t *testing.T) {
+	db, _ := newTestDB()
+	repo := NewTokenRevocationRepository(db)
+	ctx := context.Background()
+	revokedAt := time.Date(2025, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	if err := repo.RevokeUser(ctx, "u1", revokedAt, revokedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("RevokeUser() error = %v", err)
+	}
+	if err := repo.Revoke(ctx, "jti-revoked", "u2", revokedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "失効より前に発行", jti: "a", userID: "u1", issuedAt: revokedAt.Add(-time.Minute), want: true},
+		// iat は秒単位のため、同じ秒に発行されたトークンも失効させる
+		{name: "失効と同じ秒に発行", jti: "b", userID: "u1", issuedAt: revokedAt.Truncate(time.Second), want: true},
+		{name: "失効より後に発行", jti: "c", userID: "u1", issuedAt: revokedAt.Add(time.Second), want: false},
+		{name: "jti のない旧形式のトークン", userID: "u1", issuedAt: revokedAt.Add(-time.Minute), want: true},
+		{name: "他のユーザーには影響しない", jti: "d", userID: "u3", issuedAt: revokedAt.Add(-time.Minute), want: false},
+		{name: "トークン単位の失効", jti: "jti-revoked", userID: "u2", issuedAt: revokedAt.Add(time.Minute), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.IsRevoked(ctx, tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRevocationRepositoryRevokeOnce(t *testing.T) {
+	db, _ := newTestDB()
+	repo := NewTokenRevocationRepository(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	if revoked, err := repo.RevokeOnce(ctx, "jti-1", "u1", expiresAt); err != nil || !revoked {
+		t.Fatalf("RevokeOnce() = %v, %v, want true", revoked, err)
+	}
+	// 2回目は失効済みとして false（エラーにはしない）
+	if revoked, err := repo.RevokeOnce(ctx, "jti-1", "u1", expiresAt); err != nil || revoked {
+		t.Errorf("RevokeOnce(again) = %v, %v, want false", revoked, err)
+	}
+	// ログアウトの Revoke は失効済みでも成功する
+	if err := repo.Revoke(ctx, "jti-1", "u1", expiresAt); err != nil {
+		t.Errorf("Revoke(already revoked) error = %v", err)
+	}
+	if revoked, err := repo.IsRevoked(ctx, "jti-1", "u1", time.Now()); err != nil || !revoked {
+		t.Errorf("IsRevoked() = %v, %v, want true", revoked, err)
+	}
+}
@@ -0,0 +1,72 @@
+// backend/internal/repository/ttl.go
+// TTL（Time To Live）属性の共通処理
+//
+// 【TTL 属性の形式】
+//   属性名: TTL（テーブルの TimeToLiveSpecification と同じ。cmd/migrate で有効化する）
+//   型:     Number（Unix Epoch 秒）
+//   DynamoDB は Number 以外（RFC3339 の文字列など）の TTL をエラーにせず無視するため、
+//   誤った形式で書き込んでもアイテムが削除されないことに気づけない
+//   ※ SK などに使う日時は RFC3339 の文字列（並び順のため）。TTL だけは形式が違う
+//
+// 【使い方】
+//   - レコードの構造体: TTL フィールドを int64 で宣言し、ttlEpoch で計算した値を入れて marshalWithTTL で変換する
+//   - 式で書き込む場合（UpdateItem・Put の Item を直接組み立てる場合）: 値は ttlValue で作る
+//     TTL は予約語のため、式では ExpressionAttributeNames に "#ttl": ttlAttribute を指定する
+
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ttlAttribute は TTL に使う属性名
+const ttlAttribute = "TTL"
+
+// maxTTLEpoch はこれを超える TTL をミリ秒などの単位の誤りとみなす上限（西暦5000年頃の Unix Epoch 秒）
+const maxTTLEpoch = 1e11
+
+// ttlEpoch は t を TTL の値（Unix Epoch 秒）にする
+func ttlEpoch(t time.Time) int64 {
+	return t.Unix()
+}
+
+// ttlValue は TTL の値を Number 型の AttributeValue にする
+func ttlValue(epoch int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(epoch, 10)}
+}
+
+// marshalWithTTL は record を attributevalue.MarshalMap で変換し、TTL 属性の形式を確認する
+// TTL フィールドを string や time.Time で宣言した場合などは、書き込む前にエラーにする
+// TTL 属性がない（omitempty で省略された）場合は確認しない
+func marshalWithTTL(record any) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTTL(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// checkTTL はアイテムの TTL 属性が正の Unix Epoch 秒の Number 型であることを確認する
+func checkTTL(item map[string]types.AttributeValue) error {
+	av, ok := item[ttlAttribute]
+	if !ok {
+		return nil
+	}
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return fmt.Errorf("%s attribute must be a Number of Unix epoch seconds, got %T", ttlAttribute, av)
+	}
+	epoch, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil || epoch <= 0 || epoch > maxTTLEpoch {
+		return fmt.Errorf("%s attribute must be Unix epoch seconds, got %q", ttlAttribute, n.Value)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+func TestCheckTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     types.AttributeValue // nil の場合は TTL 属性なし
+		wantErr bool
+	}{
+		{name: "Unix Epoch 秒の Number", ttl: ttlValue(ttlEpoch(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))},
+		{name: "TTL 属性なし"},
+		{name: "RFC3339 の String", ttl: &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00Z"}, wantErr: true},
+		// 数字でも String 型の TTL は DynamoDB に無視される
+		{name: "数字の String", ttl: &types.AttributeValueMemberS{Value: "1735689600"}, wantErr: true},
+		{name: "ミリ秒の Number", ttl: &types.AttributeValueMemberN{Value: "1735689600000"}, wantErr: true},
+		{name: "0", ttl: &types.AttributeValueMemberN{Value: "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "X"}}
+			if tt.ttl != nil {
+				item[ttlAttribute] = tt.ttl
+			}
+			if err := checkTTL(item); (err != nil) != tt.wantErr {
+				t.Errorf("checkTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarshalWithTTLRejectsStringField(t *testing.T) {
+	record := struct {
+		PK  string `dynamodbav:"PK"`
+		TTL string `dynamodbav:"TTL"`
+	}{PK: "X", TTL: time.Now().Format(time.RFC3339)}
+
+	if _, err := marshalWithTTL(record); err == nil {
+		t.Error("marshalWithTTL() accepted a String TTL")
+	}
+}
+
+// TestRepositoriesWriteNumericTTL は TTL を持つアイテムを書き込む各リポジトリが、TTL を Number 型で書き込むことを確認する
+// TTL を持つアイテムを追加した場合は、ここにも書き込みを追加すること
+func TestRepositoriesWriteNumericTTL(t *testing.T) {
+	ctx := context.Background()
+	db, fake := newTestDB()
+	now := time.Now()
+	seedProduct(fake, "p1", 10, 0)
+
+	// 順に実行する（取り置きの解除は取り置きの後）
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{"activity", func() error {
+			return NewActivityRepository(db).Create(ctx, &domain.UserActivity{UserID: "u1", ActionType: "VIEW", ProductID: "p1"})
+		}},
+		{"activity batch", func() error {
+			return NewActivityRepository(db).BatchCreate(ctx, []*domain.UserActivity{{UserID: "u2", ActionType: "VIEW", ProductID: "p1"}})
+		}},
+		{"cart", func() error {
+			return NewCartRepository(db).Add(ctx, &domain.CartItem{UserID: "u1", ProductID: "p1", ProductName: "p1", Price: 100, Quantity: 1}, 99)
+		}},
+		{"hold", func() error {
+			return NewHoldRepository(db).Reserve(ctx, &domain.StockHold{UserID: "u1", ProductID: "p1", Quantity: 1, ExpiresAt: now.Add(time.Hour)}, 0, 10)
+		}},
+		{"hold release marker", func() error {
+			_, err := NewHoldRepository(db).ReleaseByTTL(ctx, "seq-hold", "p1", 1)
+			return err
+		}},
+		{"idempotency", func() error {
+			_, err := NewIdempotencyRepository(db).Acquire(ctx, "key", "u1")
+			return err
+		}},
+		{"password reset", func() error {
+			return NewPasswordResetRepository(db).Create(ctx, "hash", "u1", now.Add(time.Hour))
+		}},
+		{"rate limit", func() error {
+			_, err := NewRateLimitRepository(db).Hit(ctx, "key", time.Hour, now)
+			return err
+		}},
+		{"reservation", func() error {
+			return NewReservationRepository(db).Begin(ctx, &domain.StockReservation{ID: "r1", UserID: "u1", Quantities: map[string]int{"p1": 1}, ExpiresAt: now.Add(time.Hour), CreatedAt: now})
+		}},
+		{"revoked token", func() error {
+			return NewTokenRevocationRepository(db).Revoke(ctx, "jti", "u1", now.Add(time.Hour))
+		}},
+		{"revoked user", func() error {
+			return NewTokenRevocationRepository(db).RevokeUser(ctx, "u1", now, now.Add(time.Hour))
+		}},
+		{"sales stats marker", func() error {
+			_, err := NewSalesStatsRepository(db).AddSale(ctx, "seq-sales", "p1", 1, 100)
+			return err
+		}},
+		{"also bought marker", func() error {
+			_, err := NewAlsoBoughtRepository(db).AddOrder(ctx, "seq-also", "p1", []string{"p2"})
+			return err
+		}},
+	}
+	for _, w := range writes {
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: write error = %v", w.name, err)
+		}
+	}
+
+	withTTL := 0
+	for _, item := range fake.Items() {
+		av, ok := item[ttlAttribute]
+		if !ok {
+			continue
+		}
+		withTTL++
+		pk := item["PK"].(*types.AttributeValueMemberS).Value
+		if _, ok := av.(*types.AttributeValueMemberN); !ok {
+			t.Errorf("%s: %s attribute is %T, want a Number", pk, ttlAttribute, av)
+			continue
+		}
+		// 正の Unix Epoch 秒か（ミリ秒などの単位の誤りを含む）
+		if err := checkTTL(item); err != nil {
+			t.Errorf("%s: %v", pk, err)
+		}
+	}
+	// 書き込みごとに TTL を持つアイテムが1件以上ある
+	if withTTL < len(writes) {
+		t.Errorf("found %d items with %s, want at least %d", withTTL, ttlAttribute, len(writes))
+	}
+}
@@ -1,8 +1,21 @@
+// user_repo.go
+// ユーザーデータのDynamoDB操作を担当するリポジトリ
+//
+// 【キー設計】
+//   プロフィール:       PK=USER#<userId>,  SK=PROFILE  （GSI1: GSI1PK=USER, GSI1SK=EMAIL#<email>）
+//   メールアドレス予約: PK=EMAIL#<email>,  SK=EMAIL    （一意性制約用のマーカー）
+//
+// 【メールアドレスの一意性】
+//   GSIは結果整合性のため、GSI1での重複チェックだけでは同時登録を防げない
+//   メールアドレスをPKにしたマーカーアイテムを attribute_not_exists(PK) 付きで
+//   プロフィールと同じトランザクションで書き込むことで一意性を保証する
+
 package repository
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -18,6 +31,17 @@ import (
 var ErrUserNotFound = errors.New("user not found")
 var ErrEmailAlreadyExists = errors.New("email already exists")
 
+// ErrDuplicateEmail は同じメールアドレスのプロフィールが複数見つかったことを表す
+// 一意性のマーカーを導入する前のデータなどで起こりうる（どのユーザーとしてもログインさせない）
+var ErrDuplicateEmail = errors.New("multiple users share the same email")
+
+// emailMarkerRecord はメールアドレスの一意性を保証するためのマーカー
+type emailMarkerRecord struct {
+	PK     string `dynamodbav:"PK"` // EMAIL#<email>
+	SK     string `dynamodbav:"SK"` // EMAIL
+	UserID string `dynamodbav:"userId"`
+}
+
 // DynamoDB用の内部構造体
 type userRecord struct {
 	PK           string `dynamodbav:"PK"`
@@ -65,19 +89,255 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	if err != nil {
 		return err
 	}
+	marker, err := attributevalue.MarshalMap(emailMarkerRecord{
+		PK:     "EMAIL#" + user.Email,
+		SK:     "EMAIL",
+		UserID: user.ID,
+	})
+	if err != nil {
+		return err
+	}
 
 	// ConditionExpression: 条件付き書き込み
 	// - ここでは「PKが存在しない場合のみ書き込む」という条件を指定している
 	// - 既に同じPKが存在する場合はConditionalCheckFailedExceptionエラー
 	// - これにより重複登録を防止
 	// ConditionExpressionがないと、PutItemは同じPKのアイテムを無条件で上書きしてしまう
-	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           r.db.Table(),
-		Item:                item,
-		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	// 【トランザクション】プロフィールとメールアドレスのマーカーを同時に書き込む
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           r.db.Table(),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           r.db.Table(),
+					Item:                marker,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		// マーカー（2番目の操作）の条件失敗はメールアドレスの重複
+		if isConditionFailedAt(err, 1) {
+			return ErrEmailAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Update はユーザーの名前・メールアドレスを更新する
+// 【使用API】
+//   - メールアドレス変更なし: UpdateItem（attribute_exists(PK) で存在チェック）
+//   - メールアドレス変更あり: TransactWriteItems
+//     1. プロフィール更新（GSI1SK も新しいメールアドレスに変更）
+//     2. 新しいメールアドレスのマーカー作成（attribute_not_exists で一意性チェック）
+//     3. 古いメールアドレスのマーカー削除
+//
+// 【注意】パスワードはこのメソッドでは更新しない
+func (r *UserRepository) Update(ctx context.Context, user *domain.User, oldEmail string) error {
+	now := time.Now()
+	user.UpdatedAt = now
+
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + user.ID},
+		"SK": &types.AttributeValueMemberS{Value: "PROFILE"},
+	}
+
+	if user.Email == oldEmail {
+		_, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:           r.db.Table(),
+			Key:                 key,
+			UpdateExpression:    aws.String("SET #name = :name, updatedAt = :now"),
+			ConditionExpression: aws.String("attribute_exists(PK)"),
+			ExpressionAttributeNames: map[string]string{
+				"#name": "name", // name は予約語
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":name": &types.AttributeValueMemberS{Value: user.Name},
+				":now":  &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+			},
+		})
+		if err != nil {
+			var cfe *types.ConditionalCheckFailedException
+			if errors.As(err, &cfe) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		return nil
+	}
+
+	marker, err := attributevalue.MarshalMap(emailMarkerRecord{
+		PK:     "EMAIL#" + user.Email,
+		SK:     "EMAIL",
+		UserID: user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			// 1. プロフィール更新
+			{
+				Update: &types.Update{
+					TableName:           r.db.Table(),
+					Key:                 key,
+					UpdateExpression:    aws.String("SET #name = :name, email = :email, GSI1SK = :gsi1sk, updatedAt = :now"),
+					ConditionExpression: aws.String("attribute_exists(PK)"),
+					ExpressionAttributeNames: map[string]string{
+						"#name": "name",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":name":   &types.AttributeValueMemberS{Value: user.Name},
+						":email":  &types.AttributeValueMemberS{Value: user.Email},
+						":gsi1sk": &types.AttributeValueMemberS{Value: "EMAIL#" + user.Email},
+						":now":    &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+					},
+				},
+			},
+			// 2. 新しいメールアドレスのマーカー作成
+			{
+				Put: &types.Put{
+					TableName:           r.db.Table(),
+					Item:                marker,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			// 3. 古いメールアドレスのマーカー削除
+			{
+				Delete: &types.Delete{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "EMAIL#" + oldEmail},
+						"SK": &types.AttributeValueMemberS{Value: "EMAIL"},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return ErrUserNotFound
+		}
+		if isConditionFailedAt(err, 1) {
+			return ErrEmailAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePassword はユーザーのパスワードハッシュを更新する
+// 【使用API】UpdateItem + ConditionExpression（存在するユーザーのみ）
+// ユーザーが存在しない場合は ErrUserNotFound を返す
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	_, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: r.db.Table(),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "PROFILE"},
+		},
+		UpdateExpression:    aws.String("SET passwordHash = :hash, updatedAt = :now"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hash": &types.AttributeValueMemberS{Value: passwordHash},
+			":now":  &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete はユーザーのアカウントを削除する（退会）
+// 【処理フロー】
+//  1. カートアイテム（PK=USER#<userId>, SK=CART#...）を Query で列挙し、BatchWriteItem で削除
+//  2. プロフィールとメールアドレスのマーカーをトランザクションでまとめて削除
+//     → メールアドレスだけが予約されたまま残る（再登録できない）状態を作らない
+//
+// プロフィールを最後に削除するため、途中で失敗してもログインしたまま再実行できる
+// 注文（USER#<userId> / ORDER#...）は経理上の記録として残す（ACTIVITY# の行動ログも残る）
+// プロフィールが存在しない場合は ErrUserNotFound を返す
+func (r *UserRepository) Delete(ctx context.Context, user *domain.User) error {
+	// 1. カートアイテムの削除（TTL で期限切れになったが未削除のアイテムも含めて消す）
+	items, err := queryAllPages(ctx, r.db.Client, &dynamodb.QueryInput{
+		TableName:              r.db.Table(),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "USER#" + user.ID},
+			":sk": &types.AttributeValueMemberS{Value: "CART#"},
+		},
+		ProjectionExpression: aws.String("PK, SK"),
+	}, 0)
+	if err != nil {
+		return err
+	}
+	keys := make([]map[string]types.AttributeValue, len(items))
+	for i, item := range items {
+		keys[i] = keyFromItem(item, "PK", "SK")
+	}
+	if err := batchDeleteKeys(ctx, r.db, keys); err != nil {
+		return err
+	}
+
+	// 2. プロフィール + メールアドレスのマーカー
+	_, err = r.db.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "USER#" + user.ID},
+						"SK": &types.AttributeValueMemberS{Value: "PROFILE"},
+					},
+					ConditionExpression: aws.String("attribute_exists(PK)"),
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName: r.db.Table(),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "EMAIL#" + user.Email},
+						"SK": &types.AttributeValueMemberS{Value: "EMAIL"},
+					},
+				},
+			},
+		},
 	})
+	if err != nil {
+		if isConditionFailedAt(err, 0) {
+			return ErrUserNotFound
+		}
+		return err
+	}
 
-	return err
+	return nil
+}
+
+// isConditionFailedAt はトランザクションの index 番目の操作が条件チェックで失敗したかを判定する
+// CancellationReasons は TransactItems と同じ順序で返される
+func isConditionFailedAt(err error, index int) bool {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) || index >= len(tce.CancellationReasons) {
+		return false
+	}
+	code := tce.CancellationReasons[index].Code
+	return code != nil && *code == "ConditionalCheckFailed"
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
@@ -115,6 +375,56 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User,
 	}, nil
 }
 
+// BatchGetByIDs は複数のユーザーをまとめて取得し、ユーザーIDをキーとしたマップで返す（管理画面の表示用）
+// 【使用API】BatchGetItem（PK=USER#<id>, SK=PROFILE）
+//   - 同じキーを重複して指定するとエラーになるため、ID は重複を除いてから取得する
+//   - 分割と UnprocessedKeys の再試行は batchGetWithRetry に任せる
+//   - ProjectionExpression でパスワードハッシュを読み込まない（PasswordHash は常に空）
+//
+// 存在しないユーザーはマップに含まれない（エラーにはしない）
+func (r *UserRepository) BatchGetByIDs(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+	users := make(map[string]*domain.User, len(ids))
+
+	keys := make([]map[string]types.AttributeValue, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + id},
+			"SK": &types.AttributeValueMemberS{Value: "PROFILE"},
+		})
+	}
+
+	// name は予約語のため ExpressionAttributeNames で置き換える
+	items, err := batchGetWithRetry(ctx, r.db, keys, types.KeysAndAttributes{
+		ProjectionExpression:     aws.String("id, email, #name, createdAt, updatedAt"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var record userRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, err
+		}
+		users[record.ID] = &domain.User{
+			ID:        record.ID,
+			Email:     record.Email,
+			Name:      record.Name,
+			CreatedAt: timeutil.ParseTime(record.CreatedAt),
+			UpdatedAt: timeutil.ParseTime(record.UpdatedAt),
+		}
+	}
+
+	return users, nil
+}
+
+// GetByEmail はメールアドレスでユーザーを取得する（ログイン・登録時の重複確認用）
+// 同じメールアドレスのプロフィールが複数ある場合は、どれか1件を返さずに ErrDuplicateEmail を返す
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	// Query: 条件に一致する複数アイテムを取得
 	// - GetItemとの違い: PKだけでなくSKにも条件（範囲・前方一致など）を指定可能
@@ -122,6 +432,8 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	//   メインテーブルのPKはUSER#<id>なので、IDがわからないとGetItemできない
 	//   GSI1を使うことでemailからユーザーを検索可能にしている
 	// - KeyConditionExpressionでSKに使える演算子: =, begins_with, BETWEEN, <, <=, >, >=
+	// - ProjectionExpression でログインに必要な属性だけを読む（キー属性は返さない）
+	// - 重複を検出できればよいため Limit は2件
 	result, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              r.db.Table(),
 		IndexName:              aws.String("GSI1"),
@@ -130,6 +442,11 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 			":pk": &types.AttributeValueMemberS{Value: "USER"},
 			":sk": &types.AttributeValueMemberS{Value: "EMAIL#" + email},
 		},
+		ProjectionExpression: aws.String("id, email, #name, passwordHash, createdAt, updatedAt"),
+		ExpressionAttributeNames: map[string]string{
+			"#name": "name", // name は予約語
+		},
+		Limit: aws.Int32(2),
 	})
 	if err != nil {
 		return nil, err
@@ -138,6 +455,16 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	if len(result.Items) == 0 {
 		return nil, ErrUserNotFound
 	}
+	if len(result.Items) > 1 {
+		// どちらのユーザーとしてログインさせるべきか判断できないため、エラーにして調査できるようにする
+		ids := make([]string, 0, len(result.Items))
+		for _, item := range result.Items {
+			if id, ok := item["id"].(*types.AttributeValueMemberS); ok {
+				ids = append(ids, id.Value)
+			}
+		}
+		return nil, fmt.Errorf("%w: user ids %v", ErrDuplicateEmail, ids)
+	}
 
 	var record userRecord
 	if err = attributevalue.UnmarshalMap(result.Items[0], &record); err != nil {
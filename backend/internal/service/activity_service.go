@@ -36,13 +36,21 @@ var (
 	ErrInvalidActionType = errors.New("invalid action type")
 )
 
+// 最近閲覧した商品の既定の件数と上限
+const (
+	DefaultRecentlyViewedLimit = 10
+	MaxRecentlyViewedLimit     = 50
+)
+
 type ActivityService struct {
 	activityRepo *repository.ActivityRepository
+	productRepo  *repository.ProductRepository
 }
 
-func NewActivityService(activityRepo *repository.ActivityRepository) *ActivityService {
+func NewActivityService(activityRepo *repository.ActivityRepository, productRepo *repository.ProductRepository) *ActivityService {
 	return &ActivityService{
 		activityRepo: activityRepo,
+		productRepo:  productRepo,
 	}
 }
 
@@ -107,3 +115,42 @@ func (s *ActivityService) GetUserActivitiesByAction(ctx context.Context, userID
 
 	return s.activityRepo.GetByUserIDAndAction(ctx, userID, actionType, limit)
 }
+
+// GetRecentlyViewed はユーザーが最近閲覧した商品を新しい順に返す（VIEW の行動ログから作る）
+// 同じ商品を何度閲覧しても1件にまとめる。削除済み（物理・論理）の商品は除く
+// limit が範囲外の場合は既定値・上限に丸める
+func (s *ActivityService) GetRecentlyViewed(ctx context.Context, userID string, limit int) ([]*domain.Product, error) {
+	if limit <= 0 {
+		limit = DefaultRecentlyViewedLimit
+	}
+	if limit > MaxRecentlyViewedLimit {
+		limit = MaxRecentlyViewedLimit
+	}
+
+	// 削除済みの商品を除いても limit 件に届くよう、多めに読んでおく
+	ids, err := s.activityRepo.GetRecentProductIDsByAction(ctx, userID, ActionTypeView, limit*2)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*domain.Product{}, nil
+	}
+
+	products, err := s.productRepo.BatchGetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	viewed := make([]*domain.Product, 0, limit)
+	for _, id := range ids {
+		product, ok := products[id]
+		if !ok || product.DeletedAt != nil {
+			continue
+		}
+		viewed = append(viewed, product)
+		if len(viewed) == limit {
+			break
+		}
+	}
+	return viewed, nil
+}
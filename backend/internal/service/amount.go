@@ -0,0 +1,38 @@
+// backend/internal/service/amount.go
+// 金額（円、int）の計算
+//
+// 【オーバーフロー】
+//   int の範囲を超えると Go はエラーにせず値が折り返す（合計が負になる）
+//   32bit 環境の int は約21億円が上限のため、高額な商品を大量に注文すると現実的に起こりうる
+//   → 価格 × 数量、合計の加算は mulAmount / addAmount で行い、範囲を超える場合は ErrAmountOverflow を返す
+
+package service
+
+import "math"
+
+// mulAmount は単価 × 数量を返す（負の値は渡さないこと）
+func mulAmount(price, quantity int) (int, error) {
+	if price != 0 && quantity > math.MaxInt/price {
+		return 0, ErrAmountOverflow
+	}
+	return price * quantity, nil
+}
+
+// addAmount は金額の和を返す（負の値は渡さないこと）
+func addAmount(a, b int) (int, error) {
+	if a > math.MaxInt-b {
+		return 0, ErrAmountOverflow
+	}
+	return a + b, nil
+}
+
+// lineTotal はカート・注文の明細の小計（単価 × 数量）を合計に加えた値と、その明細の小計を返す
+func lineTotal(total, price, quantity int) (newTotal, subtotal int, err error) {
+	if subtotal, err = mulAmount(price, quantity); err != nil {
+		return 0, 0, err
+	}
+	if newTotal, err = addAmount(total, subtotal); err != nil {
+		return 0, 0, err
+	}
+	return newTotal, subtotal, nil
+}
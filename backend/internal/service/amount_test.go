@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestLineTotal(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		price        int
+		quantity     int
+		wantTotal    int
+		wantSubtotal int
+		wantErr      error
+	}{
+		{name: "通常の明細", total: 1000, price: 250, quantity: 4, wantTotal: 2000, wantSubtotal: 1000},
+		{name: "上限ちょうど", total: 0, price: math.MaxInt / 2, quantity: 2, wantTotal: math.MaxInt / 2 * 2, wantSubtotal: math.MaxInt / 2 * 2},
+		{name: "高額な商品を大量に注文すると小計があふれる", price: math.MaxInt/1000 + 1, quantity: 1000, wantErr: ErrAmountOverflow},
+		{name: "小計はあふれなくても合計があふれる", total: math.MaxInt - 100, price: 50, quantity: 3, wantErr: ErrAmountOverflow},
+		{name: "数量0", total: 500, price: math.MaxInt, quantity: 0, wantTotal: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, subtotal, err := lineTotal(tt.total, tt.price, tt.quantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lineTotal() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if total != tt.wantTotal || subtotal != tt.wantSubtotal {
+				t.Errorf("lineTotal() = (%d, %d), want (%d, %d)", total, subtotal, tt.wantTotal, tt.wantSubtotal)
+			}
+			if total < 0 || subtotal < 0 {
+				t.Errorf("lineTotal() wrapped around: (%d, %d)", total, subtotal)
+			}
+		})
+	}
+}
+
+func TestCalculateTaxOverflow(t *testing.T) {
+	if _, err := calculateTax(math.MaxInt, 0.10); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("calculateTax(MaxInt) error = %v, want ErrAmountOverflow", err)
+	}
+}
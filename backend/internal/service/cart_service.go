@@ -6,52 +6,109 @@
 //   2. AddItem     - カート追加（在庫チェック付き）
 //   3. UpdateQuantity - 数量更新（楽観的ロック + リトライ）
 //   4. RemoveItem  - カートからアイテム削除
+//   5. ListAbandonedCarts - 放置カートの一覧（管理者用）
 //
 // 【学習ポイント】
 //   - 楽観的ロックのリトライロジック
 //   - 在庫チェック（条件付き書き込みの前準備）
+//
+// 【在庫チェックの基準】
+//   購入可能数 = stock - reservedStock（チェックアウト中・取り置き中の数量を除く）
+//   ただし自分の取り置き分は reservedStock に含まれていても自分は購入できるため、足し戻して判定する
+//   数量が購入可能数と等しい場合は追加・更新できる（超える場合のみ ErrInsufficientStock）
+//
+// 【1商品あたりの数量の上限（maxQuantity）】
+//   在庫が多い商品でも極端な数量を入れられないよう、追加・更新後の数量を maxQuantity までに制限する
+//   （超える場合は ErrQuantityLimitExceeded。金額のオーバーフローの防止も兼ねる）
 
 package service
 
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 )
 
 var (
-	ErrInsufficientStock   = errors.New("insufficient stock for the requested item")
-	ErrInvalidQuantity     = errors.New("quantity must be greater than 0")
-	ErrOptimisticLockRetry = errors.New("failed to update after max retries due to concurrent modifications")
+	ErrInsufficientStock   = apperr.BadRequest("insufficient_stock", "Insufficient stock")
+	ErrInvalidQuantity     = apperr.BadRequest("invalid_quantity", "Invalid quantity")
+	ErrOptimisticLockRetry = apperr.Conflict("concurrent_modification", "Failed to update due to concurrent modifications, please retry")
+	ErrCartFull            = apperr.BadRequest("cart_full", "Cart has reached the maximum number of items")
+	ErrQuantityLimit       = apperr.BadRequest("quantity_limit_exceeded", "Quantity exceeds the maximum per item")
 )
 
 const maxRetries = 3
 
+// 放置カートを遡って探す最大日数（これより古い更新日のバケットは読まない）
+const abandonedCartLookbackDays = 90
+
+// maxAbandonedCartLimit は放置カート一覧の1ページあたりの件数の上限
+const maxAbandonedCartLimit = repository.MaxUpdatedBeforeLimit
+
+// abandonedCartConcurrency は放置カートの候補のカートを並行して読み込む数
+const abandonedCartConcurrency = 8
+
 type CartService struct {
 	cartRepo    *repository.CartRepository
 	productRepo *repository.ProductRepository
+	holdRepo    *repository.HoldRepository // 在庫の取り置き（CART_RESERVATIONS_ENABLED=false の場合は nil）
+	maxItems    int                        // 1つのカートに入れられる商品の種類数の上限
+	maxQuantity int                        // カートの1商品あたりの数量の上限
 }
 
-func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *CartService {
+func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, holdRepo *repository.HoldRepository, maxItems, maxQuantity int) *CartService {
 	return &CartService{
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
+		holdRepo:    holdRepo,
+		maxItems:    maxItems,
+		maxQuantity: maxQuantity,
 	}
 }
 
-func (s *CartService) GetCart(ctx context.Context, userID string) (*domain.Cart, error) {
+// GetCart はカートの内容と合計金額を返す
+// 既定ではカート追加時点の価格（スナップショット）で合計する
+// refreshPrices が true の場合は現在の商品価格を取得し、変わっていたアイテムの価格を更新してから合計する
+// 削除された（物理・論理削除）商品のアイテムは Unavailable を設定し、合計金額には含めない
+func (s *CartService) GetCart(ctx context.Context, userID string, refreshPrices bool) (*domain.Cart, error) {
 	items, err := s.cartRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+	products, err := s.productRepo.BatchGetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshPrices {
+		items, err = s.refreshPrices(ctx, userID, items, products)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	cartItems := make([]domain.CartItem, len(items))
 	var totalPrice int
 	for i, item := range items {
 		cartItems[i] = *item
-		totalPrice += item.Price * item.Quantity
+		if product, ok := products[item.ProductID]; !ok || product.DeletedAt != nil {
+			cartItems[i].Unavailable = true
+			continue
+		}
+		if totalPrice, _, err = lineTotal(totalPrice, item.Price, item.Quantity); err != nil {
+			return nil, err
+		}
 	}
 
 	return &domain.Cart{
@@ -61,30 +118,70 @@ func (s *CartService) GetCart(ctx context.Context, userID string) (*domain.Cart,
 	}, nil
 }
 
+// refreshPrices はカートアイテムの価格を現在の商品価格（products）に合わせて更新する
+// 【使用API】価格が変わったアイテムだけ UpdateItem（商品は呼び出し側が BatchGetItem でまとめて取得する）
+// 削除された商品（物理・論理削除）は価格を更新せずスナップショットのまま残す
+// 取得後に削除されたカートアイテムは結果から除く
+func (s *CartService) refreshPrices(ctx context.Context, userID string, items []*domain.CartItem, products map[string]*domain.Product) ([]*domain.CartItem, error) {
+	refreshed := make([]*domain.CartItem, 0, len(items))
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok || product.DeletedAt != nil || product.Price == item.Price {
+			refreshed = append(refreshed, item)
+			continue
+		}
+
+		updated, err := s.cartRepo.UpdatePrice(ctx, userID, item.ProductID, product.Name, product.Price)
+		if err != nil {
+			if errors.Is(err, repository.ErrCartItemNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		updated.PriceChanged = true
+		updated.PreviousPrice = item.Price
+		refreshed = append(refreshed, updated)
+	}
+	return refreshed, nil
+}
+
+// GetItemCount はカート内のアイテム数を取得する
+// GetCart の ItemCount と同じ値（商品の種類数）を、アイテム本体を読み込まずに返す
+func (s *CartService) GetItemCount(ctx context.Context, userID string) (*domain.CartCount, error) {
+	count, err := s.cartRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CartCount{Count: count}, nil
+}
+
 // AddItem はカートにアイテムを追加する
 // 【在庫チェック】商品の在庫数を確認し、不足している場合はエラー
-// 【既存アイテム】既にカートにある場合は数量を加算
+// 【既存アイテム】既にカートにある場合は数量を加算（同時に追加されても加算漏れしない）
+// 【種類数の上限】カートにない商品を追加して maxItems 種類を超える場合は ErrCartFull
+// 【数量の上限】追加する数量、および既存の数量に加算した後の数量が maxQuantity を超える場合は ErrQuantityLimit
 func (s *CartService) AddItem(ctx context.Context, userID string, req *domain.AddToCartRequest) (*domain.CartItem, error) {
 	if req.Quantity <= 0 {
 		return nil, ErrInvalidQuantity
 	}
+	if req.Quantity > s.maxQuantity {
+		return nil, s.quantityLimitError()
+	}
+	if err := s.checkCartSize(ctx, userID, req.ProductID); err != nil {
+		return nil, err
+	}
 
 	// 商品情報を取得（在庫チェック + 商品名・価格の取得）
 	product, err := s.productRepo.GetByID(ctx, req.ProductID)
 	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, ErrProductNotFound.WithCause(err)
+		}
 		return nil, err
 	}
-
-	// 既存のカートアイテムを確認
-	existingItem, err := s.cartRepo.GetItem(ctx, userID, req.ProductID)
-	if err != nil && !errors.Is(err, repository.ErrCartItemNotFound) {
-		return nil, err
-	}
-
-	// 追加後の合計数量を計算
-	totalQuantity := req.Quantity
-	if existingItem != nil {
-		totalQuantity += existingItem.Quantity
+	// 論理削除済みの商品はカートに追加できない
+	if product.DeletedAt != nil {
+		return nil, ErrProductNotFound.WithCause(repository.ErrProductNotFound)
 	}
 
 	// 在庫チェック
@@ -92,21 +189,16 @@ func (s *CartService) AddItem(ctx context.Context, userID string, req *domain.Ad
 	// ここでの在庫チェックは「楽観的」なチェック
 	// 実際の在庫減算は注文確定時にトランザクション + 条件付き書き込みで行う
 	// カート追加時点では在庫を確保しない（ECサイトの一般的なパターン）
-	if product.Stock < totalQuantity {
-		return nil, ErrInsufficientStock
+	available, err := s.availableStock(ctx, userID, product)
+	if err != nil {
+		return nil, err
 	}
-
-	if existingItem != nil {
-		// 既存アイテムがある場合は数量を更新
-		err = s.updateQuantityWithRetry(ctx, userID, req.ProductID, totalQuantity, existingItem.Version)
-		if err != nil {
-			return nil, err
-		}
-		// 更新後のアイテムを取得して返却
-		return s.cartRepo.GetItem(ctx, userID, req.ProductID)
+	if available < req.Quantity {
+		return nil, ErrInsufficientStock
 	}
 
-	// 新規アイテムを追加
+	// 追加（既にカートにある場合は数量を加算）
+	// 加算は DynamoDB 側でアトミックに行い、加算後の数量が在庫数・数量の上限の小さい方を超えないことも条件式で確認する
 	// 商品の価格が変わってもカート内の価格は変わらないようにする
 	// 注文確定時に最新価格を使うかどうかはビジネス要件次第
 	item := &domain.CartItem{
@@ -117,7 +209,14 @@ func (s *CartService) AddItem(ctx context.Context, userID string, req *domain.Ad
 		Quantity:    req.Quantity,
 	}
 
-	if err := s.cartRepo.Add(ctx, item); err != nil {
+	if err := s.cartRepo.Add(ctx, item, min(available, s.maxQuantity)); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			// 条件式の上限がどちらだったかで、在庫不足か数量の上限超過かを判断する
+			if s.maxQuantity < available {
+				return nil, s.quantityLimitError().WithCause(err)
+			}
+			return nil, ErrInsufficientStock.WithCause(err)
+		}
 		return nil, err
 	}
 
@@ -125,19 +224,34 @@ func (s *CartService) AddItem(ctx context.Context, userID string, req *domain.Ad
 }
 
 // UpdateQuantity はカートアイテムの数量を更新する
+// 【在庫の上限】購入可能数（stock - reservedStock + 自分の取り置き数）ちょうどの数量までは許可し、1つでも超える場合は ErrInsufficientStock
+// 論理削除済みの商品は数量を変更できない（AddItem と同じく ErrProductNotFound）
 // 【楽観的ロック + リトライ】
 // 他のリクエストと競合した場合は最新データを取得してリトライ
 func (s *CartService) UpdateQuantity(ctx context.Context, userID, productID string, req *domain.UpdateCartRequest) (*domain.CartItem, error) {
 	if req.Quantity <= 0 {
 		return nil, ErrInvalidQuantity
 	}
+	if req.Quantity > s.maxQuantity {
+		return nil, s.quantityLimitError()
+	}
 
-	// 商品の在庫チェック
+	// 商品の在庫チェック（購入可能数ちょうどの数量は許可する）
 	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, ErrProductNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+	if product.DeletedAt != nil {
+		return nil, ErrProductNotFound.WithCause(repository.ErrProductNotFound)
+	}
+	available, err := s.availableStock(ctx, userID, product)
 	if err != nil {
 		return nil, err
 	}
-	if product.Stock < req.Quantity {
+	if available < req.Quantity {
 		return nil, ErrInsufficientStock
 	}
 
@@ -151,6 +265,51 @@ func (s *CartService) UpdateQuantity(ctx context.Context, userID, productID stri
 	return s.cartRepo.GetItem(ctx, userID, productID)
 }
 
+// quantityLimitError は数量の上限を含めたメッセージの ErrQuantityLimit を返す
+func (s *CartService) quantityLimitError() *apperr.Error {
+	e := ErrQuantityLimit.WithCause(nil)
+	e.Message = fmt.Sprintf("Quantity per item cannot exceed %d", s.maxQuantity)
+	return e
+}
+
+// checkCartSize はカートに productID を追加しても種類数の上限を超えないかを確認する
+// 上限に達していても、既にカートにある商品（数量の加算）は追加できる
+//
+// 件数の確認と書き込みは別のリクエストのため、同時に別の商品を追加された場合は上限をわずかに超えることがある
+// （カートの削除はバッチで分割して行うため、上限を超えても Clear は失敗しない）
+func (s *CartService) checkCartSize(ctx context.Context, userID, productID string) error {
+	count, err := s.cartRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count < s.maxItems {
+		return nil
+	}
+
+	if _, err := s.cartRepo.GetItem(ctx, userID, productID); err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			return ErrCartFull
+		}
+		return err
+	}
+	return nil
+}
+
+// availableStock は userID がカートに入れられる商品の数量（stock - reservedStock + 自分の取り置き分）を返す
+// 取り置きが無効（holdRepo が nil）の場合は自分の取り置き分を足さない
+func (s *CartService) availableStock(ctx context.Context, userID string, product *domain.Product) (int, error) {
+	available := product.Stock - product.ReservedStock
+	if s.holdRepo != nil {
+		hold, err := s.holdRepo.Get(ctx, userID, product.ID)
+		if err == nil {
+			available += hold.Quantity
+		} else if !errors.Is(err, repository.ErrHoldNotFound) {
+			return 0, err
+		}
+	}
+	return available, nil
+}
+
 // updateQuantityWithRetry は楽観的ロックのリトライロジックを実装
 // 【リトライの仕組み】
 //  1. 指定されたバージョンで更新を試みる
@@ -191,7 +350,133 @@ func (s *CartService) RemoveItem(ctx context.Context, userID, productID string)
 	return s.cartRepo.Delete(ctx, userID, productID)
 }
 
-// ClearCart はカート内の全アイテムを削除する
+// ClearCart はカート内の全アイテムを削除する（カートが空の場合は何もしない）
 func (s *CartService) ClearCart(ctx context.Context, userID string) error {
 	return s.cartRepo.Clear(ctx, userID)
 }
+
+// ListAbandonedCarts は olderThan 以上更新されていない空でないカートを取得する
+// limit は 1〜maxAbandonedCartLimit に丸める
+// 【判定方法】
+//  1. GSI2（更新日バケット）から cutoff より前に更新されたカートアイテムを取得
+//  2. そのユーザーのカート全体を取得し、最も新しいアイテムも cutoff より前なら放置カートとみなす
+//  3. 1ユーザーを1回だけ返すため、カート内で最も新しいアイテムに当たったときだけ結果に含める
+//
+// 2 のカートの取得は候補のページごとにユーザー単位で重複を除き、並行して行う（loadCarts）
+// 各候補アイテムは最大1件のカートにしかならないため、残り件数分だけ候補を取得すれば
+// limit を超えることはなく、カーソルの位置もずれない
+func (s *CartService) ListAbandonedCarts(ctx context.Context, olderThan time.Duration, limit int, cursor string) (*domain.AbandonedCartPage, error) {
+	limit = min(max(limit, 1), maxAbandonedCartLimit)
+	cutoff := time.Now().Add(-olderThan)
+	oldest := cutoff.AddDate(0, 0, -abandonedCartLookbackDays)
+
+	carts := make([]domain.AbandonedCart, 0, limit)
+	for len(carts) < limit {
+		candidates, next, err := s.cartRepo.ListUpdatedBefore(ctx, cutoff, oldest, int32(limit-len(carts)), cursor)
+		if err != nil {
+			return nil, cursorError(err)
+		}
+
+		userCarts, err := s.loadCarts(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, candidate := range candidates {
+			items := userCarts[candidate.UserID]
+			latest := latestCartItem(items)
+			if latest == nil || latest.ProductID != candidate.ProductID || !latest.UpdatedAt.Before(cutoff) {
+				continue
+			}
+
+			cart := domain.AbandonedCart{
+				UserID:        candidate.UserID,
+				ItemCount:     len(items),
+				LastUpdatedAt: latest.UpdatedAt,
+			}
+			for _, item := range items {
+				if cart.TotalValue, _, err = lineTotal(cart.TotalValue, item.Price, item.Quantity); err != nil {
+					return nil, err
+				}
+			}
+			carts = append(carts, cart)
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return &domain.AbandonedCartPage{
+		Carts:      carts,
+		NextCursor: cursor,
+	}, nil
+}
+
+// loadCarts は候補アイテムのユーザーのカートを、ユーザーごとに1回だけ取得する
+// 取得は abandonedCartConcurrency 件ずつ並行して行い、いずれかが失敗した場合は残りをキャンセルしてエラーを返す
+func (s *CartService) loadCarts(ctx context.Context, candidates []*domain.CartItem) (map[string][]*domain.CartItem, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	userIDs := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		if !seen[candidate.UserID] {
+			seen[candidate.UserID] = true
+			userIDs = append(userIDs, candidate.UserID)
+		}
+	}
+
+	// 各 goroutine は results の自分の位置にだけ書き込む
+	results := make([][]*domain.CartItem, len(userIDs))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, abandonedCartConcurrency)
+	for i, userID := range userIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			items, err := s.cartRepo.GetByUserID(ctx, userID)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				cancel()
+				return
+			}
+			results[i] = items
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	carts := make(map[string][]*domain.CartItem, len(userIDs))
+	for i, userID := range userIDs {
+		carts[userID] = results[i]
+	}
+	return carts, nil
+}
+
+// latestCartItem はカート内で最も新しく更新されたアイテムを返す
+// 更新日時が同じ場合は商品IDで順序を決める（GSI2SK の並びと同じ）
+func latestCartItem(items []*domain.CartItem) *domain.CartItem {
+	var latest *domain.CartItem
+	for _, item := range items {
+		if latest == nil ||
+			item.UpdatedAt.After(latest.UpdatedAt) ||
+			(item.UpdatedAt.Equal(latest.UpdatedAt) && item.ProductID > latest.ProductID) {
+			latest = item
+		}
+	}
+	return latest
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// seedCartItem は updatedAt に更新されたカートアイテムを直接書き込む
+func seedCartItem(fake *dynamotest.Fake, userID, productID string, price, quantity int, updatedAt time.Time) {
+	t := updatedAt.UTC()
+	fake.Seed(dynamotest.Item(map[string]any{
+		"PK":          "USER#" + userID,
+		"SK":          "CART#" + productID,
+		"GSI2PK":      "CARTDAY#" + t.Format("2006-01-02"),
+		"GSI2SK":      t.Format(time.RFC3339) + "#" + userID + "#" + productID,
+		"userId":      userID,
+		"productId":   productID,
+		"productName": "商品" + productID,
+		"price":       price,
+		"quantity":    quantity,
+		"version":     1,
+		"addedAt":     t.Format(time.RFC3339),
+		"updatedAt":   t.Format(time.RFC3339),
+		"TTL":         t.Add(repository.CartItemTTL).Unix(),
+	}))
+}
+
+func TestListAbandonedCarts(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-5 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantUsers []string
+		wantValue int
+	}{
+		{name: "放置されたカートだけを返す", limit: 10, wantUsers: []string{"stale"}, wantValue: 1000*2 + 500},
+		{name: "上限を超える limit は丸める", limit: 1 << 40, wantUsers: []string{"stale"}, wantValue: 1000*2 + 500},
+		{name: "limit が 0 以下でも1件は返す", limit: 0, wantUsers: []string{"stale"}, wantValue: 1000*2 + 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			// 全アイテムが古いカート（2アイテムとも候補になるが、1件にまとめる）
+			seedCartItem(fake, "stale", "p1", 1000, 2, stale)
+			seedCartItem(fake, "stale", "p2", 500, 1, stale.Add(-time.Hour))
+			// 古いアイテムがあっても最近更新されたアイテムがあるカートは放置ではない
+			seedCartItem(fake, "fresh", "p1", 1000, 1, stale)
+			seedCartItem(fake, "fresh", "p2", 1000, 1, now)
+			svc := NewCartService(repository.NewCartRepository(db), repository.NewProductRepository(db), nil, 50, 99)
+
+			page, err := svc.ListAbandonedCarts(context.Background(), 3*24*time.Hour, tt.limit, "")
+			if err != nil {
+				t.Fatalf("ListAbandonedCarts() error = %v", err)
+			}
+			if len(page.Carts) != len(tt.wantUsers) {
+				t.Fatalf("ListAbandonedCarts() = %+v, want users %v", page.Carts, tt.wantUsers)
+			}
+			for i, cart := range page.Carts {
+				if cart.UserID != tt.wantUsers[i] || cart.ItemCount != 2 || cart.TotalValue != tt.wantValue {
+					t.Errorf("cart[%d] = %+v", i, cart)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCartsReadsEachUserOnce(t *testing.T) {
+	db, fake := newTestDB()
+	now := time.Now()
+	seedCartItem(fake, "u1", "p1", 100, 1, now)
+	seedCartItem(fake, "u1", "p2", 100, 1, now)
+	seedCartItem(fake, "u2", "p1", 100, 1, now)
+	cartRepo := repository.NewCartRepository(db)
+	svc := NewCartService(cartRepo, repository.NewProductRepository(db), nil, 50, 99)
+
+	u1, err := cartRepo.GetByUserID(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("GetByUserID() error = %v", err)
+	}
+	u2, err := cartRepo.GetByUserID(context.Background(), "u2")
+	if err != nil {
+		t.Fatalf("GetByUserID() error = %v", err)
+	}
+	before := fake.CallCount("Query")
+
+	carts, err := svc.loadCarts(context.Background(), append(u1, u2...))
+	if err != nil {
+		t.Fatalf("loadCarts() error = %v", err)
+	}
+	if len(carts["u1"]) != 2 || len(carts["u2"]) != 1 {
+		t.Errorf("loadCarts() = %v", carts)
+	}
+	if got := fake.CallCount("Query") - before; got != 2 {
+		t.Errorf("loadCarts() issued %d queries, want 2 (one per user)", got)
+	}
+}
+
+func TestAddItemConcurrentIncrements(t *testing.T) {
+	db, _ := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	cartRepo := repository.NewCartRepository(db)
+	product := createTestProduct(t, productRepo, "商品A", 1000, 100)
+	svc := NewCartService(cartRepo, productRepo, nil, 50, 99)
+
+	// 同じ商品の追加を同時に行っても、加算が失われない（上書きされない）
+	const adds = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, adds)
+	for i := 0; i < adds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.AddItem(context.Background(), "u1", &domain.AddToCartRequest{ProductID: product.ID, Quantity: 1})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("AddItem() error = %v", err)
+		}
+	}
+
+	items, err := cartRepo.GetByUserID(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("GetByUserID() error = %v", err)
+	}
+	if len(items) != 1 || items[0].Quantity != adds {
+		t.Fatalf("cart = %+v, want 1 item with quantity %d", items, adds)
+	}
+	if items[0].Version != adds {
+		t.Errorf("version = %d, want %d (one per add)", items[0].Version, adds)
+	}
+}
+
+func TestAddItemConcurrentIncrementsRespectStock(t *testing.T) {
+	db, _ := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	cartRepo := repository.NewCartRepository(db)
+	product := createTestProduct(t, productRepo, "商品A", 1000, 5)
+	svc := NewCartService(cartRepo, productRepo, nil, 50, 99)
+
+	// 在庫を超える同時追加は、加算後の数量の条件式で在庫数までに抑える
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.AddItem(context.Background(), "u1", &domain.AddToCartRequest{ProductID: product.ID, Quantity: 1})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrInsufficientStock) {
+				t.Errorf("AddItem() error = %v, want ErrInsufficientStock", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items, err := cartRepo.GetByUserID(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("GetByUserID() error = %v", err)
+	}
+	if succeeded != 5 || len(items) != 1 || items[0].Quantity != 5 {
+		t.Errorf("succeeded = %d, cart = %+v, want 5 adds and quantity 5", succeeded, items)
+	}
+}
+
+func TestGetCartAmountOverflow(t *testing.T) {
+	db, fake := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	svc := NewCartService(repository.NewCartRepository(db), productRepo, nil, 50, 99)
+	price := math.MaxInt/99 + 1
+	product := createTestProduct(t, productRepo, "高額な商品", price, 100)
+	seedCartItem(fake, "u1", product.ID, price, 99, time.Now())
+
+	if _, err := svc.GetCart(context.Background(), "u1", false); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("GetCart() error = %v, want ErrAmountOverflow", err)
+	}
+}
+
+func TestUpdateQuantityStockBoundary(t *testing.T) {
+	tests := []struct {
+		name      string
+		otherHold int // 他のユーザーの取り置き数（reservedStock に含まれる）
+		ownHold   int // 自分の取り置き数（自分の購入可能数には含める）
+		deleted   bool
+		quantity  int
+		wantErr   error
+	}{
+		{name: "在庫数ちょうど", quantity: 5},
+		{name: "在庫数より1つ多い", quantity: 6, wantErr: ErrInsufficientStock},
+		{name: "他のユーザーの取り置きを除いた数ちょうど", otherHold: 2, quantity: 3},
+		{name: "他のユーザーの取り置きを除いた数より1つ多い", otherHold: 2, quantity: 4, wantErr: ErrInsufficientStock},
+		{name: "自分の取り置き分は購入できる", ownHold: 2, quantity: 5},
+		{name: "論理削除済みの商品", deleted: true, quantity: 1, wantErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, fake := newTestDB()
+			productRepo := repository.NewProductRepository(db)
+			cartRepo := repository.NewCartRepository(db)
+			holdRepo := repository.NewHoldRepository(db)
+			product := createTestProduct(t, productRepo, "商品A", 1000, 5)
+			seedCartItem(fake, "u1", product.ID, 1000, 1, time.Now())
+
+			holds := []struct {
+				userID   string
+				quantity int
+			}{{"u2", tt.otherHold}, {"u1", tt.ownHold}}
+			for _, h := range holds {
+				if h.quantity == 0 {
+					continue
+				}
+				hold := &domain.StockHold{UserID: h.userID, ProductID: product.ID, Quantity: h.quantity, ExpiresAt: time.Now().Add(time.Hour)}
+				if err := holdRepo.Reserve(ctx, hold, 0, product.Stock); err != nil {
+					t.Fatalf("Reserve(%s) error = %v", h.userID, err)
+				}
+			}
+			if tt.deleted {
+				if err := productRepo.SoftDelete(ctx, product.ID, product.Category); err != nil {
+					t.Fatalf("SoftDelete() error = %v", err)
+				}
+			}
+			svc := NewCartService(cartRepo, productRepo, holdRepo, 50, 99)
+
+			item, err := svc.UpdateQuantity(ctx, "u1", product.ID, &domain.UpdateCartRequest{Quantity: tt.quantity, Version: 1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateQuantity() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := tt.quantity
+			if tt.wantErr != nil {
+				want = 1 // 変更されない
+			} else if item.Quantity != tt.quantity {
+				t.Errorf("UpdateQuantity() quantity = %d, want %d", item.Quantity, tt.quantity)
+			}
+			stored, err := cartRepo.GetItem(ctx, "u1", product.ID)
+			if err != nil {
+				t.Fatalf("GetItem() error = %v", err)
+			}
+			if stored.Quantity != want {
+				t.Errorf("stored quantity = %d, want %d", stored.Quantity, want)
+			}
+		})
+	}
+}
+
+// TestGetItemCountMatchesGetCart は件数だけを数える経路と、カートを読む経路が同じ件数を返すことを確認する
+// （期限切れの除外を別々に行っているため、食い違いやすい）
+func TestGetItemCountMatchesGetCart(t *testing.T) {
+	ctx := context.Background()
+	db, fake := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	live := createTestProduct(t, productRepo, "販売中", 1000, 5)
+	expired := createTestProduct(t, productRepo, "期限切れ", 1000, 5)
+	deleted := createTestProduct(t, productRepo, "削除済み", 1000, 5)
+	if err := productRepo.SoftDelete(ctx, deleted.ID, deleted.Category); err != nil {
+		t.Fatalf("SoftDelete() error = %v", err)
+	}
+
+	now := time.Now()
+	seedCartItem(fake, "u1", live.ID, 1000, 1, now)
+	// TTL を過ぎているが、まだ TTL で削除されていないアイテム
+	seedCartItem(fake, "u1", expired.ID, 1000, 1, now.Add(-repository.CartItemTTL-time.Hour))
+	// 論理削除された商品のアイテム（カートには購入不可として残る）
+	seedCartItem(fake, "u1", deleted.ID, 1000, 1, now)
+	svc := NewCartService(repository.NewCartRepository(db), productRepo, nil, 50, 99)
+
+	cart, err := svc.GetCart(ctx, "u1", false)
+	if err != nil {
+		t.Fatalf("GetCart() error = %v", err)
+	}
+	count, err := svc.GetItemCount(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetItemCount() error = %v", err)
+	}
+
+	if count.Count != len(cart.Items) {
+		t.Errorf("GetItemCount() = %d, len(GetCart().Items) = %d, want equal", count.Count, len(cart.Items))
+	}
+	if len(cart.Items) != 2 || cart.ItemCount != len(cart.Items) {
+		t.Errorf("GetCart() items = %d, ItemCount = %d, want 2 (the live and the deleted product)", len(cart.Items), cart.ItemCount)
+	}
+	for _, item := range cart.Items {
+		if item.ProductID == expired.ID {
+			t.Error("GetCart() includes the expired item")
+		}
+		if want := item.ProductID == deleted.ID; item.Unavailable != want {
+			t.Errorf("item %s Unavailable = %v, want %v", item.ProductName, item.Unavailable, want)
+		}
+	}
+}
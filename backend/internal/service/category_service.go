@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+type CategoryService struct {
+	categoryRepo *repository.CategoryRepository
+}
+
+func NewCategoryService(categoryRepo *repository.CategoryRepository) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// List は商品が存在するカテゴリの一覧を商品数付きで取得する
+func (s *CategoryService) List(ctx context.Context) ([]*domain.Category, error) {
+	return s.categoryRepo.List(ctx)
+}
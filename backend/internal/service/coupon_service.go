@@ -0,0 +1,155 @@
+// backend/internal/service/coupon_service.go
+// クーポンのビジネスロジックを担当するサービス
+//
+// 【検証の順序】
+//   0. 他のユーザー専用のクーポン → 存在しないクーポンと同じ（repository.ErrCouponNotFound）
+//      有効期限・利用回数などを他のユーザーに見せないよう、他の検証より先に確認する
+//   1. 利用開始前                → NOT_APPLICABLE
+//   2. 有効期限切れ              → EXPIRED
+//   3. 利用回数の上限に到達      → EXHAUSTED
+//
+// 【有効期限・利用回数の上限】
+//   無期限・無制限のクーポンはない（作成時に expiresAt と maxUses > 0 を必須にしている）
+//   expiresAt が未設定（ゼロ値）・maxUses が 0 のクーポンが保存されていた場合は、
+//   それぞれ期限切れ・上限到達として扱い、利用できない側に倒す
+
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+type CouponService struct {
+	couponRepo *repository.CouponRepository
+}
+
+func NewCouponService(couponRepo *repository.CouponRepository) *CouponService {
+	return &CouponService{
+		couponRepo: couponRepo,
+	}
+}
+
+// Validate はクーポンが利用可能かを検証する
+// 【注意】参照のみで利用回数は消費しない（チェックアウト前の確認用）
+func (s *CouponService) Validate(ctx context.Context, userID, code string) (*domain.CouponValidation, error) {
+	coupon, err := getUserCoupon(ctx, s.couponRepo, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := coupon.MaxUses - coupon.UsedCount
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	reason := evaluateCoupon(coupon, time.Now())
+
+	return &domain.CouponValidation{
+		Code:          coupon.Code,
+		Valid:         reason == "",
+		Reason:        reason,
+		Type:          coupon.Type,
+		Value:         coupon.Value,
+		RemainingUses: remaining,
+		ExpiresAt:     coupon.ExpiresAt,
+	}, nil
+}
+
+// Create はクーポンを作成する（管理者用）
+// コードは保存形式（大文字）に揃える。入力値の検証はハンドラー層で行う
+func (s *CouponService) Create(ctx context.Context, req *domain.CreateCouponRequest) (*domain.Coupon, error) {
+	coupon := &domain.Coupon{
+		Code:      normalizeCouponCode(req.Code),
+		Type:      strings.ToUpper(req.Type),
+		Value:     req.Value,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+		UserID:    req.UserID,
+	}
+	if req.StartsAt != nil {
+		coupon.StartsAt = *req.StartsAt
+	}
+
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// List は全クーポンを取得する（管理者用）
+func (s *CouponService) List(ctx context.Context) ([]*domain.Coupon, error) {
+	return s.couponRepo.List(ctx)
+}
+
+// getUserCoupon は userID が使えるクーポンを取得する
+// 他のユーザー専用のクーポンは、存在しない場合と同じ repository.ErrCouponNotFound を返す
+func getUserCoupon(ctx context.Context, couponRepo *repository.CouponRepository, userID, code string) (*domain.Coupon, error) {
+	coupon, err := couponRepo.GetByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if coupon.UserID != "" && coupon.UserID != userID {
+		return nil, repository.ErrCouponNotFound
+	}
+	return coupon, nil
+}
+
+// evaluateCoupon はクーポンが無効な理由を返す（有効な場合は空文字）
+// 対象ユーザーの確認は getUserCoupon で済ませておく
+// expiresAt がゼロ値の場合は期限切れ、maxUses が 0 の場合は上限到達になる（無期限・無制限としては扱わない）
+func evaluateCoupon(coupon *domain.Coupon, now time.Time) string {
+	if !coupon.StartsAt.IsZero() && now.Before(coupon.StartsAt) {
+		return domain.CouponReasonNotApplicable
+	}
+	if !now.Before(coupon.ExpiresAt) {
+		return domain.CouponReasonExpired
+	}
+	if coupon.UsedCount >= coupon.MaxUses {
+		return domain.CouponReasonExhausted
+	}
+	return ""
+}
+
+// couponReasonError は無効な理由に対応するエラーを返す（有効な場合は nil）
+func couponReasonError(reason string) error {
+	switch reason {
+	case "":
+		return nil
+	case domain.CouponReasonExpired:
+		return repository.ErrCouponExpired
+	case domain.CouponReasonExhausted:
+		return repository.ErrCouponExhausted
+	default:
+		return repository.ErrCouponNotApplicable
+	}
+}
+
+// couponDiscount は金額 amount に対するクーポンの割引額を返す
+// PERCENT は1円未満を切り捨て、割引額は amount を超えない（合計が負にならない）
+// PERCENT は amount × Value がオーバーフローしないよう、100円単位と端数に分けて計算する
+func couponDiscount(coupon *domain.Coupon, amount int) int {
+	var discount int
+	switch coupon.Type {
+	case domain.CouponTypePercent:
+		discount = amount/100*coupon.Value + amount%100*coupon.Value/100
+	case domain.CouponTypeFixed:
+		discount = coupon.Value
+	}
+	if discount > amount {
+		discount = amount
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return discount
+}
+
+// normalizeCouponCode は入力されたコードを保存形式（大文字・前後空白なし）に揃える
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
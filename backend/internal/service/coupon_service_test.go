@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+func TestCouponServiceValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		coupon     domain.Coupon
+		wantErr    error
+		wantValid  bool
+		wantReason string
+	}{
+		{
+			name:      "有効",
+			coupon:    domain.Coupon{ExpiresAt: now.Add(time.Hour), MaxUses: 3, UsedCount: 2},
+			wantValid: true,
+		},
+		{
+			name:       "期限切れ",
+			coupon:     domain.Coupon{ExpiresAt: now.Add(-time.Hour), MaxUses: 3},
+			wantReason: domain.CouponReasonExpired,
+		},
+		{
+			name:       "利用回数の上限に到達",
+			coupon:     domain.Coupon{ExpiresAt: now.Add(time.Hour), MaxUses: 3, UsedCount: 3},
+			wantReason: domain.CouponReasonExhausted,
+		},
+		{
+			name:       "利用開始前",
+			coupon:     domain.Coupon{StartsAt: now.Add(time.Hour), ExpiresAt: now.Add(2 * time.Hour), MaxUses: 3},
+			wantReason: domain.CouponReasonNotApplicable,
+		},
+		{
+			name:       "maxUses が 0 は無制限ではない",
+			coupon:     domain.Coupon{ExpiresAt: now.Add(time.Hour)},
+			wantReason: domain.CouponReasonExhausted,
+		},
+		{
+			name:      "自分専用のクーポン",
+			coupon:    domain.Coupon{ExpiresAt: now.Add(time.Hour), MaxUses: 1, UserID: "u1"},
+			wantValid: true,
+		},
+		{
+			name:    "他のユーザー専用のクーポンは存在しないものとして扱う",
+			coupon:  domain.Coupon{ExpiresAt: now.Add(-time.Hour), MaxUses: 1, UserID: "u2"},
+			wantErr: repository.ErrCouponNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newTestDB()
+			couponRepo := repository.NewCouponRepository(db)
+			coupon := tt.coupon
+			coupon.Code = "SALE"
+			coupon.Type = domain.CouponTypePercent
+			coupon.Value = 10
+			if err := couponRepo.Create(context.Background(), &coupon); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			got, err := NewCouponService(couponRepo).Validate(context.Background(), "u1", " sale ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Valid != tt.wantValid || got.Reason != tt.wantReason {
+				t.Errorf("Validate() valid/reason = %v/%q, want %v/%q", got.Valid, got.Reason, tt.wantValid, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package service
+
+import "context"
+
+// EmailSender はメールを1通送信する（実装は internal/email）
+// Send だけの小さなインターフェースにして、テストでは宛先・内容を記録する偽の実装に差し替えられるようにする
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// 複数のサービスで共通のクライアント向けエラー
+var (
+	ErrInvalidCursor   = apperr.BadRequest("invalid_cursor", "Invalid cursor")
+	ErrProductNotFound = apperr.NotFound("product_not_found", "Product not found")
+	ErrAmountOverflow  = apperr.BadRequest("amount_overflow", "Amount is too large")
+)
+
+// cursorError はページングのカーソルが不正なエラーを ErrInvalidCursor に変換する（それ以外はそのまま返す）
+func cursorError(err error) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return ErrInvalidCursor.WithCause(err)
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+// backend/internal/service/export_service.go
+// ユーザーデータのエクスポート（GDPRのデータポータビリティ対応）を担当するサービス
+//
+// 【エクスポート対象】
+//   プロフィール / カート / 注文（明細付き）/ 行動ログ
+//   各データはパーティションが異なり互いに依存しないため、並行して取得する
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// exportOrderItemsConcurrency は注文明細を並行して読み込む数
+const exportOrderItemsConcurrency = 8
+
+type ExportService struct {
+	userRepo     *repository.UserRepository
+	cartRepo     *repository.CartRepository
+	orderRepo    *repository.OrderRepository
+	activityRepo *repository.ActivityRepository
+}
+
+func NewExportService(userRepo *repository.UserRepository, cartRepo *repository.CartRepository, orderRepo *repository.OrderRepository, activityRepo *repository.ActivityRepository) *ExportService {
+	return &ExportService{
+		userRepo:     userRepo,
+		cartRepo:     cartRepo,
+		orderRepo:    orderRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// ExportUserData はユーザーの全データを1つのドキュメントにまとめる
+// いずれかの取得に失敗した場合は残りの取得をキャンセルしてエラーを返す
+func (s *ExportService) ExportUserData(ctx context.Context, userID string) (*domain.UserDataExport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	export := &domain.UserDataExport{ExportedAt: time.Now()}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	run := func(fetch func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+
+	// 各 goroutine は export の別々のフィールドにだけ書き込む
+	run(func() (err error) {
+		export.Profile, err = s.userRepo.GetByID(ctx, userID)
+		return err
+	})
+	run(func() (err error) {
+		export.Cart, err = s.cartRepo.GetByUserID(ctx, userID)
+		return err
+	})
+	run(func() (err error) {
+		export.Orders, err = s.exportOrders(ctx, userID)
+		return err
+	})
+	run(func() (err error) {
+		export.Activities, err = s.activityRepo.GetAllByUserID(ctx, userID)
+		return err
+	})
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		// ユーザーが存在しない場合はそれを優先して返す（ハンドラーで404にするため）
+		for _, err := range errs {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return nil, err
+			}
+		}
+		return nil, errs[0]
+	}
+
+	return export, nil
+}
+
+// exportOrders は注文一覧を全件取得し、各注文の明細を全件付与する
+// 注文は USER#<userId> から読んだものなので、明細は所有者を確認せずに読む（GetItemsByOrderID）
+// 明細の取得は exportOrderItemsConcurrency 件ずつ並行して行い、いずれかが失敗した場合は残りをキャンセルしてエラーを返す
+func (s *ExportService) exportOrders(ctx context.Context, userID string) ([]*domain.Order, error) {
+	orders, err := s.orderRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 各 goroutine は自分の注文の Items にだけ書き込む
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, exportOrderItemsConcurrency)
+	for _, order := range orders {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			items, err := s.orderRepo.GetItemsByOrderID(ctx, order.ID)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				cancel()
+				return
+			}
+			order.Items = items
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return orders, nil
+}
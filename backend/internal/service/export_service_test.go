@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+func TestExportUserDataContainsEverySection(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	ctx := context.Background()
+	db := repository.NewDynamoDBClientWithAPI(env.fake, "test-table")
+	activityRepo := repository.NewActivityRepository(db)
+	svc := NewExportService(env.userRepo, env.cartRepo, env.orderRepo, activityRepo)
+
+	// 明細2件の注文を3件
+	var orders []*domain.Order
+	for i := 0; i < 3; i++ {
+		orders = append(orders, env.checkout(t,
+			createTestProduct(t, env.productRepo, "a", 100, 10),
+			createTestProduct(t, env.productRepo, "b", 200, 10)))
+	}
+	// 注文していないカートの商品
+	env.addToCart(t, createTestProduct(t, env.productRepo, "c", 300, 10), 2)
+	for _, action := range []string{"VIEW", "CLICK"} {
+		if err := activityRepo.Create(ctx, &domain.UserActivity{UserID: env.user.ID, ActionType: action, ProductID: "p1"}); err != nil {
+			t.Fatalf("Create(activity) error = %v", err)
+		}
+	}
+
+	// 1ページ1件にして、注文ヘッダー・明細が1ページに収まらない場合も全件読むことを確認する
+	env.fake.PageSize = 1
+	getItems := env.fake.CallCount("GetItem")
+
+	export, err := svc.ExportUserData(ctx, env.user.ID)
+	if err != nil {
+		t.Fatalf("ExportUserData() error = %v", err)
+	}
+
+	if export.Profile == nil || export.Profile.ID != env.user.ID {
+		t.Errorf("Profile = %+v, want user %s", export.Profile, env.user.ID)
+	}
+	if len(export.Cart) != 1 || export.Cart[0].Quantity != 2 {
+		t.Errorf("Cart = %+v, want 1 item of quantity 2", export.Cart)
+	}
+	if len(export.Orders) != len(orders) {
+		t.Fatalf("got %d orders, want %d", len(export.Orders), len(orders))
+	}
+	for _, order := range export.Orders {
+		if len(order.Items) != 2 {
+			t.Errorf("order %s has %d items, want 2", order.ID, len(order.Items))
+		}
+	}
+	if len(export.Activities) != 2 {
+		t.Errorf("got %d activities, want 2", len(export.Activities))
+	}
+	if export.ExportedAt.IsZero() {
+		t.Error("ExportedAt is zero")
+	}
+	// 明細は注文ごとに所有者を確認し直さない（GetItem はプロフィールの1回だけ）
+	if got := env.fake.CallCount("GetItem") - getItems; got != 1 {
+		t.Errorf("GetItem called %d times, want 1", got)
+	}
+}
@@ -0,0 +1,139 @@
+// hold_service.go
+// カート内の商品の在庫の取り置き（ホールド）を担当するサービス（CART_RESERVATIONS_ENABLED=true の場合のみ）
+//
+// 【主な機能】
+//   1. Reserve        - カートの数量分の在庫を一定時間取り置く（既存の取り置きは数量・期限を更新）
+//   2. Release        - 取り置きを解放する
+//   3. ReleaseExpired - 期限切れの取り置きをまとめて解放する（HoldSweeper から定期実行）
+//
+// 【注文確定との関係】
+//   取り置き分は商品の reservedStock に含まれており、注文確定時に在庫減算と同じトランザクションで消費される
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// 期限切れの取り置きを1回の問い合わせで取得する件数
+const expiredHoldBatchSize = 100
+
+type HoldService struct {
+	holdRepo    *repository.HoldRepository
+	cartRepo    *repository.CartRepository
+	productRepo *repository.ProductRepository
+	duration    time.Duration // 取り置きの有効期間
+}
+
+func NewHoldService(holdRepo *repository.HoldRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, duration time.Duration) *HoldService {
+	return &HoldService{
+		holdRepo:    holdRepo,
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+		duration:    duration,
+	}
+}
+
+// Reserve はカートアイテムの数量分の在庫を取り置く
+// 【処理フロー】
+//  1. カートアイテムを取得（カートにない商品は取り置けない）
+//  2. 既存の取り置きを取得（あれば数量の差分だけ reservedStock を増減し、期限を延長する）
+//  3. 商品を読み、取り置き可能な在庫（stock - reservedStock）があるかを確認
+//  4. 取り置きの書き込みと reservedStock の更新をトランザクションで実行
+//     - 読み込み後に在庫・仮押さえ数が変わった場合は読み直して maxRetries 回まで再試行する
+//
+// 在庫が足りない場合は ErrInsufficientStock、取り置きが同時に変更された場合は repository.ErrHoldConflict を返す
+func (s *HoldService) Reserve(ctx context.Context, userID, productID string) (*domain.StockHold, error) {
+	cartItem, err := s.cartRepo.GetItem(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousQty := 0
+	existing, err := s.holdRepo.Get(ctx, userID, productID)
+	if err == nil {
+		previousQty = existing.Quantity
+	} else if !errors.Is(err, repository.ErrHoldNotFound) {
+		return nil, err
+	}
+
+	hold := &domain.StockHold{
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  cartItem.Quantity,
+		ExpiresAt: time.Now().Add(s.duration),
+	}
+	delta := hold.Quantity - previousQty
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		product, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if product.DeletedAt != nil {
+			return nil, repository.ErrProductNotFound
+		}
+		if delta > 0 && product.Stock-product.ReservedStock < delta {
+			return nil, ErrInsufficientStock
+		}
+
+		err = s.holdRepo.Reserve(ctx, hold, previousQty, product.Stock)
+		if err == nil {
+			return hold, nil
+		}
+		if !errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, err
+		}
+		// 読み込み後に在庫・仮押さえ数が変わった → 読み直して再試行
+	}
+	return nil, ErrInsufficientStock
+}
+
+// Release は取り置きを解放する
+// 取り置きがない場合は repository.ErrHoldNotFound を返す
+func (s *HoldService) Release(ctx context.Context, userID, productID string) error {
+	hold, err := s.holdRepo.Get(ctx, userID, productID)
+	if err != nil {
+		return err
+	}
+	return s.holdRepo.Release(ctx, hold)
+}
+
+// ReleaseExpired は期限が now より前の取り置きを解放し、解放した件数を返す
+// 1件ずつ条件付きで解放するため、複数のインスタンスで同時に実行しても二重に解放しない
+// （同時に注文で消費された・解放された取り置きは ErrHoldNotFound になり読み飛ばす）
+func (s *HoldService) ReleaseExpired(ctx context.Context, now time.Time) (int, error) {
+	released := 0
+	for {
+		holds, err := s.holdRepo.ListExpired(ctx, now, expiredHoldBatchSize)
+		if err != nil {
+			return released, err
+		}
+
+		progressed := false
+		for _, hold := range holds {
+			err := s.holdRepo.Release(ctx, hold)
+			if errors.Is(err, repository.ErrHoldNotFound) {
+				progressed = true
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to release expired stock hold: user=%s product=%s: %v", hold.UserID, hold.ProductID, err)
+				continue
+			}
+			released++
+			progressed = true
+		}
+
+		// 最後のページ、または1件も進まなかった（解放に失敗し続けている）場合は次回に回す
+		if len(holds) < expiredHoldBatchSize || !progressed {
+			return released, nil
+		}
+	}
+}
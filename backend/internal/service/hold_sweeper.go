@@ -0,0 +1,52 @@
+// backend/internal/service/hold_sweeper.go
+// 期限切れの在庫の取り置きを定期的に解放するバックグラウンドワーカー
+//
+// 【シャットダウンとの連携】
+//   Run は渡された ctx がキャンセルされるまでループする（PriceScheduler と同じ）
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type HoldSweeper struct {
+	holdService *HoldService
+	interval    time.Duration
+}
+
+func NewHoldSweeper(holdService *HoldService, interval time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		holdService: holdService,
+		interval:    interval,
+	}
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに期限切れの取り置きを解放する
+func (s *HoldSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep は1回分の解放処理を行う
+// エラーはログに出すだけで、次回のスイープで再試行する
+func (s *HoldSweeper) sweep(ctx context.Context) {
+	released, err := s.holdService.ReleaseExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to release expired stock holds: %v", err)
+	}
+	if released > 0 {
+		log.Printf("Released %d expired stock hold(s)", released)
+	}
+}
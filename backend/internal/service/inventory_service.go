@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
@@ -11,22 +12,26 @@ import (
 type InventoryService struct {
 	inventoryRepo *repository.InventoryRepository
 	productRepo   *repository.ProductRepository
+	productCache  *ProductCache // 在庫を変えた商品のキャッシュを無効化する（nil の場合は無効）
 }
 
-func NewInventoryService(inventoryRepo *repository.InventoryRepository, productRepo *repository.ProductRepository) *InventoryService {
+func NewInventoryService(inventoryRepo *repository.InventoryRepository, productRepo *repository.ProductRepository, productCache *ProductCache) *InventoryService {
 	return &InventoryService{
 		inventoryRepo: inventoryRepo,
 		productRepo:   productRepo,
+		productCache:  productCache,
 	}
 }
 
 // AdjustStock は在庫を調整し、変動ログを記録する
 // changeType: "IN" (入庫), "OUT" (出庫), "ADJUST" (調整)
-func (s *InventoryService) AdjustStock(ctx context.Context, productID string, changeType string, quantity int, reason string) error {
+// 変動ログの記録と在庫の更新は1つのトランザクションで行う（読み込んだバージョンのままの場合のみ。どちらか一方だけが書き込まれることはない）
+// 更新後の商品（書き込み後に強整合性読み込みで取得した値）と、記録した変動ログを返す
+func (s *InventoryService) AdjustStock(ctx context.Context, productID string, changeType string, quantity int, reason string) (*domain.Product, *domain.InventoryLog, error) {
 	// 現在の商品情報を取得
 	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	previousStock := product.Stock
@@ -58,13 +63,18 @@ func (s *InventoryService) AdjustStock(ctx context.Context, productID string, ch
 		Reason:        reason,
 	}
 
-	if err := s.inventoryRepo.Create(ctx, log); err != nil {
-		return err
+	err = s.inventoryRepo.RecordStockChange(ctx, log, product.Version)
+	s.productCache.Invalidate(ctx, productID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// 商品の在庫数を更新
-	product.Stock = newStock
-	return s.productRepo.Update(ctx, product)
+	// TransactWriteItems は更新後の値を返さないため読み直す（reservedStock など他の更新も反映された値）
+	product, err = s.productRepo.GetByIDConsistent(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, log, nil
 }
 
 // GetLogsは在庫変動履歴を取得する
@@ -76,3 +86,50 @@ func (s *InventoryService) GetLogs(ctx context.Context, productID string, limit
 func (s *InventoryService) GetLogsWithRange(ctx context.Context, productID string, startTime, endTime time.Time) ([]*domain.InventoryLog, error) {
 	return s.inventoryRepo.GetByProductIDWithRange(ctx, productID, startTime, endTime)
 }
+
+// Summarize は指定期間の在庫変動ログを ChangeType ごとに集計する
+// 期間内のログを全件取得して Go 側で合計する（呼び出し側がログをページングしなくてよい）
+// 期間内にログがない場合は、合計を 0、期首・期末の在庫数を現在の在庫数とする
+// 商品が存在しない場合は ErrProductNotFound を返す
+func (s *InventoryService) Summarize(ctx context.Context, productID string, startTime, endTime time.Time) (*domain.InventorySummary, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, ErrProductNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+
+	logs, err := s.inventoryRepo.GetByProductIDWithRange(ctx, productID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.InventorySummary{
+		ProductID:    productID,
+		Start:        startTime,
+		End:          endTime,
+		OpeningStock: product.Stock,
+		ClosingStock: product.Stock,
+		LogCount:     len(logs),
+	}
+	if len(logs) == 0 {
+		return summary, nil
+	}
+
+	// ログは新しい順に並んでいる
+	summary.OpeningStock = logs[len(logs)-1].PreviousStock
+	summary.ClosingStock = logs[0].NewStock
+	for _, l := range logs {
+		delta := l.NewStock - l.PreviousStock
+		switch l.ChangeType {
+		case "IN":
+			summary.TotalIn += delta
+		case "OUT":
+			summary.TotalOut -= delta
+		case "ADJUST":
+			summary.NetAdjust += delta
+		}
+	}
+	return summary, nil
+}
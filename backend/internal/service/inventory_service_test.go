@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// countInventoryLogs はテーブル内の在庫変動ログの件数を返す
+func countInventoryLogs(fake *dynamotest.Fake) int {
+	n := 0
+	for _, item := range fake.Items() {
+		if sk, ok := item["SK"].(*types.AttributeValueMemberS); ok && strings.HasPrefix(sk.Value, "INVLOG#") {
+			n++
+		}
+	}
+	return n
+}
+
+// beforeProductTransaction は商品を更新するトランザクションの直前に1回だけ change を実行するフック
+// （読み込みから書き込みまでの間に、別のリクエストが商品を書き換えた状況を再現する）
+func beforeProductTransaction(fake *dynamotest.Fake, productID string, change func(item map[string]types.AttributeValue)) func(ctx context.Context, op string, input any) error {
+	done := false
+	return func(ctx context.Context, op string, input any) error {
+		if op != "TransactWriteItems" || done {
+			return nil
+		}
+		done = true
+		item := fake.Get("PRODUCT#"+productID, "METADATA")
+		change(item)
+		fake.Seed(item)
+		return nil
+	}
+}
+
+func TestAdjustStockReturnsStoredProduct(t *testing.T) {
+	db, fake := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	product := createTestProduct(t, productRepo, "商品A", 1000, 10)
+	svc := NewInventoryService(repository.NewInventoryRepository(db), productRepo, nil)
+
+	// 在庫を読んだ後に、別の注文が2個を仮押さえした（バージョンは変わらない）
+	fake.Hook = beforeProductTransaction(fake, product.ID, func(item map[string]types.AttributeValue) {
+		item["reservedStock"] = &types.AttributeValueMemberN{Value: "2"}
+	})
+
+	got, log, err := svc.AdjustStock(context.Background(), product.ID, "IN", 5, "入荷")
+	if err != nil {
+		t.Fatalf("AdjustStock() error = %v", err)
+	}
+	if got.Stock != 15 || got.ReservedStock != 2 {
+		t.Errorf("stock, reservedStock = %d, %d, want 15, 2 (the stored values)", got.Stock, got.ReservedStock)
+	}
+	if got.Version != product.Version+1 {
+		t.Errorf("version = %d, want %d", got.Version, product.Version+1)
+	}
+	if log.PreviousStock != 10 || log.NewStock != 15 {
+		t.Errorf("log = %d -> %d, want 10 -> 15", log.PreviousStock, log.NewStock)
+	}
+	if n := countInventoryLogs(fake); n != 1 {
+		t.Errorf("got %d inventory logs, want 1", n)
+	}
+}
+
+func TestAdjustStockConcurrentUpdateWritesNoLog(t *testing.T) {
+	db, fake := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	product := createTestProduct(t, productRepo, "商品A", 1000, 10)
+	svc := NewInventoryService(repository.NewInventoryRepository(db), productRepo, nil)
+
+	// 在庫を読んだ後に、別の管理者が商品を更新した
+	fake.Hook = beforeProductTransaction(fake, product.ID, func(item map[string]types.AttributeValue) {
+		item["version"] = &types.AttributeValueMemberN{Value: "5"}
+	})
+
+	if _, _, err := svc.AdjustStock(context.Background(), product.ID, "ADJUST", 3, "棚卸し"); !errors.Is(err, repository.ErrProductVersionMismatch) {
+		t.Fatalf("AdjustStock() error = %v, want ErrProductVersionMismatch", err)
+	}
+	if n := countInventoryLogs(fake); n != 0 {
+		t.Errorf("got %d inventory logs, want 0 (the stock was not changed)", n)
+	}
+	assertStock(t, productRepo, product.ID, 10, 0)
+}
@@ -2,22 +2,87 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 )
 
+// 注文確定時にどの価格で請求するか（ORDER_PRICE_POLICY）
+//
+// 【snapshot】カートに追加した時点の価格（既定）
+//   - 顧客がカートで見た金額のまま注文できる
+//   - 追加後に値上げ・値下げされても反映されない（古い価格で販売することがある）
+//
+// 【current】注文確定時点の商品価格を取得し直して計算する
+//   - 常に最新の価格で販売できる
+//   - 商品の読み込み（BatchGetItem）が増え、カートで見た金額と請求額が変わることがある
+const (
+	OrderPricePolicySnapshot = "snapshot"
+	OrderPricePolicyCurrent  = "current"
+)
+
+// 注文関連のクライアント向けエラー
+var (
+	ErrCartEmpty             = apperr.BadRequest("cart_empty", "Cart is empty")
+	ErrTooManyOrderItems     = apperr.BadRequest("too_many_order_items", fmt.Sprintf("Too many products in cart (max %d per order)", repository.MaxOrderProducts))
+	ErrOrderOutOfStock       = apperr.Conflict("insufficient_stock", "Insufficient stock for one or more items")
+	ErrProductUnavailable    = apperr.Conflict("product_unavailable", "A product in the cart is no longer available")
+	ErrOrderAlreadyExists    = apperr.Conflict("order_already_exists", "Order already exists, please retry")
+	ErrIdempotencyInProgress = apperr.Conflict("idempotency_in_progress", "A request with this Idempotency-Key is already in progress")
+	ErrOrderConflict         = apperr.Conflict("transaction_conflict", "Transaction conflict, please retry")
+	ErrCouponNotFound        = apperr.BadRequest("coupon_not_found", "Coupon not found")
+	ErrCouponExpired         = apperr.BadRequest("coupon_expired", "Coupon has expired")
+	ErrCouponExhausted       = apperr.BadRequest("coupon_exhausted", "Coupon usage limit has been reached")
+	ErrCouponNotApplicable   = apperr.BadRequest("coupon_not_applicable", "Coupon is not applicable to this order")
+	ErrOrderNotFound         = apperr.NotFound("order_not_found", "Order not found")
+	ErrInvalidItemsToken     = apperr.BadRequest("invalid_items_token", "Invalid items token")
+	ErrOrderAlreadyPaid      = apperr.Conflict("order_already_paid", "Order is already paid")
+	ErrInvalidOrderStatus    = apperr.BadRequest("invalid_order_status", "Invalid order status")
+)
+
+// 在庫の仮押さえの有効期間
+// 注文確定のリクエストが終わるまでの時間より十分長く、落ちたプロセスの仮押さえが長く残らない長さにする
+const checkoutReservationTTL = 5 * time.Minute
+
+// ReleaseExpiredReservations の1回の実行で読む期限切れの仮押さえの件数（GSI1 のシャードごと）
+const expiredReservationBatchSize = 25
+
 type OrderService struct {
-	orderRepo   *repository.OrderRepository
-	cartRepo    *repository.CartRepository
-	productRepo *repository.ProductRepository
+	orderRepo       *repository.OrderRepository
+	cartRepo        *repository.CartRepository
+	productRepo     *repository.ProductRepository
+	idempotencyRepo *repository.IdempotencyRepository
+	couponRepo      *repository.CouponRepository
+	userRepo        *repository.UserRepository
+	holdRepo        *repository.HoldRepository // 在庫の取り置き（CART_RESERVATIONS_ENABLED=false の場合は nil）
+	reservationRepo *repository.ReservationRepository
+	emailSender     EmailSender // 注文確認メールの送信
+	maxOrderItems   int         // 注文詳細で1回に返す明細の上限
+	pricePolicy     string      // OrderPricePolicySnapshot / OrderPricePolicyCurrent
+	taxRate         float64     // 注文確定時に適用する消費税率（例: 0.1）
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *OrderService {
+func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, idempotencyRepo *repository.IdempotencyRepository, couponRepo *repository.CouponRepository, userRepo *repository.UserRepository, holdRepo *repository.HoldRepository, reservationRepo *repository.ReservationRepository, emailSender EmailSender, maxOrderItems int, pricePolicy string, taxRate float64) *OrderService {
 	return &OrderService{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		orderRepo:       orderRepo,
+		cartRepo:        cartRepo,
+		productRepo:     productRepo,
+		idempotencyRepo: idempotencyRepo,
+		couponRepo:      couponRepo,
+		userRepo:        userRepo,
+		holdRepo:        holdRepo,
+		reservationRepo: reservationRepo,
+		emailSender:     emailSender,
+		maxOrderItems:   maxOrderItems,
+		pricePolicy:     pricePolicy,
+		taxRate:         taxRate,
 	}
 }
 
@@ -25,12 +90,69 @@ func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository
 // 【処理フロー】
 //  1. カートを取得
 //  2. カートアイテムを注文明細に変換
-//  3. トランザクションで注文確定
+//     - pricePolicy が current の場合は現在の商品価格で計算し直す
+//     - クーポンコードが指定された場合は検証し、合計金額から割引する
+//     - 割引後の金額に消費税率をかけて税額を計算し（1円未満は四捨五入）、税率・税込合計とともに注文に保存する
+//  3. 在庫を仮押さえ（reservedStock に加算）
+//     → 他の購入者に在庫を取られてトランザクションが遅れて失敗する窓をふさぐ
+//     - 仮押さえは checkoutReservationTTL 後に期限切れになり、注文確定に至らなかった分はスイーパーが解放する
+//     - 在庫の取り置きがある商品は、取り置き数を超える分だけ仮押さえする
+//  4. トランザクションで注文確定
 //     - 注文ヘッダー作成
 //     - 注文明細作成
-//     - 在庫減算（条件付き）
+//     - 在庫減算（条件付き、仮押さえ分・取り置き分も消費）
 //     - カートクリア
-func (s *OrderService) CreateOrder(ctx context.Context, userID string) (*domain.Order, error) {
+//     - クーポン利用回数の加算（条件付き、上限に達していれば全体が失敗）
+//     - 取り置き・仮押さえアイテムの削除
+//  5. トランザクションが失敗した場合は仮押さえを解放（取り置きはそのまま残す）
+//  6. 注文確認メールを送信（失敗しても注文は確定済みのため、ログに残して成功を返す）
+//
+// 【冪等性キー】idempotencyKey が指定された場合
+//   - 同じキーで作成済みの注文があれば、新たに注文せずその注文を返す
+//   - 同じキーのリクエストが処理中であれば ErrIdempotencyInProgress を返す
+//   - 注文作成に失敗した場合はキーを解放し、同じキーで再試行できるようにする
+func (s *OrderService) CreateOrder(ctx context.Context, userID, idempotencyKey string, req *domain.CreateOrderRequest) (*domain.Order, error) {
+	order, err := s.createOrderOnce(ctx, userID, idempotencyKey, req)
+	if err != nil {
+		return nil, createOrderError(err)
+	}
+	return order, nil
+}
+
+// createOrderOnce は冪等性キーを確認してから注文を作成する
+func (s *OrderService) createOrderOnce(ctx context.Context, userID, idempotencyKey string, req *domain.CreateOrderRequest) (*domain.Order, error) {
+	if idempotencyKey == "" {
+		return s.createOrder(ctx, userID, req)
+	}
+
+	existingOrderID, err := s.idempotencyRepo.Acquire(ctx, idempotencyKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existingOrderID != "" {
+		return s.orderRepo.GetByID(ctx, userID, existingOrderID, int32(s.maxOrderItems), "")
+	}
+
+	// キーの後始末はリクエストがキャンセルされても実行する
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	order, err := s.createOrder(ctx, userID, req)
+	if err != nil {
+		if releaseErr := s.idempotencyRepo.Release(cleanupCtx, idempotencyKey, userID); releaseErr != nil {
+			log.Printf("Failed to release idempotency key: user=%s: %v", userID, releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := s.idempotencyRepo.Complete(cleanupCtx, idempotencyKey, userID, order.ID); err != nil {
+		// 注文自体は確定しているため成功として返す（キーは処理中のまま TTL で削除される）
+		log.Printf("Failed to record order on idempotency key: user=%s order=%s: %v", userID, order.ID, err)
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) createOrder(ctx context.Context, userID string, req *domain.CreateOrderRequest) (*domain.Order, error) {
 	// 1. カートを取得
 	cartItems, err := s.cartRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -39,28 +161,82 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string) (*domain.
 	if len(cartItems) == 0 {
 		return nil, repository.ErrCartItemNotFound
 	}
+	// トランザクションの操作数の上限を超える注文は在庫を仮押さえする前に断る
+	if len(cartItems) > repository.MaxOrderProducts {
+		return nil, repository.ErrTooManyOrderItems
+	}
+	holds, err := s.cartHolds(ctx, userID, cartItems)
+	if err != nil {
+		return nil, err
+	}
+	if repository.OrderExceedsTransaction(len(cartItems), len(holds)) {
+		return nil, repository.ErrTooManyOrderItems
+	}
+	// 削除された商品がカートに残っている場合は、在庫の仮押さえ（トランザクションの競合）で失敗させずに先に断る
+	products, err := s.cartProducts(ctx, cartItems)
+	if err != nil {
+		return nil, err
+	}
 	// 2. 注文データを構築
-	var totalAmount int
+	var prices map[string]*domain.Product
+	if s.pricePolicy == OrderPricePolicyCurrent {
+		prices = products
+	}
+
+	var subtotalAmount int
 	orderItems := make([]domain.OrderItem, 0, len(cartItems))
 
 	for _, cartItem := range cartItems {
-		subtotal := cartItem.Price * cartItem.Quantity
-		totalAmount += subtotal
+		price, productName := cartItem.Price, cartItem.ProductName
+		if product, ok := prices[cartItem.ProductID]; ok {
+			price, productName = product.Price, product.Name
+		}
+
+		var subtotal int
+		if subtotalAmount, subtotal, err = lineTotal(subtotalAmount, price, cartItem.Quantity); err != nil {
+			return nil, err
+		}
 
 		orderItems = append(orderItems, domain.OrderItem{
 			ProductID:   cartItem.ProductID,
-			ProductName: cartItem.ProductName,
-			Price:       cartItem.Price,
+			ProductName: productName,
+			Price:       price,
 			Quantity:    cartItem.Quantity,
 			Subtotal:    subtotal,
 		})
 	}
 
+	// クーポンの適用
+	var couponCode string
+	var discountAmount int
+	if req.CouponCode != "" {
+		coupon, err := getUserCoupon(ctx, s.couponRepo, userID, req.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+		if err := couponReasonError(evaluateCoupon(coupon, time.Now())); err != nil {
+			return nil, err
+		}
+		discountAmount = couponDiscount(coupon, subtotalAmount)
+		couponCode = coupon.Code
+	}
+
 	order := &domain.Order{
-		UserID:      userID,
-		Status:      domain.OrderStatusConfirmed,
-		TotalAmount: totalAmount,
-		ItemCount:   len(orderItems),
+		UserID:          userID,
+		Status:          domain.OrderStatusConfirmed,
+		Subtotal:        subtotalAmount,
+		CouponCode:      couponCode,
+		DiscountAmount:  discountAmount,
+		TotalAmount:     subtotalAmount - discountAmount,
+		TaxRate:         s.taxRate,
+		ItemCount:       len(orderItems),
+		ShippingAddress: req.ShippingAddress,
+	}
+	if order.TaxAmount, err = calculateTax(order.TotalAmount, s.taxRate); err != nil {
+		return nil, err
+	}
+	if order.GrandTotal, err = addAmount(order.TotalAmount, order.TaxAmount); err != nil {
+		return nil, err
 	}
 
 	// cartItemsをポインタスライスから値スライスに変換
@@ -69,25 +245,555 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string) (*domain.
 		cartItemValues[i] = *item
 	}
 
-	// 3. トランザクションで注文確定
-	// → 注文作成・在庫減算・カート削除を一括実行
-	err = s.orderRepo.CreateOrder(ctx, order, orderItems, cartItemValues)
+	// 3. 在庫の仮押さえ
+	reservation, stockBefore, err := s.reserveStock(ctx, userID, orderItems, holds)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. トランザクションで注文確定
+	// → 注文作成・在庫減算・カート削除・在庫変動ログの記録・取り置きの消費を一括実行
+	err = s.orderRepo.CreateOrder(ctx, order, orderItems, cartItemValues, stockBefore, holds, reservation)
 	if err != nil {
+		// 5. 仮押さえを解放
+		s.releaseStock(ctx, reservation)
 		// エラーの種類に応じたハンドリングはハンドラー層で行う
 		return nil, err
 	}
 
 	order.Items = orderItems
 
+	// 6. 注文確認メール（リクエストがキャンセルされても送る）
+	s.sendOrderConfirmation(context.WithoutCancel(ctx), order)
+
 	return order, nil
 }
 
-// GetOrdersはユーザーの注文一覧を取得する
-func (s *OrderService) GetOrders(ctx context.Context, userID string) ([]*domain.Order, error) {
-	return s.orderRepo.GetByUserID(ctx, userID)
+// createOrderError は注文作成のエラーをクライアント向けのエラーに変換する（想定外のエラーはそのまま返す）
+func createOrderError(err error) error {
+	var stockErr *repository.InsufficientStockError
+	switch {
+	case errors.Is(err, repository.ErrCartItemNotFound):
+		return ErrCartEmpty.WithCause(err)
+	case errors.Is(err, repository.ErrTooManyOrderItems):
+		return ErrTooManyOrderItems.WithCause(err)
+	case errors.As(err, &stockErr):
+		// 不足した商品が分かる場合はメッセージに含める
+		e := ErrOrderOutOfStock.WithCause(err)
+		e.Message = fmt.Sprintf("Insufficient stock for product %s", stockErr.ProductID)
+		return e
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return ErrOrderOutOfStock.WithCause(err)
+	case errors.Is(err, repository.ErrOrderAlreadyExists):
+		return ErrOrderAlreadyExists.WithCause(err)
+	case errors.Is(err, repository.ErrCouponNotFound):
+		return ErrCouponNotFound.WithCause(err)
+	case errors.Is(err, repository.ErrCouponExpired):
+		return ErrCouponExpired.WithCause(err)
+	case errors.Is(err, repository.ErrCouponExhausted):
+		return ErrCouponExhausted.WithCause(err)
+	case errors.Is(err, repository.ErrCouponNotApplicable):
+		return ErrCouponNotApplicable.WithCause(err)
+	case errors.Is(err, repository.ErrIdempotencyInProgress):
+		return ErrIdempotencyInProgress.WithCause(err)
+	case errors.Is(err, repository.ErrTransactionConflict):
+		return ErrOrderConflict.WithCause(err)
+	}
+	return err
+}
+
+// sendOrderConfirmation は注文者に注文確認メール（注文番号・明細・合計金額）を送る
+// 注文は確定済みのため、宛先の取得や送信に失敗してもログに残すだけにする
+func (s *OrderService) sendOrderConfirmation(ctx context.Context, order *domain.Order) {
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		log.Printf("Failed to load user for order confirmation: order=%s: %v", order.ID, err)
+		return
+	}
+
+	if err := s.emailSender.Send(ctx, user.Email, "ご注文の確認（注文番号: "+order.ID+"）", orderConfirmationBody(order)); err != nil {
+		log.Printf("Failed to send order confirmation: order=%s: %v", order.ID, err)
+	}
+}
+
+// orderConfirmationBody は注文確認メールの本文を組み立てる
+// 明細は注文に含まれるすべての商品を1行ずつ載せる
+func orderConfirmationBody(order *domain.Order) string {
+	var b strings.Builder
+	b.WriteString("ご注文ありがとうございます。\n\n")
+	fmt.Fprintf(&b, "注文番号: %s\n", order.ID)
+	fmt.Fprintf(&b, "注文日時: %s\n\n", order.CreatedAt.Format("2006-01-02 15:04"))
+
+	b.WriteString("【ご注文内容】\n")
+	for _, item := range order.Items {
+		fmt.Fprintf(&b, "%s  %d円 × %d = %d円\n", item.ProductName, item.Price, item.Quantity, item.Subtotal)
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "小計: %d円\n", order.Subtotal)
+	if order.DiscountAmount > 0 {
+		fmt.Fprintf(&b, "割引（クーポン %s）: -%d円\n", order.CouponCode, order.DiscountAmount)
+	}
+	fmt.Fprintf(&b, "合計（税抜）: %d円\n", order.TotalAmount)
+	fmt.Fprintf(&b, "消費税（%g%%）: %d円\n", math.Round(order.TaxRate*10000)/100, order.TaxAmount)
+	fmt.Fprintf(&b, "お支払い金額: %d円\n", order.GrandTotal)
+
+	if addr := order.ShippingAddress; addr != nil {
+		b.WriteString("\n【お届け先】\n")
+		fmt.Fprintf(&b, "〒%s %s%s%s\n", addr.ZipCode, addr.Prefecture, addr.City, addr.Address)
+	}
+	return b.String()
+}
+
+// cartProducts はカート内の商品を現在の内容で取得する（商品ID → 商品）
+// 見つからない・論理削除済みの商品がある場合は、その商品名を含めた ErrProductUnavailable を返す
+// （pricePolicy が current の場合は、取得した商品の価格で注文を計算する）
+func (s *OrderService) cartProducts(ctx context.Context, cartItems []*domain.CartItem) (map[string]*domain.Product, error) {
+	ids := make([]string, len(cartItems))
+	for i, item := range cartItems {
+		ids[i] = item.ProductID
+	}
+	products, err := s.productRepo.BatchGetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range cartItems {
+		if product, ok := products[item.ProductID]; !ok || product.DeletedAt != nil {
+			e := ErrProductUnavailable.WithCause(repository.ErrProductNotFound)
+			e.Message = fmt.Sprintf("Product %q is no longer available, please remove it from the cart", item.ProductName)
+			return nil, e
+		}
+	}
+	return products, nil
+}
+
+// cartHolds はカート内の商品の在庫の取り置きを取得する（商品ID → 取り置き）
+// 取り置きが無効（holdRepo が nil）の場合は nil を返す
+// 期限切れでもまだ解放されていない取り置きは reservedStock に含まれているため、そのまま消費する
+func (s *OrderService) cartHolds(ctx context.Context, userID string, cartItems []*domain.CartItem) (map[string]*domain.StockHold, error) {
+	if s.holdRepo == nil {
+		return nil, nil
+	}
+
+	all, err := s.holdRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holds := make(map[string]*domain.StockHold, len(all))
+	for _, item := range cartItems {
+		if hold, ok := all[item.ProductID]; ok {
+			holds[item.ProductID] = hold
+		}
+	}
+	return holds, nil
+}
+
+// reserveStock は注文明細の数量分だけ在庫を仮押さえする
+// 取り置きがある商品は、取り置き数を超える分だけ仮押さえする（仮押さえの数量も超過分のみ）
+// 仮押さえは期限（checkoutReservationTTL）付きの仮押さえアイテムに記録し、注文確定前にプロセスが落ちても
+// スイーパー（ReleaseExpiredReservations）が解放する。仮押さえする商品がない場合は nil を返す
+// 1件でも確保できなかった場合は、それまでに確保した分を解放して InsufficientStockError（商品ID付き）を返す
+// 条件付き更新が競合で失敗した場合は、商品を読み直して maxRetries 回まで再試行する
+// 仮押さえ時点の在庫数（商品ID → stock）も返す（在庫変動ログの previousStock に使う）
+func (s *OrderService) reserveStock(ctx context.Context, userID string, items []domain.OrderItem, holds map[string]*domain.StockHold) (*domain.StockReservation, map[string]int, error) {
+	quantities := make(map[string]int, len(items))
+	stockBefore := make(map[string]int, len(items))
+	for _, item := range items {
+		quantity := item.Quantity
+		if hold, ok := holds[item.ProductID]; ok {
+			quantity -= hold.Quantity
+		}
+		if quantity > 0 {
+			quantities[item.ProductID] = quantity
+			continue
+		}
+		// 取り置きで足りている → 在庫変動ログ用に在庫数だけ読む
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, nil, err
+		}
+		stockBefore[item.ProductID] = product.Stock
+	}
+	if len(quantities) == 0 {
+		return nil, stockBefore, nil
+	}
+
+	reservation := &domain.StockReservation{
+		UserID:     userID,
+		Quantities: quantities,
+		ExpiresAt:  time.Now().Add(checkoutReservationTTL),
+	}
+	if err := s.reservationRepo.Begin(ctx, reservation); err != nil {
+		return nil, nil, err
+	}
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			continue
+		}
+		stock, err := s.reserveItem(ctx, reservation, item.ProductID)
+		if err != nil {
+			s.releaseStock(ctx, reservation)
+			return nil, nil, err
+		}
+		stockBefore[item.ProductID] = stock
+	}
+	return reservation, stockBefore, nil
+}
+
+// reserveItem は1商品分の在庫を仮押さえし、仮押さえした時点の在庫数を返す
+func (s *OrderService) reserveItem(ctx context.Context, reservation *domain.StockReservation, productID string) (int, error) {
+	quantity := reservation.Quantities[productID]
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		product, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return 0, err
+		}
+		if product.Stock-product.ReservedStock < quantity {
+			return 0, &repository.InsufficientStockError{ProductID: productID}
+		}
+
+		err = s.reservationRepo.Reserve(ctx, reservation, productID, product.Stock)
+		if err == nil {
+			return product.Stock, nil
+		}
+		if errors.Is(err, repository.ErrReservationExpired) {
+			// 仮押さえに時間がかかりすぎた → 注文全体を再試行してもらう
+			return 0, repository.ErrTransactionConflict
+		}
+		if !errors.Is(err, repository.ErrInsufficientStock) {
+			return 0, err
+		}
+		// 読み込み後に在庫・仮押さえ数が変わった → 読み直して再試行
+	}
+	return 0, &repository.InsufficientStockError{ProductID: productID}
+}
+
+// releaseStock は仮押さえした在庫を解放し、仮押さえアイテムを削除する
+// リクエストがキャンセルされていても解放は実行したいため、キャンセルを引き継がない ctx を使う
+// 解放に失敗した場合はログに残す（仮押さえアイテムが残るため、期限後にスイーパーが解放する）
+func (s *OrderService) releaseStock(ctx context.Context, reservation *domain.StockReservation) {
+	if reservation == nil {
+		return
+	}
+	if _, err := s.releaseReservation(context.WithoutCancel(ctx), reservation); err != nil {
+		log.Printf("Failed to release reserved stock: reservation=%s: %v", reservation.ID, err)
+	}
+}
+
+// releaseReservation は仮押さえ済みの商品を1件ずつ解放し、全て解放できたら仮押さえアイテムを削除する
+// 既に解放・消費されていた商品は読み飛ばす（スイーパーと同時に実行しても二重に解放しない）
+// 解放した商品の数を返す
+func (s *OrderService) releaseReservation(ctx context.Context, reservation *domain.StockReservation) (int, error) {
+	released := 0
+	var errs []error
+	for _, productID := range reservation.ProductIDs {
+		err := s.reservationRepo.Release(ctx, reservation, productID)
+		if errors.Is(err, repository.ErrReservationNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("product %s: %w", productID, err))
+			continue
+		}
+		released++
+	}
+	if len(errs) > 0 {
+		return released, errors.Join(errs...)
+	}
+	if err := s.reservationRepo.Delete(ctx, reservation.ID); err != nil && !errors.Is(err, repository.ErrReservationNotFound) {
+		return released, err
+	}
+	return released, nil
+}
+
+// ReleaseExpiredReservations は期限が now より前の仮押さえ（注文確定に至らなかったチェックアウト）を解放し、
+// 解放した仮押さえの件数を返す（ReservationSweeper から定期的に呼ぶ）
+// 同時に注文確定で消費された仮押さえは、商品ごとの解放の条件で読み飛ばされる
+func (s *OrderService) ReleaseExpiredReservations(ctx context.Context, now time.Time) (int, error) {
+	reservations, err := s.reservationRepo.ListExpired(ctx, now, expiredReservationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, reservation := range reservations {
+		if err := ctx.Err(); err != nil {
+			return released, err
+		}
+		if _, err := s.releaseReservation(ctx, reservation); err != nil {
+			log.Printf("Failed to release expired stock reservation: reservation=%s: %v", reservation.ID, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// GetOrdersはユーザーの注文一覧を新しい順に limit 件ずつ取得する
+// status を指定した場合はそのステータスの注文だけを返す（1ページの件数は limit 未満になることがある）
+func (s *OrderService) GetOrders(ctx context.Context, userID, status string, limit int32, cursor string) (*domain.OrderPage, error) {
+	orders, next, err := s.orderRepo.ListByUserID(ctx, userID, status, limit, cursor)
+	if err != nil {
+		return nil, cursorError(err)
+	}
+	return &domain.OrderPage{
+		Orders:     orders,
+		NextCursor: next,
+	}, nil
+}
+
+// 注文の集計アイテムの初期値を作る際に読む注文ヘッダーのページ数の上限
+// 集計に使う属性だけを読むため1ページ（1MB）に数千件の注文が入る → 上限に達するのは数万件の注文があるユーザーのみ
+// 上限を超えた場合は途中までの集計を返さずにエラーにする
+const maxOrderSummaryPages = 10
+
+// Summarize はユーザーの注文を集計する（注文数・キャンセルを除いた税込支払額の合計・ステータスごとの件数・最終注文日時）
+//
+// 【集計方法】注文確定・ステータス変更のトランザクションで更新している集計アイテム（USER#<userId>/ORDERSTATS）を1回の GetItem で読む
+//   - 注文数によらず読み込みは1件で済む（代わりに注文確定・ステータス変更の書き込みが1件増える）
+//   - 集計アイテムの導入前の注文は含まれていないため、集計済み（backfilled）でない場合に限り
+//     注文ヘッダーを全件読んで集計し、その値で集計アイテムを置き換える（以降は GetItem だけで済む）
+func (s *OrderService) Summarize(ctx context.Context, userID string) (*domain.OrderSummary, error) {
+	stats, err := s.orderRepo.GetUserStats(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrOrderStatsNotFound) {
+		return nil, err
+	}
+	if stats != nil && stats.Backfilled {
+		return stats.Summary, nil
+	}
+
+	// 集計アイテムを読んだ「後」に全件を読む → 途中で注文確定などがあれば revision が変わり、置き換えは失敗する
+	revision := 0
+	if stats != nil {
+		revision = stats.Revision
+	}
+	summary, err := s.summarizeOrders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.BackfillUserStats(ctx, userID, summary, revision); err != nil && !errors.Is(err, repository.ErrOrderStatsConflict) {
+		return nil, err
+	}
+	// 競合した場合も、全件を読んだ集計はその時点の値として正しい（次回の呼び出しで置き換え直す）
+	return summary, nil
+}
+
+// summarizeOrders はユーザーの注文ヘッダーを全件読み、Go 側で集計する
+func (s *OrderService) summarizeOrders(ctx context.Context, userID string) (*domain.OrderSummary, error) {
+	orders, err := s.orderRepo.ListAllByUserID(ctx, userID, maxOrderSummaryPages)
+	if err != nil {
+		return nil, fmt.Errorf("summarize orders: %w", err)
+	}
+
+	summary := &domain.OrderSummary{
+		TotalOrders:  len(orders),
+		StatusCounts: make(map[string]int),
+	}
+	for _, order := range orders {
+		summary.StatusCounts[order.Status]++
+		if order.Status != domain.OrderStatusCancelled {
+			summary.TotalSpent += order.GrandTotal
+		}
+	}
+	// 注文は新しい順に並んでいる
+	if len(orders) > 0 {
+		lastOrderAt := orders[0].CreatedAt
+		summary.LastOrderAt = &lastOrderAt
+	}
+	return summary, nil
+}
+
+// ListByMonthは指定月（yyyy-mm）の注文を新しい順に取得する（管理者用）
+// 各注文に注文者の名前・メールアドレスを付ける
+func (s *OrderService) ListByMonth(ctx context.Context, yyyymm string, limit int32, cursor string) (*domain.OrderPage, error) {
+	orders, next, err := s.orderRepo.GetByMonth(ctx, yyyymm, limit, cursor)
+	if err != nil {
+		return nil, cursorError(err)
+	}
+	if err := s.attachCustomers(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return &domain.OrderPage{
+		Orders:     orders,
+		NextCursor: next,
+	}, nil
+}
+
+// ListByStatus は指定ステータスの注文を、そのステータスになった日時の古い順に取得する（管理者用）
+// ListByMonth と同じく、各注文に注文者の名前・メールアドレスを付ける
+func (s *OrderService) ListByStatus(ctx context.Context, status string, limit int32, cursor string) (*domain.OrderPage, error) {
+	if !domain.IsValidOrderStatus(status) {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	orders, next, err := s.orderRepo.ListByStatus(ctx, status, limit, cursor)
+	if err != nil {
+		return nil, cursorError(err)
+	}
+	if err := s.attachCustomers(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return &domain.OrderPage{
+		Orders:     orders,
+		NextCursor: next,
+	}, nil
+}
+
+// attachCustomers は注文に注文者の名前・メールアドレスを付ける（1ページ分のユーザーを BatchGetItem でまとめて取得）
+// 退会などでユーザーが見つからない注文は userId のみのままにする
+func (s *OrderService) attachCustomers(ctx context.Context, orders []*domain.Order) error {
+	userIDs := make([]string, len(orders))
+	for i, order := range orders {
+		userIDs[i] = order.UserID
+	}
+	users, err := s.userRepo.BatchGetByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if user, ok := users[order.UserID]; ok {
+			order.CustomerName = user.Name
+			order.CustomerEmail = user.Email
+		}
+	}
+	return nil
+}
+
+// EachByMonth は指定月（yyyy-mm）の注文をページ単位で fn に渡す（管理者用・CSVエクスポート）
+func (s *OrderService) EachByMonth(ctx context.Context, yyyymm string, fn func(orders []*domain.Order) error) error {
+	return s.orderRepo.EachByMonth(ctx, yyyymm, fn)
 }
 
 // GetOrderByIDは注文詳細を取得する
-func (s *OrderService) GetOrderByID(ctx context.Context, userID, orderID string) (*domain.Order, error) {
-	return s.orderRepo.GetByID(ctx, userID, orderID)
+// 明細は最大 itemLimit 件（未指定または上限超過の場合は maxOrderItems 件）まで返す
+// 通常サイズの注文は1ページに収まり、上限を超える大口注文のみ itemsToken でページングする
+func (s *OrderService) GetOrderByID(ctx context.Context, userID, orderID string, itemLimit int, itemsToken string) (*domain.Order, error) {
+	if itemLimit <= 0 || (s.maxOrderItems > 0 && itemLimit > s.maxOrderItems) {
+		itemLimit = s.maxOrderItems
+	}
+	order, err := s.orderRepo.GetByID(ctx, userID, orderID, int32(itemLimit), itemsToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return nil, ErrOrderNotFound.WithCause(err)
+		case errors.Is(err, repository.ErrInvalidCursor):
+			return nil, ErrInvalidItemsToken.WithCause(err)
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// BuildInvoice は注文の請求書を組み立てる
+// 税率・税額は注文確定時に保存した値を使う（現在の TAX_RATE では計算し直さない）
+// 明細はページングせずに全件載せる
+//
+// 注文がない（他のユーザーの注文を含む）場合は ErrOrderNotFound を返す
+func (s *OrderService) BuildInvoice(ctx context.Context, userID, orderID string) (*domain.Invoice, error) {
+	order, err := s.GetOrderByID(ctx, userID, orderID, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	// 明細が1ページに収まらない場合は、所有者を確認済みのため全件を読み直す
+	items := order.Items
+	if order.ItemsNextToken != "" {
+		if items, err = s.orderRepo.GetItemsByOrderID(ctx, orderID); err != nil {
+			return nil, err
+		}
+	}
+
+	lines := make([]domain.InvoiceLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, domain.InvoiceLine{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			UnitPrice:   item.Price,
+			Quantity:    item.Quantity,
+			Subtotal:    item.Subtotal,
+		})
+	}
+
+	return &domain.Invoice{
+		OrderID:         order.ID,
+		OrderedAt:       order.CreatedAt,
+		Lines:           lines,
+		Subtotal:        order.Subtotal,
+		CouponCode:      order.CouponCode,
+		DiscountAmount:  order.DiscountAmount,
+		TaxableAmount:   order.TotalAmount,
+		TaxRate:         order.TaxRate,
+		TaxAmount:       order.TaxAmount,
+		GrandTotal:      order.GrandTotal,
+		ShippingAddress: order.ShippingAddress,
+	}, nil
+}
+
+// calculateTax は課税対象額 amount に税率 rate をかけた消費税額を返す
+// 【端数処理】1円未満は四捨五入（0.5円は切り上げ）。例: 105円 × 10% = 10.5円 → 11円
+// 浮動小数点の誤差で端数の扱いがずれないよう、税率を 0.01% 単位の整数にしてから計算する
+// 税額は注文全体の課税対象額に対して1回だけ計算する（明細ごとに丸めない）
+// 計算途中で int64 の範囲を超える場合は ErrAmountOverflow を返す
+func calculateTax(amount int, rate float64) (int, error) {
+	if amount <= 0 || rate <= 0 {
+		return 0, nil
+	}
+	basisPoints := int64(math.Round(rate * 10000))
+	if int64(amount) > (math.MaxInt64-5000)/basisPoints {
+		return 0, ErrAmountOverflow
+	}
+	return int((int64(amount)*basisPoints + 5000) / 10000), nil
+}
+
+// MarkPaidは注文を入金済みにする（管理者用）
+// 注文IDから所有ユーザーを逆引きしてヘッダーを更新し、更新後の注文を返す
+func (s *OrderService) MarkPaid(ctx context.Context, orderID, reference string) (*domain.Order, error) {
+	userID, err := s.orderRepo.GetOwner(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+
+	if err := s.orderRepo.MarkPaid(ctx, userID, orderID, reference, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return nil, ErrOrderNotFound.WithCause(err)
+		case errors.Is(err, repository.ErrOrderAlreadyPaid):
+			return nil, ErrOrderAlreadyPaid.WithCause(err)
+		}
+		return nil, err
+	}
+
+	return s.orderRepo.GetByID(ctx, userID, orderID, int32(s.maxOrderItems), "")
+}
+
+// UpdateStatus は注文のステータスを変更する（管理者用）
+// 注文IDから所有ユーザーを逆引きしてヘッダーを更新し、更新後の注文を返す
+func (s *OrderService) UpdateStatus(ctx context.Context, orderID, status string) (*domain.Order, error) {
+	if !domain.IsValidOrderStatus(status) {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	userID, err := s.orderRepo.GetOwner(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+
+	order, err := s.orderRepo.UpdateStatus(ctx, userID, orderID, status)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return nil, ErrOrderNotFound.WithCause(err)
+		case errors.Is(err, repository.ErrTransactionConflict):
+			return nil, ErrOrderConflict.WithCause(err)
+		}
+		return nil, err
+	}
+	return order, nil
 }
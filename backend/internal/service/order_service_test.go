@@ -0,0 +1,628 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// recordingSender は送信したメールを記録する EmailSender（テスト用）
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []sentEmail
+}
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+func (s *recordingSender) Send(ctx context.Context, to, subject, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// orderTestEnv は注文のテストに使うリポジトリとサービス一式
+type orderTestEnv struct {
+	fake            *dynamotest.Fake
+	productRepo     *repository.ProductRepository
+	cartRepo        *repository.CartRepository
+	orderRepo       *repository.OrderRepository
+	userRepo        *repository.UserRepository
+	reservationRepo *repository.ReservationRepository
+	sender          *recordingSender
+	svc             *OrderService
+	user            *domain.User
+}
+
+func newOrderTestEnv(t *testing.T, maxOrderItems int, taxRate float64) *orderTestEnv {
+	t.Helper()
+	db, fake := newTestDB()
+	env := &orderTestEnv{
+		fake:            fake,
+		productRepo:     repository.NewProductRepository(db),
+		cartRepo:        repository.NewCartRepository(db),
+		orderRepo:       repository.NewOrderRepository(db),
+		userRepo:        repository.NewUserRepository(db),
+		reservationRepo: repository.NewReservationRepository(db),
+		sender:          &recordingSender{},
+		user:            &domain.User{Email: "buyer@example.com", Name: "buyer", PasswordHash: "x"},
+	}
+	env.svc = NewOrderService(env.orderRepo, env.cartRepo, env.productRepo, repository.NewIdempotencyRepository(db),
+		repository.NewCouponRepository(db), env.userRepo, nil, env.reservationRepo, env.sender, maxOrderItems, OrderPricePolicySnapshot, taxRate)
+	if err := env.userRepo.Create(context.Background(), env.user); err != nil {
+		t.Fatalf("Create(user) error = %v", err)
+	}
+	return env
+}
+
+// newBuyer は env.user とは別の購入者を作成する
+func (env *orderTestEnv) newBuyer(t *testing.T, email string) *domain.User {
+	t.Helper()
+	user := &domain.User{Email: email, Name: email, PasswordHash: "x"}
+	if err := env.userRepo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create(%s) error = %v", email, err)
+	}
+	return user
+}
+
+// addToCart は商品を quantity 個 env.user のカートに入れる
+func (env *orderTestEnv) addToCart(t *testing.T, product *domain.Product, quantity int) {
+	t.Helper()
+	env.addToCartOf(t, env.user, product, quantity)
+}
+
+// addToCartOf は商品を quantity 個 user のカートに入れる
+func (env *orderTestEnv) addToCartOf(t *testing.T, user *domain.User, product *domain.Product, quantity int) {
+	t.Helper()
+	item := &domain.CartItem{UserID: user.ID, ProductID: product.ID, ProductName: product.Name, Price: product.Price, Quantity: quantity}
+	if err := env.cartRepo.Add(context.Background(), item, 1<<30); err != nil {
+		t.Fatalf("Add(%s) error = %v", product.Name, err)
+	}
+}
+
+// checkout は商品ごとに1個ずつカートに入れて注文する
+func (env *orderTestEnv) checkout(t *testing.T, products ...*domain.Product) *domain.Order {
+	t.Helper()
+	for _, product := range products {
+		env.addToCart(t, product, 1)
+	}
+	order, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	return order
+}
+
+func TestGetOrderByIDPagesItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int // Query の1ページの件数（1MB 制限の再現）
+	}{
+		{name: "明細が1ページに収まらない注文"},
+		{name: "Query が途中で打ち切られる", pageSize: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOrderTestEnv(t, 2, 0)
+			var products []*domain.Product
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				products = append(products, createTestProduct(t, env.productRepo, name, 100, 10))
+			}
+			order := env.checkout(t, products...)
+			env.fake.PageSize = tt.pageSize
+
+			seen := map[string]bool{}
+			token := ""
+			pages := 0
+			for {
+				got, err := env.svc.GetOrderByID(context.Background(), env.user.ID, order.ID, 0, token)
+				if err != nil {
+					t.Fatalf("GetOrderByID() error = %v", err)
+				}
+				pages++
+				if len(got.Items) > 2 {
+					t.Errorf("page %d has %d items, want at most 2", pages, len(got.Items))
+				}
+				for _, item := range got.Items {
+					if seen[item.ProductID] {
+						t.Errorf("item %s returned twice", item.ProductName)
+					}
+					seen[item.ProductID] = true
+				}
+				if token = got.ItemsNextToken; token == "" {
+					break
+				}
+			}
+			if len(seen) != len(products) || pages != 3 {
+				t.Errorf("got %d items in %d pages, want %d items in 3 pages", len(seen), pages, len(products))
+			}
+		})
+	}
+
+	t.Run("不正なトークン", func(t *testing.T) {
+		env := newOrderTestEnv(t, 2, 0)
+		order := env.checkout(t, createTestProduct(t, env.productRepo, "a", 100, 10))
+		_, err := env.svc.GetOrderByID(context.Background(), env.user.ID, order.ID, 0, "not-a-token")
+		if !errors.Is(err, ErrInvalidItemsToken) {
+			t.Errorf("GetOrderByID() error = %v, want %v", err, ErrInvalidItemsToken)
+		}
+	})
+}
+
+func TestGetOrderByIDOtherUser(t *testing.T) {
+	env := newOrderTestEnv(t, 0, 0)
+	order := env.checkout(t, createTestProduct(t, env.productRepo, "a", 100, 10))
+	other := env.newBuyer(t, "other@example.com")
+	ctx := context.Background()
+
+	// ユーザー B はユーザー A の注文IDを知っていても、注文も明細も読めない
+	if _, err := env.svc.GetOrderByID(ctx, other.ID, order.ID, 0, ""); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("GetOrderByID(other user) error = %v, want %v", err, ErrOrderNotFound)
+	}
+	items, _, err := env.orderRepo.GetOrderItems(ctx, other.ID, order.ID, 0, "")
+	if !errors.Is(err, repository.ErrOrderNotFound) || len(items) != 0 {
+		t.Errorf("GetOrderItems(other user) = %d items, error = %v, want %v", len(items), err, repository.ErrOrderNotFound)
+	}
+
+	// 本人は読める
+	got, err := env.svc.GetOrderByID(ctx, env.user.ID, order.ID, 0, "")
+	if err != nil {
+		t.Fatalf("GetOrderByID(owner) error = %v", err)
+	}
+	if len(got.Items) != 1 {
+		t.Errorf("owner got %d items, want 1", len(got.Items))
+	}
+}
+
+// assertStock は商品の在庫数と仮押さえ数を確認する
+func assertStock(t *testing.T, productRepo *repository.ProductRepository, productID string, wantStock, wantReserved int) {
+	t.Helper()
+	stock, reserved, err := productRepo.GetStock(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("GetStock() error = %v", err)
+	}
+	if stock != wantStock || reserved != wantReserved {
+		t.Errorf("stock, reservedStock = %d, %d, want %d, %d", stock, reserved, wantStock, wantReserved)
+	}
+}
+
+// assertNoReservations は仮押さえアイテムが残っていないことを確認する
+func assertNoReservations(t *testing.T, reservationRepo *repository.ReservationRepository) {
+	t.Helper()
+	left, err := reservationRepo.ListExpired(context.Background(), time.Now().Add(24*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("ListExpired() error = %v", err)
+	}
+	if len(left) != 0 {
+		t.Errorf("%d stock reservation(s) left, want 0", len(left))
+	}
+}
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	t.Run("仮押さえの後に割り込んだ注文は在庫不足で早く失敗する", func(t *testing.T) {
+		env := newOrderTestEnv(t, 100, 0)
+		product := createTestProduct(t, env.productRepo, "last one", 100, 1)
+		other := env.newBuyer(t, "other@example.com")
+		env.addToCart(t, product, 1)
+		env.addToCartOf(t, other, product, 1)
+
+		// env.user が在庫を仮押さえした後、注文確定のトランザクションの直前に other が注文する
+		var otherErr error
+		interleaved := false
+		env.fake.Hook = func(ctx context.Context, op string, input any) error {
+			in, ok := input.(*dynamodb.TransactWriteItemsInput)
+			if op != "TransactWriteItems" || !ok || len(in.TransactItems) <= 2 || interleaved {
+				return nil
+			}
+			interleaved = true
+			_, otherErr = env.svc.CreateOrder(ctx, other.ID, "", &domain.CreateOrderRequest{})
+			return nil
+		}
+
+		if _, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{}); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		if !interleaved {
+			t.Fatal("the other checkout did not run")
+		}
+		if !errors.Is(otherErr, ErrOrderOutOfStock) {
+			t.Errorf("interleaved CreateOrder() error = %v, want %v", otherErr, ErrOrderOutOfStock)
+		}
+		assertStock(t, env.productRepo, product.ID, 0, 0)
+		assertNoReservations(t, env.reservationRepo)
+	})
+
+	t.Run("同時の注文で在庫を超えて売らない", func(t *testing.T) {
+		const stock, buyers = 3, 10
+		env := newOrderTestEnv(t, 100, 0)
+		product := createTestProduct(t, env.productRepo, "limited", 100, stock)
+		users := make([]*domain.User, buyers)
+		for i := range users {
+			users[i] = env.newBuyer(t, fmt.Sprintf("buyer%d@example.com", i))
+			env.addToCartOf(t, users[i], product, 1)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, buyers)
+		for i, user := range users {
+			wg.Go(func() {
+				_, errs[i] = env.svc.CreateOrder(context.Background(), user.ID, "", &domain.CreateOrderRequest{})
+			})
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, ErrOrderOutOfStock):
+				t.Errorf("CreateOrder() error = %v, want nil or %v", err, ErrOrderOutOfStock)
+			}
+		}
+		if succeeded != stock {
+			t.Errorf("%d orders succeeded, want %d", succeeded, stock)
+		}
+		assertStock(t, env.productRepo, product.ID, 0, 0)
+		assertNoReservations(t, env.reservationRepo)
+	})
+
+	t.Run("注文確定に失敗したら仮押さえを解放する", func(t *testing.T) {
+		env := newOrderTestEnv(t, 100, 0)
+		product := createTestProduct(t, env.productRepo, "a", 100, 5)
+		env.addToCart(t, product, 2)
+		env.fake.Hook = func(ctx context.Context, op string, input any) error {
+			if in, ok := input.(*dynamodb.TransactWriteItemsInput); ok && len(in.TransactItems) > 2 {
+				return dynamotest.TransactionCanceled("None", "TransactionConflict")
+			}
+			return nil
+		}
+
+		_, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+		if !errors.Is(err, ErrOrderConflict) {
+			t.Fatalf("CreateOrder() error = %v, want %v", err, ErrOrderConflict)
+		}
+		assertStock(t, env.productRepo, product.ID, 5, 0)
+		assertNoReservations(t, env.reservationRepo)
+	})
+}
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	a := createTestProduct(t, env.productRepo, "a", 100, 5)
+	b := createTestProduct(t, env.productRepo, "b", 100, 5)
+
+	// 仮押さえの途中でプロセスが落ちた（b は仮押さえ前）チェックアウトを再現する
+	reservation := &domain.StockReservation{
+		UserID:     env.user.ID,
+		Quantities: map[string]int{a.ID: 2, b.ID: 1},
+		ExpiresAt:  time.Now().Add(checkoutReservationTTL),
+	}
+	if err := env.reservationRepo.Begin(context.Background(), reservation); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := env.reservationRepo.Reserve(context.Background(), reservation, a.ID, 5); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	assertStock(t, env.productRepo, a.ID, 5, 2)
+
+	released, err := env.svc.ReleaseExpiredReservations(context.Background(), time.Now())
+	if err != nil || released != 0 {
+		t.Fatalf("ReleaseExpiredReservations(before expiry) = %d, %v, want 0, nil", released, err)
+	}
+	assertStock(t, env.productRepo, a.ID, 5, 2)
+
+	released, err = env.svc.ReleaseExpiredReservations(context.Background(), reservation.ExpiresAt.Add(time.Minute))
+	if err != nil || released != 1 {
+		t.Fatalf("ReleaseExpiredReservations(after expiry) = %d, %v, want 1, nil", released, err)
+	}
+	assertStock(t, env.productRepo, a.ID, 5, 0)
+	assertStock(t, env.productRepo, b.ID, 5, 0)
+	assertNoReservations(t, env.reservationRepo)
+
+	// 解放後は同じチェックアウトで仮押さえを増やせない
+	if err := env.reservationRepo.Reserve(context.Background(), reservation, b.ID, 5); !errors.Is(err, repository.ErrReservationExpired) {
+		t.Errorf("Reserve(after release) error = %v, want %v", err, repository.ErrReservationExpired)
+	}
+}
+
+func TestMarkPaid(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	order := env.checkout(t, createTestProduct(t, env.productRepo, "a", 100, 10))
+	if order.PaymentStatus != domain.PaymentStatusUnpaid {
+		t.Fatalf("PaymentStatus after checkout = %q, want %q", order.PaymentStatus, domain.PaymentStatusUnpaid)
+	}
+
+	paid, err := env.svc.MarkPaid(context.Background(), order.ID, "pay_123")
+	if err != nil {
+		t.Fatalf("MarkPaid() error = %v", err)
+	}
+	if paid.PaymentStatus != domain.PaymentStatusPaid || paid.PaymentReference != "pay_123" || paid.PaidAt == nil {
+		t.Errorf("MarkPaid() = status %q, reference %q, paidAt %v, want %q, %q, set",
+			paid.PaymentStatus, paid.PaymentReference, paid.PaidAt, domain.PaymentStatusPaid, "pay_123")
+	}
+	// 注文の状態（ステータス）は支払いと独立している
+	if paid.Status != order.Status {
+		t.Errorf("Status = %q, want %q", paid.Status, order.Status)
+	}
+
+	// 支払い済みの注文は二重に支払い済みにしない（最初の決済リファレンスを上書きしない）
+	if _, err := env.svc.MarkPaid(context.Background(), order.ID, "pay_456"); !errors.Is(err, ErrOrderAlreadyPaid) {
+		t.Errorf("MarkPaid(again) error = %v, want %v", err, ErrOrderAlreadyPaid)
+	}
+	got, err := env.svc.GetOrderByID(context.Background(), env.user.ID, order.ID, 0, "")
+	if err != nil {
+		t.Fatalf("GetOrderByID() error = %v", err)
+	}
+	if got.PaymentStatus != domain.PaymentStatusPaid || got.PaymentReference != "pay_123" {
+		t.Errorf("GetOrderByID() = status %q, reference %q, want %q, %q", got.PaymentStatus, got.PaymentReference, domain.PaymentStatusPaid, "pay_123")
+	}
+
+	if _, err := env.svc.MarkPaid(context.Background(), "missing", "pay_789"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("MarkPaid(missing) error = %v, want %v", err, ErrOrderNotFound)
+	}
+}
+
+func TestSummarizeBackfillsOrdersBeforeStats(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	ctx := context.Background()
+	first := env.checkout(t, createTestProduct(t, env.productRepo, "a", 100, 10))
+	env.checkout(t, createTestProduct(t, env.productRepo, "b", 200, 10))
+	if _, err := env.orderRepo.UpdateStatus(ctx, env.user.ID, first.ID, domain.OrderStatusCancelled); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	// 集計アイテム導入前の注文しかない状態にする
+	if _, err := env.fake.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + env.user.ID},
+			"SK": &types.AttributeValueMemberS{Value: "ORDERSTATS"},
+		},
+	}); err != nil {
+		t.Fatalf("DeleteItem() error = %v", err)
+	}
+
+	want := &domain.OrderSummary{
+		TotalOrders:  2,
+		TotalSpent:   200,
+		StatusCounts: map[string]int{domain.OrderStatusConfirmed: 1, domain.OrderStatusCancelled: 1},
+	}
+	assertSummary := func(t *testing.T, got *domain.OrderSummary, want *domain.OrderSummary) {
+		t.Helper()
+		if got.TotalOrders != want.TotalOrders || got.TotalSpent != want.TotalSpent || !maps.Equal(got.StatusCounts, want.StatusCounts) || got.LastOrderAt == nil {
+			t.Errorf("Summarize() = %+v, want %+v", got, want)
+		}
+	}
+
+	// 1回目: 注文ヘッダーを全件読んで集計し、集計アイテムを置き換える
+	queries := env.fake.CallCount("Query")
+	got, err := env.svc.Summarize(ctx, env.user.ID)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	assertSummary(t, got, want)
+	if env.fake.CallCount("Query") == queries {
+		t.Error("first Summarize() did not read the orders")
+	}
+	stats, err := env.orderRepo.GetUserStats(ctx, env.user.ID)
+	if err != nil || !stats.Backfilled {
+		t.Fatalf("GetUserStats() = %+v, %v, want backfilled stats", stats, err)
+	}
+
+	// 2回目以降: 集計アイテムだけを読む（その後の注文も加算されている）
+	env.checkout(t, createTestProduct(t, env.productRepo, "c", 300, 10))
+	queries = env.fake.CallCount("Query")
+	got, err = env.svc.Summarize(ctx, env.user.ID)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	assertSummary(t, got, &domain.OrderSummary{
+		TotalOrders:  3,
+		TotalSpent:   500,
+		StatusCounts: map[string]int{domain.OrderStatusConfirmed: 2, domain.OrderStatusCancelled: 1},
+	})
+	if n := env.fake.CallCount("Query") - queries; n != 0 {
+		t.Errorf("Summarize() after the backfill issued %d queries, want 0", n)
+	}
+}
+
+func TestCreateOrderReportsShortProduct(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	a := createTestProduct(t, env.productRepo, "a", 100, 10)
+	b := createTestProduct(t, env.productRepo, "b", 100, 1)
+	c := createTestProduct(t, env.productRepo, "c", 100, 10)
+	env.addToCart(t, a, 2)
+	env.addToCart(t, b, 2) // 2番目の商品だけ在庫が足りない
+	env.addToCart(t, c, 2)
+
+	_, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+	if !errors.Is(err, ErrOrderOutOfStock) {
+		t.Fatalf("CreateOrder() error = %v, want %v", err, ErrOrderOutOfStock)
+	}
+	var stockErr *repository.InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.ProductID != b.ID {
+		t.Errorf("CreateOrder() error = %v, want InsufficientStockError for %s", err, b.ID)
+	}
+	if want := "Insufficient stock for product " + b.ID; !strings.Contains(err.Error(), want) {
+		t.Errorf("CreateOrder() error = %q, want it to contain %q", err.Error(), want)
+	}
+
+	// 先に仮押さえした商品も解放され、カートはそのまま残る
+	assertStock(t, env.productRepo, a.ID, 10, 0)
+	assertStock(t, env.productRepo, b.ID, 1, 0)
+	assertStock(t, env.productRepo, c.ID, 10, 0)
+	assertNoReservations(t, env.reservationRepo)
+	if n, err := env.cartRepo.CountByUserID(context.Background(), env.user.ID); err != nil || n != 3 {
+		t.Errorf("cart items = %d, %v, want 3", n, err)
+	}
+}
+
+func TestCreateOrderDecrementsStockReadByGetByID(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	product := createTestProduct(t, env.productRepo, "a", 100, 5)
+	env.addToCart(t, product, 2)
+	if _, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, err := env.productRepo.GetByID(context.Background(), product.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Stock != 3 {
+		t.Errorf("GetByID().Stock = %d, want 3", got.Stock)
+	}
+	// 別名の属性（大文字の Stock など）が作られていない
+	item := env.fake.Get("PRODUCT#"+product.ID, "METADATA")
+	for name := range item {
+		if name != "stock" && strings.EqualFold(name, "stock") {
+			t.Errorf("product has a second stock attribute %q", name)
+		}
+	}
+}
+
+func TestCreateOrderSendsConfirmationWithEveryItem(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0.10)
+	quantities := map[string]int{"ノート": 3, "ボールペン": 1, "消しゴム": 2}
+	for _, name := range []string{"ノート", "ボールペン", "消しゴム"} {
+		env.addToCart(t, createTestProduct(t, env.productRepo, name, 120, 10), quantities[name])
+	}
+	order, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	if len(env.sender.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(env.sender.sent))
+	}
+	sent := env.sender.sent[0]
+	if sent.to != env.user.Email {
+		t.Errorf("to = %q, want %q", sent.to, env.user.Email)
+	}
+	if !strings.Contains(sent.subject, order.ID) {
+		t.Errorf("subject %q does not contain order ID %s", sent.subject, order.ID)
+	}
+	for name, qty := range quantities {
+		line := fmt.Sprintf("%s  120円 × %d = %d円\n", name, qty, 120*qty)
+		if !strings.Contains(sent.body, line) {
+			t.Errorf("body does not contain line item %q:\n%s", line, sent.body)
+		}
+	}
+	if want := fmt.Sprintf("お支払い金額: %d円\n", order.GrandTotal); !strings.Contains(sent.body, want) {
+		t.Errorf("body does not contain %q:\n%s", want, sent.body)
+	}
+}
+
+// failingSender は常に送信に失敗する EmailSender（テスト用）
+type failingSender struct{}
+
+func (failingSender) Send(ctx context.Context, to, subject, body string) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestCreateOrderSucceedsWhenConfirmationFails(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	env.svc.emailSender = failingSender{}
+	product := createTestProduct(t, env.productRepo, "a", 100, 5)
+
+	order := env.checkout(t, product)
+	if order.ID == "" {
+		t.Fatal("CreateOrder() returned an order without ID")
+	}
+	assertStock(t, env.productRepo, product.ID, 4, 0)
+}
+
+func TestCalculateTax(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int
+		rate   float64
+		want   int
+	}{
+		{name: "割り切れる", amount: 1000, rate: 0.10, want: 100},
+		{name: "0.5円は切り上げ", amount: 105, rate: 0.10, want: 11},
+		{name: "0.5円未満は切り捨て", amount: 104, rate: 0.10, want: 10},
+		{name: "0.5円を超えると切り上げ", amount: 1234, rate: 0.08, want: 99},
+		// 0.1 などは2進数で正確に表せないが、0.01% 単位の整数にしてから計算するため端数がずれない
+		{name: "浮動小数点の誤差の影響を受けない", amount: 5, rate: 0.10, want: 1},
+		{name: "税率0", amount: 1000, rate: 0, want: 0},
+		{name: "金額0", amount: 0, rate: 0.10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateTax(tt.amount, tt.rate)
+			if err != nil {
+				t.Fatalf("calculateTax() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateTax(%d, %g) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOrderGrandTotalIncludesRoundedTax(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0.10)
+	env.addToCart(t, createTestProduct(t, env.productRepo, "a", 105, 10), 1)
+	env.addToCart(t, createTestProduct(t, env.productRepo, "b", 250, 10), 2)
+
+	order, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	// 小計 605円 × 10% = 60.5円 → 61円（四捨五入）
+	const wantSubtotal, wantTax = 605, 61
+	stored, err := env.svc.GetOrderByID(context.Background(), env.user.ID, order.ID, 0, "")
+	if err != nil {
+		t.Fatalf("GetOrderByID() error = %v", err)
+	}
+	for name, got := range map[string]*domain.Order{"CreateOrder": order, "GetOrderByID": stored} {
+		if got.TotalAmount != wantSubtotal || got.TaxAmount != wantTax || got.GrandTotal != wantSubtotal+wantTax {
+			t.Errorf("%s: total = %d, tax = %d, grand total = %d, want %d + %d = %d",
+				name, got.TotalAmount, got.TaxAmount, got.GrandTotal, wantSubtotal, wantTax, wantSubtotal+wantTax)
+		}
+		if got.TaxRate != 0.10 {
+			t.Errorf("%s: tax rate = %g, want 0.10", name, got.TaxRate)
+		}
+	}
+}
+
+func TestCreateOrderAmountOverflow(t *testing.T) {
+	env := newOrderTestEnv(t, 100, 0)
+	// 単価 × 数量が int の範囲を超える（折り返して負の合計にならない）
+	product := createTestProduct(t, env.productRepo, "高額な商品", math.MaxInt/3+1, 10)
+	env.addToCart(t, product, 3)
+
+	_, err := env.svc.CreateOrder(context.Background(), env.user.ID, "", &domain.CreateOrderRequest{})
+	if !errors.Is(err, ErrAmountOverflow) {
+		t.Fatalf("CreateOrder() error = %v, want ErrAmountOverflow", err)
+	}
+	// 在庫もカートも変わらない
+	assertStock(t, env.productRepo, product.ID, 10, 0)
+	items, err := env.cartRepo.GetByUserID(context.Background(), env.user.ID)
+	if err != nil {
+		t.Fatalf("GetByUserID() error = %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("cart has %d items, want 1", len(items))
+	}
+}
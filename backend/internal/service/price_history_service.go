@@ -2,59 +2,326 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
 	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/apperr"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 )
 
+// 価格履歴関連のクライアント向けエラー
+var (
+	ErrInvalidPriceInterval   = apperr.BadRequest("invalid_interval", "Invalid interval (use hourly, daily or weekly)")
+	ErrProductVersionMismatch = apperr.Conflict("version_mismatch", "Product was modified by another request, please refetch and retry")
+	ErrScheduledPriceNotFound = apperr.NotFound("scheduled_price_not_found", "Scheduled price change not found")
+)
+
 type PriceHistoryService struct {
-	priceHistoryRepo *repository.PriceHistoryRepository
-	productRepo      *repository.ProductRepository
+	priceHistoryRepo   *repository.PriceHistoryRepository
+	scheduledPriceRepo *repository.ScheduledPriceRepository
+	productRepo        *repository.ProductRepository
+	productCache       *ProductCache // 価格を変えた商品のキャッシュを無効化する（nil の場合は無効）
 }
 
-func NewPriceHistoryService(priceHistoryRepo *repository.PriceHistoryRepository, productRepo *repository.ProductRepository) *PriceHistoryService {
+func NewPriceHistoryService(priceHistoryRepo *repository.PriceHistoryRepository, scheduledPriceRepo *repository.ScheduledPriceRepository, productRepo *repository.ProductRepository, productCache *ProductCache) *PriceHistoryService {
 	return &PriceHistoryService{
-		priceHistoryRepo: priceHistoryRepo,
-		productRepo:      productRepo,
+		priceHistoryRepo:   priceHistoryRepo,
+		scheduledPriceRepo: scheduledPriceRepo,
+		productRepo:        productRepo,
+		productCache:       productCache,
 	}
 }
 
 // UpdatePriceは商品価格を更新し、価格履歴を記録する
-func (s *PriceHistoryService) UpdatePrice(ctx context.Context, productID string, newPrice int, changedBy string) error {
+// 【楽観的ロック】expectedVersion はクライアントが読み込んだ商品のバージョン
+// 現在のバージョンと違う場合（他の管理者が先に変更した場合）は ErrProductVersionMismatch を返す
+// 商品を読み込んでから書き込むまでの間の変更も、トランザクションの条件で検出する
+// 更新後の商品と、記録した価格履歴を返す（価格が変わらない場合は何も記録せず、履歴は nil）
+func (s *PriceHistoryService) UpdatePrice(ctx context.Context, productID string, newPrice, expectedVersion int, changedBy string) (*domain.Product, *domain.PriceHistory, error) {
 	// 商品の現在の価格を取得
 	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	if product.Version != expectedVersion {
+		return nil, nil, ErrProductVersionMismatch.WithCause(repository.ErrProductVersionMismatch)
 	}
 
+	history, err := s.changePrice(ctx, product, newPrice, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, history, nil
+}
+
+// changePrice は読み込んだ商品（product）の価格を newPrice に変更し、記録した価格履歴を返す
+// product のバージョンのまま更新されていないことを条件にする
+// 成功した場合は product を更新後の内容にする
+// （TransactWriteItems は更新後の値を返さないため、強整合性読み込みで読み直す。reservedStock など他の更新も反映された値になる）
+func (s *PriceHistoryService) changePrice(ctx context.Context, product *domain.Product, newPrice int, changedBy string) (*domain.PriceHistory, error) {
 	// 価格が変わらない場合は何もしない
 	if product.Price == newPrice {
-		return nil
+		return nil, nil
 	}
+	productID := product.ID
 
-	// 価格履歴を記録
+	// 価格履歴の記録と商品価格の更新を1つのトランザクションで行う（履歴と現在の価格が食い違わない）
 	history := &domain.PriceHistory{
 		ProductID: productID,
 		Price:     newPrice,
 		ChangedBy: changedBy,
 	}
 
-	if err := s.priceHistoryRepo.Create(ctx, history); err != nil {
+	err := s.priceHistoryRepo.RecordPriceChange(ctx, history, product.Version)
+	s.productCache.Invalidate(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductVersionMismatch) {
+			return nil, ErrProductVersionMismatch.WithCause(err)
+		}
+		return nil, err
+	}
+
+	updated, err := s.productRepo.GetByIDConsistent(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	*product = *updated
+	return history, nil
+}
+
+// GetHistoryは価格履歴を取得する（新しい順）
+// 各点の前の価格との変化率・向きと、取得した範囲の統計を付けて返す
+func (s *PriceHistoryService) GetHistory(ctx context.Context, productID string, limit int32) (*domain.PriceHistoryResponse, error) {
+	histories, err := s.priceHistoryRepo.GetByProductID(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return summarizePriceHistory(histories), nil
+}
+
+// GetHistoryWithRangeは指定期間の価格履歴を取得する（古い順）
+// interval（PriceIntervalHourly など）を指定した場合は区間ごとに最後の価格だけに間引く（空の場合は全件）
+// GetHistory と同じく変化率・向きと統計を付けて返す（間引いた場合は間引いた後の点で計算する）
+func (s *PriceHistoryService) GetHistoryWithRange(ctx context.Context, productID string, startTime, endTime time.Time, interval string) (*domain.PriceHistoryResponse, error) {
+	bucket, err := priceBucketFunc(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := s.priceHistoryRepo.GetByProductIDWithRange(ctx, productID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if bucket != nil {
+		histories = downsamplePriceHistory(histories, bucket)
+	}
+	return summarizePriceHistory(histories), nil
+}
+
+// priceBucketFunc は interval に対応する、時刻を区間の開始時刻（UTC）に切り捨てる関数を返す
+// interval が空の場合は nil（間引かない）、不正な値の場合は ErrInvalidPriceInterval を返す
+func priceBucketFunc(interval string) (func(time.Time) time.Time, error) {
+	switch interval {
+	case "":
+		return nil, nil
+	case domain.PriceIntervalHourly:
+		return func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) }, nil
+	case domain.PriceIntervalDaily:
+		return startOfDayUTC, nil
+	case domain.PriceIntervalWeekly:
+		return func(t time.Time) time.Time {
+			day := startOfDayUTC(t)
+			// 月曜始まり（Sunday=0 のため日曜は6日戻す）
+			return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		}, nil
+	}
+	return nil, ErrInvalidPriceInterval
+}
+
+func startOfDayUTC(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// downsamplePriceHistory は区間（bucket の結果）ごとに最も新しい点だけを残す
+// 並び順は入力の順（区間の順）のまま返す
+func downsamplePriceHistory(histories []*domain.PriceHistory, bucket func(time.Time) time.Time) []*domain.PriceHistory {
+	latest := make(map[time.Time]*domain.PriceHistory, len(histories))
+	order := make([]time.Time, 0, len(histories))
+	for _, h := range histories {
+		key := bucket(h.Timestamp)
+		current, ok := latest[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || !h.Timestamp.Before(current.Timestamp) {
+			latest[key] = h
+		}
+	}
+
+	sampled := make([]*domain.PriceHistory, len(order))
+	for i, key := range order {
+		sampled[i] = latest[key]
+	}
+	return sampled
+}
+
+// summarizePriceHistory は各点に前の価格（時系列で1つ古い点）との変化率・向きを設定し、統計を計算する
+// 並び順は取得した順のまま返す（計算は時刻の古い順に行う）
+// 最も古い点は比較対象がないため、変化率・向きを設定しない
+func summarizePriceHistory(histories []*domain.PriceHistory) *domain.PriceHistoryResponse {
+	resp := &domain.PriceHistoryResponse{
+		Points: histories,
+		Stats:  domain.PriceHistoryStats{Count: len(histories)},
+	}
+	if len(histories) == 0 {
+		return resp
+	}
+
+	chronological := slices.Clone(histories)
+	slices.SortStableFunc(chronological, func(a, b *domain.PriceHistory) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	resp.Stats.Min, resp.Stats.Max = chronological[0].Price, chronological[0].Price
+	var sum float64
+	for i, h := range chronological {
+		resp.Stats.Min = min(resp.Stats.Min, h.Price)
+		resp.Stats.Max = max(resp.Stats.Max, h.Price)
+		sum += float64(h.Price)
+
+		if i == 0 {
+			continue
+		}
+		prev := chronological[i-1].Price
+		switch {
+		case h.Price > prev:
+			h.Direction = domain.PriceDirectionUp
+		case h.Price < prev:
+			h.Direction = domain.PriceDirectionDown
+		default:
+			h.Direction = domain.PriceDirectionFlat
+		}
+		if prev != 0 {
+			percent := roundHundredths(float64(h.Price-prev) / float64(prev) * 100)
+			h.ChangePercent = &percent
+		}
+	}
+	resp.Stats.Average = roundHundredths(sum / float64(len(chronological)))
+	return resp
+}
+
+// roundHundredths は小数第2位に丸める
+func roundHundredths(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// applyPrice は最新の商品を読み込み、そのバージョンを条件に価格を変更する（予約価格の適用用）
+func (s *PriceHistoryService) applyPrice(ctx context.Context, productID string, newPrice int, changedBy string) error {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
 		return err
 	}
+	_, err = s.changePrice(ctx, product, newPrice, changedBy)
+	return err
+}
+
+// SchedulePriceChangeは指定日時に適用する価格変更を予約する
+// 適用日時が過去の場合は次回のスイープで即座に適用される
+func (s *PriceHistoryService) SchedulePriceChange(ctx context.Context, productID string, price int, effectiveAt time.Time, changedBy string) (*domain.ScheduledPrice, error) {
+	// 存在しない商品への予約を防ぐ
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	scheduled := &domain.ScheduledPrice{
+		ProductID:   productID,
+		Price:       price,
+		EffectiveAt: effectiveAt.UTC(),
+		CreatedBy:   changedBy,
+	}
 
-	// 商品価格を更新
-	product.Price = newPrice
-	return s.productRepo.Update(ctx, product)
+	if err := s.scheduledPriceRepo.Create(ctx, scheduled); err != nil {
+		return nil, err
+	}
+
+	return scheduled, nil
 }
 
-// GetHistoryは価格履歴を取得する
-func (s *PriceHistoryService) GetHistory(ctx context.Context, productID string, limit int32) ([]*domain.PriceHistory, error) {
-	return s.priceHistoryRepo.GetByProductID(ctx, productID, limit)
+// ListScheduledPricesは商品の適用前の予約を適用日時の古い順に返す
+func (s *PriceHistoryService) ListScheduledPrices(ctx context.Context, productID string) ([]*domain.ScheduledPrice, error) {
+	return s.scheduledPriceRepo.ListByProduct(ctx, productID)
 }
 
-// GetHistoryWithRangeは指定期間の価格履歴を取得する
-func (s *PriceHistoryService) GetHistoryWithRange(ctx context.Context, productID string, startTime, endTime time.Time) ([]*domain.PriceHistory, error) {
-	return s.priceHistoryRepo.GetByProductIDWithRange(ctx, productID, startTime, endTime)
+// CancelScheduledPriceは適用前の予約を取り消す
+// 予約が存在しない（適用済み・取り消し済みを含む）場合は ErrScheduledPriceNotFound を返す
+func (s *PriceHistoryService) CancelScheduledPrice(ctx context.Context, productID string, effectiveAt time.Time) error {
+	if err := s.scheduledPriceRepo.Cancel(ctx, productID, effectiveAt); err != nil {
+		if errors.Is(err, repository.ErrScheduledPriceNotFound) {
+			return ErrScheduledPriceNotFound.WithCause(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// ApplyScheduledPricesは適用日時を過ぎた予約価格を反映し、適用した件数を返す
+// 【処理フロー】
+//  1. 適用日時 <= now の予約を古い順に取得
+//  2. 価格履歴の記録と商品価格の更新を1つのトランザクションで行う（UpdatePrice と同じ）
+//  3. 適用済みの予約を削除
+//
+// 商品が削除されていた場合は予約だけを破棄する
+// 【失敗した予約】
+//
+//	1件の失敗で残りの予約が止まらないよう、失敗した予約はログに出して次の予約に進む
+//	失敗した予約は削除しないため、次回のスイープで再試行される
+//	失敗があった場合は、適用した件数とともに全ての失敗をまとめたエラー（errors.Join）を返す
+func (s *PriceHistoryService) ApplyScheduledPrices(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.scheduledPriceRepo.ListDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var errs []error
+	for _, scheduled := range due {
+		// シャットダウン中は残りを次回に回す
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := s.applyScheduledPrice(ctx, scheduled); err != nil {
+			if errors.Is(err, repository.ErrProductNotFound) {
+				continue
+			}
+			log.Printf("Failed to apply scheduled price: product=%s effectiveAt=%s: %v",
+				scheduled.ProductID, scheduled.EffectiveAt.Format(time.RFC3339), err)
+			errs = append(errs, fmt.Errorf("product %s: %w", scheduled.ProductID, err))
+			continue
+		}
+		applied++
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// applyScheduledPrice は予約1件を適用し、予約を削除する
+// 商品が削除されていた場合は予約だけを削除して repository.ErrProductNotFound を返す
+func (s *PriceHistoryService) applyScheduledPrice(ctx context.Context, scheduled *domain.ScheduledPrice) error {
+	// 予約の適用は管理者の読み込みに基づかないため、最新の商品を読み込んでその時点のバージョンで更新する
+	updateErr := s.applyPrice(ctx, scheduled.ProductID, scheduled.Price, scheduled.CreatedBy)
+	if updateErr != nil && !errors.Is(updateErr, repository.ErrProductNotFound) {
+		return updateErr
+	}
+
+	if err := s.scheduledPriceRepo.Delete(ctx, scheduled.ProductID, scheduled.EffectiveAt); err != nil {
+		return err
+	}
+	return updateErr
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// failProductTransaction は productID の商品を更新するトランザクションを失敗させるフック
+func failProductTransaction(productID string, err error) func(ctx context.Context, op string, input any) error {
+	return func(ctx context.Context, op string, input any) error {
+		in, ok := input.(*dynamodb.TransactWriteItemsInput)
+		if !ok {
+			return nil
+		}
+		for _, item := range in.TransactItems {
+			if item.Update == nil {
+				continue
+			}
+			if pk, ok := item.Update.Key["PK"].(*types.AttributeValueMemberS); ok && pk.Value == "PRODUCT#"+productID {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestApplyScheduledPrices(t *testing.T) {
+	errUnavailable := errors.New("service unavailable")
+	tests := []struct {
+		name        string
+		failSecond  bool
+		wantApplied int
+		wantErr     bool
+		wantPrices  [2]int
+		wantPending int // 次回のスイープで再試行する予約の数
+	}{
+		{
+			name:        "過去の予約を次のスイープで適用",
+			wantApplied: 2,
+			wantPrices:  [2]int{800, 1800},
+		},
+		{
+			name:        "失敗した予約があっても残りを適用",
+			failSecond:  true,
+			wantApplied: 1,
+			wantErr:     true,
+			wantPrices:  [2]int{800, 2000},
+			wantPending: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB()
+			productRepo := repository.NewProductRepository(db)
+			scheduledRepo := repository.NewScheduledPriceRepository(db)
+			svc := NewPriceHistoryService(repository.NewPriceHistoryRepository(db), scheduledRepo, productRepo, nil)
+			ctx := context.Background()
+
+			first := createTestProduct(t, productRepo, "first", 1000, 1)
+			second := createTestProduct(t, productRepo, "second", 2000, 1)
+			now := time.Now()
+			if _, err := svc.SchedulePriceChange(ctx, first.ID, 800, now.Add(-2*time.Minute), "admin"); err != nil {
+				t.Fatalf("SchedulePriceChange() error = %v", err)
+			}
+			if _, err := svc.SchedulePriceChange(ctx, second.ID, 1800, now.Add(-time.Minute), "admin"); err != nil {
+				t.Fatalf("SchedulePriceChange() error = %v", err)
+			}
+			// 未来の予約は適用しない
+			if _, err := svc.SchedulePriceChange(ctx, first.ID, 500, now.Add(time.Hour), "admin"); err != nil {
+				t.Fatalf("SchedulePriceChange() error = %v", err)
+			}
+			if tt.failSecond {
+				fake.Hook = failProductTransaction(second.ID, errUnavailable)
+			}
+
+			applied, err := svc.ApplyScheduledPrices(ctx, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyScheduledPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errUnavailable) {
+				t.Errorf("ApplyScheduledPrices() error = %v, want it to wrap %v", err, errUnavailable)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("ApplyScheduledPrices() applied = %d, want %d", applied, tt.wantApplied)
+			}
+
+			fake.Hook = nil
+			for i, id := range []string{first.ID, second.ID} {
+				product, err := productRepo.GetByID(ctx, id)
+				if err != nil {
+					t.Fatalf("GetByID() error = %v", err)
+				}
+				if product.Price != tt.wantPrices[i] {
+					t.Errorf("price of product %d = %d, want %d", i, product.Price, tt.wantPrices[i])
+				}
+			}
+			due, err := scheduledRepo.ListDue(ctx, now)
+			if err != nil {
+				t.Fatalf("ListDue() error = %v", err)
+			}
+			if len(due) != tt.wantPending {
+				t.Errorf("pending scheduled prices = %d, want %d", len(due), tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestUpdatePriceReturnsStoredProduct(t *testing.T) {
+	db, fake := newTestDB()
+	productRepo := repository.NewProductRepository(db)
+	svc := NewPriceHistoryService(repository.NewPriceHistoryRepository(db), repository.NewScheduledPriceRepository(db), productRepo, nil)
+	product := createTestProduct(t, productRepo, "商品A", 1000, 10)
+
+	// 価格を読んだ後に、別の注文が2個を仮押さえした（バージョンは変わらない）
+	fake.Hook = beforeProductTransaction(fake, product.ID, func(item map[string]types.AttributeValue) {
+		item["reservedStock"] = &types.AttributeValueMemberN{Value: "2"}
+	})
+
+	got, history, err := svc.UpdatePrice(context.Background(), product.ID, 1500, product.Version, "admin")
+	if err != nil {
+		t.Fatalf("UpdatePrice() error = %v", err)
+	}
+	if history == nil {
+		t.Fatal("history = nil, want the recorded history")
+	}
+	stored, err := productRepo.GetByIDConsistent(context.Background(), product.ID)
+	if err != nil {
+		t.Fatalf("GetByIDConsistent() error = %v", err)
+	}
+	if got.Price != 1500 || got.ReservedStock != 2 || got.Version != stored.Version || !got.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Errorf("UpdatePrice() product = %+v, want the stored product %+v", got, stored)
+	}
+}
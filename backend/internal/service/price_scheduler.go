@@ -0,0 +1,53 @@
+// backend/internal/service/price_scheduler.go
+// 予約価格を定期的に適用するバックグラウンドワーカー
+//
+// 【シャットダウンとの連携】
+//   Run は渡された ctx がキャンセルされるまでループする
+//   main.go ではシグナル受信時に ctx をキャンセルし、Run の終了を待ってからプロセスを終了する
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type PriceScheduler struct {
+	priceHistoryService *PriceHistoryService
+	interval            time.Duration
+}
+
+func NewPriceScheduler(priceHistoryService *PriceHistoryService, interval time.Duration) *PriceScheduler {
+	return &PriceScheduler{
+		priceHistoryService: priceHistoryService,
+		interval:            interval,
+	}
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに予約価格を適用する
+func (s *PriceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep は1回分の適用処理を行う
+// エラーはログに出すだけで、次回のスイープで再試行する（一部が失敗しても適用できた分は反映済み）
+func (s *PriceScheduler) sweep(ctx context.Context) {
+	applied, err := s.priceHistoryService.ApplyScheduledPrices(ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to apply scheduled prices: %v", err)
+	}
+	if applied > 0 {
+		log.Printf("Applied %d scheduled price change(s)", applied)
+	}
+}
@@ -0,0 +1,101 @@
+// backend/internal/service/product_cache.go
+// 商品の読み込み（ProductService.GetByID / List / ListSummaries）のキャッシュ
+//
+// 【無効化】
+//   商品を書き換えるサービス（商品の更新・削除、価格変更、在庫調整）は書き込み後に Invalidate を呼ぶ
+//   一覧は商品1件の変更でも内容が変わりうるため、変更のたびに全件削除する
+//
+// 【キャッシュを使わない読み込み】
+//   注文確定・カート追加の在庫チェック、および商品を書き換える前の読み込みはリポジトリを直接読む
+//   → 古い在庫数で判断して売り越したり、古い内容で上書きしたりしないように
+//
+// nil の *ProductCache はキャッシュ無効（常にミス、無効化は何もしない）として扱う
+
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/cache"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+)
+
+type ProductCache struct {
+	products  cache.Cache[*domain.Product]          // 商品ID → 商品（論理削除済みも含む）
+	lists     cache.Cache[[]*domain.Product]        // カテゴリ + includeDeleted → 一覧
+	summaries cache.Cache[[]*domain.ProductSummary] // カテゴリ → カード表示用の一覧
+}
+
+func NewProductCache(products cache.Cache[*domain.Product], lists cache.Cache[[]*domain.Product], summaries cache.Cache[[]*domain.ProductSummary]) *ProductCache {
+	return &ProductCache{
+		products:  products,
+		lists:     lists,
+		summaries: summaries,
+	}
+}
+
+// Invalidate は商品とすべての一覧のキャッシュを削除する
+func (c *ProductCache) Invalidate(ctx context.Context, productID string) {
+	if c == nil {
+		return
+	}
+	c.products.Delete(ctx, productID)
+	c.lists.Clear(ctx)
+	c.summaries.Clear(ctx)
+}
+
+// InvalidateLists は一覧のキャッシュだけを削除する（商品の追加時）
+func (c *ProductCache) InvalidateLists(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.lists.Clear(ctx)
+	c.summaries.Clear(ctx)
+}
+
+func (c *ProductCache) getProduct(ctx context.Context, id string) (*domain.Product, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.products.Get(ctx, id)
+}
+
+func (c *ProductCache) setProduct(ctx context.Context, product *domain.Product) {
+	if c == nil {
+		return
+	}
+	c.products.Set(ctx, product.ID, product)
+}
+
+func (c *ProductCache) getList(ctx context.Context, category string, includeDeleted bool) ([]*domain.Product, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.lists.Get(ctx, listCacheKey(category, includeDeleted))
+}
+
+func (c *ProductCache) setList(ctx context.Context, category string, includeDeleted bool, products []*domain.Product) {
+	if c == nil {
+		return
+	}
+	c.lists.Set(ctx, listCacheKey(category, includeDeleted), products)
+}
+
+func (c *ProductCache) getSummaries(ctx context.Context, category string) ([]*domain.ProductSummary, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.summaries.Get(ctx, category)
+}
+
+func (c *ProductCache) setSummaries(ctx context.Context, category string, summaries []*domain.ProductSummary) {
+	if c == nil {
+		return
+	}
+	c.summaries.Set(ctx, category, summaries)
+}
+
+func listCacheKey(category string, includeDeleted bool) string {
+	return strconv.FormatBool(includeDeleted) + "#" + category
+}
@@ -2,31 +2,346 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/cache"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
 )
 
+// SKU 自動採番用のカウンター名
+const skuCounterName = "SKU"
+
+// MaxBestsellers は売れ筋商品として返す件数の上限（キャッシュする件数）
+const MaxBestsellers = 50
+
+// MaxRelatedProducts は「一緒に購入されている商品」として返す件数の上限（商品ごとにキャッシュする件数）
+const MaxRelatedProducts = 50
+
+// 「一緒に購入されている商品」をキャッシュする商品数の上限
+const relatedCacheSize = 1000
+
 type ProductService struct {
-	repo *repository.ProductRepository
+	repo           *repository.ProductRepository
+	counterRepo    *repository.CounterRepository
+	salesStatsRepo *repository.SalesStatsRepository
+	alsoBoughtRepo *repository.AlsoBoughtRepository
+	cache          *ProductCache // 商品の読み込みのキャッシュ（nil の場合は無効）
+	skuPrefix      string        // 自動採番する SKU の接頭辞（例: PRD）
+
+	// 「一緒に購入されている商品」のキャッシュ（商品ID → 回数の多い順の上位 MaxRelatedProducts 件）
+	// 集計を全件読んで並べ替えるため、売れ筋商品と同じ TTL の間キャッシュする
+	related cache.Cache[[]*domain.RelatedProduct]
+
+	// 売れ筋商品のキャッシュ（トップページの表示ごとに DynamoDB を読まないように）
+	bestsellerTTL    time.Duration
+	bestsellerMu     sync.Mutex
+	bestsellers      []*domain.Bestseller
+	bestsellerExpiry time.Time
+
+	// ファセット（カテゴリ別の商品数）のキャッシュ（全商品を読んで集計するため）
+	facetTTL    time.Duration
+	facetMu     sync.Mutex
+	facets      *domain.ProductFacets
+	facetExpiry time.Time
 }
 
-func NewProductService(repo *repository.ProductRepository) *ProductService {
+func NewProductService(repo *repository.ProductRepository, counterRepo *repository.CounterRepository, salesStatsRepo *repository.SalesStatsRepository, alsoBoughtRepo *repository.AlsoBoughtRepository, productCache *ProductCache, skuPrefix string, bestsellerTTL, facetTTL time.Duration) *ProductService {
 	return &ProductService{
-		repo: repo,
+		repo:           repo,
+		counterRepo:    counterRepo,
+		salesStatsRepo: salesStatsRepo,
+		alsoBoughtRepo: alsoBoughtRepo,
+		related:        cache.NewMemory[[]*domain.RelatedProduct](bestsellerTTL, relatedCacheSize),
+		cache:          productCache,
+		skuPrefix:      skuPrefix,
+		bestsellerTTL:  bestsellerTTL,
+		facetTTL:       facetTTL,
+	}
+}
+
+// List は商品一覧を取得する（キャッシュ付き）
+// includeDeleted が false の場合は論理削除済みの商品を除外する
+func (s *ProductService) List(ctx context.Context, category string, includeDeleted bool) ([]*domain.Product, error) {
+	if products, ok := s.cache.getList(ctx, category, includeDeleted); ok {
+		return products, nil
+	}
+
+	products, err := s.repo.List(ctx, category, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.setList(ctx, category, includeDeleted, products)
+	return products, nil
+}
+
+// ListSummaries はカード表示用の商品一覧を取得する（キャッシュ付き、論理削除済みの商品は除外）
+func (s *ProductService) ListSummaries(ctx context.Context, category string) ([]*domain.ProductSummary, error) {
+	if summaries, ok := s.cache.getSummaries(ctx, category); ok {
+		return summaries, nil
+	}
+
+	summaries, err := s.repo.ListSummaries(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.setSummaries(ctx, category, summaries)
+	return summaries, nil
+}
+
+// ListByCreatedRange は作成日時の範囲で商品を取得する（レポート用）
+func (s *ProductService) ListByCreatedRange(ctx context.Context, start, end time.Time, limit int32, nextToken string) (*domain.ProductPage, error) {
+	products, next, err := s.repo.ListByCreatedRange(ctx, start, end, limit, nextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ProductPage{
+		Products:  products,
+		NextToken: next,
+	}, nil
+}
+
+// ListLowStock は在庫数が threshold 以下の商品を在庫の少ない順に取得する（在庫アラート用）
+func (s *ProductService) ListLowStock(ctx context.Context, threshold int, limit int32, nextToken string) (*domain.ProductPage, error) {
+	products, next, err := s.repo.ListLowStock(ctx, threshold, limit, nextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ProductPage{
+		Products:  products,
+		NextToken: next,
+	}, nil
+}
+
+// ListBestsellers は販売数の多い順に最大 limit 件（MaxBestsellers まで）の商品を取得する
+// 販売実績は DynamoDB Streams のワーカー（cmd/worker）が集計する
+// → ワーカーが動いていない・まだ注文がない場合は空のリストを返す
+// 結果は bestsellerTTL の間メモリにキャッシュする（上位 MaxBestsellers 件をまとめてキャッシュし、limit 件に切り詰めて返す）
+func (s *ProductService) ListBestsellers(ctx context.Context, limit int) ([]*domain.Bestseller, error) {
+	s.bestsellerMu.Lock()
+	defer s.bestsellerMu.Unlock()
+
+	if s.bestsellers == nil || time.Now().After(s.bestsellerExpiry) {
+		bestsellers, err := s.loadBestsellers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.bestsellers = bestsellers
+		s.bestsellerExpiry = time.Now().Add(s.bestsellerTTL)
+	}
+
+	return s.bestsellers[:min(limit, len(s.bestsellers))], nil
+}
+
+// ListRelated は商品と同じ注文で購入された回数の多い順に「一緒に購入されている商品」を返す
+// 回数は DynamoDB Streams のワーカー（cmd/worker）が集計する（ワーカーが動いていない場合は空のリスト）
+// 上位 MaxRelatedProducts 件を商品ごとにキャッシュし、nextToken（次の位置）で limit 件ずつ返す
+//
+// 商品が存在しない（論理削除済みを含む）場合は ErrProductNotFound、nextToken が不正な場合は ErrInvalidCursor を返す
+func (s *ProductService) ListRelated(ctx context.Context, productID string, limit int, nextToken string) (*domain.RelatedProductPage, error) {
+	offset := 0
+	if nextToken != "" {
+		n, err := strconv.Atoi(nextToken)
+		if err != nil || n < 0 {
+			return nil, ErrInvalidCursor
+		}
+		offset = n
+	}
+
+	if _, err := s.GetByID(ctx, productID, false); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, ErrProductNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+
+	related, ok := s.related.Get(ctx, productID)
+	if !ok {
+		var err error
+		if related, err = s.loadRelated(ctx, productID); err != nil {
+			return nil, err
+		}
+		s.related.Set(ctx, productID, related)
+	}
+
+	page := &domain.RelatedProductPage{Products: []*domain.RelatedProduct{}}
+	if offset >= len(related) {
+		return page, nil
+	}
+	end := min(offset+limit, len(related))
+	page.Products = related[offset:end]
+	if end < len(related) {
+		page.NextToken = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+// loadRelated は集計を全件読み、回数の多い順（同数は商品ID順）に上位 MaxRelatedProducts 件の商品を返す
+// 削除済み（物理・論理）の商品は除く
+func (s *ProductService) loadRelated(ctx context.Context, productID string) ([]*domain.RelatedProduct, error) {
+	counts, err := s.alsoBoughtRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].OtherProductID < counts[j].OtherProductID
+	})
+
+	// 削除済みの商品を除いても上限まで埋まるよう、多めに読んでおく
+	counts = counts[:min(len(counts), MaxRelatedProducts*2)]
+	ids := make([]string, len(counts))
+	for i, c := range counts {
+		ids[i] = c.OtherProductID
+	}
+	products, err := s.repo.BatchGetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]*domain.RelatedProduct, 0, min(len(counts), MaxRelatedProducts))
+	for _, c := range counts {
+		product, ok := products[c.OtherProductID]
+		if !ok || product.DeletedAt != nil {
+			continue
+		}
+		related = append(related, &domain.RelatedProduct{
+			Product:        *product,
+			BoughtTogether: c.Count,
+		})
+		if len(related) == MaxRelatedProducts {
+			break
+		}
+	}
+	return related, nil
+}
+
+// GetFacets は論理削除されていない商品のカテゴリ別の商品数を取得する
+// 全商品を読んで集計するため、結果を facetTTL の間メモリにキャッシュする
+// （商品の追加・変更は TTL が切れるまで反映されない）
+func (s *ProductService) GetFacets(ctx context.Context) (*domain.ProductFacets, error) {
+	s.facetMu.Lock()
+	defer s.facetMu.Unlock()
+
+	if s.facets == nil || time.Now().After(s.facetExpiry) {
+		counts, err := s.repo.CountByCategory(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.facets = &domain.ProductFacets{Category: counts}
+		s.facetExpiry = time.Now().Add(s.facetTTL)
+	}
+	return s.facets, nil
+}
+
+// loadBestsellers は販売実績の上位 MaxBestsellers 件を読み、商品の内容を付けて返す
+// 削除済み（物理・論理）の商品は除く
+func (s *ProductService) loadBestsellers(ctx context.Context) ([]*domain.Bestseller, error) {
+	stats, err := s.salesStatsRepo.ListTop(ctx, MaxBestsellers)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(stats))
+	for i, stat := range stats {
+		ids[i] = stat.ProductID
+	}
+	products, err := s.repo.BatchGetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	bestsellers := make([]*domain.Bestseller, 0, len(stats))
+	for _, stat := range stats {
+		product, ok := products[stat.ProductID]
+		if !ok || product.DeletedAt != nil {
+			continue
+		}
+		bestsellers = append(bestsellers, &domain.Bestseller{
+			Product:   *product,
+			UnitsSold: stat.UnitsSold,
+		})
 	}
+	return bestsellers, nil
 }
 
-func (s *ProductService) List(ctx context.Context, category string) ([]*domain.Product, error) {
-	return s.repo.List(ctx, category)
+// GetByID は商品を1件取得する（キャッシュ付き）
+// includeDeleted が false の場合、論理削除済みの商品は ErrProductNotFound として扱う
+// 呼び出し側が書き換えてもキャッシュに影響しないよう、コピーを返す
+func (s *ProductService) GetByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error) {
+	product, ok := s.cache.getProduct(ctx, id)
+	if !ok {
+		var err error
+		if product, err = s.repo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		s.cache.setProduct(ctx, product)
+	}
+	if !includeDeleted && product.DeletedAt != nil {
+		return nil, repository.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+// CheckAvailability は商品を quantity 個購入できるかどうかを返す
+// 在庫数だけを読むため、キャッシュは使わずに DynamoDB の最新の値で判定する
+//
+// quantity が正でない場合は ErrInvalidQuantity、商品が存在しない（論理削除済みを含む）場合は ErrProductNotFound を返す
+func (s *ProductService) CheckAvailability(ctx context.Context, id string, quantity int) (*domain.ProductAvailability, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	stock, reservedStock, err := s.repo.GetStock(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, ErrProductNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+
+	available := max(stock-reservedStock, 0)
+	return &domain.ProductAvailability{
+		Available: quantity <= available,
+		Stock:     available,
+	}, nil
 }
 
-func (s *ProductService) GetByID(ctx context.Context, id string) (*domain.Product, error) {
-	return s.repo.GetByID(ctx, id)
+// getActive は論理削除されていない商品をキャッシュを使わずに取得する（書き換える前の読み込み用）
+func (s *ProductService) getActive(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.DeletedAt != nil {
+		return nil, repository.ErrProductNotFound
+	}
+	return product, nil
 }
 
 func (s *ProductService) Create(ctx context.Context, req *domain.CreateProductRequest) (*domain.Product, error) {
+	// SKU が指定されていない場合はアトミックカウンターで採番する
+	sku := req.SKU
+	if sku == "" {
+		generated, err := s.generateSKU(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sku = generated
+	}
+
 	product := &domain.Product{
+		SKU:         sku,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
@@ -38,15 +353,162 @@ func (s *ProductService) Create(ctx context.Context, req *domain.CreateProductRe
 	if err := s.repo.Create(ctx, product); err != nil {
 		return nil, err
 	}
+	s.cache.InvalidateLists(ctx)
 
 	return product, nil
 }
 
+// ImportProducts は商品を一括登録する
+// 【処理フロー】
+//  1. 各リクエストを検証し、不正なものは結果にエラーを記録して除外する
+//  2. SKU 未指定の商品には NextN でまとめて連番を払い出す
+//  3. MaxBatchWriteItems（25件）ずつ BatchWriteItem で保存する
+//  4. 保存できた商品の分だけカテゴリの商品数を加算する
+//
+// 一部の商品が失敗しても処理は継続し、1件ごとの結果と件数のサマリーを返す
+func (s *ProductService) ImportProducts(ctx context.Context, reqs []*domain.CreateProductRequest) (*domain.ProductImportResponse, error) {
+	results := make([]domain.ProductImportResult, len(reqs))
+
+	// 1. 検証
+	products := make([]*domain.Product, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs)) // products[i] のリクエスト上の位置
+	needSKU := 0
+	for i, req := range reqs {
+		results[i].Index = i
+		if msg := validateProductImport(req); msg != "" {
+			results[i].Error = msg
+			continue
+		}
+		if req.SKU == "" {
+			needSKU++
+		}
+		products = append(products, &domain.Product{
+			SKU:         req.SKU,
+			Name:        req.Name,
+			Description: req.Description,
+			Price:       req.Price,
+			Category:    req.Category,
+			Stock:       req.Stock,
+			ImageURL:    req.ImageURL,
+		})
+		indexes = append(indexes, i)
+	}
+
+	// 2. SKU の一括採番（払い出した番号は last-needSKU+1 〜 last）
+	if needSKU > 0 {
+		last, err := s.counterRepo.NextN(ctx, skuCounterName, needSKU)
+		if err != nil {
+			return nil, err
+		}
+		seq := last - int64(needSKU) + 1
+		for _, product := range products {
+			if product.SKU == "" {
+				product.SKU = fmt.Sprintf("%s-%06d", s.skuPrefix, seq)
+				seq++
+			}
+		}
+	}
+
+	// 3. 25件ずつ保存
+	categoryCounts := make(map[string]int)
+	for start := 0; start < len(products); start += repository.MaxBatchWriteItems {
+		end := start + repository.MaxBatchWriteItems
+		if end > len(products) {
+			end = len(products)
+		}
+		chunk := products[start:end]
+
+		failedIDs, err := s.repo.BatchCreate(ctx, chunk)
+		failed := make(map[string]bool, len(failedIDs))
+		for _, id := range failedIDs {
+			failed[id] = true
+		}
+
+		for j, product := range chunk {
+			result := &results[indexes[start+j]]
+			if err != nil || failed[product.ID] {
+				result.Error = "Failed to save product"
+				continue
+			}
+			result.Success = true
+			result.ProductID = product.ID
+			result.SKU = product.SKU
+			if product.Category != "" {
+				categoryCounts[product.Category]++
+			}
+		}
+		if err != nil {
+			log.Printf("Failed to import product batch: %v", err)
+		}
+	}
+
+	s.cache.InvalidateLists(ctx)
+
+	// 4. カテゴリの商品数を加算
+	// 商品は保存済みのため、失敗しても結果は成功として返しログに残す
+	if err := s.repo.AddCategoryCounts(ctx, categoryCounts); err != nil {
+		log.Printf("Failed to update category counts after import: %v", err)
+	}
+
+	resp := &domain.ProductImportResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			resp.Created++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+// validateProductImport は一括登録する商品を検証し、不正な場合は理由を返す
+// 複数のフィールドが不正な場合は "name is required; price must be positive" のようにフィールド名順で連結する
+func validateProductImport(req *domain.CreateProductRequest) string {
+	if req == nil {
+		return "product is required"
+	}
+	fields := req.Validate()
+	if fields == nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + " " + fields[name]
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// generateSKU は <prefix>-<6桁の連番> 形式の SKU を生成する
+// 採番後に商品作成が失敗した場合、その番号は欠番になる（重複はしない）
+func (s *ProductService) generateSKU(ctx context.Context) (string, error) {
+	seq, err := s.counterRepo.Next(ctx, skuCounterName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%06d", s.skuPrefix, seq), nil
+}
+
+// Update は商品の内容を更新する
+// 【楽観的ロック】req.Version（クライアントが読み込んだ商品のバージョン）が現在のバージョンと違う場合は
+// repository.ErrProductVersionMismatch を返す。読み込んでから書き込むまでの間の変更も条件式で検出する
+// 論理削除済み・存在しない商品は repository.ErrProductNotFound を返す
 func (s *ProductService) Update(ctx context.Context, id string, req *domain.UpdateProductRequest) (*domain.Product, error) {
-	product, err := s.repo.GetByID(ctx, id)
+	product, err := s.getActive(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if product.Version != req.Version {
+		return nil, repository.ErrProductVersionMismatch
+	}
+
+	oldCategory := product.Category
 
 	// リクエストの値で更新
 	product.Name = req.Name
@@ -55,13 +517,57 @@ func (s *ProductService) Update(ctx context.Context, id string, req *domain.Upda
 	product.Category = req.Category
 	product.ImageURL = req.ImageURL
 
-	if err := s.repo.Update(ctx, product); err != nil {
+	err = s.repo.Update(ctx, product, oldCategory)
+	s.cache.Invalidate(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
+// Delete は商品を論理削除する
+// カテゴリの商品数を減算するため、先に商品を読み込んでカテゴリを特定する
+// 論理削除済みの商品は ErrProductNotFound を返す
 func (s *ProductService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	product, err := s.getActive(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.SoftDelete(ctx, id, product.Category)
+	s.cache.Invalidate(ctx, id)
+	return err
+}
+
+// Restore は論理削除した商品を元に戻す
+// 削除されていない商品は ErrProductNotDeleted を返す
+func (s *ProductService) Restore(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.DeletedAt == nil {
+		return nil, repository.ErrProductNotDeleted
+	}
+
+	err = s.repo.Restore(ctx, id, product.Category)
+	s.cache.Invalidate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.DeletedAt = nil
+	return product, nil
+}
+
+// HardDelete は商品を物理削除する（管理者用）
+// 論理削除済みかどうかに関わらず削除する
+func (s *ProductService) HardDelete(ctx context.Context, id string) error {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.HardDelete(ctx, id, product.Category, product.DeletedAt != nil)
+	s.cache.Invalidate(ctx, id)
+	return err
 }
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/cache"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// newTestProductService はフェイクの DynamoDB を使う ProductService を返す（キャッシュなし）
+func newTestProductService() *ProductService {
+	db, _ := newTestDB()
+	return NewProductService(repository.NewProductRepository(db), repository.NewCounterRepository(db),
+		repository.NewSalesStatsRepository(db), repository.NewAlsoBoughtRepository(db), nil, "PRD", time.Minute, time.Minute)
+}
+
+// newCachedTestProductService はキャッシュ付きの ProductService と、直接書き換えに使うフェイクを返す
+func newCachedTestProductService() (*ProductService, *dynamotest.Fake) {
+	db, fake := newTestDB()
+	productCache := NewProductCache(cache.NewMemory[*domain.Product](time.Hour, 100),
+		cache.NewMemory[[]*domain.Product](time.Hour, 100), cache.NewMemory[[]*domain.ProductSummary](time.Hour, 100))
+	svc := NewProductService(repository.NewProductRepository(db), repository.NewCounterRepository(db),
+		repository.NewSalesStatsRepository(db), repository.NewAlsoBoughtRepository(db), productCache, "PRD", time.Minute, time.Minute)
+	return svc, fake
+}
+
+func TestProductServiceUpdateInvalidatesCache(t *testing.T) {
+	ctx := context.Background()
+	svc, fake := newCachedTestProductService()
+
+	created, err := svc.Create(ctx, &domain.CreateProductRequest{Name: "before", Price: 100, Category: "test"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// 詳細と一覧をキャッシュに載せる
+	if _, err := svc.GetByID(ctx, created.ID, false); err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if _, err := svc.List(ctx, "", false); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	reads := fake.CallCount("GetItem")
+	if _, err := svc.GetByID(ctx, created.ID, false); err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got := fake.CallCount("GetItem"); got != reads {
+		t.Fatalf("second GetByID read DynamoDB (%d reads), want it served from the cache", got-reads)
+	}
+
+	if _, err := svc.Update(ctx, created.ID, &domain.UpdateProductRequest{Name: "after", Price: 200, Category: "test", Version: created.Version}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err := svc.GetByID(ctx, created.ID, false)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "after" || got.Price != 200 {
+		t.Errorf("GetByID() after Update = %s/%d, want after/200 (stale cache entry)", got.Name, got.Price)
+	}
+	list, err := svc.List(ctx, "", false)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "after" {
+		t.Errorf("List() after Update = %+v, want the updated product", list)
+	}
+}
+
+func TestProductServiceCreateGeneratesSKU(t *testing.T) {
+	t.Run("SKU 未指定の商品には連番を払い出し、指定された SKU は採番しない", func(t *testing.T) {
+		svc := newTestProductService()
+		var got []string
+		for _, sku := range []string{"", "", "CUSTOM-1", ""} {
+			product, err := svc.Create(context.Background(), &domain.CreateProductRequest{Name: "p", Price: 100, Category: "test", SKU: sku})
+			if err != nil {
+				t.Fatalf("Create(sku=%q) error = %v", sku, err)
+			}
+			got = append(got, product.SKU)
+		}
+
+		// 指定された SKU はカウンターを進めないため、次の採番は 000003 になる
+		want := []string{"PRD-000001", "PRD-000002", "CUSTOM-1", "PRD-000003"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("SKUs = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("同時に作成しても SKU が重複しない", func(t *testing.T) {
+		const n = 20
+		svc := newTestProductService()
+		skus := make([]string, n)
+		errs := make([]error, n)
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Go(func() {
+				product, err := svc.Create(context.Background(), &domain.CreateProductRequest{Name: "p", Price: 100, Category: "test"})
+				if err == nil {
+					skus[i] = product.SKU
+				}
+				errs[i] = err
+			})
+		}
+		wg.Wait()
+
+		seen := map[string]bool{}
+		for i, sku := range skus {
+			if errs[i] != nil {
+				t.Fatalf("Create() error = %v", errs[i])
+			}
+			seen[sku] = true
+		}
+		// 1〜n が欠けも重複もなく払い出されている
+		for i := 1; i <= n; i++ {
+			if sku := fmt.Sprintf("PRD-%06d", i); !seen[sku] {
+				t.Errorf("SKU %s was not generated (got %v)", sku, skus)
+			}
+		}
+	})
+}
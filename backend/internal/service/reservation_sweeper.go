@@ -0,0 +1,52 @@
+// backend/internal/service/reservation_sweeper.go
+// 注文確定に至らなかったチェックアウトの在庫の仮押さえを定期的に解放するバックグラウンドワーカー
+//
+// 【シャットダウンとの連携】
+//   Run は渡された ctx がキャンセルされるまでループする（HoldSweeper と同じ）
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type ReservationSweeper struct {
+	orderService *OrderService
+	interval     time.Duration
+}
+
+func NewReservationSweeper(orderService *OrderService, interval time.Duration) *ReservationSweeper {
+	return &ReservationSweeper{
+		orderService: orderService,
+		interval:     interval,
+	}
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに期限切れの仮押さえを解放する
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep は1回分の解放処理を行う
+// エラーはログに出すだけで、次回のスイープで再試行する
+func (s *ReservationSweeper) sweep(ctx context.Context) {
+	released, err := s.orderService.ReleaseExpiredReservations(ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to release expired stock reservations: %v", err)
+	}
+	if released > 0 {
+		log.Printf("Released %d expired stock reservation(s)", released)
+	}
+}
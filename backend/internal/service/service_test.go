@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// newTestDB はメモリ上のフェイクを使う DynamoDBClient を返す（テスト用）
+func newTestDB() (*repository.DynamoDBClient, *dynamotest.Fake) {
+	fake := dynamotest.New()
+	return repository.NewDynamoDBClientWithAPI(fake, "test-table"), fake
+}
+
+// createTestProduct は商品を作成する
+func createTestProduct(t *testing.T, productRepo *repository.ProductRepository, name string, price, stock int) *domain.Product {
+	t.Helper()
+	product := &domain.Product{Name: name, Price: price, Stock: stock, Category: "test"}
+	if err := productRepo.Create(context.Background(), product); err != nil {
+		t.Fatalf("Create(%s) error = %v", name, err)
+	}
+	return product
+}
@@ -2,7 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
 	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
@@ -11,14 +18,41 @@ import (
 
 var ErrInvalidCredentials = errors.New("invalid credentials")
 var ErrEmailAlreadyExists = errors.New("email already exists")
+var ErrTooManyResetRequests = errors.New("too many password reset requests")
+
+// パスワード再設定トークンの有効期間
+const passwordResetTTL = time.Hour
+
+// パスワード再設定の申請回数の制限（メールアドレスごと）
+// 再設定メールを大量に送りつけられないようにする
+const (
+	maxPasswordResetRequests   = 3
+	passwordResetRequestWindow = time.Hour
+)
+
+// TokenRevoker はユーザーの発行済みトークンをまとめて失効させる
+// 実装は middleware.JWTAuth
+type TokenRevoker interface {
+	RevokeUser(ctx context.Context, userID string) error
+}
 
 type UserService struct {
-	repo *repository.UserRepository
+	repo              *repository.UserRepository
+	passwordResetRepo *repository.PasswordResetRepository
+	rateLimitRepo     *repository.RateLimitRepository
+	tokenRevoker      TokenRevoker
+	emailSender       EmailSender
+	bcryptCost        int // パスワードハッシュの計算コスト
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo *repository.UserRepository, passwordResetRepo *repository.PasswordResetRepository, rateLimitRepo *repository.RateLimitRepository, tokenRevoker TokenRevoker, emailSender EmailSender, bcryptCost int) *UserService {
 	return &UserService{
-		repo: repo,
+		repo:              repo,
+		passwordResetRepo: passwordResetRepo,
+		rateLimitRepo:     rateLimitRepo,
+		tokenRevoker:      tokenRevoker,
+		emailSender:       emailSender,
+		bcryptCost:        bcryptCost,
 	}
 }
 
@@ -39,7 +73,7 @@ func (s *UserService) Register(ctx context.Context, req *domain.RegisterRequest)
 	}
 
 	// パスワードをハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +85,9 @@ func (s *UserService) Register(ctx context.Context, req *domain.RegisterRequest)
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrEmailAlreadyExists) {
+			return nil, ErrEmailAlreadyExists
+		}
 		return nil, err
 	}
 
@@ -77,3 +114,148 @@ func (s *UserService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 func (s *UserService) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+// UpdateProfile はユーザーの名前・メールアドレスを更新する
+// メールアドレスを変更する場合は登録時と同様に重複チェックを行う
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, req *domain.UpdateProfileRequest) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	oldEmail := user.Email
+
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	if req.Email != "" && req.Email != oldEmail {
+		// マーカーを持たない既存ユーザーとの重複も検出するため、GSI1でも確認する
+		// （最終的な一意性はリポジトリのトランザクションで保証される）
+		_, err := s.repo.GetByEmail(ctx, req.Email)
+		if err == nil {
+			return nil, ErrEmailAlreadyExists
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+		user.Email = req.Email
+	}
+
+	if err := s.repo.Update(ctx, user, oldEmail); err != nil {
+		if errors.Is(err, repository.ErrEmailAlreadyExists) {
+			return nil, ErrEmailAlreadyExists
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteAccount はパスワードを確認してからアカウントを削除する（退会）
+// パスワードが一致しない場合は ErrInvalidCredentials を返す
+// 注文履歴は経理上の記録として残す（削除対象は repository.UserRepository.Delete を参照）
+// 削除後にユーザーの発行済みトークンをすべて失効させる
+// （残したままだと、退会したアカウントのトークンでリフレッシュやカートへの追加ができ、削除したアイテムが作り直される）
+func (s *UserService) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.repo.Delete(ctx, user); err != nil {
+		return err
+	}
+	return s.tokenRevoker.RevokeUser(ctx, userID)
+}
+
+// RequestPasswordReset はメールアドレスのユーザーにパスワード再設定トークンを発行する
+// 【トークン】32バイトの乱数（URL-safe Base64）。DynamoDB には SHA-256 だけを保存する
+// 【ユーザー列挙の防止】登録されていないメールアドレスでもエラーにしない（呼び出し側は常に同じ応答を返す）
+// 【回数制限】同じメールアドレスへの申請は passwordResetRequestWindow あたり maxPasswordResetRequests 回まで
+//
+//	超えた場合は ErrTooManyResetRequests を返す。登録の有無を確認する前に数えるため、制限からもアカウントの有無はわからない
+//
+// トークンはメールで本人に送る。送信の失敗もエラーにしない（応答の違いからアカウントの有無を推測させない）
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	hits, err := s.rateLimitRepo.Hit(ctx, "FORGOTPW#"+hashRateLimitKey(email), passwordResetRequestWindow, time.Now())
+	if err != nil {
+		return err
+	}
+	if hits > maxPasswordResetRequests {
+		return ErrTooManyResetRequests
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := s.passwordResetRepo.Create(ctx, hashResetToken(token), user.ID, time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	body := "パスワード再設定のリクエストを受け付けました。\n" +
+		"以下の再設定トークンを使って、" + passwordResetTTL.String() + "以内に新しいパスワードを設定してください。\n\n" +
+		token + "\n\n" +
+		"このリクエストに心当たりがない場合は、このメールを破棄してください。\n"
+	if err := s.emailSender.Send(ctx, user.Email, "パスワード再設定のご案内", body); err != nil {
+		log.Printf("Failed to send password reset email: user=%s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword は再設定トークンを消費してパスワードを変更する
+// トークンは成否に関わらず1回しか使えない（パスワードの更新に失敗した場合は再発行が必要）
+// トークンが無効・期限切れの場合は repository.ErrResetTokenInvalid を返す
+//
+// 【発行済みトークンの失効】パスワードの変更後、そのユーザーの JWT（アクセス・リフレッシュ）をすべて失効させる
+//
+//	古いパスワードで取得されたトークン（漏えいしたものを含む）を使い続けられないようにする
+//	失効に失敗した場合はエラーを返す（パスワードは変更済みのため、新しいパスワードでログインできる）
+func (s *UserService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userID, err := s.passwordResetRepo.Consume(ctx, hashResetToken(token), time.Now())
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.bcryptCost)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		// トークン発行後に退会した
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return repository.ErrResetTokenInvalid
+		}
+		return err
+	}
+
+	return s.tokenRevoker.RevokeUser(ctx, userID)
+}
+
+// hashRateLimitKey は回数制限のキーに使うメールアドレスのハッシュ（SHA-256 の16進表記）を返す
+// 大文字・小文字や前後の空白の違いで制限を回避できないよう正規化してからハッシュ化する（メールアドレスそのものは保存しない）
+func hashRateLimitKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashResetToken は再設定トークンの保存用ハッシュ（SHA-256 の16進表記）を返す
+// トークンは十分な長さの乱数のため、bcrypt のような低速なハッシュは不要
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/domain"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/middleware"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// recordingRevoker は失効させたユーザーIDを記録する TokenRevoker（テスト用）
+type recordingRevoker struct {
+	revoked []string
+}
+
+func (r *recordingRevoker) RevokeUser(ctx context.Context, userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+// newTestUserService は bcryptCost でパスワードをハッシュ化する UserService を返す
+func newTestUserService(bcryptCost int) *UserService {
+	svc, _, _ := newRecordingUserService(bcryptCost)
+	return svc
+}
+
+// newRecordingUserService は送信したメールと失効させたユーザーを記録する UserService を返す
+func newRecordingUserService(bcryptCost int) (*UserService, *recordingSender, *recordingRevoker) {
+	db, _ := newTestDB()
+	sender := &recordingSender{}
+	revoker := &recordingRevoker{}
+	svc := NewUserService(repository.NewUserRepository(db), repository.NewPasswordResetRepository(db), repository.NewRateLimitRepository(db), revoker, sender, bcryptCost)
+	return svc, sender, revoker
+}
+
+func TestUserServiceBcryptCost(t *testing.T) {
+	ctx := context.Background()
+	// テストなどで下げた最小のコストでも、ハッシュ化と検証ができる
+	svc := newTestUserService(bcrypt.MinCost)
+
+	user, err := svc.Register(ctx, &domain.RegisterRequest{Email: "u1@example.com", Password: "password123", Name: "u1"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	cost, err := bcrypt.Cost([]byte(user.PasswordHash))
+	if err != nil {
+		t.Fatalf("bcrypt.Cost() error = %v", err)
+	}
+	if cost != bcrypt.MinCost {
+		t.Errorf("hash cost = %d, want %d", cost, bcrypt.MinCost)
+	}
+
+	if _, err := svc.Login(ctx, &domain.LoginRequest{Email: "u1@example.com", Password: "password123"}); err != nil {
+		t.Errorf("Login() error = %v", err)
+	}
+	if _, err := svc.Login(ctx, &domain.LoginRequest{Email: "u1@example.com", Password: "wrong-password"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login(wrong password) error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+// resetTokenFrom は再設定メールの本文からトークンを取り出す（本文の空行に挟まれた行）
+func resetTokenFrom(t *testing.T, body string) string {
+	t.Helper()
+	parts := strings.Split(body, "\n\n")
+	if len(parts) < 3 {
+		t.Fatalf("reset token not found in body: %q", body)
+	}
+	return parts[1]
+}
+
+func TestResetPasswordRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, sender, revoker := newRecordingUserService(bcrypt.MinCost)
+
+	user, err := svc.Register(ctx, &domain.RegisterRequest{Email: "u1@example.com", Password: "password123", Name: "u1"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := svc.RequestPasswordReset(ctx, "u1@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sender.sent))
+	}
+	token := resetTokenFrom(t, sender.sent[0].body)
+
+	if err := svc.ResetPassword(ctx, token, "new-password123"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if len(revoker.revoked) != 1 || revoker.revoked[0] != user.ID {
+		t.Errorf("revoked users = %v, want [%s]", revoker.revoked, user.ID)
+	}
+	if _, err := svc.Login(ctx, &domain.LoginRequest{Email: "u1@example.com", Password: "new-password123"}); err != nil {
+		t.Errorf("Login(new password) error = %v", err)
+	}
+
+	// 使用済みのトークンでは変更できず、失効もさせない
+	if err := svc.ResetPassword(ctx, token, "another-password123"); !errors.Is(err, repository.ErrResetTokenInvalid) {
+		t.Errorf("ResetPassword(used token) error = %v, want ErrResetTokenInvalid", err)
+	}
+	if len(revoker.revoked) != 1 {
+		t.Errorf("revoked users = %v, want 1 entry", revoker.revoked)
+	}
+}
+
+func TestDeleteAccountRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB()
+	jwtAuth := middleware.NewJWTAuth("test-secret", time.Minute, time.Hour, repository.NewTokenRevocationRepository(db), true)
+	svc := NewUserService(repository.NewUserRepository(db), repository.NewPasswordResetRepository(db), repository.NewRateLimitRepository(db),
+		jwtAuth, &recordingSender{}, bcrypt.MinCost)
+
+	user, err := svc.Register(ctx, &domain.RegisterRequest{Email: "u1@example.com", Password: "password123", Name: "u1"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	access, err := jwtAuth.GenerateToken(user.ID, user.Email)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	refresh, err := jwtAuth.GenerateRefreshToken(user.ID, user.Email)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	// パスワードが違う場合は削除せず、トークンも失効させない
+	if err := svc.DeleteAccount(ctx, user.ID, "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("DeleteAccount(wrong password) error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := jwtAuth.ValidateToken(ctx, access); err != nil {
+		t.Fatalf("ValidateToken() after a failed deletion error = %v", err)
+	}
+
+	if err := svc.DeleteAccount(ctx, user.ID, "password123"); err != nil {
+		t.Fatalf("DeleteAccount() error = %v", err)
+	}
+	// 退会前に発行したトークンは、アクセスにもリフレッシュにも使えない
+	if _, err := jwtAuth.ValidateToken(ctx, access); !errors.Is(err, middleware.ErrTokenRevoked) {
+		t.Errorf("ValidateToken(access) error = %v, want ErrTokenRevoked", err)
+	}
+	if _, _, err := jwtAuth.RefreshToken(ctx, refresh); !errors.Is(err, middleware.ErrTokenRevoked) {
+		t.Errorf("RefreshToken() error = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestRequestPasswordResetRateLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, sender, _ := newRecordingUserService(bcrypt.MinCost)
+	if _, err := svc.Register(ctx, &domain.RegisterRequest{Email: "u1@example.com", Password: "password123", Name: "u1"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	for i := 0; i < maxPasswordResetRequests; i++ {
+		if err := svc.RequestPasswordReset(ctx, "u1@example.com"); err != nil {
+			t.Fatalf("RequestPasswordReset() #%d error = %v", i+1, err)
+		}
+	}
+	// 大文字・小文字を変えても同じメールアドレスとして数える
+	if err := svc.RequestPasswordReset(ctx, "U1@Example.com"); !errors.Is(err, ErrTooManyResetRequests) {
+		t.Errorf("RequestPasswordReset() over the limit error = %v, want ErrTooManyResetRequests", err)
+	}
+	if len(sender.sent) != maxPasswordResetRequests {
+		t.Errorf("sent %d emails, want %d", len(sender.sent), maxPasswordResetRequests)
+	}
+
+	// 登録されていないメールアドレスも同じように制限する（制限の有無からアカウントの有無を推測させない）
+	for i := 0; i < maxPasswordResetRequests; i++ {
+		if err := svc.RequestPasswordReset(ctx, "nobody@example.com"); err != nil {
+			t.Fatalf("RequestPasswordReset(unregistered) #%d error = %v", i+1, err)
+		}
+	}
+	if err := svc.RequestPasswordReset(ctx, "nobody@example.com"); !errors.Is(err, ErrTooManyResetRequests) {
+		t.Errorf("RequestPasswordReset(unregistered) over the limit error = %v, want ErrTooManyResetRequests", err)
+	}
+}
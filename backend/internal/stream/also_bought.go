@@ -0,0 +1,55 @@
+// backend/internal/stream/also_bought.go
+// 注文明細の INSERT イベントから「一緒に購入されている商品」の回数を集計するハンドラー
+//
+// 【なぜ Streams で集計するか】
+//   組み合わせの数は注文の商品数の2乗で増えるため、注文確定のトランザクションには入りきらない
+//   （販売実績と同じく、チェックアウトには手を入れずに非同期で集計する）
+//
+// 【同じ注文の他の商品】
+//   注文明細はすべて1つのトランザクションで書き込まれるため、イベントが届いた時点で同じ注文の明細は揃っている
+//   → 注文の明細を読み直して、イベントの商品以外を集計の対象にする
+
+package stream
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+type AlsoBoughtAggregator struct {
+	orderRepo      *repository.OrderRepository
+	alsoBoughtRepo *repository.AlsoBoughtRepository
+}
+
+func NewAlsoBoughtAggregator(orderRepo *repository.OrderRepository, alsoBoughtRepo *repository.AlsoBoughtRepository) *AlsoBoughtAggregator {
+	return &AlsoBoughtAggregator{
+		orderRepo:      orderRepo,
+		alsoBoughtRepo: alsoBoughtRepo,
+	}
+}
+
+// Handle は注文明細の INSERT イベントの商品について、同じ注文の他の商品の回数を加算する
+// それ以外のイベントは無視する。同じシーケンス番号のイベントが再送された場合は二重に加算しない
+func (a *AlsoBoughtAggregator) Handle(ctx context.Context, event *Event) error {
+	if event.Name != EventInsert || !strings.HasPrefix(event.PK, "ORDER#") || !strings.HasPrefix(event.SK, "ITEM#") {
+		return nil
+	}
+	orderID := strings.TrimPrefix(event.PK, "ORDER#")
+	productID := strings.TrimPrefix(event.SK, "ITEM#")
+
+	items, err := a.orderRepo.GetItemsByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	otherIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.ProductID != productID {
+			otherIDs = append(otherIDs, item.ProductID)
+		}
+	}
+
+	_, err = a.alsoBoughtRepo.AddOrder(ctx, event.SequenceNumber, productID, otherIDs)
+	return err
+}
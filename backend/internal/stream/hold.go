@@ -0,0 +1,63 @@
+// backend/internal/stream/hold.go
+// TTL で削除された在庫の取り置きの数量を、商品の reservedStock に戻すハンドラー
+//
+// 【なぜ Streams で戻すか】
+//   期限切れの取り置きは通常スイーパー（HoldService.ReleaseExpired）が解放するが、
+//   スイーパーが止まっている間に TTL で削除されると reservedStock だけが残り、その分の在庫が売れなくなる
+//   TTL の削除はトランザクションにできないため、削除のイベントを受けて後から戻す
+//   （スイーパー・注文確定による削除は TTL の削除ではないため、ここでは戻さない）
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// holdImage は取り置き（PK: USER#<userId>, SK: HOLD#<productId>）の解放に使う属性
+type holdImage struct {
+	ProductID string `dynamodbav:"productId"`
+	Quantity  int    `dynamodbav:"quantity"`
+}
+
+type HoldReleaser struct {
+	holdRepo *repository.HoldRepository
+}
+
+func NewHoldReleaser(holdRepo *repository.HoldRepository) *HoldReleaser {
+	return &HoldReleaser{holdRepo: holdRepo}
+}
+
+// Handle は TTL で削除された取り置きの数量を商品の reservedStock から戻す
+// それ以外のイベントは無視する
+// 同じシーケンス番号のイベントが再送された場合は二重に戻さない
+// reservedStock が足りない（商品が削除された等）場合は、読み直しても直らないためログに出して読み飛ばす
+func (h *HoldReleaser) Handle(ctx context.Context, event *Event) error {
+	if event.Name != EventRemove || !event.TTLExpired || !strings.HasPrefix(event.PK, "USER#") || !strings.HasPrefix(event.SK, "HOLD#") {
+		return nil
+	}
+
+	var hold holdImage
+	if err := attributevalue.UnmarshalMap(event.OldImage, &hold); err != nil {
+		return err
+	}
+	if hold.ProductID == "" {
+		hold.ProductID = strings.TrimPrefix(event.SK, "HOLD#")
+	}
+	if hold.Quantity <= 0 {
+		return nil
+	}
+
+	_, err := h.holdRepo.ReleaseByTTL(ctx, event.SequenceNumber, hold.ProductID, hold.Quantity)
+	if errors.Is(err, repository.ErrReservedStockUnderflow) {
+		log.Printf("Skipped releasing expired hold %s/%s: %v", event.PK, event.SK, err)
+		return nil
+	}
+	return err
+}
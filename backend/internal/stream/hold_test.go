@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/dynamotest"
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// holdRemoveRecord は取り置き（数量 qty）の REMOVE レコードを返す
+// identity が nil でない場合は、そのユーザーによる削除とする（TTL の場合は Service）
+func holdRemoveRecord(seq, qty string, identity *types.Identity) types.Record {
+	return types.Record{
+		EventName: types.OperationTypeRemove,
+		Dynamodb: &types.StreamRecord{
+			SequenceNumber: aws.String(seq),
+			Keys: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "USER#u1"},
+				"SK": &types.AttributeValueMemberS{Value: "HOLD#p1"},
+			},
+			OldImage: map[string]types.AttributeValue{
+				"PK":        &types.AttributeValueMemberS{Value: "USER#u1"},
+				"SK":        &types.AttributeValueMemberS{Value: "HOLD#p1"},
+				"productId": &types.AttributeValueMemberS{Value: "p1"},
+				"quantity":  &types.AttributeValueMemberN{Value: qty},
+			},
+		},
+		UserIdentity: identity,
+	}
+}
+
+var ttlIdentity = &types.Identity{Type: aws.String("Service"), PrincipalId: aws.String("dynamodb.amazonaws.com")}
+
+func TestHoldReleaserReleasesTTLExpiredHolds(t *testing.T) {
+	fake := dynamotest.New()
+	fake.Seed(dynamotest.Item(map[string]any{"PK": "PRODUCT#p1", "SK": "METADATA", "stock": 10, "reservedStock": 5}))
+	releaser := NewHoldReleaser(repository.NewHoldRepository(repository.NewDynamoDBClientWithAPI(fake, "test-table")))
+	ctx := context.Background()
+
+	reservedStock := func() string {
+		n, _ := fake.Get("PRODUCT#p1", "METADATA")["reservedStock"].(*ddbtypes.AttributeValueMemberN)
+		return n.Value
+	}
+
+	tests := []struct {
+		name string
+		rec  types.Record
+		want string
+	}{
+		{name: "TTL で削除された取り置きの数量を戻す", rec: holdRemoveRecord("1", "2", ttlIdentity), want: "3"},
+		{name: "再送されたイベントは二重に戻さない", rec: holdRemoveRecord("1", "2", ttlIdentity), want: "3"},
+		{name: "スイーパー・注文確定による削除は戻さない", rec: holdRemoveRecord("2", "1", nil), want: "3"},
+		{name: "reservedStock より多い数量は読み飛ばす", rec: holdRemoveRecord("3", "9", ttlIdentity), want: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseRecord(tt.rec)
+			if err != nil {
+				t.Fatalf("ParseRecord() error = %v", err)
+			}
+			if err := releaser.Handle(ctx, event); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if got := reservedStock(); got != tt.want {
+				t.Errorf("reservedStock = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
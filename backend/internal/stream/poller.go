@@ -0,0 +1,238 @@
+// backend/internal/stream/poller.go
+// DynamoDB Streams のシャードをポーリングしてレコードを Handler に渡すワーカー
+// AWS 実環境・DynamoDB Local のどちらでも同じ API（DescribeStream / GetShardIterator / GetRecords）で動く
+//
+// 【シャードの扱い】
+//   - ストリームは複数のシャードに分かれ、シャードは数時間ごとに閉じて子シャードに引き継がれる
+//   - 同じアイテムの変更順を守るため、親シャードを読み終えてから子シャードを読み始める
+//   - GetRecords の NextShardIterator が返らなくなったシャードは閉じている（読み終わり）
+//
+// 【チェックポイント】
+//   読み終えた位置（シーケンス番号）はメモリにのみ保持する
+//   再起動時は保持期間（24時間）の先頭（TRIM_HORIZON）から読み直すため、Handler は冪等にすること
+//
+// 【シャットダウンとの連携】
+//   Run は渡された ctx がキャンセルされるまでループする（PriceScheduler と同じ）
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// GetRecords 1回で読むレコード数の上限（API の上限は 1000）
+const getRecordsLimit = 1000
+
+// シャードの一覧（DescribeStream）を取り直す間隔
+const shardRefreshInterval = time.Minute
+
+// StreamsAPI は Poller が使用する DynamoDB Streams の操作を定義するインターフェース
+// *dynamodbstreams.Client はこのインターフェースを満たす
+type StreamsAPI interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+var _ StreamsAPI = (*dynamodbstreams.Client)(nil)
+
+// Handler は解析したイベントを処理する
+// エラーを返した場合、そのシャードは次のポーリングで同じイベントから読み直す
+type Handler interface {
+	Handle(ctx context.Context, event *Event) error
+}
+
+// Handlers は複数の Handler に順にイベントを渡す（途中で失敗した場合はそのエラーを返す）
+// 失敗したイベントは読み直されてすべての Handler に再び渡されるため、各 Handler は冪等であること
+type Handlers []Handler
+
+func (hs Handlers) Handle(ctx context.Context, event *Event) error {
+	for _, h := range hs {
+		if err := h.Handle(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardState はシャードの読み取り位置
+type shardState struct {
+	iterator     *string // nil の場合は次のポーリングで lastSequence の次から取り直す
+	lastSequence string  // 処理を終えた最後のシーケンス番号（空の場合は先頭から）
+}
+
+type Poller struct {
+	client    StreamsAPI
+	streamARN string
+	handler   Handler
+	interval  time.Duration
+
+	shards      map[string]*shardState // 読み取り中のシャード
+	finished    map[string]bool        // 読み終えた（閉じた）シャード
+	lastRefresh time.Time
+}
+
+func NewPoller(client StreamsAPI, streamARN string, handler Handler, interval time.Duration) *Poller {
+	return &Poller{
+		client:    client,
+		streamARN: streamARN,
+		handler:   handler,
+		interval:  interval,
+		shards:    make(map[string]*shardState),
+		finished:  make(map[string]bool),
+	}
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに全シャードのレコードを読む
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll は1回分のポーリングを行う
+// エラーはログに出すだけで、次回のポーリングで再試行する
+func (p *Poller) poll(ctx context.Context) {
+	if time.Since(p.lastRefresh) >= shardRefreshInterval || len(p.shards) == 0 {
+		if err := p.refreshShards(ctx); err != nil {
+			log.Printf("Failed to describe stream: %v", err)
+		}
+	}
+
+	for shardID, state := range p.shards {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.pollShard(ctx, shardID, state); err != nil {
+			log.Printf("Failed to read stream shard %s: %v", shardID, err)
+		}
+	}
+}
+
+// refreshShards はストリームのシャード一覧を取得し、読み始められるシャードを追加する
+// 親シャードがまだ一覧にあり読み終えていない場合は、子シャードの読み取りを保留する
+func (p *Poller) refreshShards(ctx context.Context) error {
+	var shards []types.Shard
+	var startShardID *string
+	for {
+		out, err := p.client.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             aws.String(p.streamARN),
+			ExclusiveStartShardId: startShardID,
+		})
+		if err != nil {
+			return err
+		}
+		shards = append(shards, out.StreamDescription.Shards...)
+		startShardID = out.StreamDescription.LastEvaluatedShardId
+		if startShardID == nil {
+			break
+		}
+	}
+	p.lastRefresh = time.Now()
+
+	listed := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		listed[aws.ToString(shard.ShardId)] = true
+	}
+	for _, shard := range shards {
+		shardID := aws.ToString(shard.ShardId)
+		if _, ok := p.shards[shardID]; ok || p.finished[shardID] {
+			continue
+		}
+		if parent := aws.ToString(shard.ParentShardId); parent != "" && listed[parent] && !p.finished[parent] {
+			continue
+		}
+		p.shards[shardID] = &shardState{}
+	}
+	return nil
+}
+
+// pollShard はシャードからレコードを読み、1件ずつ Handler に渡す
+// Handler が失敗した場合は読み取り位置をそのイベントの直前に戻して中断する
+func (p *Poller) pollShard(ctx context.Context, shardID string, state *shardState) error {
+	if state.iterator == nil {
+		iterator, err := p.shardIterator(ctx, shardID, state.lastSequence)
+		if err != nil {
+			return err
+		}
+		state.iterator = iterator
+	}
+
+	out, err := p.client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+		ShardIterator: state.iterator,
+		Limit:         aws.Int32(getRecordsLimit),
+	})
+	if err != nil {
+		// イテレーターの期限切れ（15分）・保持期間切れ → 次回に取り直す
+		var expired *types.ExpiredIteratorException
+		var trimmed *types.TrimmedDataAccessException
+		if errors.As(err, &expired) || errors.As(err, &trimmed) {
+			state.iterator = nil
+			if trimmed != nil {
+				// 読み終えた位置が保持期間を過ぎた → 読める範囲の先頭から読み直す
+				state.lastSequence = ""
+			}
+			return nil
+		}
+		return err
+	}
+
+	for _, rec := range out.Records {
+		event, err := ParseRecord(rec)
+		if err != nil {
+			// 解析できないレコードは再試行しても解析できないため読み飛ばす
+			log.Printf("Skipping stream record in shard %s: %v", shardID, err)
+			continue
+		}
+		if err := p.handler.Handle(ctx, event); err != nil {
+			state.iterator = nil
+			return err
+		}
+		state.lastSequence = event.SequenceNumber
+	}
+
+	if out.NextShardIterator == nil {
+		// シャードが閉じた → 子シャードを読み始められるように読み終わりとして記録する
+		delete(p.shards, shardID)
+		p.finished[shardID] = true
+		p.lastRefresh = time.Time{}
+		return nil
+	}
+	state.iterator = out.NextShardIterator
+	return nil
+}
+
+// shardIterator は lastSequence の次（空の場合は保持期間の先頭）から読むイテレーターを取得する
+func (p *Poller) shardIterator(ctx context.Context, shardID, lastSequence string) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(p.streamARN),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+	if lastSequence != "" {
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(lastSequence)
+	}
+
+	out, err := p.client.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return out.ShardIterator, nil
+}
@@ -0,0 +1,82 @@
+// backend/internal/stream/record.go
+// DynamoDB Streams のレコードを解析する
+//
+// 【学習ポイント】
+//   - Streams は INSERT/MODIFY/REMOVE の3種のイベントを送信する
+//   - Streams の AttributeValue は dynamodbstreams/types の型のため、
+//     attributevalue.FromDynamoDBStreamsMap で dynamodb/types に変換してから UnmarshalMap する
+//   - SequenceNumber はストリーム内でレコードを一意に識別する（冪等性のキーに使う）
+//   - TTL による削除は UserIdentity（Type = Service, PrincipalId = dynamodb.amazonaws.com）で見分ける
+
+package stream
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// イベントの種類
+const (
+	EventInsert = string(types.OperationTypeInsert)
+	EventModify = string(types.OperationTypeModify)
+	EventRemove = string(types.OperationTypeRemove)
+)
+
+var ErrInvalidRecord = errors.New("invalid stream record")
+
+// Event は Streams のレコードを解析した結果
+type Event struct {
+	Name           string // INSERT / MODIFY / REMOVE
+	SequenceNumber string
+	PK             string
+	SK             string
+	NewImage       map[string]ddbtypes.AttributeValue // REMOVE の場合は nil
+	OldImage       map[string]ddbtypes.AttributeValue // INSERT の場合は nil
+	TTLExpired     bool                               // TTL で削除された REMOVE の場合は true
+}
+
+// ParseRecord は Streams のレコードを Event に変換する
+// キー（PK/SK）やシーケンス番号がないレコードは ErrInvalidRecord を返す
+func ParseRecord(rec types.Record) (*Event, error) {
+	if rec.Dynamodb == nil || rec.Dynamodb.SequenceNumber == nil {
+		return nil, ErrInvalidRecord
+	}
+
+	keys, err := attributevalue.FromDynamoDBStreamsMap(rec.Dynamodb.Keys)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := keys["PK"].(*ddbtypes.AttributeValueMemberS)
+	if !ok {
+		return nil, ErrInvalidRecord
+	}
+	sk, ok := keys["SK"].(*ddbtypes.AttributeValueMemberS)
+	if !ok {
+		return nil, ErrInvalidRecord
+	}
+
+	event := &Event{
+		Name:           string(rec.EventName),
+		SequenceNumber: *rec.Dynamodb.SequenceNumber,
+		PK:             pk.Value,
+		SK:             sk.Value,
+	}
+	if id := rec.UserIdentity; id != nil {
+		event.TTLExpired = aws.ToString(id.Type) == "Service" && aws.ToString(id.PrincipalId) == "dynamodb.amazonaws.com"
+	}
+	if rec.Dynamodb.NewImage != nil {
+		if event.NewImage, err = attributevalue.FromDynamoDBStreamsMap(rec.Dynamodb.NewImage); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Dynamodb.OldImage != nil {
+		if event.OldImage, err = attributevalue.FromDynamoDBStreamsMap(rec.Dynamodb.OldImage); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,53 @@
+// backend/internal/stream/sales.go
+// 注文明細の INSERT イベントから商品ごとの販売実績を集計するハンドラー
+//
+// 【なぜ Streams で集計するか】
+//   注文確定のトランザクション（チェックアウトのホットパス）に集計の書き込みを足すと、
+//   操作数の上限と人気商品のアイテムへの書き込み競合が増える
+//   → 注文明細が書き込まれた後に非同期で集計し、チェックアウトには手を入れない
+
+package stream
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+
+	"github.com/hosokawa-y/dynamodb-shop/backend/internal/repository"
+)
+
+// orderItemImage は注文明細（PK: ORDER#<orderId>, SK: ITEM#<productId>）の集計に使う属性
+type orderItemImage struct {
+	ProductID string `dynamodbav:"productId"`
+	Quantity  int    `dynamodbav:"quantity"`
+	Subtotal  int    `dynamodbav:"subtotal"`
+}
+
+type SalesAggregator struct {
+	salesStatsRepo *repository.SalesStatsRepository
+}
+
+func NewSalesAggregator(salesStatsRepo *repository.SalesStatsRepository) *SalesAggregator {
+	return &SalesAggregator{salesStatsRepo: salesStatsRepo}
+}
+
+// Handle は注文明細の INSERT イベントを販売実績に加算する
+// それ以外のイベントは無視する（注文明細は作成後に変更・削除されない）
+// 同じシーケンス番号のイベントが再送された場合は二重に加算しない
+func (a *SalesAggregator) Handle(ctx context.Context, event *Event) error {
+	if event.Name != EventInsert || !strings.HasPrefix(event.PK, "ORDER#") || !strings.HasPrefix(event.SK, "ITEM#") {
+		return nil
+	}
+
+	var item orderItemImage
+	if err := attributevalue.UnmarshalMap(event.NewImage, &item); err != nil {
+		return err
+	}
+	if item.ProductID == "" {
+		item.ProductID = strings.TrimPrefix(event.SK, "ITEM#")
+	}
+
+	_, err := a.salesStatsRepo.AddSale(ctx, event.SequenceNumber, item.ProductID, item.Quantity, item.Subtotal)
+	return err
+}
@@ -0,0 +1,66 @@
+// Package httputil はハンドラー共通のHTTPリクエスト処理を提供する
+//
+// 【DecodeJSON の方針】
+//   - http.MaxBytesReader でボディサイズを制限する（巨大なボディによるメモリ消費を防ぐ）
+//   - DisallowUnknownFields で未知のフィールドを拒否する（フィールド名のタイプミスを検出する）
+//   - 失敗理由は以下のエラーで返し、ハンドラー側で 400 / 413 に振り分ける
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes は通常のAPIで許容するリクエストボディの上限（1MB）
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+var (
+	ErrEmptyBody    = errors.New("request body is empty")
+	ErrBodyTooLarge = errors.New("request body is too large")
+	ErrInvalidJSON  = errors.New("request body is not valid JSON")
+	ErrUnknownField = errors.New("request body contains unknown field")
+)
+
+// DecodeJSON はリクエストボディを dst にデコードする
+// maxBytes を超えるボディは ErrBodyTooLarge、JSONオブジェクトが2つ以上続く場合は ErrInvalidJSON を返す
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return classifyDecodeError(err)
+	}
+
+	// 末尾に余分なデータがないことを確認する
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return ErrBodyTooLarge
+		}
+		return fmt.Errorf("%w: unexpected data after JSON value", ErrInvalidJSON)
+	}
+
+	return nil
+}
+
+func classifyDecodeError(err error) error {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return ErrBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json は未知フィールドを専用の型で返さないため、メッセージで判定する
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return fmt.Errorf("%w: %s", ErrUnknownField, field)
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+}
@@ -6,13 +6,21 @@ import (
 )
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string            `json:"error"`
+	Code   string            `json:"code,omitempty"`   // クライアントが処理を分岐するための機械可読なコード
+	Fields map[string]string `json:"fields,omitempty"` // 入力エラーのあったフィールド名 → メッセージ
 }
 
 type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+// DataResponse は成功メッセージと、操作の結果（更新後のリソースなど）を返す
+type DataResponse struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
 func JSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -25,6 +33,23 @@ func Error(w http.ResponseWriter, status int, message string) {
 	JSON(w, status, ErrorResponse{Error: message})
 }
 
+// ErrorWithCode はエラーメッセージに機械可読なコードを付けて返す
+func ErrorWithCode(w http.ResponseWriter, status int, message, code string) {
+	JSON(w, status, ErrorResponse{Error: message, Code: code})
+}
+
+// ValidationError は入力エラーをフィールドごとのメッセージとともに 400 で返す
+// 例: {"error":"validation failed","fields":{"price":"must be positive"}}
+func ValidationError(w http.ResponseWriter, fields map[string]string) {
+	JSON(w, http.StatusBadRequest, ErrorResponse{Error: "validation failed", Fields: fields})
+}
+
 func Success(w http.ResponseWriter, status int, message string) {
 	JSON(w, status, SuccessResponse{Message: message})
 }
+
+// Data は成功メッセージに操作の結果を付けて返す（取得し直すためのリクエストを省ける）
+// 例: {"message":"Stock adjusted successfully","data":{"stock":10,"log":{...}}}
+func Data(w http.ResponseWriter, status int, message string, data interface{}) {
+	JSON(w, status, DataResponse{Message: message, Data: data})
+}
@@ -1,5 +1,5 @@
 import apiClient from './client'
-import type { UserActivity, LogActivityRequest } from './types'
+import type { UserActivity, LogActivityRequest, Product } from './types'
 
 export const activityApi = {
   // 行動ログを1件記録
@@ -28,4 +28,10 @@ export const activityApi = {
     })
     return response.data
   },
+
+  // 最近閲覧した商品（新しい順、同じ商品は1件にまとめる）
+  async getRecentlyViewed(limit?: number): Promise<Product[]> {
+    const response = await apiClient.get<Product[]>('/users/me/recently-viewed', { params: { limit } })
+    return response.data
+  },
 }
@@ -1,5 +1,14 @@
 import apiClient from './client'
-import type { AuthResponse, LoginRequest, RegisterRequest, User } from './types'
+import type {
+  AuthResponse,
+  DeleteAccountRequest,
+  ForgotPasswordRequest,
+  LoginRequest,
+  RegisterRequest,
+  ResetPasswordRequest,
+  SuccessResponse,
+  User,
+} from './types'
 
 export const authApi = {
   async register(data: RegisterRequest): Promise<AuthResponse> {
@@ -16,4 +25,21 @@ export const authApi = {
     const response = await apiClient.get<User>('/auth/profile')
     return response.data
   },
+
+  // 退会（注文履歴は残る）
+  async deleteAccount(data: DeleteAccountRequest): Promise<SuccessResponse> {
+    const response = await apiClient.delete<SuccessResponse>('/auth/profile', { data })
+    return response.data
+  },
+
+  // パスワード再設定の申請（登録されていないメールアドレスでも成功を返す）
+  async forgotPassword(data: ForgotPasswordRequest): Promise<SuccessResponse> {
+    const response = await apiClient.post<SuccessResponse>('/auth/forgot-password', data)
+    return response.data
+  },
+
+  async resetPassword(data: ResetPasswordRequest): Promise<SuccessResponse> {
+    const response = await apiClient.post<SuccessResponse>('/auth/reset-password', data)
+    return response.data
+  },
 }
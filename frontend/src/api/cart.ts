@@ -1,12 +1,20 @@
 import apiClient from './client'
-import type { Cart, CartItem, AddToCartRequest, UpdateCartRequest } from './types'
+import type { Cart, CartCount, CartItem, AddToCartRequest, UpdateCartRequest, StockHold } from './types'
 
 export const cartApi = {
-  async getCart(): Promise<Cart> {
-    const response = await apiClient.get<Cart>('/cart')
+  // refreshPrices: 現在の商品価格で再計算する
+  async getCart(refreshPrices = false): Promise<Cart> {
+    const params = refreshPrices ? { refreshPrices: true } : {}
+    const response = await apiClient.get<Cart>('/cart', { params })
     return response.data
   },
 
+  // ヘッダーのバッジ用：アイテム数のみ取得
+  async getCount(): Promise<number> {
+    const response = await apiClient.get<CartCount>('/cart/count')
+    return response.data.count
+  },
+
   async addItem(data: AddToCartRequest): Promise<CartItem> {
     const response = await apiClient.post<CartItem>('/cart/items', data)
     return response.data
@@ -20,4 +28,18 @@ export const cartApi = {
   async removeItem(productId: string): Promise<void> {
     await apiClient.delete(`/cart/items/${productId}`)
   },
+
+  async clear(): Promise<void> {
+    await apiClient.delete('/cart')
+  },
+
+  // 在庫の取り置き（サーバーで CART_RESERVATIONS_ENABLED=true の場合のみ）
+  async reserveItem(productId: string): Promise<StockHold> {
+    const response = await apiClient.post<StockHold>(`/cart/items/${productId}/reserve`)
+    return response.data
+  },
+
+  async releaseReservation(productId: string): Promise<void> {
+    await apiClient.delete(`/cart/items/${productId}/reserve`)
+  },
 }
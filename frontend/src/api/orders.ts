@@ -1,14 +1,23 @@
 import apiClient from './client'
-import type { Order } from './types'
+import type { CreateOrderRequest, Invoice, Order, OrderPage, OrderSummary } from './types'
 
 export const ordersApi = {
-  async createOrder(): Promise<Order> {
-    const response = await apiClient.post<Order>('/orders')
+  async createOrder(data: CreateOrderRequest): Promise<Order> {
+    const response = await apiClient.post<Order>('/orders', data)
     return response.data
   },
 
-  async getOrders(): Promise<Order[]> {
-    const response = await apiClient.get<Order[]>('/orders')
+  // status を指定するとそのステータスの注文だけを返す（1ページの件数が limit 未満になることがある）
+  async getOrders(limit?: number, cursor?: string, status?: string): Promise<OrderPage> {
+    const response = await apiClient.get<OrderPage>('/orders', {
+      params: { limit, cursor, status },
+    })
+    return response.data
+  },
+
+  // 注文数・支払額の合計・ステータス別件数・最終注文日時
+  async getSummary(): Promise<OrderSummary> {
+    const response = await apiClient.get<OrderSummary>('/orders/summary')
     return response.data
   },
 
@@ -16,4 +25,10 @@ export const ordersApi = {
     const response = await apiClient.get<Order>(`/orders/${orderId}`)
     return response.data
   },
+
+  // 請求書（明細・割引・消費税・税込合計）
+  async getInvoice(orderId: string): Promise<Invoice> {
+    const response = await apiClient.get<Invoice>(`/orders/${orderId}/invoice`)
+    return response.data
+  },
 }
@@ -1,10 +1,22 @@
 import apiClient from './client'
 import type {
+  Bestseller,
+  Category,
   CreateProductRequest,
+  DataResponse,
+  ProductFacets,
   Product,
+  ProductAvailability,
+  ProductImportResponse,
+  ProductSummary,
+  RelatedProductPage,
   UpdateProductRequest,
-  PriceHistory,
+  PriceHistoryResponse,
+  PriceUpdate,
+  ScheduledPrice,
+  StockAdjustment,
   InventoryLog,
+  InventorySummary,
   UpdatePriceRequest,
   AdjustStockRequest,
 } from './types'
@@ -16,6 +28,42 @@ export const productsApi = {
     return response.data
   },
 
+  // カード表示用の項目だけの一覧（説明文などを含まない）
+  async listSummaries(category?: string): Promise<ProductSummary[]> {
+    const params = category ? { category, fields: 'card' } : { fields: 'card' }
+    const response = await apiClient.get<ProductSummary[]>('/products', { params })
+    return response.data
+  },
+
+  // 販売数の多い順（集計ワーカーが動いていない場合は空）
+  async listBestsellers(limit = 10): Promise<Bestseller[]> {
+    const response = await apiClient.get<Bestseller[]>('/products/bestsellers', { params: { limit } })
+    return response.data
+  },
+
+  // カテゴリ別の商品数（サイドバー用、サーバー側で数分キャッシュされる）
+  async getFacets(): Promise<ProductFacets> {
+    const response = await apiClient.get<ProductFacets>('/products/facets')
+    return response.data
+  },
+
+  async listCategories(): Promise<Category[]> {
+    const response = await apiClient.get<Category[]>('/categories')
+    return response.data
+  },
+
+  // 一緒に購入されている商品（同じ注文で購入された回数の多い順、集計ワーカーが動いていない場合は空）
+  async listRelated(id: string, params?: { limit?: number; nextToken?: string }): Promise<RelatedProductPage> {
+    const response = await apiClient.get<RelatedProductPage>(`/products/${id}/related`, { params })
+    return response.data
+  },
+
+  // 指定した数量を購入できるか（在庫数だけを確認する）
+  async checkAvailability(id: string, quantity: number): Promise<ProductAvailability> {
+    const response = await apiClient.get<ProductAvailability>(`/products/${id}/availability`, { params: { quantity } })
+    return response.data
+  },
+
   async getById(id: string): Promise<Product> {
     const response = await apiClient.get<Product>(`/products/${id}`)
     return response.data
@@ -26,6 +74,12 @@ export const productsApi = {
     return response.data
   },
 
+  // 管理者用：商品の一括登録（最大500件）
+  async importProducts(data: CreateProductRequest[]): Promise<ProductImportResponse> {
+    const response = await apiClient.post<ProductImportResponse>('/admin/products/import', data)
+    return response.data
+  },
+
   async update(id: string, data: UpdateProductRequest): Promise<Product> {
     const response = await apiClient.put<Product>(`/products/${id}`, data)
     return response.data
@@ -38,14 +92,37 @@ export const productsApi = {
   // 価格履歴API
   async getPriceHistory(
     id: string,
-    params?: { limit?: number; start?: string; end?: string },
-  ): Promise<PriceHistory[]> {
-    const response = await apiClient.get<PriceHistory[]>(`/products/${id}/price-history`, { params })
+    params?: { limit?: number; start?: string; end?: string; interval?: 'hourly' | 'daily' | 'weekly' },
+  ): Promise<PriceHistoryResponse> {
+    const response = await apiClient.get<PriceHistoryResponse>(`/products/${id}/price-history`, { params })
+    return response.data
+  },
+
+  async updatePrice(id: string, data: UpdatePriceRequest): Promise<PriceUpdate> {
+    const response = await apiClient.put<DataResponse<PriceUpdate>>(`/products/${id}/price`, data)
+    return response.data.data
+  },
+
+  // 管理者用：価格変更の予約
+  async schedulePrice(id: string, data: { price: number; effectiveAt: string }): Promise<ScheduledPrice> {
+    const response = await apiClient.post<ScheduledPrice>(`/admin/products/${id}/schedule-price`, data)
     return response.data
   },
 
-  async updatePrice(id: string, data: UpdatePriceRequest): Promise<void> {
-    await apiClient.put(`/products/${id}/price`, data)
+  async listScheduledPrices(id: string): Promise<ScheduledPrice[]> {
+    const response = await apiClient.get<ScheduledPrice[]>(`/admin/products/${id}/scheduled-prices`)
+    return response.data
+  },
+
+  // effectiveAt は予約の適用日時（RFC3339）
+  async cancelScheduledPrice(id: string, effectiveAt: string): Promise<void> {
+    await apiClient.delete(`/admin/products/${id}/scheduled-prices/${encodeURIComponent(effectiveAt)}`)
+  },
+
+  // 適用日時を過ぎた予約をその場で適用する（ローカル開発用）
+  async applyScheduledPrices(): Promise<{ applied: number }> {
+    const response = await apiClient.post<{ applied: number }>('/admin/apply-scheduled-prices')
+    return response.data
   },
 
   // 在庫管理API
@@ -57,8 +134,14 @@ export const productsApi = {
     return response.data
   },
 
-  async adjustStock(id: string, data: AdjustStockRequest): Promise<void> {
-    await apiClient.put(`/products/${id}/stock`, data)
+  async getInventorySummary(id: string, params: { start: string; end: string }): Promise<InventorySummary> {
+    const response = await apiClient.get<InventorySummary>(`/products/${id}/inventory-summary`, { params })
+    return response.data
+  },
+
+  async adjustStock(id: string, data: AdjustStockRequest): Promise<StockAdjustment> {
+    const response = await apiClient.put<DataResponse<StockAdjustment>>(`/products/${id}/stock`, data)
+    return response.data.data
   },
 
   // 管理者用：在庫変動履歴（全商品）
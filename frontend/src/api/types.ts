@@ -18,6 +18,20 @@ export interface LoginRequest {
   password: string
 }
 
+export interface DeleteAccountRequest {
+  password: string
+  refreshToken?: string
+}
+
+export interface ForgotPasswordRequest {
+  email: string
+}
+
+export interface ResetPasswordRequest {
+  token: string
+  password: string
+}
+
 export interface AuthResponse {
   token: string
   user: User
@@ -35,6 +49,44 @@ export interface Product {
   version: number
   createdAt: string
   updatedAt: string
+  deletedAt?: string
+}
+
+export interface Bestseller extends Product {
+  unitsSold: number
+}
+
+export interface RelatedProduct extends Product {
+  boughtTogether: number
+}
+
+export interface RelatedProductPage {
+  products: RelatedProduct[]
+  nextToken?: string
+}
+
+// 商品一覧のカード表示用（GET /products?fields=card）
+export interface ProductSummary {
+  id: string
+  name: string
+  price: number
+  imageUrl: string
+  stock: number
+}
+
+// stock は購入可能数（チェックアウト中の仮押さえ分を除く）
+export interface ProductAvailability {
+  available: boolean
+  stock: number
+}
+
+export interface ProductFacets {
+  category: Record<string, number>
+}
+
+export interface Category {
+  name: string
+  productCount: number
 }
 
 export interface CreateProductRequest {
@@ -46,6 +98,20 @@ export interface CreateProductRequest {
   imageUrl: string
 }
 
+export interface ProductImportResult {
+  index: number
+  success: boolean
+  productId?: string
+  sku?: string
+  error?: string
+}
+
+export interface ProductImportResponse {
+  created: number
+  failed: number
+  results: ProductImportResult[]
+}
+
 export interface UpdateProductRequest {
   name: string
   description: string
@@ -65,6 +131,9 @@ export interface CartItem {
   version: number
   addedAt: string
   updatedAt: string
+  priceChanged?: boolean
+  previousPrice?: number
+  unavailable?: boolean // 商品が削除された（注文できない）場合のみ true。合計金額には含まれない
 }
 
 export interface Cart {
@@ -73,6 +142,10 @@ export interface Cart {
   itemCount: number
 }
 
+export interface CartCount {
+  count: number
+}
+
 export interface AddToCartRequest {
   productId: string
   quantity: number
@@ -83,6 +156,14 @@ export interface UpdateCartRequest {
   version: number
 }
 
+export interface StockHold {
+  userId: string
+  productId: string
+  quantity: number
+  expiresAt: string
+  createdAt: string
+}
+
 // Order types
 export interface OrderItem {
   orderId: string
@@ -93,23 +174,124 @@ export interface OrderItem {
   subtotal: number
 }
 
+export interface Address {
+  zipCode: string
+  prefecture: string
+  city: string
+  address: string
+}
+
+export interface CreateOrderRequest {
+  shippingAddress: Address
+  couponCode?: string
+}
+
 export interface Order {
   id: string
   userId: string
+  customerName?: string // 管理者向けの注文一覧でのみ返る
+  customerEmail?: string
   status: string
-  totalAmount: number
+  paymentStatus: string
+  paidAt?: string
+  paymentReference?: string
+  subtotal: number
+  couponCode?: string
+  discountAmount: number
+  totalAmount: number // 税抜
+  taxRate: number // 注文確定時の消費税率（例: 0.1）
+  taxAmount: number // 1円未満は四捨五入
+  grandTotal: number // 税込の支払額（totalAmount + taxAmount）
   itemCount: number
   items?: OrderItem[]
+  shippingAddress?: Address
   createdAt: string
   updatedAt: string
 }
 
+// 注文の集計（totalSpent はキャンセルした注文を除く税込支払額の合計）
+export interface OrderSummary {
+  totalOrders: number
+  totalSpent: number
+  statusCounts: Record<string, number>
+  lastOrderAt?: string
+}
+
+export interface OrderPage {
+  orders: Order[]
+  nextCursor?: string
+}
+
+// 請求書（金額は注文確定時に保存した値）
+export interface InvoiceLine {
+  productId: string
+  productName: string
+  unitPrice: number
+  quantity: number
+  subtotal: number
+}
+
+export interface Invoice {
+  orderId: string
+  orderedAt: string
+  lines: InvoiceLine[]
+  subtotal: number
+  couponCode?: string
+  discountAmount: number
+  taxableAmount: number
+  taxRate: number
+  taxAmount: number
+  grandTotal: number
+  shippingAddress?: Address
+}
+
 // Price History types
 export interface PriceHistory {
   productId: string
   price: number
   changedBy: string
   timestamp: string
+  changePercent?: number // 1つ前の価格との変化率（%）。最も古い点にはない
+  direction?: 'up' | 'down' | 'flat'
+}
+
+export interface PriceHistoryStats {
+  min: number
+  max: number
+  average: number
+  count: number
+}
+
+export interface PriceHistoryResponse {
+  points: PriceHistory[]
+  stats: PriceHistoryStats
+}
+
+// 成功メッセージと操作の結果（PUT /products/:id/stock, PUT /products/:id/price）
+export interface DataResponse<T> {
+  message: string
+  data: T
+}
+
+export interface StockAdjustment {
+  stock: number // 調整後の在庫数
+  product: Product // 更新後の商品
+  log: InventoryLog
+}
+
+export interface PriceUpdate {
+  price: number // 変更後の価格
+  product: Product // 更新後の商品
+  history: PriceHistory | null // 価格が変わらなかった場合は null
+}
+
+// 価格変更の予約
+export interface ScheduledPrice {
+  productId: string
+  price: number
+  effectiveAt: string
+  createdBy: string
+  createdAt: string
 }
 
 // Inventory Log types
@@ -124,8 +306,22 @@ export interface InventoryLog {
   timestamp: string
 }
 
+// 期間内の在庫変動の集計（openingStock + totalIn - totalOut + netAdjust = closingStock）
+export interface InventorySummary {
+  productId: string
+  start: string
+  end: string
+  openingStock: number
+  closingStock: number
+  totalIn: number
+  totalOut: number
+  netAdjust: number
+  logCount: number
+}
+
 export interface UpdatePriceRequest {
   price: number
+  version: number // 読み込んだ商品の version（他の変更と競合した場合は 409）
 }
 
 export interface AdjustStockRequest {
@@ -154,6 +350,8 @@ export interface LogActivityRequest {
 // API response types
 export interface ErrorResponse {
   error: string
+  code?: string // token_missing, token_expired, token_invalid, token_revoked, insufficient_stock, cart_empty, cart_full, quantity_limit_exceeded, product_unavailable など
+  fields?: Record<string, string> // 入力エラーのあったフィールド名 → メッセージ
 }
 
 export interface SuccessResponse {
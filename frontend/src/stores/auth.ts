@@ -42,8 +42,14 @@ export const useAuthStore = defineStore('auth', () => {
 
       return response
     } catch (e: unknown) {
-      const err = e as { response?: { data?: { error?: string } } }
-      error.value = err.response?.data?.error || 'Registration failed'
+      const err = e as { response?: { data?: { error?: string; fields?: Record<string, string> } } }
+      const fields = err.response?.data?.fields
+      // 入力エラーはフィールドごとのメッセージを表示する（例: "password must be at least 8 characters"）
+      error.value = fields
+        ? Object.entries(fields)
+            .map(([field, message]) => `${field} ${message}`)
+            .join(', ')
+        : err.response?.data?.error || 'Registration failed'
       throw e
     } finally {
       loading.value = false
@@ -1,22 +1,25 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
 import { ordersApi } from '@/api/orders'
-import type { Order } from '@/api/types'
+import type { Address, Order } from '@/api/types'
 
 export const useOrderStore = defineStore('order', () => {
   const orders = ref<Order[]>([])
   const currentOrder = ref<Order | null>(null)
+  const nextCursor = ref<string | null>(null)
   const loading = ref(false)
+  const loadingMore = ref(false)
   const error = ref<string | null>(null)
 
   const orderCount = computed(() => orders.value.length)
+  const hasMoreOrders = computed(() => nextCursor.value !== null)
 
-  async function createOrder(): Promise<Order> {
+  async function createOrder(shippingAddress: Address): Promise<Order> {
     loading.value = true
     error.value = null
 
     try {
-      const order = await ordersApi.createOrder()
+      const order = await ordersApi.createOrder({ shippingAddress })
       // 注文一覧に追加
       orders.value.unshift(order)
       currentOrder.value = order
@@ -36,7 +39,9 @@ export const useOrderStore = defineStore('order', () => {
     error.value = null
 
     try {
-      orders.value = await ordersApi.getOrders()
+      const page = await ordersApi.getOrders()
+      orders.value = page.orders
+      nextCursor.value = page.nextCursor || null
     } catch (e: unknown) {
       const err = e as { response?: { data?: { error?: string } } }
       error.value = err.response?.data?.error || 'Failed to fetch orders'
@@ -46,6 +51,26 @@ export const useOrderStore = defineStore('order', () => {
     }
   }
 
+  // 次のページの注文を一覧の末尾に追加する
+  async function fetchMoreOrders() {
+    if (!nextCursor.value || loadingMore.value) return
+
+    loadingMore.value = true
+    error.value = null
+
+    try {
+      const page = await ordersApi.getOrders(undefined, nextCursor.value)
+      orders.value.push(...page.orders)
+      nextCursor.value = page.nextCursor || null
+    } catch (e: unknown) {
+      const err = e as { response?: { data?: { error?: string } } }
+      error.value = err.response?.data?.error || 'Failed to fetch orders'
+      throw e
+    } finally {
+      loadingMore.value = false
+    }
+  }
+
   async function fetchOrderById(orderId: string) {
     loading.value = true
     error.value = null
@@ -69,10 +94,13 @@ export const useOrderStore = defineStore('order', () => {
     orders,
     currentOrder,
     loading,
+    loadingMore,
     error,
     orderCount,
+    hasMoreOrders,
     createOrder,
     fetchOrders,
+    fetchMoreOrders,
     fetchOrderById,
     clearCurrentOrder,
   }
@@ -1,5 +1,5 @@
 import { defineStore } from 'pinia'
-import { ref, computed } from 'vue'
+import { ref } from 'vue'
 import { productsApi } from '@/api'
 import type { Product, CreateProductRequest, UpdateProductRequest } from '@/api/types'
 
@@ -9,10 +9,7 @@ export const useProductStore = defineStore('product', () => {
   const loading = ref(false)
   const error = ref<string | null>(null)
 
-  const categories = computed(() => {
-    const cats = new Set(products.value.map((p) => p.category))
-    return Array.from(cats).sort()
-  })
+  const categories = ref<string[]>([])
 
   async function fetchProducts(category?: string) {
     loading.value = true
@@ -29,6 +26,17 @@ export const useProductStore = defineStore('product', () => {
     }
   }
 
+  async function fetchCategories() {
+    try {
+      const list = await productsApi.listCategories()
+      categories.value = list.map((c) => c.name)
+    } catch (e: unknown) {
+      const err = e as { response?: { data?: { error?: string } } }
+      error.value = err.response?.data?.error || 'Failed to fetch categories'
+      throw e
+    }
+  }
+
   async function fetchProductById(id: string) {
     loading.value = true
     error.value = null
@@ -115,6 +123,7 @@ export const useProductStore = defineStore('product', () => {
     error,
     categories,
     fetchProducts,
+    fetchCategories,
     fetchProductById,
     createProduct,
     updateProduct,